@@ -13,13 +13,19 @@ import (
 	"gopkg.in/urfave/cli.v1"
 
 	"github.com/vitelabs/go-vite/v2/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/v2/cmd/subcmd_check_chain"
+	"github.com/vitelabs/go-vite/v2/cmd/subcmd_consensus"
+	"github.com/vitelabs/go-vite/v2/cmd/subcmd_devnet"
 	"github.com/vitelabs/go-vite/v2/cmd/subcmd_export"
+	"github.com/vitelabs/go-vite/v2/cmd/subcmd_genesis"
 	"github.com/vitelabs/go-vite/v2/cmd/subcmd_ledger"
 	"github.com/vitelabs/go-vite/v2/cmd/subcmd_loadledger"
 	"github.com/vitelabs/go-vite/v2/cmd/subcmd_plugin_data"
 	"github.com/vitelabs/go-vite/v2/cmd/subcmd_recover"
+	"github.com/vitelabs/go-vite/v2/cmd/subcmd_replay"
 	"github.com/vitelabs/go-vite/v2/cmd/subcmd_rpc"
 	"github.com/vitelabs/go-vite/v2/cmd/subcmd_virtualnode"
+	"github.com/vitelabs/go-vite/v2/cmd/subcmd_vmtest"
 	"github.com/vitelabs/go-vite/v2/cmd/utils"
 	"github.com/vitelabs/go-vite/v2/log15"
 	"github.com/vitelabs/go-vite/v2/version"
@@ -54,12 +60,18 @@ func init() {
 		versionCommand,
 		licenseCommand,
 		subcmd_recover.LedgerRecoverCommand,
+		subcmd_check_chain.CheckChainCommand,
 		subcmd_export.ExportCommand,
 		subcmd_plugin_data.PluginDataCommand,
 		subcmd_rpc.RpcCommand,
 		subcmd_loadledger.LoadLedgerCommand,
 		subcmd_ledger.QueryLedgerCommand,
 		subcmd_virtualnode.VirtualNodeCommand,
+		subcmd_devnet.DevNetCommand,
+		subcmd_genesis.GenesisCommand,
+		subcmd_vmtest.VmTestCommand,
+		subcmd_replay.ReplayCommand,
+		subcmd_consensus.ConsensusCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
@@ -67,7 +79,7 @@ func init() {
 	for _, element := range app.Commands {
 		app.Flags = utils.MergeFlags(app.Flags, element.Flags)
 	}
-	app.Flags = utils.MergeFlags(app.Flags, utils.StatFlags)
+	app.Flags = utils.MergeFlags(app.Flags, utils.StatFlags, utils.NetFlags, utils.PruneFlags, utils.ProducerFlags)
 
 	app.Before = beforeAction
 	app.Action = action
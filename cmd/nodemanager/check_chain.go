@@ -1,11 +1,15 @@
 package nodemanager
 
 import (
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 
 	"gopkg.in/urfave/cli.v1"
 
+	"github.com/vitelabs/go-vite/v2/cmd/utils"
 	"github.com/vitelabs/go-vite/v2/common"
+	"github.com/vitelabs/go-vite/v2/ledger/chain"
 	"github.com/vitelabs/go-vite/v2/log15"
 	"github.com/vitelabs/go-vite/v2/node"
 )
@@ -51,6 +55,10 @@ func (nodeManager *CheckChainNodeManager) Start() error {
 	}
 
 	c := node.Vite().Chain()
+	if nodeManager.ctx.GlobalBool(utils.CheckChainFullFlag.Name) {
+		return nodeManager.checkFull(c)
+	}
+
 	fmt.Println("start check.")
 	// check recent blocks
 	nodeManager.log.Info("start check recent blocks")
@@ -81,6 +89,68 @@ func (nodeManager *CheckChainNodeManager) Start() error {
 	return nil
 }
 
+// fullCheckReport is the report of checkChain --full
+type fullCheckReport struct {
+	*chain.FullCheckReport
+
+	// RecoverCommand deletes the snapshot blocks from the first inconsistent height
+	RecoverCommand string `json:"recoverCommand,omitempty"`
+	Suggestion     string `json:"suggestion,omitempty"`
+}
+
+func (nodeManager *CheckChainNodeManager) checkFull(c chain.Chain) error {
+	fmt.Println("start full check, it may take hours.")
+	nodeManager.log.Info("start full check")
+
+	result, err := c.CheckFull()
+	if err != nil {
+		return err
+	}
+	nodeManager.log.Info("finish full check")
+
+	report := &fullCheckReport{
+		FullCheckReport: result,
+	}
+	if result.UnlocatedIssueCount > 0 {
+		// deleting the snapshot blocks from a known height can't fix the issues
+		report.Suggestion = fmt.Sprintf("%d issues can't be located at a snapshot height, remove the ledger and sync from scratch", result.UnlocatedIssueCount)
+	} else if height := result.FirstInconsistentHeight; height > 0 {
+		if height > result.LatestSnapshotHeight {
+			// only the unconfirmed blocks are inconsistent
+			height = result.LatestSnapshotHeight
+		}
+
+		if height <= 1 {
+			report.Suggestion = "the genesis block is inconsistent, remove the ledger and sync from scratch"
+		} else {
+			report.RecoverCommand = fmt.Sprintf("gvite recover --del=%d", height)
+			if nodeManager.ctx.GlobalIsSet(utils.ConfigFileFlag.Name) {
+				report.RecoverCommand += fmt.Sprintf(" --config=%s", nodeManager.ctx.GlobalString(utils.ConfigFileFlag.Name))
+			}
+			report.Suggestion = fmt.Sprintf("delete the snapshot blocks from height %d and sync them again", height)
+		}
+	}
+
+	buf, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+	if file := nodeManager.ctx.GlobalString(utils.CheckChainReportFlag.Name); file != "" {
+		if err := ioutil.WriteFile(file, buf, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("the report is written to %s\n", file)
+	} else {
+		fmt.Println(string(buf))
+	}
+
+	if result.IssueCount > 0 {
+		return fmt.Errorf("found %d issues, the first inconsistent snapshot height is %d", result.IssueCount, result.FirstInconsistentHeight)
+	}
+	fmt.Println("check success.")
+	return nil
+}
+
 func (nodeManager *CheckChainNodeManager) Stop() error {
 
 	StopNode(nodeManager.node)
@@ -136,6 +136,16 @@ func mappingNodeConfig(ctx *cli.Context, cfg *nodeconfig.Config) {
 		cfg.MinerEnabled = ctx.GlobalBool(utils.MinerFlag.Name)
 	}
 
+	if leaseFile := ctx.GlobalString(utils.ProducerLeaseFlag.Name); len(leaseFile) > 0 {
+		cfg.ProducerLeaseFile = leaseFile
+	}
+	if leaseOwner := ctx.GlobalString(utils.ProducerLeaseOwnerFlag.Name); len(leaseOwner) > 0 {
+		cfg.ProducerLeaseOwner = leaseOwner
+	}
+	if ctx.GlobalIsSet(utils.ProducerLeaseTTLFlag.Name) {
+		cfg.ProducerLeaseTTL = ctx.GlobalUint64(utils.ProducerLeaseTTLFlag.Name)
+	}
+
 	//Log Level Config
 	if logLevel := ctx.GlobalString(utils.LogLvlFlag.Name); len(logLevel) > 0 {
 		cfg.LogLevel = logLevel
@@ -164,6 +174,18 @@ func mappingNodeConfig(ctx *cli.Context, cfg *nodeconfig.Config) {
 	if ctx.GlobalIsSet(utils.SingleFlag.Name) {
 		cfg.Single = ctx.GlobalBool(utils.SingleFlag.Name)
 	}
+
+	if ctx.GlobalIsSet(utils.StateSyncFlag.Name) {
+		cfg.StateSync = ctx.GlobalBool(utils.StateSyncFlag.Name)
+	}
+
+	//Prune
+	if ctx.GlobalIsSet(utils.PruneFlag.Name) {
+		cfg.Prune = ctx.GlobalBool(utils.PruneFlag.Name)
+	}
+	if ctx.GlobalIsSet(utils.PruneRetainFlag.Name) {
+		cfg.PruneRetain = ctx.GlobalUint64(utils.PruneRetainFlag.Name)
+	}
 }
 
 func overrideNodeConfigs(ctx *cli.Context, cfg *nodeconfig.Config) {
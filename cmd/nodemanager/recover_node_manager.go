@@ -1,7 +1,9 @@
 package nodemanager
 
 import (
+	"encoding/json"
 	"fmt"
+	"path/filepath"
 
 	"gopkg.in/urfave/cli.v1"
 
@@ -41,6 +43,20 @@ func (nodeManager *RecoverNodeManager) getDeleteToHeight() uint64 {
 	return deleteToHeight
 }
 
+func (nodeManager *RecoverNodeManager) getMaxDepth() uint64 {
+	if nodeManager.ctx.GlobalIsSet(utils.LedgerMaxDepthFlag.Name) {
+		return nodeManager.ctx.GlobalUint64(utils.LedgerMaxDepthFlag.Name)
+	}
+	return DefaultRecoverMaxDepth
+}
+
+func (nodeManager *RecoverNodeManager) getBackupDir(latestHeight uint64) string {
+	if nodeManager.ctx.GlobalIsSet(utils.LedgerBackupFlag.Name) {
+		return nodeManager.ctx.GlobalString(utils.LedgerBackupFlag.Name)
+	}
+	return filepath.Join(nodeManager.node.ViteConfig().DataDir, fmt.Sprintf("recover_backup_%d_%d", nodeManager.getDeleteToHeight(), latestHeight))
+}
+
 func (nodeManager *RecoverNodeManager) Start() error {
 
 	// Start up the node
@@ -73,9 +89,51 @@ func (nodeManager *RecoverNodeManager) Start() error {
 		panic(err)
 	}
 
-	fmt.Printf("Latest snapshot block height is %d\n", c.GetLatestSnapshotBlock().Height)
+	latestHeight := c.GetLatestSnapshotBlock().Height
+	if deleteToHeight > latestHeight || deleteToHeight <= 1 {
+		return fmt.Errorf("deleteToHeight is %d, must be in [2, %d]", deleteToHeight, latestHeight)
+	}
+
+	fmt.Printf("Latest snapshot block height is %d\n", latestHeight)
 	fmt.Printf("Delete target height is %d\n", deleteToHeight)
 
+	maxDepth := nodeManager.getMaxDepth()
+	depth := latestHeight - deleteToHeight + 1
+
+	if nodeManager.ctx.GlobalBool(utils.LedgerDryRunFlag.Name) {
+		plan, err := makeRecoverPlan(c, deleteToHeight, maxDepth)
+		if err != nil {
+			return err
+		}
+		buf, err := json.MarshalIndent(plan, "", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(buf))
+		if plan.UnconfirmedBlocks > 0 {
+			fmt.Printf("%d unconfirmed blocks will be deleted, they are not backed up\n", plan.UnconfirmedBlocks)
+		}
+		if depth > maxDepth {
+			fmt.Printf("%d snapshot blocks will be deleted, more than the max depth %d\n", depth, maxDepth)
+		}
+		return nil
+	}
+
+	if depth > maxDepth {
+		return fmt.Errorf("%d snapshot blocks will be deleted, more than the max depth %d, set --%s to delete them",
+			depth, maxDepth, utils.LedgerMaxDepthFlag.Name)
+	}
+
+	backupDir := nodeManager.getBackupDir(latestHeight)
+	fmt.Printf("Back up the snapshot blocks from %d to %d into %s\n", deleteToHeight, latestHeight, backupDir)
+	if err := backupDeletedChunks(c, deleteToHeight, backupDir); err != nil {
+		return fmt.Errorf("back up failed. Error: %s", err)
+	}
+	fmt.Printf("Back up success, the blocks can be loaded again by gvite load --fromDir=%s\n", backupDir)
+	if unconfirmed := len(c.GetAllUnconfirmedBlocks()); unconfirmed > 0 {
+		fmt.Printf("%d unconfirmed blocks will be deleted, they are not backed up\n", unconfirmed)
+	}
+
 	fmt.Printf("Start deleting, don't shut down. View the deletion process through the log in %s\n", viteConfig.RunLogDir())
 	if _, err := c.DeleteSnapshotBlocksToHeight(deleteToHeight); err != nil {
 		return err
@@ -0,0 +1,270 @@
+package nodemanager
+
+import (
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/ledger/chain"
+	chain_block "github.com/vitelabs/go-vite/v2/ledger/chain/block"
+)
+
+const (
+	// about one day of snapshot blocks
+	DefaultRecoverMaxDepth = 86400
+
+	recoverReadBatch = 1000
+)
+
+type recoverChunk struct {
+	Height        uint64     `json:"height"`
+	Hash          types.Hash `json:"hash"`
+	AccountBlocks int        `json:"accountBlocks"`
+}
+
+type recoverBlock struct {
+	Height    uint64     `json:"height"`
+	Hash      types.Hash `json:"hash"`
+	BlockType byte       `json:"blockType"`
+
+	// SnapshotHeight is 0 if the block is unconfirmed
+	SnapshotHeight uint64 `json:"snapshotHeight"`
+}
+
+type recoverBalance struct {
+	Current string `json:"current"`
+	After   string `json:"after"`
+	Change  string `json:"change"`
+}
+
+type recoverAccount struct {
+	Blocks   []*recoverBlock                       `json:"blocks"`
+	Balances map[types.TokenTypeId]*recoverBalance `json:"balances"`
+
+	// the tokens of the deleted send blocks, and the send blocks received by the deleted receive blocks
+	tokens     map[types.TokenTypeId]struct{}
+	fromHashes []types.Hash
+}
+
+// recoverPlan is the report of recover --dry-run
+type recoverPlan struct {
+	LatestHeight   uint64 `json:"latestHeight"`
+	DeleteToHeight uint64 `json:"deleteToHeight"`
+	Depth          uint64 `json:"depth"`
+	MaxDepth       uint64 `json:"maxDepth"`
+
+	SnapshotChunks []*recoverChunk `json:"snapshotChunks"`
+	AccountBlocks  int             `json:"accountBlocks"`
+
+	// the unconfirmed blocks are deleted but not backed up, only the snapshot chunks are
+	UnconfirmedBlocks int `json:"unconfirmedBlocks"`
+
+	Accounts map[types.Address]*recoverAccount `json:"accounts"`
+
+	// the balances are not reported if the history balances can't be read
+	BalanceError string `json:"balanceError,omitempty"`
+}
+
+// rangeDeletedChunks reads the snapshot chunks from toHeight to the latest in batches
+func rangeDeletedChunks(c chain.Chain, toHeight uint64, f func(chunk *ledger.SnapshotChunk) error) error {
+	latestHeight := c.GetLatestSnapshotBlock().Height
+	for start := toHeight; start <= latestHeight; start += recoverReadBatch {
+		end := start + recoverReadBatch - 1
+		if end > latestHeight {
+			end = latestHeight
+		}
+
+		// the first chunk is the snapshot block at start - 1
+		chunks, err := c.GetSubLedger(start-1, end)
+		if err != nil {
+			return err
+		}
+		if len(chunks) <= 0 || chunks[len(chunks)-1].SnapshotBlock.Height != end {
+			return fmt.Errorf("failed to read snapshot chunks from %d to %d", start, end)
+		}
+
+		for _, chunk := range chunks[1:] {
+			if err := f(chunk); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func makeRecoverPlan(c chain.Chain, toHeight uint64, maxDepth uint64) (*recoverPlan, error) {
+	latestHeight := c.GetLatestSnapshotBlock().Height
+	plan := &recoverPlan{
+		LatestHeight:   latestHeight,
+		DeleteToHeight: toHeight,
+		Depth:          latestHeight - toHeight + 1,
+		MaxDepth:       maxDepth,
+		SnapshotChunks: make([]*recoverChunk, 0),
+		Accounts:       make(map[types.Address]*recoverAccount),
+	}
+
+	addBlock := func(block *ledger.AccountBlock, snapshotHeight uint64) {
+		account, ok := plan.Accounts[block.AccountAddress]
+		if !ok {
+			account = &recoverAccount{tokens: make(map[types.TokenTypeId]struct{})}
+			plan.Accounts[block.AccountAddress] = account
+		}
+		if block.IsSendBlock() {
+			account.tokens[block.TokenId] = struct{}{}
+		} else {
+			account.fromHashes = append(account.fromHashes, block.FromBlockHash)
+			for _, sendBlock := range block.SendBlockList {
+				account.tokens[sendBlock.TokenId] = struct{}{}
+			}
+		}
+		account.Blocks = append(account.Blocks, &recoverBlock{
+			Height:         block.Height,
+			Hash:           block.Hash,
+			BlockType:      block.BlockType,
+			SnapshotHeight: snapshotHeight,
+		})
+	}
+
+	if err := rangeDeletedChunks(c, toHeight, func(chunk *ledger.SnapshotChunk) error {
+		plan.SnapshotChunks = append(plan.SnapshotChunks, &recoverChunk{
+			Height:        chunk.SnapshotBlock.Height,
+			Hash:          chunk.SnapshotBlock.Hash,
+			AccountBlocks: len(chunk.AccountBlocks),
+		})
+		plan.AccountBlocks += len(chunk.AccountBlocks)
+		for _, block := range chunk.AccountBlocks {
+			addBlock(block, chunk.SnapshotBlock.Height)
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	unconfirmedBlocks := c.GetAllUnconfirmedBlocks()
+	plan.UnconfirmedBlocks = len(unconfirmedBlocks)
+	plan.AccountBlocks += len(unconfirmedBlocks)
+	for _, block := range unconfirmedBlocks {
+		addBlock(block, 0)
+	}
+
+	if err := fillRecoverBalances(c, plan); err != nil {
+		plan.BalanceError = err.Error()
+	}
+	return plan, nil
+}
+
+// fillRecoverBalances compares the current balances with the balances at the snapshot block before toHeight, of the
+// tokens the accounts hold now and the tokens transferred by the deleted blocks
+func fillRecoverBalances(c chain.Chain, plan *recoverPlan) error {
+	sb, err := c.GetSnapshotHeaderByHeight(plan.DeleteToHeight - 1)
+	if err != nil {
+		return err
+	}
+	if sb == nil {
+		return fmt.Errorf("snapshot block %d is not existed", plan.DeleteToHeight-1)
+	}
+
+	currentBalances := make(map[types.TokenTypeId]map[types.Address]*big.Int)
+	for addr, account := range plan.Accounts {
+		balanceMap, err := c.GetBalanceMap(addr)
+		if err != nil {
+			return err
+		}
+		for _, fromHash := range account.fromHashes {
+			sendBlock, err := c.GetAccountBlockByHash(fromHash)
+			if err != nil {
+				return err
+			}
+			if sendBlock == nil {
+				return fmt.Errorf("send block %s is not existed", fromHash)
+			}
+			account.tokens[sendBlock.TokenId] = struct{}{}
+		}
+		for tokenId := range balanceMap {
+			account.tokens[tokenId] = struct{}{}
+		}
+
+		account.Balances = make(map[types.TokenTypeId]*recoverBalance)
+		for tokenId := range account.tokens {
+			if _, ok := currentBalances[tokenId]; !ok {
+				currentBalances[tokenId] = make(map[types.Address]*big.Int)
+			}
+			// no balance of the token now
+			balance := balanceMap[tokenId]
+			if balance == nil {
+				balance = big.NewInt(0)
+			}
+			currentBalances[tokenId][addr] = balance
+		}
+	}
+
+	for tokenId, balances := range currentBalances {
+		addrList := make([]types.Address, 0, len(balances))
+		for addr := range balances {
+			addrList = append(addrList, addr)
+		}
+
+		afterBalances, err := c.GetConfirmedBalanceList(addrList, tokenId, sb.Hash)
+		if err != nil {
+			return err
+		}
+
+		for addr, current := range balances {
+			after := afterBalances[addr]
+			if after == nil {
+				after = big.NewInt(0)
+			}
+			if current.Cmp(after) == 0 {
+				continue
+			}
+			plan.Accounts[addr].Balances[tokenId] = &recoverBalance{
+				Current: current.String(),
+				After:   after.String(),
+				Change:  new(big.Int).Sub(after, current).String(),
+			}
+		}
+	}
+	return nil
+}
+
+// backupDeletedChunks writes the snapshot chunks from toHeight to the latest into a block db at dir,
+// the blocks can be loaded again by gvite load --fromDir. The unconfirmed blocks are not included.
+func backupDeletedChunks(c chain.Chain, toHeight uint64, dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("backup directory %s is existed", dir)
+	}
+
+	blockDB, err := chain_block.NewBlockDB(dir)
+	if err != nil {
+		return err
+	}
+	defer blockDB.Close()
+
+	count := 0
+	if err := rangeDeletedChunks(c, toHeight, func(chunk *ledger.SnapshotChunk) error {
+		if _, _, err := blockDB.Write(chunk); err != nil {
+			return err
+		}
+
+		count++
+		if count%recoverReadBatch == 0 {
+			return flushBlockDB(blockDB)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return flushBlockDB(blockDB)
+}
+
+func flushBlockDB(blockDB *chain_block.BlockDB) error {
+	blockDB.Prepare()
+	if err := blockDB.Commit(); err != nil {
+		blockDB.CancelPrepare()
+		return err
+	}
+	blockDB.AfterCommit()
+	return nil
+}
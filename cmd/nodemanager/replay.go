@@ -0,0 +1,499 @@
+package nodemanager
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/ledger/chain"
+	chain_state "github.com/vitelabs/go-vite/v2/ledger/chain/state"
+	"github.com/vitelabs/go-vite/v2/ledger/consensus/core"
+	"github.com/vitelabs/go-vite/v2/ledger/generator"
+)
+
+const (
+	replayReadBatch = 1000
+
+	replayProgressInterval = 10000
+)
+
+type replayDiff struct {
+	Item     string `json:"item"`
+	Stored   string `json:"stored"`
+	Replayed string `json:"replayed"`
+}
+
+type replayDivergence struct {
+	SnapshotHeight uint64        `json:"snapshotHeight"`
+	SnapshotHash   types.Hash    `json:"snapshotHash"`
+	Address        types.Address `json:"address"`
+	BlockHeight    uint64        `json:"blockHeight"`
+	BlockHash      types.Hash    `json:"blockHash"`
+
+	// the vm failed to execute the block
+	Error string        `json:"error,omitempty"`
+	Diffs []*replayDiff `json:"diffs,omitempty"`
+}
+
+type replayReport struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+
+	// the state at From is rebuilt by replaying from RebuildFrom, the rebuilt blocks are compared too
+	RebuildFrom uint64 `json:"rebuildFrom,omitempty"`
+
+	// the latest snapshot height at which the replay matches the ledger
+	ReplayedHeight uint64 `json:"replayedHeight"`
+	AccountBlocks  uint64 `json:"accountBlocks"`
+
+	Divergence *replayDivergence `json:"divergence,omitempty"`
+}
+
+// replayState is the storage keys and the tokens written by the replayed blocks of a snapshot block,
+// they are compared after the snapshot block is inserted if there's no redo log in the ledger
+type replayState struct {
+	block   *ledger.AccountBlock
+	storage map[string]struct{}
+	tokens  map[types.TokenTypeId]struct{}
+}
+
+type replayer struct {
+	source    chain.Chain
+	work      chain.Chain
+	sbpReader core.SBPStatReader
+}
+
+func newReplayer(source, work chain.Chain, sbpReader core.SBPStatReader) *replayer {
+	return &replayer{
+		source:    source,
+		work:      work,
+		sbpReader: sbpReader,
+	}
+}
+
+func (r *replayer) replay(from, to uint64) (*replayReport, error) {
+	if err := r.prepare(from); err != nil {
+		return nil, err
+	}
+
+	start := r.work.GetLatestSnapshotBlock().Height + 1
+	report := &replayReport{
+		From:           from,
+		To:             to,
+		ReplayedHeight: start - 1,
+	}
+	if start <= from {
+		report.RebuildFrom = start
+		fmt.Printf("Rebuild the state from %d to %d\n", start, from)
+	}
+
+	for ; start <= to; start += replayReadBatch {
+		end := start + replayReadBatch - 1
+		if end > to {
+			end = to
+		}
+
+		// the first chunk is the snapshot block at start - 1
+		chunks, err := r.source.GetSubLedger(start-1, end)
+		if err != nil {
+			return nil, err
+		}
+		if len(chunks) <= 0 || chunks[len(chunks)-1].SnapshotBlock.Height != end {
+			return nil, fmt.Errorf("failed to read snapshot chunks from %d to %d", start, end)
+		}
+
+		for _, chunk := range chunks[1:] {
+			divergence, err := r.replayChunk(chunk)
+			if err != nil {
+				return nil, err
+			}
+			if divergence != nil {
+				report.Divergence = divergence
+				return report, nil
+			}
+
+			report.ReplayedHeight = chunk.SnapshotBlock.Height
+			report.AccountBlocks += uint64(len(chunk.AccountBlocks))
+			if report.ReplayedHeight%replayProgressInterval == 0 {
+				fmt.Printf("Replayed to %d, %d account blocks\n", report.ReplayedHeight, report.AccountBlocks)
+			}
+		}
+	}
+	return report, nil
+}
+
+// prepare rolls the rebuilt ledger back to from, the blocks left by an interrupted replay are deleted
+func (r *replayer) prepare(from uint64) error {
+	if unconfirmed := r.work.GetAllUnconfirmedBlocks(); len(unconfirmed) > 0 {
+		if _, err := r.work.DeleteAccountBlocks(unconfirmed[0].AccountAddress, unconfirmed[0].Hash); err != nil {
+			return err
+		}
+	}
+
+	if r.work.GetLatestSnapshotBlock().Height > from {
+		if _, err := r.work.DeleteSnapshotBlocksToHeight(from + 1); err != nil {
+			return err
+		}
+	}
+
+	latest := r.work.GetLatestSnapshotBlock()
+	hash, err := r.source.GetSnapshotHashByHeight(latest.Height)
+	if err != nil {
+		return err
+	}
+	if hash == nil || *hash != latest.Hash {
+		return fmt.Errorf("the rebuilt snapshot block %s at %d is not in the ledger, remove the work dir and replay again",
+			latest.Hash, latest.Height)
+	}
+	return nil
+}
+
+func (r *replayer) replayChunk(chunk *ledger.SnapshotChunk) (*replayDivergence, error) {
+	sb := chunk.SnapshotBlock
+	latestHash := r.work.GetLatestSnapshotBlock().Hash
+
+	newDivergence := func(block *ledger.AccountBlock) *replayDivergence {
+		return &replayDivergence{
+			SnapshotHeight: sb.Height,
+			SnapshotHash:   sb.Hash,
+			Address:        block.AccountAddress,
+			BlockHeight:    block.Height,
+			BlockHash:      block.Hash,
+		}
+	}
+
+	// the redo logs are only kept for the recent snapshot blocks
+	_, _, stateDB := r.source.DBs()
+	redoLog, ok, err := stateDB.Redo().QueryLog(sb.Height)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		redoLog = nil
+	}
+
+	var states []*replayState
+	for _, block := range chunk.AccountBlocks {
+		var fromBlock *ledger.AccountBlock
+		if block.IsReceiveBlock() {
+			fromBlock, err = r.work.GetAccountBlockByHash(block.FromBlockHash)
+			if err != nil {
+				return nil, err
+			}
+			if fromBlock == nil {
+				d := newDivergence(block)
+				d.Error = fmt.Sprintf("send block %s is not replayed", block.FromBlockHash)
+				return d, nil
+			}
+		}
+
+		gen, err := generator.NewGenerator(r.work, r.sbpReader, block.AccountAddress, &latestHash, &block.PrevHash)
+		if err != nil {
+			return nil, err
+		}
+		result, err := gen.GenerateWithBlock(block.Copy(), fromBlock)
+		if err == nil && result.VMBlock == nil {
+			err = result.Err
+			if err == nil {
+				err = fmt.Errorf("vm failed, no block is generated")
+			}
+		}
+		if err != nil {
+			d := newDivergence(block)
+			d.Error = err.Error()
+			return d, nil
+		}
+
+		vmBlock := result.VMBlock
+		diffs := diffReplayedBlock(block, vmBlock.AccountBlock)
+		if block.LogHash != nil && !sameHash(block.LogHash, vmBlock.AccountBlock.LogHash) {
+			storedLogs, err := r.source.GetVmLogList(block.LogHash)
+			if err != nil {
+				return nil, err
+			}
+			diffs = append(diffs, diffVmLogs(storedLogs, vmBlock.VmDb.GetLogList())...)
+		}
+
+		if item := findRedoLogItem(redoLog, block); item != nil {
+			diffs = append(diffs, diffStorage(item.Storage, vmBlock.VmDb.GetUnsavedStorage())...)
+			diffs = append(diffs, diffBalances(item.BalanceMap, vmBlock.VmDb.GetUnsavedBalanceMap())...)
+		} else {
+			states = append(states, newReplayState(block, vmBlock.VmDb))
+		}
+
+		if len(diffs) > 0 {
+			d := newDivergence(block)
+			d.Diffs = diffs
+			return d, nil
+		}
+
+		if err := r.work.InsertAccountBlock(&interfaces.VmAccountBlock{
+			AccountBlock: block,
+			VmDb:         vmBlock.VmDb,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := r.work.InsertSnapshotBlock(sb); err != nil {
+		return nil, err
+	}
+	if latest := r.work.GetLatestSnapshotBlock(); latest.Hash != sb.Hash {
+		return nil, fmt.Errorf("insert snapshot block %s at %d failed, the latest is %s", sb.Hash, sb.Height, latest.Hash)
+	}
+
+	for _, state := range states {
+		diffs, err := r.diffState(sb, state)
+		if err != nil {
+			return nil, err
+		}
+		if len(diffs) > 0 {
+			d := newDivergence(state.block)
+			d.Diffs = diffs
+			return d, nil
+		}
+	}
+	return nil, nil
+}
+
+// diffState compares the state written by a replayed block at the snapshot block in both ledgers
+func (r *replayer) diffState(sb *ledger.SnapshotBlock, state *replayState) ([]*replayDiff, error) {
+	_, _, sourceDB := r.source.DBs()
+	_, _, workDB := r.work.DBs()
+	addr := state.block.AccountAddress
+
+	var diffs []*replayDiff
+	for _, key := range sortedStrings(state.storage) {
+		stored, err := sourceDB.GetSnapshotValue(sb.Height, addr, []byte(key))
+		if err != nil {
+			return nil, err
+		}
+		replayed, err := workDB.GetSnapshotValue(sb.Height, addr, []byte(key))
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(stored, replayed) {
+			diffs = append(diffs, &replayDiff{
+				Item:     fmt.Sprintf("storage[%s]", hex.EncodeToString([]byte(key))),
+				Stored:   hex.EncodeToString(stored),
+				Replayed: hex.EncodeToString(replayed),
+			})
+		}
+	}
+
+	for tokenId := range state.tokens {
+		stored, err := r.source.GetConfirmedBalanceList([]types.Address{addr}, tokenId, sb.Hash)
+		if err != nil {
+			return nil, err
+		}
+		replayed, err := r.work.GetConfirmedBalanceList([]types.Address{addr}, tokenId, sb.Hash)
+		if err != nil {
+			return nil, err
+		}
+		if bigIntCmp(stored[addr], replayed[addr]) != 0 {
+			diffs = append(diffs, &replayDiff{
+				Item:     fmt.Sprintf("balance[%s]", tokenId),
+				Stored:   bigIntString(stored[addr]),
+				Replayed: bigIntString(replayed[addr]),
+			})
+		}
+	}
+	return diffs, nil
+}
+
+func newReplayState(block *ledger.AccountBlock, vmDb interfaces.VmDb) *replayState {
+	state := &replayState{
+		block:   block,
+		storage: make(map[string]struct{}),
+		tokens:  make(map[types.TokenTypeId]struct{}),
+	}
+	for _, kv := range vmDb.GetUnsavedStorage() {
+		state.storage[string(kv[0])] = struct{}{}
+	}
+	for tokenId := range vmDb.GetUnsavedBalanceMap() {
+		state.tokens[tokenId] = struct{}{}
+	}
+	return state
+}
+
+func findRedoLogItem(redoLog chain_state.SnapshotLog, block *ledger.AccountBlock) *chain_state.LogItem {
+	items := redoLog[block.AccountAddress]
+	for i := range items {
+		if items[i].Height == block.Height {
+			return &items[i]
+		}
+	}
+	return nil
+}
+
+// diffReplayedBlock compares the fields of the replayed block with the stored block
+func diffReplayedBlock(stored, replayed *ledger.AccountBlock) []*replayDiff {
+	var diffs []*replayDiff
+	add := func(item string, stored, replayed interface{}) {
+		s, r := fmt.Sprint(stored), fmt.Sprint(replayed)
+		if s != r {
+			diffs = append(diffs, &replayDiff{Item: item, Stored: s, Replayed: r})
+		}
+	}
+
+	add("hash", stored.Hash, replayed.Hash)
+	add("blockType", stored.BlockType, replayed.BlockType)
+	add("toAddress", stored.ToAddress, replayed.ToAddress)
+	add("fromBlockHash", stored.FromBlockHash, replayed.FromBlockHash)
+	add("height", stored.Height, replayed.Height)
+	add("data", hex.EncodeToString(stored.Data), hex.EncodeToString(replayed.Data))
+	add("logHash", hashString(stored.LogHash), hashString(replayed.LogHash))
+	add("quota", stored.Quota, replayed.Quota)
+	add("quotaUsed", stored.QuotaUsed, replayed.QuotaUsed)
+	add("amount", bigIntString(stored.Amount), bigIntString(replayed.Amount))
+	add("fee", bigIntString(stored.Fee), bigIntString(replayed.Fee))
+	add("tokenId", stored.TokenId, replayed.TokenId)
+
+	add("sendBlockList len", len(stored.SendBlockList), len(replayed.SendBlockList))
+	for i := 0; i < len(stored.SendBlockList) && i < len(replayed.SendBlockList); i++ {
+		add(fmt.Sprintf("sendBlockList[%d] hash", i), stored.SendBlockList[i].Hash, replayed.SendBlockList[i].Hash)
+	}
+	return diffs
+}
+
+// diffVmLogs compares the vm logs, the stored logs are nil if the ledger doesn't save the logs of the contract
+func diffVmLogs(stored, replayed ledger.VmLogList) []*replayDiff {
+	if stored == nil {
+		return nil
+	}
+
+	var diffs []*replayDiff
+	for i := 0; i < len(stored) || i < len(replayed); i++ {
+		var s, r string
+		if i < len(stored) {
+			s = vmLogString(stored[i])
+		}
+		if i < len(replayed) {
+			r = vmLogString(replayed[i])
+		}
+		if s != r {
+			diffs = append(diffs, &replayDiff{Item: fmt.Sprintf("vmLog[%d]", i), Stored: s, Replayed: r})
+		}
+	}
+	return diffs
+}
+
+// diffStorage compares the storage written by a block, the latest value of a key wins
+func diffStorage(stored, replayed [][2][]byte) []*replayDiff {
+	storedMap, replayedMap := storageMap(stored), storageMap(replayed)
+	keys := make(map[string]struct{}, len(storedMap)+len(replayedMap))
+	for key := range storedMap {
+		keys[key] = struct{}{}
+	}
+	for key := range replayedMap {
+		keys[key] = struct{}{}
+	}
+
+	var diffs []*replayDiff
+	for _, key := range sortedStrings(keys) {
+		s, sOk := storedMap[key]
+		r, rOk := replayedMap[key]
+		if sOk != rOk || !bytes.Equal(s, r) {
+			diffs = append(diffs, &replayDiff{
+				Item:     fmt.Sprintf("storage[%s]", hex.EncodeToString([]byte(key))),
+				Stored:   storageValueString(s, sOk),
+				Replayed: storageValueString(r, rOk),
+			})
+		}
+	}
+	return diffs
+}
+
+func diffBalances(stored, replayed map[types.TokenTypeId]*big.Int) []*replayDiff {
+	tokens := make(map[types.TokenTypeId]struct{}, len(stored)+len(replayed))
+	for tokenId := range stored {
+		tokens[tokenId] = struct{}{}
+	}
+	for tokenId := range replayed {
+		tokens[tokenId] = struct{}{}
+	}
+
+	var diffs []*replayDiff
+	for tokenId := range tokens {
+		s, sOk := stored[tokenId]
+		r, rOk := replayed[tokenId]
+		if sOk != rOk || bigIntCmp(s, r) != 0 {
+			diffs = append(diffs, &replayDiff{
+				Item:     fmt.Sprintf("balance[%s]", tokenId),
+				Stored:   bigIntString(s),
+				Replayed: bigIntString(r),
+			})
+		}
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Item < diffs[j].Item
+	})
+	return diffs
+}
+
+func storageMap(kvs [][2][]byte) map[string][]byte {
+	m := make(map[string][]byte, len(kvs))
+	for _, kv := range kvs {
+		m[string(kv[0])] = kv[1]
+	}
+	return m
+}
+
+func storageValueString(value []byte, ok bool) string {
+	if !ok {
+		return "unchanged"
+	}
+	if len(value) <= 0 {
+		return "deleted"
+	}
+	return hex.EncodeToString(value)
+}
+
+func vmLogString(log *ledger.VmLog) string {
+	return fmt.Sprintf("topics: %v, data: %s", log.Topics, hex.EncodeToString(log.Data))
+}
+
+func sortedStrings(set map[string]struct{}) []string {
+	list := make([]string, 0, len(set))
+	for s := range set {
+		list = append(list, s)
+	}
+	sort.Strings(list)
+	return list
+}
+
+func sameHash(a, b *types.Hash) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func hashString(hash *types.Hash) string {
+	if hash == nil {
+		return "nil"
+	}
+	return hash.String()
+}
+
+func bigIntString(n *big.Int) string {
+	if n == nil {
+		return "0"
+	}
+	return n.String()
+}
+
+func bigIntCmp(a, b *big.Int) int {
+	if a == nil {
+		a = big.NewInt(0)
+	}
+	if b == nil {
+		b = big.NewInt(0)
+	}
+	return a.Cmp(b)
+}
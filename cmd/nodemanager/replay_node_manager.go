@@ -0,0 +1,139 @@
+package nodemanager
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/vitelabs/go-vite/v2/cmd/utils"
+	"github.com/vitelabs/go-vite/v2/common/upgrade"
+	"github.com/vitelabs/go-vite/v2/ledger/chain"
+	"github.com/vitelabs/go-vite/v2/ledger/consensus"
+	"github.com/vitelabs/go-vite/v2/ledger/pool/lock"
+	"github.com/vitelabs/go-vite/v2/node"
+	"github.com/vitelabs/go-vite/v2/vm"
+)
+
+type ReplayNodeManager struct {
+	ctx  *cli.Context
+	node *node.Node
+
+	// source is the ledger in the data dir, it's only read
+	source chain.Chain
+	// work is the ledger rebuilt by re-executing the blocks of source
+	work chain.Chain
+}
+
+func NewReplayNodeManager(ctx *cli.Context, maker NodeMaker) (*ReplayNodeManager, error) {
+	node, err := maker.MakeNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReplayNodeManager{
+		ctx:  ctx,
+		node: node,
+	}, nil
+}
+
+func (nodeManager *ReplayNodeManager) getWorkDir() string {
+	if nodeManager.ctx.GlobalIsSet(utils.ReplayWorkDirFlag.Name) {
+		return nodeManager.ctx.GlobalString(utils.ReplayWorkDirFlag.Name)
+	}
+	return filepath.Join(nodeManager.node.ViteConfig().DataDir, "replay")
+}
+
+func (nodeManager *ReplayNodeManager) Start() error {
+	viteConfig := nodeManager.node.ViteConfig()
+
+	dataDir := viteConfig.DataDir
+	chainCfg := viteConfig.Chain
+	genesisCfg := viteConfig.Genesis
+	// set upgrade
+	upgrade.InitUpgradeBox(genesisCfg.UpgradeCfg.MakeUpgradeBox())
+	vm.InitVMConfig(viteConfig.IsVmTest, viteConfig.IsUseVmTestParam, viteConfig.IsUseQuotaTestParam, false, dataDir)
+
+	source := chain.NewChain(dataDir, chainCfg, genesisCfg)
+	if err := source.Init(); err != nil {
+		return err
+	}
+	if err := source.Start(); err != nil {
+		return err
+	}
+	nodeManager.source = source
+
+	latestHeight := source.GetLatestSnapshotBlock().Height
+	from := nodeManager.ctx.GlobalUint64(utils.ReplayFromFlag.Name)
+	to := latestHeight
+	if nodeManager.ctx.GlobalIsSet(utils.ReplayToFlag.Name) {
+		to = nodeManager.ctx.GlobalUint64(utils.ReplayToFlag.Name)
+	}
+	if from < 1 || from >= to || to > latestHeight {
+		return fmt.Errorf("replay from %d to %d, must be 1 <= from < to <= %d", from, to, latestHeight)
+	}
+
+	// the rebuilt ledger only keeps what the vm reads
+	workCfg := *chainCfg
+	workCfg.LedgerGc = false
+	workCfg.OpenPlugins = false
+	workCfg.StateSnapshotInterval = 0
+	workCfg.Prune = false
+	workCfg.VmLogAll = true
+
+	workDir := nodeManager.getWorkDir()
+	work := chain.NewChain(workDir, &workCfg, genesisCfg)
+	if err := work.Init(); err != nil {
+		return err
+	}
+	if err := work.Start(); err != nil {
+		return err
+	}
+	nodeManager.work = work
+
+	if work.GetGenesisSnapshotBlock().Hash != source.GetGenesisSnapshotBlock().Hash {
+		return fmt.Errorf("the genesis of %s is different from the data dir, remove it or set another --%s",
+			workDir, utils.ReplayWorkDirFlag.Name)
+	}
+
+	cs := consensus.NewConsensus(work, &lock.EasyImpl{})
+	if err := cs.Init(consensus.Cfg()); err != nil {
+		return err
+	}
+	work.SetConsensus(cs, cs.SBPReader().GetPeriodTimeIndex())
+
+	fmt.Printf("Latest snapshot block height is %d\n", latestHeight)
+	fmt.Printf("Replay from %d to %d in %s\n", from, to, workDir)
+
+	r := newReplayer(source, work, cs.SBPReader())
+	report, err := r.replay(from, to)
+	if err != nil {
+		return err
+	}
+
+	buf, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(buf))
+	if report.Divergence != nil {
+		return fmt.Errorf("replay diverged at snapshot height %d, account %s, block height %d",
+			report.Divergence.SnapshotHeight, report.Divergence.Address, report.Divergence.BlockHeight)
+	}
+	return nil
+}
+
+func (nodeManager *ReplayNodeManager) Stop() error {
+	if nodeManager.work != nil {
+		nodeManager.work.Stop()
+	}
+	if nodeManager.source != nil {
+		nodeManager.source.Stop()
+	}
+	return nil
+}
+
+func (nodeManager *ReplayNodeManager) Node() *node.Node {
+	return nodeManager.node
+}
@@ -16,7 +16,7 @@ var dir = flag.String("dataDir", "devdata", "data dir, for example: devdata")
 
 func main() {
 	flag.Parse()
-	db, err := chain_index.NewIndexDB(*dir)
+	db, err := chain_index.NewIndexDB(*dir, "")
 
 	helper.AssertNil(err)
 	hash := types.HexToHashPanic(*hash)
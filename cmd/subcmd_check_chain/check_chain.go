@@ -12,14 +12,16 @@ import (
 )
 
 var (
-	checkChainCommand = cli.Command{
-		Action:   utils.MigrateFlags(checkChainAction),
-		Name:     "checkChain",
-		Usage:    "checkChain",
-		Category: "CHECK CHAIN COMMANDS",
-		Flags:    utils.ConfigFlags,
+	CheckChainCommand = cli.Command{
+		Action:    utils.MigrateFlags(checkChainAction),
+		Name:      "checkChain",
+		Usage:     "checkChain --full --report=report.json",
+		ArgsUsage: "--full --report=report.json",
+		Category:  "CHECK CHAIN COMMANDS",
+		Flags:     append(utils.CheckChainFlags, utils.ConfigFlags...),
 		Description: `
-check chain
+Check chain. With --full, all blocks in block files are checked, and a json report with the first
+inconsistent snapshot height and the recover command is written.
 `,
 	}
 	log = log15.New("module", "gvite/export")
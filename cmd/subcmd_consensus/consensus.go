@@ -0,0 +1,106 @@
+package subcmd_consensus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/vitelabs/go-vite/v2/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/v2/cmd/utils"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/ledger/consensus"
+	"github.com/vitelabs/go-vite/v2/ledger/consensus/core"
+)
+
+// defaultSimulatePeriods is the count of the periods simulated by default, about one hour of the mainnet
+const defaultSimulatePeriods = 48
+
+var (
+	ConsensusCommand = cli.Command{
+		Name:        "consensus",
+		Usage:       "consensus tools",
+		Category:    "LOCAL COMMANDS",
+		Description: `Tools of the snapshot consensus group on the local ledger.`,
+		Subcommands: []cli.Command{
+			{
+				Name:  "simulate",
+				Usage: "replay the historical votes through the election algorithms",
+				Description: `Replay the votes at the proof snapshot block of each period through the election algorithms,
+report the elected producers, their success rates and the concentration of their votes.
+The first algorithm is the baseline which the others are compared with.`,
+				Flags:  append(utils.ConfigFlags, utils.SimulateFlags...),
+				Action: utils.MigrateFlags(simulateAction),
+			},
+		},
+	}
+)
+
+func simulateAction(ctx *cli.Context) error {
+	node, err := nodemanager.LocalNodeMaker{}.MakeNode(ctx)
+	if err != nil {
+		return err
+	}
+	if err := node.Prepare(); err != nil {
+		return err
+	}
+	v := node.Vite()
+
+	cs := v.Consensus()
+	if err := cs.Init(consensus.Cfg()); err != nil {
+		return err
+	}
+
+	algos := strings.Split(ctx.String(utils.SimulateAlgosFlag.Name), ",")
+	for i := range algos {
+		algos[i] = strings.TrimSpace(algos[i])
+	}
+
+	// the period of the latest snapshot block is not finished yet
+	latest := v.Chain().GetLatestSnapshotBlock()
+	latestIndex, err := cs.VoteTimeToIndex(types.SNAPSHOT_GID, *latest.Timestamp)
+	if err != nil {
+		return err
+	}
+	if latestIndex < 2 {
+		return fmt.Errorf("no finished period to simulate, latest index is %d", latestIndex)
+	}
+	end := latestIndex - 1
+	if ctx.IsSet(utils.SimulateEndIndexFlag.Name) {
+		end = ctx.Uint64(utils.SimulateEndIndexFlag.Name)
+	}
+	start := uint64(1)
+	if end > defaultSimulatePeriods {
+		start = end - defaultSimulatePeriods + 1
+	}
+	if ctx.IsSet(utils.SimulateStartIndexFlag.Name) {
+		start = ctx.Uint64(utils.SimulateStartIndexFlag.Name)
+	}
+	if start < 1 || start > end || end >= latestIndex {
+		return fmt.Errorf("simulate from index %d to %d, must be 1 <= start <= end < %d", start, end, latestIndex)
+	}
+
+	fmt.Printf("Registered algorithms are %v\n", core.AlgoNames())
+	fmt.Printf("Simulate %v from index %d to %d\n", algos, start, end)
+
+	report, err := cs.API().SimulateElection(algos, start, end)
+	if err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(report, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	if output := ctx.String(utils.SimulateOutputFlag.Name); output != "" {
+		if err := ioutil.WriteFile(output, buf, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("The report is written to %s\n", output)
+		return nil
+	}
+	fmt.Println(string(buf))
+	return nil
+}
@@ -0,0 +1,119 @@
+package subcmd_devnet
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/vitelabs/go-vite/v2/cmd/utils"
+)
+
+var (
+	DevNetCommand = cli.Command{
+		Action:   utils.MigrateFlags(devnetAction),
+		Name:     "devnet",
+		Usage:    "generate and run a local private network with N SBPs",
+		Flags:    devnetFlags,
+		Category: "LOCAL COMMANDS",
+		Description: `Generate the genesis, entropy stores and node configs of a local private network in --devnetdir,
+then start every SBP node as a child process connected through localhost static nodes.
+An existing devnet in --devnetdir is started again without regenerating.`,
+	}
+
+	devnetFlags = []cli.Flag{
+		devnetDirFlag,
+		devnetNodesFlag,
+		devnetAccountsFlag,
+		devnetBasePortFlag,
+		devnetGenerateFlag,
+		utils.NetworkIdFlag,
+	}
+)
+
+var (
+	devnetDirFlag = cli.StringFlag{
+		Name:  "devnetdir",
+		Usage: "The `dir` of the devnet",
+		Value: "devnet",
+	}
+	devnetNodesFlag = cli.IntFlag{
+		Name:  "nodes",
+		Usage: "The count of SBP nodes in the devnet",
+		Value: DefaultNodes,
+	}
+	devnetAccountsFlag = cli.IntFlag{
+		Name:  "accounts",
+		Usage: "The count of prefunded accounts in the devnet, the first one is the genesis account",
+		Value: DefaultAccounts,
+	}
+	devnetBasePortFlag = cli.IntFlag{
+		Name:  "baseport",
+		Usage: "The first port of the devnet, every node takes 10 ports from it",
+		Value: DefaultBasePort,
+	}
+	devnetGenerateFlag = cli.BoolFlag{
+		Name:  "generate",
+		Usage: "Only generate the devnet, don't start the nodes",
+	}
+
+	devnetStopTimeout = 30 * time.Second
+)
+
+func devnetAction(ctx *cli.Context) error {
+	if args := ctx.Args(); len(args) > 0 {
+		return fmt.Errorf("invalid command: %q", args[0])
+	}
+
+	dir := ctx.GlobalString(devnetDirFlag.Name)
+	network, err := Load(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return err
+		}
+		network, err = Generate(Options{
+			Dir:      dir,
+			Nodes:    ctx.GlobalInt(devnetNodesFlag.Name),
+			Accounts: ctx.GlobalInt(devnetAccountsFlag.Name),
+			BasePort: ctx.GlobalInt(devnetBasePortFlag.Name),
+			NetID:    int(ctx.GlobalUint(utils.NetworkIdFlag.Name)),
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("devnet is generated in %s\n", network.Dir)
+	}
+
+	for _, node := range network.Nodes {
+		fmt.Printf("node %s, coinbase: %s, rpc: %s, config: %s\n", node.Name, node.Coinbase, node.HttpEndpoint(), node.ConfigFile)
+	}
+	if ctx.GlobalBool(devnetGenerateFlag.Name) {
+		return nil
+	}
+
+	bin, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := network.Start(bin); err != nil {
+		return err
+	}
+	fmt.Printf("devnet is started with %d nodes, press Ctrl+C to stop\n", len(network.Nodes))
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sig)
+
+	select {
+	case <-sig:
+		fmt.Println("stopping devnet...")
+		network.Stop(devnetStopTimeout)
+		return nil
+	case node := <-network.Exited():
+		network.Stop(devnetStopTimeout)
+		return fmt.Errorf("node %s exited, %v, see %s", node.Name, node.Err(), node.LogFile())
+	}
+}
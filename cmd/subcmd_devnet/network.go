@@ -0,0 +1,490 @@
+package subcmd_devnet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/crypto/ed25519"
+	"github.com/vitelabs/go-vite/v2/interfaces/core"
+	nodeconfig "github.com/vitelabs/go-vite/v2/node/config"
+	"github.com/vitelabs/go-vite/v2/wallet"
+	"github.com/vitelabs/go-vite/v2/wallet/entropystore"
+)
+
+const (
+	DefaultNodes    = 3
+	DefaultAccounts = 5
+	DefaultBasePort = 18480
+	DefaultNetID    = 5
+
+	maxNodes = 25
+
+	manifestFileName = "devnet.json"
+	genesisFileName  = "genesis.json"
+	nodeConfigName   = "node_config.json"
+	nodeLogName      = "gvite.log"
+
+	// every node takes portStep ports from base port: p2p, file, http, ws
+	portStep = 10
+
+	entropyStorePassword = "123456"
+)
+
+var (
+	viteDecimals = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+	viteTotalSupply   = vite(1000000000)
+	sbpRegisterAmount = vite(100000)
+	sbpBalance        = vite(1000000)
+	accountBalance    = vite(10000000)
+	stakeAmount       = vite(10000)
+)
+
+func vite(n int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(n), viteDecimals)
+}
+
+// Options of the generated devnet
+type Options struct {
+	Dir      string
+	Nodes    int
+	Accounts int
+	BasePort int
+	NetID    int
+	Upgrade  string // upgrade level of genesis, latest or mainnet
+}
+
+// Account is a prefunded account, the first one is the genesis account
+type Account struct {
+	Address    types.Address `json:"address"`
+	PrivateKey string        `json:"privateKey"`
+	Mnemonic   string        `json:"mnemonic"`
+}
+
+// Node is a SBP node of the devnet
+type Node struct {
+	Name       string        `json:"name"`
+	Dir        string        `json:"dir"`
+	ConfigFile string        `json:"configFile"`
+	Coinbase   types.Address `json:"coinbase"`
+	PeerId     string        `json:"peerId"`
+	Port       int           `json:"port"`
+	HttpPort   int           `json:"httpPort"`
+	WSPort     int           `json:"wsPort"`
+
+	cmd    *exec.Cmd
+	log    *os.File
+	exited chan struct{}
+	err    error
+}
+
+// HttpEndpoint is the rpc endpoint of the node
+func (n *Node) HttpEndpoint() string {
+	return fmt.Sprintf("http://127.0.0.1:%d", n.HttpPort)
+}
+
+// LogFile is the output of the node process
+func (n *Node) LogFile() string {
+	return filepath.Join(n.Dir, nodeLogName)
+}
+
+// Network is a local private network with N SBPs, it's persisted in devnet.json of the dir
+type Network struct {
+	Dir         string     `json:"dir"`
+	NetID       int        `json:"netId"`
+	GenesisFile string     `json:"genesisFile"`
+	Nodes       []*Node    `json:"nodes"`
+	Accounts    []*Account `json:"accounts"`
+}
+
+// Generate writes the genesis, the entropy stores and the node configs of a new devnet into opts.Dir
+func Generate(opts Options) (*Network, error) {
+	if opts.Nodes < 1 || opts.Nodes > maxNodes {
+		return nil, fmt.Errorf("the count of nodes must be in [1, %d]", maxNodes)
+	}
+	if opts.Accounts < 1 {
+		return nil, errors.New("at least one account is required as the genesis account")
+	}
+	if opts.BasePort <= 0 {
+		opts.BasePort = DefaultBasePort
+	}
+	if opts.NetID < 3 {
+		opts.NetID = DefaultNetID
+	}
+	if opts.Upgrade == "" {
+		opts.Upgrade = "latest"
+	}
+
+	dir, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(filepath.Join(dir, manifestFileName)); err == nil {
+		return nil, fmt.Errorf("devnet is existed in %s", dir)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	network := &Network{
+		Dir:         dir,
+		NetID:       opts.NetID,
+		GenesisFile: filepath.Join(dir, genesisFileName),
+	}
+
+	for i := 0; i < opts.Accounts; i++ {
+		addr, key, mnemonic, err := wallet.RandomMnemonic24()
+		if err != nil {
+			return nil, err
+		}
+		network.Accounts = append(network.Accounts, &Account{
+			Address:    addr,
+			PrivateKey: key.Hex(),
+			Mnemonic:   mnemonic,
+		})
+	}
+
+	peerKeys := make([]ed25519.PrivateKey, opts.Nodes)
+	for i := 0; i < opts.Nodes; i++ {
+		node, peerKey, err := newNode(dir, i, opts.BasePort)
+		if err != nil {
+			return nil, err
+		}
+		network.Nodes = append(network.Nodes, node)
+		peerKeys[i] = peerKey
+	}
+
+	genesis := network.makeGenesis(opts.Upgrade)
+	if err := writeJson(network.GenesisFile, genesis); err != nil {
+		return nil, err
+	}
+
+	for i, node := range network.Nodes {
+		if err := writeJson(node.ConfigFile, network.makeNodeConfig(node, peerKeys[i])); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeJson(filepath.Join(dir, manifestFileName), network); err != nil {
+		return nil, err
+	}
+	return network, nil
+}
+
+// Load reads the devnet generated in dir
+func Load(dir string) (*Network, error) {
+	buf, err := ioutil.ReadFile(filepath.Join(dir, manifestFileName))
+	if err != nil {
+		return nil, err
+	}
+	network := &Network{}
+	if err := json.Unmarshal(buf, network); err != nil {
+		return nil, err
+	}
+	return network, nil
+}
+
+func newNode(dir string, index int, basePort int) (*Node, ed25519.PrivateKey, error) {
+	name := fmt.Sprintf("s%d", index+1)
+	node := &Node{
+		Name:     name,
+		Dir:      filepath.Join(dir, name),
+		Port:     basePort + index*portStep,
+		HttpPort: basePort + index*portStep + 2,
+		WSPort:   basePort + index*portStep + 3,
+	}
+	node.ConfigFile = filepath.Join(node.Dir, nodeConfigName)
+
+	_, _, mnemonic, err := wallet.RandomMnemonic24()
+	if err != nil {
+		return nil, nil, err
+	}
+	em, err := entropystore.StoreNewEntropy(filepath.Join(node.Dir, "wallet"), mnemonic, entropyStorePassword, entropystore.DefaultMaxIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	node.Coinbase = em.GetPrimaryAddr()
+
+	pub, peerKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	node.PeerId = pub.Hex()
+	return node, peerKey, nil
+}
+
+func (network *Network) makeGenesis(upgradeLevel string) *config.Genesis {
+	genesisAddr := network.Accounts[0].Address
+	tokenId := core.ViteTokenId
+
+	balances := make(map[string]map[string]*big.Int)
+	addBalance := func(addr types.Address, amount *big.Int) {
+		if _, ok := balances[addr.String()]; !ok {
+			balances[addr.String()] = make(map[string]*big.Int)
+		}
+		balance, ok := balances[addr.String()][tokenId.String()]
+		if !ok {
+			balance = big.NewInt(0)
+		}
+		balances[addr.String()][tokenId.String()] = new(big.Int).Add(balance, amount)
+	}
+
+	registrations := make(map[string]*config.RegistrationInfo)
+	votes := make(map[string]string)
+	stakes := make([]*config.StakeInfo, 0)
+	beneficial := make(map[string]*big.Int)
+	stake := func(addr types.Address) {
+		beneficiary := addr
+		stakes = append(stakes, &config.StakeInfo{
+			Amount:           stakeAmount,
+			ExpirationHeight: 259200,
+			Beneficiary:      &beneficiary,
+		})
+		beneficial[addr.String()] = stakeAmount
+		addBalance(types.AddressQuota, stakeAmount)
+	}
+
+	for _, node := range network.Nodes {
+		addr := node.Coinbase
+		registrations[node.Name] = &config.RegistrationInfo{
+			BlockProducingAddress: &addr,
+			StakeAddress:          &addr,
+			Amount:                sbpRegisterAmount,
+			ExpirationHeight:      7776000,
+			RewardTime:            1,
+			RevokeTime:            0,
+			HistoryAddressList:    []types.Address{addr},
+		}
+		votes[addr.String()] = node.Name
+		addBalance(types.AddressGovernance, sbpRegisterAmount)
+		addBalance(addr, sbpBalance)
+		stake(addr)
+	}
+	for _, account := range network.Accounts {
+		if account.Address != genesisAddr {
+			addBalance(account.Address, accountBalance)
+		}
+		stake(account.Address)
+	}
+
+	// the rest of the supply belongs to the genesis account
+	rest := new(big.Int).Set(viteTotalSupply)
+	for _, tokens := range balances {
+		rest.Sub(rest, tokens[tokenId.String()])
+	}
+	addBalance(genesisAddr, rest)
+
+	nodeCount := uint8(len(network.Nodes))
+	group := func(interval int64, perCount int64, repeat uint16, checkLevel uint8) *config.ConsensusGroupInfo {
+		return &config.ConsensusGroupInfo{
+			NodeCount:           nodeCount,
+			Interval:            interval,
+			PerCount:            perCount,
+			RandCount:           2,
+			RandRank:            100,
+			Repeat:              repeat,
+			CheckLevel:          checkLevel,
+			CountingTokenId:     tokenId,
+			RegisterConditionId: 1,
+			RegisterConditionParam: config.RegisterConditionParam{
+				StakeAmount: sbpRegisterAmount,
+				StakeToken:  tokenId,
+				StakeHeight: 1,
+			},
+			VoteConditionId: 1,
+			Owner:           genesisAddr,
+			StakeAmount:     big.NewInt(0),
+			// the groups of genesis never expire
+			ExpirationHeight: 1,
+		}
+	}
+
+	return &config.Genesis{
+		GenesisAccountAddress: &genesisAddr,
+		UpgradeCfg:            &config.Upgrade{Level: upgradeLevel},
+		GovernanceInfo: &config.GovernanceContractInfo{
+			ConsensusGroupInfoMap: map[string]*config.ConsensusGroupInfo{
+				types.SNAPSHOT_GID.String(): group(1, 3, 1, 0),
+				types.DELEGATE_GID.String(): group(3, 1, 48, 1),
+			},
+			RegistrationInfoMap: map[string]map[string]*config.RegistrationInfo{
+				types.SNAPSHOT_GID.String(): registrations,
+			},
+			VoteStatusMap: map[string]map[string]string{
+				types.SNAPSHOT_GID.String(): votes,
+			},
+		},
+		AssetInfo: &config.AssetContractInfo{
+			TokenInfoMap: map[string]*config.TokenInfo{
+				tokenId.String(): {
+					TokenName:       "Vite Token",
+					TokenSymbol:     "VITE",
+					TotalSupply:     viteTotalSupply,
+					Decimals:        18,
+					Owner:           genesisAddr,
+					MaxSupply:       new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1)),
+					IsOwnerBurnOnly: false,
+					IsReIssuable:    true,
+				},
+			},
+			LogList: []*config.GenesisVmLog{
+				{
+					Data: "",
+					Topics: []types.Hash{
+						types.HexToHashPanic("3f9dcc00d5e929040142c3fb2b67a3be1b0e91e98dac18d5bc2b7817a4cfecb6"),
+						types.HexToHashPanic("000000000000000000000000000000000000000000005649544520544f4b454e"),
+					},
+				},
+			},
+		},
+		QuotaInfo: &config.QuotaContractInfo{
+			StakeInfoMap:       map[string][]*config.StakeInfo{genesisAddr.String(): stakes},
+			StakeBeneficialMap: beneficial,
+		},
+		AccountBalanceMap: balances,
+	}
+}
+
+func (network *Network) makeNodeConfig(node *Node, peerKey ed25519.PrivateKey) *nodeconfig.Config {
+	cfg := nodeconfig.DefaultNodeConfig
+	cfg.DataDir = node.Dir
+	cfg.KeyStoreDir = node.Dir
+	cfg.GenesisFile = network.GenesisFile
+
+	// a single node doesn't wait for peers to sync
+	cfg.Single = len(network.Nodes) == 1
+	cfg.Identity = node.Name
+	cfg.NetID = network.NetID
+	cfg.ListenInterface = "127.0.0.1"
+	cfg.Port = node.Port
+	cfg.FilePort = node.Port + 1
+	cfg.PeerKey = peerKey.Hex()
+	cfg.Discover = false
+	cfg.MinPeers = len(network.Nodes) - 1
+	for _, other := range network.Nodes {
+		if other != node {
+			cfg.StaticNodes = append(cfg.StaticNodes, fmt.Sprintf("%s@127.0.0.1:%d/%d", other.PeerId, other.Port, network.NetID))
+		}
+	}
+
+	cfg.EntropyStorePath = entropystore.FullKeyFileName(filepath.Join(node.Dir, "wallet"), node.Coinbase)
+	cfg.EntropyStorePassword = entropyStorePassword
+	cfg.CoinBase = "0:" + node.Coinbase.String()
+	cfg.MinerEnabled = true
+
+	cfg.RPCEnabled = true
+	cfg.WSEnabled = true
+	cfg.HttpHost = "127.0.0.1"
+	cfg.HttpPort = node.HttpPort
+	cfg.WSHost = "127.0.0.1"
+	cfg.WSPort = node.WSPort
+	return &cfg
+}
+
+// Start launches every node as a child process of bin
+func (network *Network) Start(bin string) error {
+	for _, node := range network.Nodes {
+		if err := node.start(bin); err != nil {
+			network.Stop(0)
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop interrupts the nodes and kills the nodes which are still alive after timeout
+func (network *Network) Stop(timeout time.Duration) {
+	for _, node := range network.Nodes {
+		node.interrupt()
+	}
+	deadline := time.After(timeout)
+	for _, node := range network.Nodes {
+		node.wait(deadline)
+	}
+}
+
+// Exited receives the nodes which exited
+func (network *Network) Exited() <-chan *Node {
+	ch := make(chan *Node, len(network.Nodes))
+	for _, node := range network.Nodes {
+		if node.exited == nil {
+			continue
+		}
+		go func(node *Node) {
+			<-node.exited
+			ch <- node
+		}(node)
+	}
+	return ch
+}
+
+// Err is the exit error of the node process
+func (n *Node) Err() error {
+	return n.err
+}
+
+func (n *Node) start(bin string) error {
+	logFile, err := os.OpenFile(n.LogFile(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(bin, "--config", n.ConfigFile)
+	cmd.Dir = n.Dir
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	if err := cmd.Start(); err != nil {
+		logFile.Close()
+		return fmt.Errorf("start node %s failed, %s", n.Name, err.Error())
+	}
+	n.cmd = cmd
+	n.log = logFile
+	n.exited = make(chan struct{})
+	go func() {
+		n.err = cmd.Wait()
+		close(n.exited)
+	}()
+	return nil
+}
+
+func (n *Node) interrupt() {
+	if n.cmd == nil || n.cmd.Process == nil {
+		return
+	}
+	if err := n.cmd.Process.Signal(os.Interrupt); err != nil {
+		n.cmd.Process.Kill()
+	}
+}
+
+func (n *Node) wait(deadline <-chan time.Time) {
+	if n.cmd == nil {
+		return
+	}
+	select {
+	case <-n.exited:
+	case <-deadline:
+		n.cmd.Process.Kill()
+		<-n.exited
+	}
+	n.log.Close()
+	n.cmd = nil
+}
+
+func writeJson(file string, v interface{}) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, buf, 0600)
+}
@@ -0,0 +1,58 @@
+package subcmd_devnet
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vitelabs/go-vite/v2/cmd/subcmd_genesis"
+	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/interfaces/core"
+	nodeconfig "github.com/vitelabs/go-vite/v2/node/config"
+)
+
+func TestGenerate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "devnet")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	network, err := Generate(Options{Dir: dir, Nodes: 3, Accounts: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(network.Nodes))
+	assert.Equal(t, 2, len(network.Accounts))
+
+	_, err = Generate(Options{Dir: dir, Nodes: 3, Accounts: 2})
+	assert.Error(t, err)
+
+	buf, err := ioutil.ReadFile(network.GenesisFile)
+	assert.NoError(t, err)
+	genesis := &config.Genesis{}
+	assert.NoError(t, json.Unmarshal(buf, genesis))
+	assert.True(t, config.IsCompleteGenesisConfig(genesis))
+	assert.Empty(t, subcmd_genesis.Validate(genesis))
+	assert.Equal(t, 3, len(genesis.GovernanceInfo.RegistrationInfoMap["00000000000000000001"]))
+
+	total := big.NewInt(0)
+	for _, tokens := range genesis.AccountBalanceMap {
+		total.Add(total, tokens[core.ViteTokenId.String()])
+	}
+	assert.Equal(t, viteTotalSupply.String(), total.String())
+
+	for _, node := range network.Nodes {
+		cfg := &nodeconfig.Config{}
+		assert.NoError(t, cfg.ParseFromFile(node.ConfigFile))
+		assert.Equal(t, 2, len(cfg.StaticNodes))
+		assert.Equal(t, "0:"+node.Coinbase.String(), cfg.CoinBase)
+		_, err := os.Stat(cfg.EntropyStorePath)
+		assert.NoError(t, err)
+	}
+
+	loaded, err := Load(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, network.Nodes[2].Coinbase, loaded.Nodes[2].Coinbase)
+	assert.Equal(t, network.Accounts[0].Address, loaded.Accounts[0].Address)
+}
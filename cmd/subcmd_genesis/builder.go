@@ -0,0 +1,343 @@
+package subcmd_genesis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/helper"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/common/upgrade"
+	"github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_genesis "github.com/vitelabs/go-vite/v2/ledger/chain/genesis"
+	"github.com/vitelabs/go-vite/v2/vm/contracts/abi"
+)
+
+var (
+	viteDecimals = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+	// DefaultViteSupply is 1 billion VITE
+	DefaultViteSupply = new(big.Int).Mul(big.NewInt(1000000000), viteDecimals)
+	// DefaultSbpStakeAmount is the stake amount of registering a SBP, 100 thousand VITE
+	DefaultSbpStakeAmount = new(big.Int).Mul(big.NewInt(100000), viteDecimals)
+)
+
+const (
+	sbpExpirationHeight = 7776000
+)
+
+// InitOptions of a new genesis
+type InitOptions struct {
+	Owner     types.Address // the genesis account, it owns the VITE token and the consensus groups
+	Supply    *big.Int      // the total supply of VITE, all of it belongs to the owner
+	NodeCount uint8         // the node count of the snapshot group and the delegate group
+	Upgrade   string        // the upgrade level, mainnet, latest or custom
+}
+
+// Init creates a genesis with the snapshot group, the delegate group and the VITE token,
+// SBPs must be added to the snapshot group before it's valid.
+func Init(opts InitOptions) *config.Genesis {
+	owner := opts.Owner
+	supply := opts.Supply
+	if supply == nil {
+		supply = DefaultViteSupply
+	}
+	nodeCount := opts.NodeCount
+	if nodeCount == 0 {
+		nodeCount = 1
+	}
+	upgradeLevel := opts.Upgrade
+	if upgradeLevel == "" {
+		upgradeLevel = "latest"
+	}
+	tokenId := core.ViteTokenId
+
+	group := func(interval int64, perCount int64, repeat uint16, checkLevel uint8) *config.ConsensusGroupInfo {
+		return &config.ConsensusGroupInfo{
+			NodeCount:           nodeCount,
+			Interval:            interval,
+			PerCount:            perCount,
+			RandCount:           2,
+			RandRank:            100,
+			Repeat:              repeat,
+			CheckLevel:          checkLevel,
+			CountingTokenId:     tokenId,
+			RegisterConditionId: 1,
+			RegisterConditionParam: config.RegisterConditionParam{
+				StakeAmount: DefaultSbpStakeAmount,
+				StakeToken:  tokenId,
+				StakeHeight: 1,
+			},
+			VoteConditionId: 1,
+			Owner:           owner,
+			StakeAmount:     big.NewInt(0),
+			// the groups of genesis never expire
+			ExpirationHeight: 1,
+		}
+	}
+
+	return &config.Genesis{
+		GenesisAccountAddress: &owner,
+		UpgradeCfg:            &config.Upgrade{Level: upgradeLevel},
+		GovernanceInfo: &config.GovernanceContractInfo{
+			ConsensusGroupInfoMap: map[string]*config.ConsensusGroupInfo{
+				types.SNAPSHOT_GID.String(): group(1, 3, 1, 0),
+				types.DELEGATE_GID.String(): group(3, 1, 48, 1),
+			},
+			RegistrationInfoMap: map[string]map[string]*config.RegistrationInfo{},
+		},
+		AssetInfo: &config.AssetContractInfo{
+			TokenInfoMap: map[string]*config.TokenInfo{
+				tokenId.String(): {
+					TokenName:       "Vite Token",
+					TokenSymbol:     "VITE",
+					TotalSupply:     new(big.Int).Set(supply),
+					Decimals:        18,
+					Owner:           owner,
+					MaxSupply:       new(big.Int).Set(helper.Tt256m1),
+					IsOwnerBurnOnly: false,
+					IsReIssuable:    true,
+				},
+			},
+			LogList: []*config.GenesisVmLog{issueLog(tokenId)},
+		},
+		QuotaInfo: &config.QuotaContractInfo{
+			StakeInfoMap:       map[string][]*config.StakeInfo{},
+			StakeBeneficialMap: map[string]*big.Int{},
+		},
+		AccountBalanceMap: map[string]map[string]*big.Int{
+			owner.String(): {tokenId.String(): new(big.Int).Set(supply)},
+		},
+	}
+}
+
+// SbpOptions of a SBP registered in genesis
+type SbpOptions struct {
+	Gid          types.Gid
+	Name         string
+	Producer     types.Address
+	StakeAddress *types.Address // the producer by default
+	Amount       *big.Int       // the stake amount of the group by default
+}
+
+// AddSbp registers a SBP to the consensus group,
+// the stake amount is moved from the genesis account to the governance contract.
+func AddSbp(g *config.Genesis, opts SbpOptions) error {
+	if g.GovernanceInfo == nil {
+		return errors.New("governance info is missing")
+	}
+	group, ok := g.GovernanceInfo.ConsensusGroupInfoMap[opts.Gid.String()]
+	if !ok {
+		return fmt.Errorf("consensus group %s is not existed", opts.Gid)
+	}
+	if opts.Gid == types.DELEGATE_GID {
+		return errors.New("SBP can't be registered to the delegate group")
+	}
+	if !isValidSbpName(opts.Name) {
+		return fmt.Errorf("invalid SBP name %q", opts.Name)
+	}
+	registrations := g.GovernanceInfo.RegistrationInfoMap[opts.Gid.String()]
+	if _, ok := registrations[opts.Name]; ok {
+		return fmt.Errorf("SBP %s is existed", opts.Name)
+	}
+	for name, info := range registrations {
+		if info.BlockProducingAddress != nil && *info.BlockProducingAddress == opts.Producer {
+			return fmt.Errorf("producer %s is used by SBP %s", opts.Producer, name)
+		}
+	}
+
+	amount := opts.Amount
+	if amount == nil {
+		amount = group.RegisterConditionParam.StakeAmount
+	}
+	if amount.Cmp(group.RegisterConditionParam.StakeAmount) < 0 {
+		return fmt.Errorf("stake amount must be at least %s", group.RegisterConditionParam.StakeAmount)
+	}
+	if g.GenesisAccountAddress == nil {
+		return errors.New("genesis account is missing")
+	}
+	if err := transfer(g, *g.GenesisAccountAddress, types.AddressGovernance, group.RegisterConditionParam.StakeToken, amount); err != nil {
+		return err
+	}
+
+	producer := opts.Producer
+	stakeAddress := producer
+	if opts.StakeAddress != nil {
+		stakeAddress = *opts.StakeAddress
+	}
+	if registrations == nil {
+		registrations = make(map[string]*config.RegistrationInfo)
+		g.GovernanceInfo.RegistrationInfoMap[opts.Gid.String()] = registrations
+	}
+	registrations[opts.Name] = &config.RegistrationInfo{
+		BlockProducingAddress: &producer,
+		StakeAddress:          &stakeAddress,
+		Amount:                new(big.Int).Set(amount),
+		ExpirationHeight:      sbpExpirationHeight,
+		RewardTime:            1,
+		RevokeTime:            0,
+		HistoryAddressList:    []types.Address{producer},
+	}
+	return nil
+}
+
+// TokenOptions of a token issued in genesis
+type TokenOptions struct {
+	TokenId         *types.TokenTypeId // created from the name, the symbol and the owner by default
+	TokenName       string
+	TokenSymbol     string
+	Decimals        uint8
+	TotalSupply     *big.Int
+	MaxSupply       *big.Int
+	Owner           types.Address
+	IsReIssuable    bool
+	IsOwnerBurnOnly bool
+}
+
+// AddToken issues a token in genesis, the total supply belongs to the owner.
+func AddToken(g *config.Genesis, opts TokenOptions) (types.TokenTypeId, error) {
+	if g.AssetInfo == nil {
+		g.AssetInfo = &config.AssetContractInfo{}
+	}
+	if g.AssetInfo.TokenInfoMap == nil {
+		g.AssetInfo.TokenInfoMap = make(map[string]*config.TokenInfo)
+	}
+
+	var tokenId types.TokenTypeId
+	if opts.TokenId != nil {
+		tokenId = *opts.TokenId
+	} else {
+		tokenId = types.CreateTokenTypeId([]byte(opts.TokenName), []byte(opts.TokenSymbol), opts.Owner.Bytes())
+	}
+	if _, ok := g.AssetInfo.TokenInfoMap[tokenId.String()]; ok {
+		return tokenId, fmt.Errorf("token %s is existed", tokenId)
+	}
+
+	maxSupply := opts.MaxSupply
+	if maxSupply == nil {
+		maxSupply = big.NewInt(0)
+		if opts.IsReIssuable {
+			maxSupply = helper.Tt256m1
+		}
+	}
+	info := &config.TokenInfo{
+		TokenName:       opts.TokenName,
+		TokenSymbol:     opts.TokenSymbol,
+		TotalSupply:     new(big.Int).Set(opts.TotalSupply),
+		Decimals:        opts.Decimals,
+		Owner:           opts.Owner,
+		MaxSupply:       new(big.Int).Set(maxSupply),
+		IsOwnerBurnOnly: opts.IsOwnerBurnOnly,
+		IsReIssuable:    opts.IsReIssuable,
+	}
+	if err := checkToken(info); err != nil {
+		return tokenId, err
+	}
+
+	g.AssetInfo.TokenInfoMap[tokenId.String()] = info
+	g.AssetInfo.LogList = append(g.AssetInfo.LogList, issueLog(tokenId))
+	addBalance(g, opts.Owner, tokenId, info.TotalSupply)
+	return tokenId, nil
+}
+
+// AddBalance moves the amount of the token from the address from to the address to,
+// the token owner pays by default.
+func AddBalance(g *config.Genesis, to types.Address, tokenId types.TokenTypeId, amount *big.Int, from *types.Address) error {
+	if amount.Sign() <= 0 {
+		return errors.New("amount must be positive")
+	}
+	if g.AssetInfo == nil {
+		return fmt.Errorf("token %s is not existed", tokenId)
+	}
+	info, ok := g.AssetInfo.TokenInfoMap[tokenId.String()]
+	if !ok {
+		return fmt.Errorf("token %s is not existed", tokenId)
+	}
+	payer := info.Owner
+	if from != nil {
+		payer = *from
+	}
+	return transfer(g, payer, to, tokenId, amount)
+}
+
+// Hash computes the genesis snapshot block hash, the genesis must be validated before.
+// The upgrade box is initialized by the genesis, since the genesis blocks depend on it.
+func Hash(g *config.Genesis) types.Hash {
+	upgrade.CleanupUpgradeBox()
+	upgrade.InitUpgradeBox(g.UpgradeCfg.MakeUpgradeBox())
+	defer upgrade.CleanupUpgradeBox()
+
+	accountBlocks := chain_genesis.NewGenesisAccountBlocks(g)
+	return chain_genesis.NewGenesisSnapshotBlock(accountBlocks).Hash
+}
+
+// Load reads the genesis file without checking it
+func Load(file string) (*config.Genesis, error) {
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	g := new(config.Genesis)
+	if err := json.Unmarshal(buf, g); err != nil {
+		return nil, fmt.Errorf("invalid genesis file %s, %s", file, err.Error())
+	}
+	return g, nil
+}
+
+// Save writes the genesis file
+func Save(file string, g *config.Genesis) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	buf, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+func transfer(g *config.Genesis, from types.Address, to types.Address, tokenId types.TokenTypeId, amount *big.Int) error {
+	balance := big.NewInt(0)
+	if tokens, ok := g.AccountBalanceMap[from.String()]; ok && tokens[tokenId.String()] != nil {
+		balance = tokens[tokenId.String()]
+	}
+	if balance.Cmp(amount) < 0 {
+		return fmt.Errorf("insufficient balance of %s, %s %s is required but %s", from, amount, tokenId, balance)
+	}
+	addBalance(g, from, tokenId, new(big.Int).Neg(amount))
+	addBalance(g, to, tokenId, amount)
+	return nil
+}
+
+func addBalance(g *config.Genesis, addr types.Address, tokenId types.TokenTypeId, amount *big.Int) {
+	if g.AccountBalanceMap == nil {
+		g.AccountBalanceMap = make(map[string]map[string]*big.Int)
+	}
+	tokens, ok := g.AccountBalanceMap[addr.String()]
+	if !ok {
+		tokens = make(map[string]*big.Int)
+		g.AccountBalanceMap[addr.String()] = tokens
+	}
+	balance := big.NewInt(0)
+	if tokens[tokenId.String()] != nil {
+		balance = tokens[tokenId.String()]
+	}
+	tokens[tokenId.String()] = new(big.Int).Add(balance, amount)
+}
+
+func issueLog(tokenId types.TokenTypeId) *config.GenesisVmLog {
+	topics, _, err := abi.ABIAsset.PackEvent(eventNameIssue, tokenId)
+	if err != nil {
+		panic(err)
+	}
+	return &config.GenesisVmLog{Data: "", Topics: topics}
+}
@@ -0,0 +1,356 @@
+package subcmd_genesis
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/vitelabs/go-vite/v2/cmd/utils"
+	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/types"
+)
+
+var (
+	GenesisCommand = cli.Command{
+		Name:     "genesis",
+		Usage:    "build and validate the genesis file of a private chain",
+		Category: "LOCAL COMMANDS",
+		Description: `Edit the genesis file step by step, every step is checked before the file is written.
+The genesis snapshot hash is printed once the genesis is valid, nodes with the same hash are on the same chain.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "init",
+				Usage:  "create a genesis with the consensus groups and the VITE token owned by the genesis account",
+				Flags:  []cli.Flag{fileFlag, ownerFlag, supplyFlag, nodeCountFlag, upgradeFlag, forceFlag},
+				Action: utils.MigrateFlags(initAction),
+			},
+			{
+				Name:   "add-sbp",
+				Usage:  "register a SBP, the stake is paid by the genesis account",
+				Flags:  []cli.Flag{fileFlag, gidFlag, nameFlag, addressFlag, stakeAddressFlag, amountFlag},
+				Action: utils.MigrateFlags(addSbpAction),
+			},
+			{
+				Name:  "add-token",
+				Usage: "issue a token, the total supply belongs to the owner",
+				Flags: []cli.Flag{fileFlag, tokenIdFlag, tokenNameFlag, tokenSymbolFlag, decimalsFlag, supplyFlag, maxSupplyFlag,
+					ownerFlag, reIssuableFlag, ownerBurnOnlyFlag},
+				Action: utils.MigrateFlags(addTokenAction),
+			},
+			{
+				Name:   "add-balance",
+				Usage:  "transfer a token to the address, paid by the token owner by default",
+				Flags:  []cli.Flag{fileFlag, addressFlag, tokenIdFlag, amountFlag, fromFlag},
+				Action: utils.MigrateFlags(addBalanceAction),
+			},
+			{
+				Name:   "validate",
+				Usage:  "check the genesis and print the genesis snapshot hash",
+				Flags:  []cli.Flag{fileFlag},
+				Action: utils.MigrateFlags(validateAction),
+			},
+			{
+				Name:   "hash",
+				Usage:  "print the genesis snapshot hash of a valid genesis",
+				Flags:  []cli.Flag{fileFlag},
+				Action: utils.MigrateFlags(hashAction),
+			},
+		},
+	}
+)
+
+var (
+	fileFlag = cli.StringFlag{
+		Name:  "genesisfile",
+		Usage: "The genesis `file`",
+		Value: "genesis.json",
+	}
+	ownerFlag = cli.StringFlag{
+		Name:  "owner",
+		Usage: "The owner `address`, the genesis account for init",
+	}
+	supplyFlag = cli.StringFlag{
+		Name:  "supply",
+		Usage: "The total supply in the smallest unit, 1e27 VITE for init",
+	}
+	nodeCountFlag = cli.UintFlag{
+		Name:  "nodecount",
+		Usage: "The node count of the consensus groups",
+		Value: 1,
+	}
+	upgradeFlag = cli.StringFlag{
+		Name:  "upgrade",
+		Usage: "The upgrade level, mainnet, latest or custom",
+		Value: "latest",
+	}
+	forceFlag = cli.BoolFlag{
+		Name:  "force",
+		Usage: "Overwrite the existed genesis file",
+	}
+	gidFlag = cli.StringFlag{
+		Name:  "gid",
+		Usage: "The consensus group id",
+		Value: types.SNAPSHOT_GID.String(),
+	}
+	nameFlag = cli.StringFlag{
+		Name:  "name",
+		Usage: "The SBP name",
+	}
+	addressFlag = cli.StringFlag{
+		Name:  "address",
+		Usage: "The block producing address of the SBP, or the address to receive the balance",
+	}
+	stakeAddressFlag = cli.StringFlag{
+		Name:  "stakeaddress",
+		Usage: "The stake address of the SBP, the block producing address by default",
+	}
+	amountFlag = cli.StringFlag{
+		Name:  "amount",
+		Usage: "The amount in the smallest unit, the stake amount of the group for add-sbp",
+	}
+	tokenIdFlag = cli.StringFlag{
+		Name:  "tokenid",
+		Usage: "The token id, created from the name, the symbol and the owner for add-token by default",
+	}
+	tokenNameFlag = cli.StringFlag{
+		Name:  "tokenname",
+		Usage: "The token name",
+	}
+	tokenSymbolFlag = cli.StringFlag{
+		Name:  "symbol",
+		Usage: "The token symbol",
+	}
+	decimalsFlag = cli.UintFlag{
+		Name:  "decimals",
+		Usage: "The token decimals",
+		Value: 18,
+	}
+	maxSupplyFlag = cli.StringFlag{
+		Name:  "maxsupply",
+		Usage: "The max supply of the re-issuable token, 2**256-1 by default",
+	}
+	reIssuableFlag = cli.BoolFlag{
+		Name:  "reissuable",
+		Usage: "The token is re-issuable",
+	}
+	ownerBurnOnlyFlag = cli.BoolFlag{
+		Name:  "ownerburnonly",
+		Usage: "Only the owner can burn the re-issuable token",
+	}
+	fromFlag = cli.StringFlag{
+		Name:  "from",
+		Usage: "The `address` pays the balance",
+	}
+)
+
+func initAction(ctx *cli.Context) error {
+	file := ctx.String(fileFlag.Name)
+	if _, err := os.Stat(file); err == nil && !ctx.Bool(forceFlag.Name) {
+		return fmt.Errorf("genesis file %s is existed, use --%s to overwrite it", file, forceFlag.Name)
+	}
+	owner, err := types.HexToAddress(ctx.String(ownerFlag.Name))
+	if err != nil {
+		return fmt.Errorf("invalid --%s, %v", ownerFlag.Name, err)
+	}
+	supply, err := parseAmount(ctx, supplyFlag.Name, false)
+	if err != nil {
+		return err
+	}
+	nodeCount := ctx.Uint(nodeCountFlag.Name)
+	if nodeCount == 0 || nodeCount > 255 {
+		return fmt.Errorf("--%s must be in [1, 255]", nodeCountFlag.Name)
+	}
+	g := Init(InitOptions{
+		Owner:     owner,
+		Supply:    supply,
+		NodeCount: uint8(nodeCount),
+		Upgrade:   ctx.String(upgradeFlag.Name),
+	})
+	return save(file, g)
+}
+
+func addSbpAction(ctx *cli.Context) error {
+	file := ctx.String(fileFlag.Name)
+	g, err := Load(file)
+	if err != nil {
+		return err
+	}
+	gid, err := types.HexToGid(ctx.String(gidFlag.Name))
+	if err != nil {
+		return fmt.Errorf("invalid --%s, %v", gidFlag.Name, err)
+	}
+	producer, err := types.HexToAddress(ctx.String(addressFlag.Name))
+	if err != nil {
+		return fmt.Errorf("invalid --%s, %v", addressFlag.Name, err)
+	}
+	stakeAddress, err := parseAddress(ctx, stakeAddressFlag.Name)
+	if err != nil {
+		return err
+	}
+	amount, err := parseAmount(ctx, amountFlag.Name, false)
+	if err != nil {
+		return err
+	}
+	err = AddSbp(g, SbpOptions{
+		Gid:          gid,
+		Name:         ctx.String(nameFlag.Name),
+		Producer:     producer,
+		StakeAddress: stakeAddress,
+		Amount:       amount,
+	})
+	if err != nil {
+		return err
+	}
+	return save(file, g)
+}
+
+func addTokenAction(ctx *cli.Context) error {
+	file := ctx.String(fileFlag.Name)
+	g, err := Load(file)
+	if err != nil {
+		return err
+	}
+	var tokenId *types.TokenTypeId
+	if ctx.IsSet(tokenIdFlag.Name) {
+		id, err := types.HexToTokenTypeId(ctx.String(tokenIdFlag.Name))
+		if err != nil {
+			return fmt.Errorf("invalid --%s, %v", tokenIdFlag.Name, err)
+		}
+		tokenId = &id
+	}
+	owner, err := types.HexToAddress(ctx.String(ownerFlag.Name))
+	if err != nil {
+		return fmt.Errorf("invalid --%s, %v", ownerFlag.Name, err)
+	}
+	supply, err := parseAmount(ctx, supplyFlag.Name, true)
+	if err != nil {
+		return err
+	}
+	maxSupply, err := parseAmount(ctx, maxSupplyFlag.Name, false)
+	if err != nil {
+		return err
+	}
+	decimals := ctx.Uint(decimalsFlag.Name)
+	if decimals > 255 {
+		return fmt.Errorf("--%s must be in [0, 255]", decimalsFlag.Name)
+	}
+	id, err := AddToken(g, TokenOptions{
+		TokenId:         tokenId,
+		TokenName:       ctx.String(tokenNameFlag.Name),
+		TokenSymbol:     ctx.String(tokenSymbolFlag.Name),
+		Decimals:        uint8(decimals),
+		TotalSupply:     supply,
+		MaxSupply:       maxSupply,
+		Owner:           owner,
+		IsReIssuable:    ctx.Bool(reIssuableFlag.Name),
+		IsOwnerBurnOnly: ctx.Bool(ownerBurnOnlyFlag.Name),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("token %s is issued\n", id)
+	return save(file, g)
+}
+
+func addBalanceAction(ctx *cli.Context) error {
+	file := ctx.String(fileFlag.Name)
+	g, err := Load(file)
+	if err != nil {
+		return err
+	}
+	addr, err := types.HexToAddress(ctx.String(addressFlag.Name))
+	if err != nil {
+		return fmt.Errorf("invalid --%s, %v", addressFlag.Name, err)
+	}
+	tokenId, err := types.HexToTokenTypeId(ctx.String(tokenIdFlag.Name))
+	if err != nil {
+		return fmt.Errorf("invalid --%s, %v", tokenIdFlag.Name, err)
+	}
+	amount, err := parseAmount(ctx, amountFlag.Name, true)
+	if err != nil {
+		return err
+	}
+	from, err := parseAddress(ctx, fromFlag.Name)
+	if err != nil {
+		return err
+	}
+	if err := AddBalance(g, addr, tokenId, amount, from); err != nil {
+		return err
+	}
+	return save(file, g)
+}
+
+func validateAction(ctx *cli.Context) error {
+	g, err := Load(ctx.String(fileFlag.Name))
+	if err != nil {
+		return err
+	}
+	if errs := Validate(g); len(errs) > 0 {
+		printProblems(errs)
+		return fmt.Errorf("genesis is invalid, %d problems found", len(errs))
+	}
+	fmt.Printf("genesis is valid, snapshot hash: %s\n", Hash(g))
+	return nil
+}
+
+func hashAction(ctx *cli.Context) error {
+	g, err := Load(ctx.String(fileFlag.Name))
+	if err != nil {
+		return err
+	}
+	if errs := Validate(g); len(errs) > 0 {
+		printProblems(errs)
+		return fmt.Errorf("genesis is invalid, %d problems found", len(errs))
+	}
+	fmt.Println(Hash(g))
+	return nil
+}
+
+// save writes the genesis and reports whether it's ready for the nodes
+func save(file string, g *config.Genesis) error {
+	if err := Save(file, g); err != nil {
+		return err
+	}
+	fmt.Printf("genesis is written to %s\n", file)
+	if errs := Validate(g); len(errs) > 0 {
+		fmt.Println("genesis is not valid yet:")
+		printProblems(errs)
+		return nil
+	}
+	fmt.Printf("genesis is valid, snapshot hash: %s\n", Hash(g))
+	return nil
+}
+
+func printProblems(errs []error) {
+	for _, err := range errs {
+		fmt.Printf("  - %v\n", err)
+	}
+}
+
+func parseAddress(ctx *cli.Context, name string) (*types.Address, error) {
+	if !ctx.IsSet(name) {
+		return nil, nil
+	}
+	addr, err := types.HexToAddress(ctx.String(name))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s, %v", name, err)
+	}
+	return &addr, nil
+}
+
+func parseAmount(ctx *cli.Context, name string, required bool) (*big.Int, error) {
+	if !ctx.IsSet(name) {
+		if required {
+			return nil, fmt.Errorf("--%s is required", name)
+		}
+		return nil, nil
+	}
+	amount, ok := new(big.Int).SetString(ctx.String(name), 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, errors.New("invalid --" + name + ", a non-negative integer in the smallest unit is required")
+	}
+	return amount, nil
+}
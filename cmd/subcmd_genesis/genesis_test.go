@@ -0,0 +1,88 @@
+package subcmd_genesis
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces/core"
+)
+
+func newTestGenesis(t *testing.T) (*config.Genesis, types.Address, types.Address) {
+	owner := types.HexToAddressPanic("vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a")
+	producer := types.HexToAddressPanic("vite_360232b0378111b122685a15e612143dc9a89cfa7e803f4b5a")
+
+	g := Init(InitOptions{Owner: owner})
+	assert.NotEmpty(t, Validate(g))
+	assert.NoError(t, AddSbp(g, SbpOptions{Gid: types.SNAPSHOT_GID, Name: "s1", Producer: producer}))
+	assert.Empty(t, Validate(g))
+	return g, owner, producer
+}
+
+func TestBuild(t *testing.T) {
+	g, owner, producer := newTestGenesis(t)
+
+	// the issue log is the same as the one of mock genesis
+	assert.Equal(t, config.MockGenesis().AssetInfo.LogList[0].Topics, issueLog(core.ViteTokenId).Topics)
+
+	assert.Error(t, AddSbp(g, SbpOptions{Gid: types.SNAPSHOT_GID, Name: "s1", Producer: owner}))
+	assert.Error(t, AddSbp(g, SbpOptions{Gid: types.SNAPSHOT_GID, Name: "s2", Producer: producer}))
+	assert.Error(t, AddSbp(g, SbpOptions{Gid: types.DELEGATE_GID, Name: "s2", Producer: owner}))
+	assert.Error(t, AddSbp(g, SbpOptions{Gid: types.SNAPSHOT_GID, Name: "s2", Producer: owner, Amount: big.NewInt(1)}))
+
+	tokenId, err := AddToken(g, TokenOptions{TokenName: "Test Coin", TokenSymbol: "TC", TotalSupply: big.NewInt(1000), Owner: producer})
+	assert.NoError(t, err)
+	_, err = AddToken(g, TokenOptions{TokenId: &tokenId, TokenName: "Test Coin", TokenSymbol: "TC", TotalSupply: big.NewInt(1000), Owner: producer})
+	assert.Error(t, err)
+	_, err = AddToken(g, TokenOptions{TokenName: "Bad", TokenSymbol: "bad", TotalSupply: big.NewInt(1000), Owner: producer})
+	assert.Error(t, err)
+
+	assert.NoError(t, AddBalance(g, owner, tokenId, big.NewInt(400), nil))
+	assert.Error(t, AddBalance(g, owner, tokenId, big.NewInt(601), nil))
+	assert.Error(t, AddBalance(g, owner, core.ViteTokenId, big.NewInt(1), &producer))
+	assert.Equal(t, "600", g.AccountBalanceMap[producer.String()][tokenId.String()].String())
+	assert.Empty(t, Validate(g))
+
+	dir, err := ioutil.TempDir("", "genesis")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "genesis.json")
+	assert.NoError(t, Save(file, g))
+	loaded, err := Load(file)
+	assert.NoError(t, err)
+	assert.Empty(t, Validate(loaded))
+	assert.Equal(t, Hash(g), Hash(loaded))
+}
+
+func TestValidate(t *testing.T) {
+	g, owner, producer := newTestGenesis(t)
+	hash := Hash(g)
+
+	// the stake of SBP isn't locked in the governance contract
+	g.AccountBalanceMap[types.AddressGovernance.String()][core.ViteTokenId.String()] = big.NewInt(0)
+	assert.Equal(t, 2, len(Validate(g)))
+
+	g, owner, producer = newTestGenesis(t)
+	g.GovernanceInfo.VoteStatusMap = map[string]map[string]string{
+		"00000000000000000003":      {owner.String(): "s1"},
+		types.SNAPSHOT_GID.String(): {producer.String(): "s2"},
+	}
+	assert.Equal(t, 3, len(Validate(g)))
+
+	g, owner, producer = newTestGenesis(t)
+	g.QuotaInfo.StakeInfoMap[owner.String()] = []*config.StakeInfo{{Amount: big.NewInt(10), ExpirationHeight: 1, Beneficiary: &producer}}
+	assert.Equal(t, 2, len(Validate(g)))
+
+	g, _, _ = newTestGenesis(t)
+	g.AssetInfo.LogList = nil
+	assert.Equal(t, 1, len(Validate(g)))
+
+	g, _, _ = newTestGenesis(t)
+	assert.Equal(t, hash, Hash(g))
+}
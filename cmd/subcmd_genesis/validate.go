@@ -0,0 +1,427 @@
+package subcmd_genesis
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"regexp"
+	"sort"
+
+	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/helper"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/vm/contracts/abi"
+)
+
+const (
+	// the issue event of the tokens in genesis
+	eventNameIssue = "mint"
+
+	// the same limits as the governance contract and the asset contract
+	sbpNameLengthMax     = 40
+	tokenNameLengthMax   = 40
+	tokenSymbolLengthMax = 10
+)
+
+var (
+	sbpNameRegexp     = regexp.MustCompile("^([0-9a-zA-Z_.]+[ ]?)*[0-9a-zA-Z_.]$")
+	tokenNameRegexp   = regexp.MustCompile("^([a-zA-Z_]+[ ]?)*[a-zA-Z_]$")
+	tokenSymbolRegexp = regexp.MustCompile("^[A-Z0-9]+$")
+)
+
+// Validate checks the genesis beyond config.IsCompleteGenesisConfig, including the cross references
+// between the consensus groups, the SBPs, the tokens, the stakes and the balances.
+// It returns all the problems found, the genesis is valid if it's empty.
+func Validate(g *config.Genesis) []error {
+	v := &validator{g: g}
+	if !config.IsCompleteGenesisConfig(g) {
+		v.errorf("genesis is not complete, the consensus groups, the SBPs, the tokens and the balances are required")
+		if g == nil {
+			return v.errs
+		}
+	}
+	if g.GenesisAccountAddress == nil {
+		v.errorf("GenesisAccountAddress is missing")
+	}
+	v.checkUpgrade()
+	v.checkTokens()
+	v.checkBalances()
+	v.checkGroups()
+	v.checkRegistrations()
+	v.checkQuota()
+	if g.DexFundInfo != nil && g.DexFundInfo.Owner == nil {
+		v.errorf("DexFundInfo.Owner is missing")
+	}
+	return v.errs
+}
+
+type validator struct {
+	g    *config.Genesis
+	errs []error
+
+	// the sum of balances of every token
+	supply map[types.TokenTypeId]*big.Int
+}
+
+func (v *validator) errorf(format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf(format, args...))
+}
+
+func (v *validator) checkUpgrade() {
+	cfg := v.g.UpgradeCfg
+	if cfg == nil {
+		v.errorf("UpgradeCfg is missing")
+		return
+	}
+	switch cfg.Level {
+	case "mainnet", "latest":
+	case "custom":
+		if len(cfg.Points) == 0 {
+			v.errorf("UpgradeCfg.Points is required by the custom upgrade level")
+		}
+	default:
+		v.errorf("unknown upgrade level %q", cfg.Level)
+	}
+}
+
+func (v *validator) token(id types.TokenTypeId) *config.TokenInfo {
+	if v.g.AssetInfo == nil {
+		return nil
+	}
+	return v.g.AssetInfo.TokenInfoMap[id.String()]
+}
+
+func (v *validator) balance(addr types.Address, id types.TokenTypeId) *big.Int {
+	if balance := v.g.AccountBalanceMap[addr.String()][id.String()]; balance != nil {
+		return balance
+	}
+	return big.NewInt(0)
+}
+
+func (v *validator) checkTokens() {
+	if v.g.AssetInfo == nil {
+		return
+	}
+	issued := make(map[types.Hash]bool)
+	for _, log := range v.g.AssetInfo.LogList {
+		if len(log.Topics) == 2 {
+			issued[log.Topics[1]] = true
+		}
+	}
+	for _, idStr := range sortedKeys(v.g.AssetInfo.TokenInfoMap) {
+		info := v.g.AssetInfo.TokenInfoMap[idStr]
+		id, err := types.HexToTokenTypeId(idStr)
+		if err != nil {
+			v.errorf("invalid token id %s, %v", idStr, err)
+			continue
+		}
+		if info == nil {
+			v.errorf("token %s: info is missing", idStr)
+			continue
+		}
+		if err := checkToken(info); err != nil {
+			v.errorf("token %s: %v", idStr, err)
+		}
+		if info.Owner == types.ZERO_ADDRESS {
+			v.errorf("token %s: owner is missing", idStr)
+		}
+		topics, _, _ := abi.ABIAsset.PackEvent(eventNameIssue, id)
+		if !issued[topics[1]] {
+			v.errorf("token %s: the issue log is missing", idStr)
+		}
+	}
+}
+
+func (v *validator) checkBalances() {
+	v.supply = make(map[types.TokenTypeId]*big.Int)
+	for _, addrStr := range sortedKeys(v.g.AccountBalanceMap) {
+		if _, err := types.HexToAddress(addrStr); err != nil {
+			v.errorf("invalid address %s in AccountBalanceMap, %v", addrStr, err)
+			continue
+		}
+		tokens := v.g.AccountBalanceMap[addrStr]
+		for _, idStr := range sortedKeys(tokens) {
+			id, err := types.HexToTokenTypeId(idStr)
+			if err != nil {
+				v.errorf("invalid token id %s in the balances of %s, %v", idStr, addrStr, err)
+				continue
+			}
+			if v.token(id) == nil {
+				v.errorf("balance of %s: token %s is not issued", addrStr, idStr)
+				continue
+			}
+			balance := tokens[idStr]
+			if balance == nil || balance.Sign() < 0 {
+				v.errorf("balance of %s: %s of token %s is invalid", addrStr, balance, idStr)
+				continue
+			}
+			if v.supply[id] == nil {
+				v.supply[id] = big.NewInt(0)
+			}
+			v.supply[id].Add(v.supply[id], balance)
+		}
+	}
+	if v.g.AssetInfo == nil {
+		return
+	}
+	for _, idStr := range sortedKeys(v.g.AssetInfo.TokenInfoMap) {
+		info := v.g.AssetInfo.TokenInfoMap[idStr]
+		id, err := types.HexToTokenTypeId(idStr)
+		if err != nil || info == nil || info.TotalSupply == nil {
+			continue
+		}
+		sum := v.supply[id]
+		if sum == nil {
+			sum = big.NewInt(0)
+		}
+		if sum.Cmp(info.TotalSupply) != 0 {
+			v.errorf("token %s: the total supply is %s, but the sum of balances is %s", idStr, info.TotalSupply, sum)
+		}
+	}
+}
+
+func (v *validator) checkGroups() {
+	gov := v.g.GovernanceInfo
+	if gov == nil {
+		return
+	}
+	if _, ok := gov.ConsensusGroupInfoMap[types.SNAPSHOT_GID.String()]; !ok {
+		v.errorf("the snapshot consensus group %s is missing", types.SNAPSHOT_GID)
+	}
+	if _, ok := gov.ConsensusGroupInfoMap[types.DELEGATE_GID.String()]; !ok {
+		v.errorf("the delegate consensus group %s is missing", types.DELEGATE_GID)
+	}
+	for _, gidStr := range sortedKeys(gov.ConsensusGroupInfoMap) {
+		group := gov.ConsensusGroupInfoMap[gidStr]
+		if _, err := types.HexToGid(gidStr); err != nil {
+			v.errorf("invalid gid %s, %v", gidStr, err)
+			continue
+		}
+		if group == nil {
+			v.errorf("group %s: info is missing", gidStr)
+			continue
+		}
+		if group.NodeCount == 0 || group.Interval <= 0 || group.PerCount <= 0 || group.Repeat == 0 {
+			v.errorf("group %s: NodeCount, Interval, PerCount and Repeat must be positive", gidStr)
+		}
+		if group.Owner == types.ZERO_ADDRESS {
+			v.errorf("group %s: owner is missing", gidStr)
+		}
+		if v.token(group.CountingTokenId) == nil {
+			v.errorf("group %s: counting token %s is not issued", gidStr, group.CountingTokenId)
+		}
+		if group.RegisterConditionId == 1 {
+			param := group.RegisterConditionParam
+			if v.token(param.StakeToken) == nil {
+				v.errorf("group %s: stake token %s is not issued", gidStr, param.StakeToken)
+			}
+			if param.StakeAmount == nil || param.StakeAmount.Sign() <= 0 {
+				v.errorf("group %s: stake amount must be positive", gidStr)
+			}
+		}
+		if group.StakeAmount == nil || group.StakeAmount.Sign() < 0 {
+			v.errorf("group %s: StakeAmount is invalid", gidStr)
+		}
+	}
+
+	// every gid referred must be a consensus group
+	refs := map[string][]string{
+		"RegistrationInfoMap": sortedKeys(gov.RegistrationInfoMap),
+		"HisNameMap":          sortedKeys(gov.HisNameMap),
+		"VoteStatusMap":       sortedKeys(gov.VoteStatusMap),
+	}
+	for _, field := range sortedKeys(refs) {
+		for _, gidStr := range refs[field] {
+			if _, ok := gov.ConsensusGroupInfoMap[gidStr]; !ok {
+				v.errorf("%s: consensus group %s is not existed", field, gidStr)
+			}
+		}
+	}
+}
+
+func (v *validator) checkRegistrations() {
+	gov := v.g.GovernanceInfo
+	if gov == nil {
+		return
+	}
+	staked := make(map[types.TokenTypeId]*big.Int)
+	for _, gidStr := range sortedKeys(gov.RegistrationInfoMap) {
+		registrations := gov.RegistrationInfoMap[gidStr]
+		group := gov.ConsensusGroupInfoMap[gidStr]
+		if gidStr == types.DELEGATE_GID.String() && len(registrations) > 0 {
+			v.errorf("group %s: SBP can't be registered to the delegate group", gidStr)
+		}
+		active := 0
+		producers := make(map[types.Address]string)
+		for _, name := range sortedKeys(registrations) {
+			info := registrations[name]
+			if !isValidSbpName(name) {
+				v.errorf("group %s: invalid SBP name %q", gidStr, name)
+			}
+			if info == nil || info.BlockProducingAddress == nil || info.StakeAddress == nil || info.Amount == nil {
+				v.errorf("SBP %s: BlockProducingAddress, StakeAddress and Amount are required", name)
+				continue
+			}
+			if other, ok := producers[*info.BlockProducingAddress]; ok {
+				v.errorf("SBP %s: producer %s is used by SBP %s", name, info.BlockProducingAddress, other)
+			}
+			producers[*info.BlockProducingAddress] = name
+			if info.RevokeTime != 0 {
+				continue
+			}
+			active++
+			if group == nil || group.RegisterConditionId != 1 || group.RegisterConditionParam.StakeAmount == nil {
+				continue
+			}
+			param := group.RegisterConditionParam
+			if info.Amount.Cmp(param.StakeAmount) < 0 {
+				v.errorf("SBP %s: stake amount %s is less than %s required by group %s", name, info.Amount, param.StakeAmount, gidStr)
+			}
+			if staked[param.StakeToken] == nil {
+				staked[param.StakeToken] = big.NewInt(0)
+			}
+			staked[param.StakeToken].Add(staked[param.StakeToken], info.Amount)
+		}
+		if gidStr == types.SNAPSHOT_GID.String() && active == 0 {
+			v.errorf("group %s: at least one SBP is required", gidStr)
+		}
+	}
+	if _, ok := gov.RegistrationInfoMap[types.SNAPSHOT_GID.String()]; !ok {
+		v.errorf("group %s: at least one SBP is required", types.SNAPSHOT_GID)
+	}
+
+	// the stakes of SBPs are locked in the governance contract
+	for _, id := range sortedTokens(staked) {
+		if balance := v.balance(types.AddressGovernance, id); balance.Cmp(staked[id]) != 0 {
+			v.errorf("the SBPs stake %s %s, but the balance of the governance contract is %s", staked[id], id, balance)
+		}
+	}
+
+	for _, gidStr := range sortedKeys(gov.VoteStatusMap) {
+		for _, voter := range sortedKeys(gov.VoteStatusMap[gidStr]) {
+			name := gov.VoteStatusMap[gidStr][voter]
+			if _, err := types.HexToAddress(voter); err != nil {
+				v.errorf("invalid voter %s, %v", voter, err)
+			}
+			if _, ok := gov.RegistrationInfoMap[gidStr][name]; !ok {
+				v.errorf("vote of %s: SBP %s is not registered in group %s", voter, name, gidStr)
+			}
+		}
+	}
+	for _, gidStr := range sortedKeys(gov.HisNameMap) {
+		for _, producer := range sortedKeys(gov.HisNameMap[gidStr]) {
+			name := gov.HisNameMap[gidStr][producer]
+			if _, err := types.HexToAddress(producer); err != nil {
+				v.errorf("invalid producer %s, %v", producer, err)
+			}
+			if _, ok := gov.RegistrationInfoMap[gidStr][name]; !ok {
+				v.errorf("history name of %s: SBP %s is not registered in group %s", producer, name, gidStr)
+			}
+		}
+	}
+}
+
+func (v *validator) checkQuota() {
+	quota := v.g.QuotaInfo
+	if quota == nil {
+		return
+	}
+	staked := big.NewInt(0)
+	beneficial := make(map[types.Address]*big.Int)
+	for _, addrStr := range sortedKeys(quota.StakeInfoMap) {
+		if _, err := types.HexToAddress(addrStr); err != nil {
+			v.errorf("invalid stake address %s, %v", addrStr, err)
+			continue
+		}
+		for _, info := range quota.StakeInfoMap[addrStr] {
+			if info == nil || info.Amount == nil || info.Amount.Sign() <= 0 || info.Beneficiary == nil {
+				v.errorf("stake of %s: positive Amount and Beneficiary are required", addrStr)
+				continue
+			}
+			staked.Add(staked, info.Amount)
+			if beneficial[*info.Beneficiary] == nil {
+				beneficial[*info.Beneficiary] = big.NewInt(0)
+			}
+			beneficial[*info.Beneficiary].Add(beneficial[*info.Beneficiary], info.Amount)
+		}
+	}
+	for _, addrStr := range sortedKeys(quota.StakeBeneficialMap) {
+		addr, err := types.HexToAddress(addrStr)
+		if err != nil {
+			v.errorf("invalid beneficiary %s, %v", addrStr, err)
+			continue
+		}
+		amount := quota.StakeBeneficialMap[addrStr]
+		if sum := beneficial[addr]; sum == nil || amount == nil || sum.Cmp(amount) != 0 {
+			v.errorf("beneficiary %s: the beneficial amount is %s, but the sum of stakes is %s", addrStr, amount, sum)
+		}
+		delete(beneficial, addr)
+	}
+	for addr, sum := range beneficial {
+		v.errorf("beneficiary %s: %s is staked but StakeBeneficialMap is missing", addr, sum)
+	}
+
+	// the stakes for quota are locked in the quota contract
+	tokenId := types.TokenTypeId{}
+	if v.g.GovernanceInfo != nil {
+		if group := v.g.GovernanceInfo.ConsensusGroupInfoMap[types.SNAPSHOT_GID.String()]; group != nil {
+			tokenId = group.CountingTokenId
+		}
+	}
+	if balance := v.balance(types.AddressQuota, tokenId); staked.Sign() > 0 && balance.Cmp(staked) != 0 {
+		v.errorf("the stakes for quota are %s %s, but the balance of the quota contract is %s", staked, tokenId, balance)
+	}
+}
+
+func checkToken(info *config.TokenInfo) error {
+	if info.TotalSupply == nil || info.TotalSupply.Sign() < 0 || info.TotalSupply.Cmp(helper.Tt256m1) > 0 {
+		return fmt.Errorf("invalid total supply %s", info.TotalSupply)
+	}
+	if len(info.TokenName) == 0 || len(info.TokenName) > tokenNameLengthMax || !tokenNameRegexp.MatchString(info.TokenName) {
+		return fmt.Errorf("invalid token name %q", info.TokenName)
+	}
+	if len(info.TokenSymbol) == 0 || len(info.TokenSymbol) > tokenSymbolLengthMax || !tokenSymbolRegexp.MatchString(info.TokenSymbol) {
+		return fmt.Errorf("invalid token symbol %q", info.TokenSymbol)
+	}
+	if info.MaxSupply == nil {
+		return fmt.Errorf("max supply is missing")
+	}
+	if info.IsReIssuable {
+		if info.MaxSupply.Cmp(info.TotalSupply) < 0 || info.MaxSupply.Cmp(helper.Tt256m1) > 0 {
+			return fmt.Errorf("max supply %s must be in [total supply, 2**256-1]", info.MaxSupply)
+		}
+	} else {
+		if info.TotalSupply.Sign() <= 0 {
+			return fmt.Errorf("total supply of the token which is not re-issuable must be positive")
+		}
+		if info.MaxSupply.Sign() > 0 || info.IsOwnerBurnOnly {
+			return fmt.Errorf("max supply and owner burn only are only for the re-issuable token")
+		}
+	}
+	return nil
+}
+
+func isValidSbpName(name string) bool {
+	return len(name) > 0 && len(name) <= sbpNameLengthMax && sbpNameRegexp.MatchString(name)
+}
+
+// sortedKeys returns the sorted keys of a map with string keys, so the problems are reported in order
+func sortedKeys(m interface{}) []string {
+	keys := make([]string, 0)
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedTokens(m map[types.TokenTypeId]*big.Int) []types.TokenTypeId {
+	ids := make([]types.TokenTypeId, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+	return ids
+}
@@ -15,12 +15,14 @@ var (
 	LedgerRecoverCommand = cli.Command{
 		Action:    utils.MigrateFlags(recoverLedgerAction),
 		Name:      "recover",
-		Usage:     "recover --del=500000",
+		Usage:     "recover --del=500000 [--dry-run] [--maxdepth=86400] [--backup=/xxx/xxx]",
 		ArgsUsage: "--del=500000",
 		Flags:     append(utils.LedgerFlags, utils.ConfigFlags...),
 		Category:  "RECOVER COMMANDS",
 		Description: `
-Recover ledger.
+Recover ledger by deleting the snapshot blocks from --del height. The deleted snapshot chunks are backed up
+and can be loaded by gvite load --fromDir, the unconfirmed blocks are deleted without backup. With --dry-run,
+the blocks and the balances to be changed are reported without deleting.
 `,
 	}
 	log = log15.New("module", "gvite/recover")
@@ -0,0 +1,48 @@
+package subcmd_replay
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/vitelabs/go-vite/v2/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/v2/cmd/utils"
+	"github.com/vitelabs/go-vite/v2/log15"
+)
+
+var (
+	ReplayCommand = cli.Command{
+		Action:    utils.MigrateFlags(replayAction),
+		Name:      "replay",
+		Usage:     "replay --from=500000 [--to=510000] [--workdir=/xxx/xxx]",
+		ArgsUsage: "--from=500000 --to=510000",
+		Flags:     append(utils.ReplayFlags, utils.ConfigFlags...),
+		Category:  "LOCAL COMMANDS",
+		Description: `
+Replay the account blocks of the snapshot blocks from --from+1 to --to with the current vm. The state at
+--from is rebuilt in --workdir from the ledger, then every account block is executed again and the
+result (hash, storage, balances, vm logs and quota) is compared against the stored block. A json report
+with the first divergence is printed. The ledger in the data dir is not modified, and the rebuilt
+ledger is kept in --workdir so the next replay starts from where it stopped.
+`,
+	}
+	log = log15.New("module", "gvite/replay")
+)
+
+func replayAction(ctx *cli.Context) error {
+	nodeManager, err := nodemanager.NewReplayNodeManager(ctx, nodemanager.FullNodeMaker{})
+	if err != nil {
+		log.Error(fmt.Sprintf("new Node error, %+v", err))
+		return err
+	}
+	err = nodeManager.Start()
+	nodeManager.Stop()
+	if err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	os.Exit(0)
+	return nil
+}
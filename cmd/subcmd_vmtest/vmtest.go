@@ -0,0 +1,47 @@
+package subcmd_vmtest
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/urfave/cli.v1"
+
+	"github.com/vitelabs/go-vite/v2/cmd/utils"
+	"github.com/vitelabs/go-vite/v2/vm/scenario"
+)
+
+var (
+	VmTestCommand = cli.Command{
+		Name:      "vmtest",
+		Usage:     "run the declarative vm scenarios",
+		ArgsUsage: "<file or dir>...",
+		Category:  "LOCAL COMMANDS",
+		Description: `Run the vm scenario files, or all the json files in the directories.
+The scenarios are run against an in-memory ledger, no data dir or node is required.`,
+		Action: utils.MigrateFlags(vmTestAction),
+	}
+)
+
+func vmTestAction(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return errors.New("scenario file or dir required")
+	}
+	list, err := scenario.LoadAll(ctx.Args()...)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, s := range list {
+		if err := scenario.Run(s); err != nil {
+			failed++
+			fmt.Printf("FAIL %s (%s)\n    %v\n", s.Name, s.File(), err)
+			continue
+		}
+		fmt.Printf("PASS %s\n", s.Name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d scenarios failed", failed, len(list))
+	}
+	fmt.Printf("%d scenarios passed\n", len(list))
+	return nil
+}
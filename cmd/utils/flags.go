@@ -139,6 +139,21 @@ var (
 		Usage: "Miner Interval(unit: second)",
 	}
 
+	ProducerLeaseFlag = cli.StringFlag{
+		Name:  "producerlease",
+		Usage: "The lease `file` shared by the active and standby producers, only the lease holder produces blocks",
+	}
+
+	ProducerLeaseOwnerFlag = cli.StringFlag{
+		Name:  "producerleaseowner",
+		Usage: "The unique owner name of this producer in the lease, default is hostname:datadir",
+	}
+
+	ProducerLeaseTTLFlag = cli.Uint64Flag{
+		Name:  "producerleasettl",
+		Usage: "The lease ttl(unit: second), the standby takes over after the lease expired",
+	}
+
 	//Log Lvl
 	LogLvlFlag = cli.StringFlag{
 		Name:  "loglevel",
@@ -175,12 +190,68 @@ var (
 		Usage: "Delete to height",
 	}
 
+	LedgerDryRunFlag = cli.BoolFlag{
+		Name:  "dry-run",
+		Usage: "Report the blocks and the balances to be deleted without deleting",
+	}
+	LedgerMaxDepthFlag = cli.Uint64Flag{
+		Name:  "maxdepth",
+		Usage: "The max count of snapshot blocks to delete",
+	}
+	LedgerBackupFlag = cli.StringFlag{
+		Name:  "backup",
+		Usage: "The directory to back up the deleted snapshot chunks, it can be loaded by gvite load --fromDir. The unconfirmed blocks are not backed up",
+	}
+
 	// Trie
 	RecoverTrieFlag = cli.BoolFlag{
 		Name:  "trie",
 		Usage: "Recover trie",
 	}
 
+	// Replay
+	ReplayFromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "The snapshot height to replay from, the blocks above it are re-executed",
+	}
+	ReplayToFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "The snapshot height to replay to, the latest height by default",
+	}
+	ReplayWorkDirFlag = cli.StringFlag{
+		Name:  "workdir",
+		Usage: "The directory of the ledger rebuilt by the replay, it's reused by the next replay. <datadir>/replay by default",
+	}
+
+	// Consensus simulate
+	SimulateAlgosFlag = cli.StringFlag{
+		Name:  "algos",
+		Usage: "The comma separated election algorithms to simulate, the first one is the baseline",
+		Value: "default,top",
+	}
+	SimulateStartIndexFlag = cli.Uint64Flag{
+		Name:  "startIndex",
+		Usage: "The period index of the snapshot consensus group to simulate from, 48 periods before the end by default",
+	}
+	SimulateEndIndexFlag = cli.Uint64Flag{
+		Name:  "endIndex",
+		Usage: "The period index of the snapshot consensus group to simulate to, the latest finished period by default",
+	}
+	SimulateOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "The file to write the simulation report to, printed by default",
+	}
+
+	// Check chain
+	CheckChainFullFlag = cli.BoolFlag{
+		Name:  "full",
+		Usage: "Check all blocks, index, balances and onroad blocks of the ledger",
+	}
+	CheckChainReportFlag = cli.StringFlag{
+		Name:  "report",
+		Usage: "The file to write the report of the full check, the report is printed if not set",
+	}
+
 	// Export sb height
 	ExportSbHeightFlags = cli.Uint64Flag{
 		Name:  "sbHeight",
@@ -198,10 +269,26 @@ var (
 		Usage: "File transfer listening port",
 	}
 
+	StateSyncFlag = cli.BoolFlag{
+		Name:  "statesync",
+		Usage: "Download a recent state snapshot from peers instead of syncing the whole ledger, only for an empty ledger",
+	}
+
+	// Prune
+	PruneFlag = cli.BoolFlag{
+		Name:  "prune",
+		Usage: "Delete the old block files, only headers and the state are kept for old snapshot blocks",
+	}
+
+	PruneRetainFlag = cli.Uint64Flag{
+		Name:  "pruneretain",
+		Usage: "Keep full blocks for the latest `count` snapshot blocks when pruning, at least 172800",
+	}
+
 	//Stat
 	PProfEnabledFlag = cli.BoolFlag{
 		Name:  "pprof",
-		Usage: "Enable chain performance analysis tool, you can visit the address[http://localhost:8080/debug/pprof]",
+		Usage: "Enable chain performance analysis tool, you can visit the address[http://localhost:8080/debug/pprof] and the metrics[http://localhost:8080/debug/vars]",
 	}
 
 	PProfPortFlag = cli.UintFlag{
@@ -296,6 +383,9 @@ var (
 		MinerFlag,
 		CoinBaseFlag,
 		MinerIntervalFlag,
+		ProducerLeaseFlag,
+		ProducerLeaseOwnerFlag,
+		ProducerLeaseTTLFlag,
 	}
 
 	//Log
@@ -313,6 +403,13 @@ var (
 	NetFlags = []cli.Flag{
 		SingleFlag,
 		FilePortFlag,
+		StateSyncFlag,
+	}
+
+	//Prune
+	PruneFlags = []cli.Flag{
+		PruneFlag,
+		PruneRetainFlag,
 	}
 
 	//Stat
@@ -324,9 +421,33 @@ var (
 	// Ledger
 	LedgerFlags = []cli.Flag{
 		LedgerDeleteToHeight,
+		LedgerDryRunFlag,
+		LedgerMaxDepthFlag,
+		LedgerBackupFlag,
 		RecoverTrieFlag,
 	}
 
+	// Replay
+	ReplayFlags = []cli.Flag{
+		ReplayFromFlag,
+		ReplayToFlag,
+		ReplayWorkDirFlag,
+	}
+
+	// Consensus simulate
+	SimulateFlags = []cli.Flag{
+		SimulateAlgosFlag,
+		SimulateStartIndexFlag,
+		SimulateEndIndexFlag,
+		SimulateOutputFlag,
+	}
+
+	// Check chain
+	CheckChainFlags = []cli.Flag{
+		CheckChainFullFlag,
+		CheckChainReportFlag,
+	}
+
 	// Export
 	ExportFlags = []cli.Flag{
 		ExportSbHeightFlags,
@@ -11,4 +11,12 @@ type Chain struct {
 
 	VmLogWhiteList []types.Address // contract address white list which save VM logs
 	VmLogAll       bool            // save all VM logs, it will cost more disk space
+
+	StateSnapshotInterval uint64 // export a state snapshot for peers every interval snapshot blocks, 0 means never
+
+	Prune       bool   // delete the old block files, only headers and the state are kept for old snapshot blocks
+	PruneRetain uint64 // keep full blocks for the latest PruneRetain snapshot blocks
+
+	DBEngine       string // the db engine of a new state and index db, "leveldb" or "bolt", it must match the engine of an existing db
+	PluginDBEngine string // the db engine of a new plugins db, "leveldb", "btree" or "bolt", it must match the engine of an existing db
 }
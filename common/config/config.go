@@ -15,6 +15,8 @@ type Config struct {
 	*Net        `json:"Net"`
 	*NodeReward `json:"Reward"`
 	*Genesis    `json:"Genesis"`
+	*Exporter   `json:"Exporter"`
+	*SBPStat    `json:"SBPStat"`
 
 	// global keys
 	DataDir string `json:"DataDir"`
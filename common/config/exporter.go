@@ -0,0 +1,23 @@
+package config
+
+const (
+	DefaultExporterDirName     = "exporter"
+	DefaultExporterFileFormat  = "json"
+	DefaultExporterFileMaxSize = 256 * 1024 * 1024
+)
+
+// Exporter config, the exporter emits the confirmed blocks, the VM logs and the rollbacks to external sinks
+type Exporter struct {
+	Enabled     bool   `json:"Enabled"`
+	Dir         string `json:"Dir"`         // checkpoints of the sinks and the event files
+	StartHeight uint64 `json:"StartHeight"` // the first snapshot height exported on the first run, 0 means the latest one
+
+	FileEnabled bool   `json:"FileEnabled"`
+	FileFormat  string `json:"FileFormat"`  // json or protobuf
+	FileMaxSize int64  `json:"FileMaxSize"` // bytes, the event file is rotated once it exceeds the size
+
+	WebhookURLs     []string `json:"WebhookURLs"`
+	WebhookSecret   string   `json:"WebhookSecret"`   // the body is signed by hmac-sha256 if set
+	WebhookTimeout  uint64   `json:"WebhookTimeout"`  // seconds
+	WebhookMaxRetry int      `json:"WebhookMaxRetry"` // retries of a request before the sink reports the error
+}
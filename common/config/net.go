@@ -94,6 +94,9 @@ type Net struct {
 	WhiteBlockList     []string
 
 	MineKey ed25519.PrivateKey
+
+	// StateSync means download a state snapshot from peers when the ledger is empty, then sync the subsequent blocks
+	StateSync bool
 }
 
 func getPeerKey(filename string) (privateKey ed25519.PrivateKey, err error) {
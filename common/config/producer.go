@@ -17,6 +17,11 @@ type Producer struct {
 	index    uint32
 
 	VirtualSnapshotVerifier bool `json:"VirtualSnapshotVerifier"`
+
+	// active/standby producers with the same coinbase share the lease file, only the lease holder signs blocks
+	LeaseFile  string `json:"LeaseFile"`
+	LeaseOwner string `json:"LeaseOwner"` // hostname:datadir if empty
+	LeaseTTL   uint64 `json:"LeaseTTL"`   // seconds, the standby takes over after the lease expired
 }
 
 func (cfg *Producer) IsMine() bool {
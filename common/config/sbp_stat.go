@@ -0,0 +1,11 @@
+package config
+
+const (
+	DefaultSBPStatRetention = 24 * 60 * 60
+)
+
+// SBPStat config, the node records the planned producer and the outcome of each slot of the snapshot consensus group
+type SBPStat struct {
+	Enabled   bool   `json:"Enabled"`
+	Retention uint64 `json:"Retention"` // seconds of the slots kept in the ring buffer, the older slots are overwritten
+}
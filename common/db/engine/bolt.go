@@ -0,0 +1,363 @@
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+)
+
+// The bolt engine keeps the keys in the b+tree pages of a single mmaped file, only the touched pages are
+// in memory. Every write is a transaction synced to the disk, a crash never leaves a partial batch.
+// A read transaction is opened for every snapshot and iterator, and closed by Release. The writer
+// waits for the open read transactions when the file grows beyond boltMmapSize and has to be remapped,
+// so a snapshot or iterator must not be held by the goroutine writing the engine.
+const (
+	boltFile = "bolt.db"
+)
+
+var (
+	boltBucket = []byte("kv")
+	// 64GB on the 64-bit platforms, the mapping is not backed by memory until the file grows
+	boltMmapSize = 1 << (strconv.IntSize - 28)
+)
+
+type boltDB struct {
+	db *bolt.DB
+}
+
+func openBolt(dir string) (*boltDB, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(filepath.Join(dir, boltFile), 0600, &bolt.Options{
+		Timeout:         time.Second,
+		InitialMmapSize: boltMmapSize,
+		FreelistType:    bolt.FreelistMapType,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(boltBucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &boltDB{db: db}, nil
+}
+
+func (b *boltDB) Get(key []byte) (value []byte, err error) {
+	err = b.db.View(func(tx *bolt.Tx) error {
+		value, err = boltGet(tx.Bucket(boltBucket), key)
+		return err
+	})
+	return value, err
+}
+
+func (b *boltDB) Has(key []byte) (bool, error) {
+	_, err := b.Get(key)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (b *boltDB) NewIterator(slice *util.Range) Iterator {
+	tx, err := b.db.Begin(false)
+	if err != nil {
+		return &boltIterator{err: err, released: true}
+	}
+	return newBoltIterator(tx, tx.Bucket(boltBucket), slice)
+}
+
+func (b *boltDB) Put(key, value []byte) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put(key, value)
+	})
+}
+
+func (b *boltDB) Delete(key []byte) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Delete(key)
+	})
+}
+
+func (b *boltDB) Write(batch *leveldb.Batch) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		r := &boltReplayer{bucket: tx.Bucket(boltBucket)}
+		if err := batch.Replay(r); err != nil {
+			return err
+		}
+		return r.err
+	})
+}
+
+func (b *boltDB) GetSnapshot() (Snapshot, error) {
+	tx, err := b.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	return &boltSnapshot{tx: tx, bucket: tx.Bucket(boltBucket)}, nil
+}
+
+// CompactRange does nothing, the pages freed by the deletes are reused by the later writes
+func (b *boltDB) CompactRange(r util.Range) error {
+	return nil
+}
+
+type boltStats struct {
+	Engine        string
+	Size          int64
+	TxN           int
+	OpenTxN       int
+	FreePageN     int
+	PendingPageN  int
+	FreeAlloc     int
+	FreelistInuse int
+}
+
+func (b *boltDB) Stats() string {
+	s := b.db.Stats()
+	stats := &boltStats{
+		Engine:        Bolt,
+		TxN:           s.TxN,
+		OpenTxN:       s.OpenTxN,
+		FreePageN:     s.FreePageN,
+		PendingPageN:  s.PendingPageN,
+		FreeAlloc:     s.FreeAlloc,
+		FreelistInuse: s.FreelistInuse,
+	}
+	if err := b.db.View(func(tx *bolt.Tx) error {
+		stats.Size = tx.Size()
+		return nil
+	}); err != nil {
+		return "Error:" + err.Error()
+	}
+
+	status, err := json.Marshal(stats)
+	if err != nil {
+		return "Error:" + err.Error()
+	}
+	return string(status)
+}
+
+// Close waits for the snapshots and iterators to be released
+func (b *boltDB) Close() error {
+	return b.db.Close()
+}
+
+type boltReplayer struct {
+	bucket *bolt.Bucket
+	err    error
+}
+
+func (r *boltReplayer) Put(key, value []byte) {
+	if r.err == nil {
+		r.err = r.bucket.Put(key, value)
+	}
+}
+
+func (r *boltReplayer) Delete(key []byte) {
+	if r.err == nil {
+		r.err = r.bucket.Delete(key)
+	}
+}
+
+// boltSnapshot reads a read transaction, the mutex serializes the cursors opened on the transaction
+type boltSnapshot struct {
+	mu       sync.Mutex
+	tx       *bolt.Tx
+	bucket   *bolt.Bucket
+	released bool
+}
+
+func (s *boltSnapshot) Get(key []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.released {
+		return nil, leveldb.ErrSnapshotReleased
+	}
+	return boltGet(s.bucket, key)
+}
+
+func (s *boltSnapshot) Has(key []byte) (bool, error) {
+	_, err := s.Get(key)
+	if err == ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// NewIterator iterates the snapshot, the iterator must be released before the snapshot
+func (s *boltSnapshot) NewIterator(slice *util.Range) Iterator {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.released {
+		return &boltIterator{err: leveldb.ErrSnapshotReleased, released: true}
+	}
+	return newBoltIterator(nil, s.bucket, slice)
+}
+
+func (s *boltSnapshot) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.released {
+		s.released = true
+		s.tx.Rollback()
+	}
+}
+
+// boltGet copies the value out of the mmap, the pages are not valid after the transaction is closed
+func boltGet(bucket *bolt.Bucket, key []byte) ([]byte, error) {
+	k, v := bucket.Cursor().Seek(key)
+	if k == nil || !bytes.Equal(k, key) {
+		return nil, ErrNotFound
+	}
+	return append([]byte{}, v...), nil
+}
+
+// boltIterator iterates a cursor, the key and value are copied out of the mmap. The iterator owns
+// the transaction if tx is not nil, and closes it when released
+type boltIterator struct {
+	tx     *bolt.Tx
+	cursor *bolt.Cursor
+	slice  *util.Range
+
+	key       []byte
+	value     []byte
+	valid     bool
+	exhausted int
+	released  bool
+	err       error
+}
+
+func newBoltIterator(tx *bolt.Tx, bucket *bolt.Bucket, slice *util.Range) *boltIterator {
+	return &boltIterator{tx: tx, cursor: bucket.Cursor(), slice: slice, exhausted: -1}
+}
+
+// check sets the iterator invalid if the key is out of the slice
+func (it *boltIterator) check(key, value []byte, dir int) bool {
+	it.valid = key != nil
+	if it.valid && it.slice != nil {
+		if it.slice.Start != nil && bytes.Compare(key, it.slice.Start) < 0 {
+			it.valid = false
+		}
+		if it.slice.Limit != nil && bytes.Compare(key, it.slice.Limit) >= 0 {
+			it.valid = false
+		}
+	}
+	if it.valid {
+		it.key = append([]byte{}, key...)
+		it.value = append([]byte{}, value...)
+		it.exhausted = -1
+	} else {
+		it.exhausted = dir
+	}
+	return it.valid
+}
+
+func (it *boltIterator) First() bool {
+	if it.released {
+		return false
+	}
+	if it.slice != nil && it.slice.Start != nil {
+		return it.Seek(it.slice.Start)
+	}
+	k, v := it.cursor.First()
+	return it.check(k, v, dirForward)
+}
+
+func (it *boltIterator) Last() bool {
+	if it.released {
+		return false
+	}
+	if it.slice != nil && it.slice.Limit != nil {
+		if k, _ := it.cursor.Seek(it.slice.Limit); k != nil {
+			k, v := it.cursor.Prev()
+			return it.check(k, v, dirBackward)
+		}
+	}
+	k, v := it.cursor.Last()
+	return it.check(k, v, dirBackward)
+}
+
+func (it *boltIterator) Seek(key []byte) bool {
+	if it.released {
+		return false
+	}
+	if it.slice != nil && it.slice.Start != nil && bytes.Compare(key, it.slice.Start) < 0 {
+		key = it.slice.Start
+	}
+	k, v := it.cursor.Seek(key)
+	return it.check(k, v, dirForward)
+}
+
+func (it *boltIterator) Next() bool {
+	if it.released {
+		return false
+	}
+	if !it.valid {
+		if it.exhausted == dirForward {
+			return false
+		}
+		return it.First()
+	}
+	k, v := it.cursor.Next()
+	return it.check(k, v, dirForward)
+}
+
+func (it *boltIterator) Prev() bool {
+	if it.released {
+		return false
+	}
+	if !it.valid {
+		if it.exhausted == dirBackward {
+			return false
+		}
+		return it.Last()
+	}
+	k, v := it.cursor.Prev()
+	return it.check(k, v, dirBackward)
+}
+
+func (it *boltIterator) Valid() bool {
+	return it.valid
+}
+
+func (it *boltIterator) Key() []byte {
+	if !it.valid {
+		return nil
+	}
+	return it.key
+}
+
+func (it *boltIterator) Value() []byte {
+	if !it.valid {
+		return nil
+	}
+	return it.value
+}
+
+func (it *boltIterator) Error() error {
+	return it.err
+}
+
+func (it *boltIterator) Release() {
+	if !it.released && it.tx != nil {
+		it.tx.Rollback()
+	}
+	it.released = true
+	it.valid = false
+	it.cursor = nil
+	it.tx = nil
+}
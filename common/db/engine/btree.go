@@ -0,0 +1,523 @@
+package engine
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+)
+
+// btreeDegree is the minimum degree of the b-tree, a node has at most 2*btreeDegree-1 items
+const btreeDegree = 32
+
+const maxItems = 2*btreeDegree - 1
+
+type item struct {
+	key   []byte
+	value []byte
+}
+
+// cowToken marks the nodes created by a writer, a writer modifies the nodes of its own token in place
+// and copies the others, so the nodes reachable from an older root are never modified.
+// It is not zero-sized, the pointers to zero-sized values may be equal
+type cowToken struct {
+	_ byte
+}
+
+type node struct {
+	items    []item
+	children []*node
+	cow      *cowToken
+}
+
+func (n *node) leaf() bool {
+	return len(n.children) == 0
+}
+
+func (n *node) mutable(cow *cowToken) *node {
+	if n.cow == cow {
+		return n
+	}
+	c := &node{
+		items: append(make([]item, 0, maxItems), n.items...),
+		cow:   cow,
+	}
+	if !n.leaf() {
+		c.children = append(make([]*node, 0, maxItems+1), n.children...)
+	}
+	return c
+}
+
+func (n *node) mutableChild(i int, cow *cowToken) *node {
+	c := n.children[i].mutable(cow)
+	n.children[i] = c
+	return c
+}
+
+// find returns the index of the first item not less than the key, and whether it equals the key
+func (n *node) find(key []byte) (int, bool) {
+	i := sort.Search(len(n.items), func(i int) bool {
+		return bytes.Compare(n.items[i].key, key) >= 0
+	})
+	return i, i < len(n.items) && bytes.Equal(n.items[i].key, key)
+}
+
+func (n *node) get(key []byte) ([]byte, bool) {
+	for {
+		i, found := n.find(key)
+		if found {
+			return n.items[i].value, true
+		}
+		if n.leaf() {
+			return nil, false
+		}
+		n = n.children[i]
+	}
+}
+
+// split moves the items after i to a new node, it returns the item at i and the new node
+func (n *node) split(i int, cow *cowToken) (item, *node) {
+	it := n.items[i]
+	next := &node{cow: cow}
+	next.items = append(make([]item, 0, maxItems), n.items[i+1:]...)
+	n.items = n.items[:i]
+	if !n.leaf() {
+		next.children = append(make([]*node, 0, maxItems+1), n.children[i+1:]...)
+		n.children = n.children[:i+1]
+	}
+	return it, next
+}
+
+// insert puts the item into the subtree of a mutable node which is not full, it returns true if a new key is added
+func (n *node) insert(it item, cow *cowToken) bool {
+	for {
+		i, found := n.find(it.key)
+		if found {
+			n.items[i] = it
+			return false
+		}
+		if n.leaf() {
+			n.items = append(n.items, item{})
+			copy(n.items[i+1:], n.items[i:])
+			n.items[i] = it
+			return true
+		}
+		if len(n.children[i].items) >= maxItems {
+			mid, next := n.mutableChild(i, cow).split(btreeDegree-1, cow)
+			n.items = append(n.items, item{})
+			copy(n.items[i+1:], n.items[i:])
+			n.items[i] = mid
+			n.children = append(n.children, nil)
+			copy(n.children[i+2:], n.children[i+1:])
+			n.children[i+1] = next
+			switch c := bytes.Compare(it.key, mid.key); {
+			case c == 0:
+				n.items[i] = it
+				return false
+			case c > 0:
+				i++
+			}
+		}
+		n = n.mutableChild(i, cow)
+	}
+}
+
+// remove deletes the key from the subtree of a mutable node, it returns true if the key existed.
+// Every node descended into has at least btreeDegree items, so a removal never underflows it
+func (n *node) remove(key []byte, cow *cowToken) bool {
+	for {
+		i, found := n.find(key)
+		if n.leaf() {
+			if !found {
+				return false
+			}
+			n.items = append(n.items[:i], n.items[i+1:]...)
+			return true
+		}
+		if found {
+			switch {
+			case len(n.children[i].items) >= btreeDegree:
+				// replace with the predecessor
+				child := n.mutableChild(i, cow)
+				n.items[i] = child.removeMax(cow)
+				return true
+			case len(n.children[i+1].items) >= btreeDegree:
+				// replace with the successor
+				child := n.mutableChild(i+1, cow)
+				n.items[i] = child.removeMin(cow)
+				return true
+			default:
+				// merge the item and the right child into the left child, then remove from it
+				n = n.merge(i, cow)
+				continue
+			}
+		}
+		n = n.growChild(i, cow)
+	}
+}
+
+// growChild makes sure the child i has at least btreeDegree items before descending into it, it returns the child
+func (n *node) growChild(i int, cow *cowToken) *node {
+	if len(n.children[i].items) >= btreeDegree {
+		return n.mutableChild(i, cow)
+	}
+	if i > 0 && len(n.children[i-1].items) >= btreeDegree {
+		// borrow from the left sibling
+		child := n.mutableChild(i, cow)
+		left := n.mutableChild(i-1, cow)
+		child.items = append(child.items, item{})
+		copy(child.items[1:], child.items)
+		child.items[0] = n.items[i-1]
+		n.items[i-1] = left.items[len(left.items)-1]
+		left.items = left.items[:len(left.items)-1]
+		if !left.leaf() {
+			child.children = append(child.children, nil)
+			copy(child.children[1:], child.children)
+			child.children[0] = left.children[len(left.children)-1]
+			left.children = left.children[:len(left.children)-1]
+		}
+		return child
+	}
+	if i < len(n.items) && len(n.children[i+1].items) >= btreeDegree {
+		// borrow from the right sibling
+		child := n.mutableChild(i, cow)
+		right := n.mutableChild(i+1, cow)
+		child.items = append(child.items, n.items[i])
+		n.items[i] = right.items[0]
+		right.items = append(right.items[:0], right.items[1:]...)
+		if !right.leaf() {
+			child.children = append(child.children, right.children[0])
+			right.children = append(right.children[:0], right.children[1:]...)
+		}
+		return child
+	}
+	if i == len(n.items) {
+		i--
+	}
+	return n.merge(i, cow)
+}
+
+// merge merges the item i and the child i+1 into the child i, it returns the merged child
+func (n *node) merge(i int, cow *cowToken) *node {
+	child := n.mutableChild(i, cow)
+	right := n.children[i+1]
+	child.items = append(child.items, n.items[i])
+	child.items = append(child.items, right.items...)
+	if !child.leaf() {
+		child.children = append(child.children, right.children...)
+	}
+	n.items = append(n.items[:i], n.items[i+1:]...)
+	n.children = append(n.children[:i+1], n.children[i+2:]...)
+	return child
+}
+
+func (n *node) removeMax(cow *cowToken) item {
+	for !n.leaf() {
+		n = n.growChild(len(n.items), cow)
+	}
+	it := n.items[len(n.items)-1]
+	n.items = n.items[:len(n.items)-1]
+	return it
+}
+
+func (n *node) removeMin(cow *cowToken) item {
+	for !n.leaf() {
+		n = n.growChild(0, cow)
+	}
+	it := n.items[0]
+	n.items = append(n.items[:0], n.items[1:]...)
+	return it
+}
+
+// btree is an immutable version of the tree, the writer creates a new version from it
+type btree struct {
+	root   *node
+	length int
+}
+
+func (t *btree) get(key []byte) ([]byte, bool) {
+	if t.root == nil {
+		return nil, false
+	}
+	return t.root.get(key)
+}
+
+// btreeWriter applies the changes of a batch to a new version of the tree
+type btreeWriter struct {
+	root   *node
+	length int
+	cow    *cowToken
+}
+
+func newBTreeWriter(t *btree) *btreeWriter {
+	return &btreeWriter{root: t.root, length: t.length, cow: &cowToken{}}
+}
+
+// Put copies the key and the value, they are owned by the batch being replayed
+func (w *btreeWriter) Put(key, value []byte) {
+	it := item{key: append([]byte{}, key...), value: append([]byte{}, value...)}
+	if w.root == nil {
+		w.root = &node{items: append(make([]item, 0, maxItems), it), cow: w.cow}
+		w.length++
+		return
+	}
+	w.root = w.root.mutable(w.cow)
+	if len(w.root.items) >= maxItems {
+		mid, next := w.root.split(btreeDegree-1, w.cow)
+		old := w.root
+		w.root = &node{cow: w.cow}
+		w.root.items = append(make([]item, 0, maxItems), mid)
+		w.root.children = append(make([]*node, 0, maxItems+1), old, next)
+	}
+	if w.root.insert(it, w.cow) {
+		w.length++
+	}
+}
+
+func (w *btreeWriter) Delete(key []byte) {
+	if w.root == nil {
+		return
+	}
+	w.root = w.root.mutable(w.cow)
+	if w.root.remove(key, w.cow) {
+		w.length--
+	}
+	if len(w.root.items) == 0 {
+		if w.root.leaf() {
+			w.root = nil
+		} else {
+			w.root = w.root.children[0]
+		}
+	}
+}
+
+func (w *btreeWriter) tree() *btree {
+	return &btree{root: w.root, length: w.length}
+}
+
+type frame struct {
+	n *node
+	i int
+}
+
+// btreeIterator iterates a version of the tree, a frame below the top means the iterator is in its child i,
+// the top frame points to the current item
+type btreeIterator struct {
+	root  *node
+	slice *util.Range
+	stack []frame
+
+	valid     bool
+	exhausted int
+	released  bool
+}
+
+func newBTreeIterator(t *btree, slice *util.Range) *btreeIterator {
+	return &btreeIterator{root: t.root, slice: slice, exhausted: -1}
+}
+
+func (it *btreeIterator) current() item {
+	top := it.stack[len(it.stack)-1]
+	return top.n.items[top.i]
+}
+
+// check sets the iterator invalid if the current item is out of the slice
+func (it *btreeIterator) check(dir int) bool {
+	it.valid = len(it.stack) > 0
+	if it.valid && it.slice != nil {
+		key := it.current().key
+		if it.slice.Start != nil && bytes.Compare(key, it.slice.Start) < 0 {
+			it.valid = false
+		}
+		if it.slice.Limit != nil && bytes.Compare(key, it.slice.Limit) >= 0 {
+			it.valid = false
+		}
+	}
+	if it.valid {
+		it.exhausted = -1
+	} else {
+		it.stack = it.stack[:0]
+		it.exhausted = dir
+	}
+	return it.valid
+}
+
+func (it *btreeIterator) descendFirst(n *node) {
+	for {
+		it.stack = append(it.stack, frame{n, 0})
+		if n.leaf() {
+			return
+		}
+		n = n.children[0]
+	}
+}
+
+func (it *btreeIterator) descendLast(n *node) {
+	for !n.leaf() {
+		it.stack = append(it.stack, frame{n, len(n.items)})
+		n = n.children[len(n.items)]
+	}
+	it.stack = append(it.stack, frame{n, len(n.items) - 1})
+}
+
+// seek positions the iterator at the first item not less than the key
+func (it *btreeIterator) seek(key []byte) {
+	it.stack = it.stack[:0]
+	n := it.root
+	if n == nil {
+		return
+	}
+	for {
+		i, found := n.find(key)
+		it.stack = append(it.stack, frame{n, i})
+		if found || n.leaf() {
+			break
+		}
+		n = n.children[i]
+	}
+	it.ascendForward()
+}
+
+// ascendForward pops the exhausted frames, the parent frame points to the next item
+func (it *btreeIterator) ascendForward() {
+	for len(it.stack) > 0 {
+		top := it.stack[len(it.stack)-1]
+		if top.i < len(top.n.items) {
+			return
+		}
+		it.stack = it.stack[:len(it.stack)-1]
+	}
+}
+
+func (it *btreeIterator) ascendBackward() {
+	for len(it.stack) > 0 {
+		top := &it.stack[len(it.stack)-1]
+		if top.i >= 0 {
+			return
+		}
+		it.stack = it.stack[:len(it.stack)-1]
+		if len(it.stack) > 0 {
+			// back from the child i of the parent, the previous item is i-1
+			it.stack[len(it.stack)-1].i--
+		}
+	}
+}
+
+func (it *btreeIterator) First() bool {
+	if it.released {
+		return false
+	}
+	if it.slice != nil && it.slice.Start != nil {
+		return it.Seek(it.slice.Start)
+	}
+	it.stack = it.stack[:0]
+	if it.root != nil {
+		it.descendFirst(it.root)
+	}
+	return it.check(dirForward)
+}
+
+func (it *btreeIterator) Last() bool {
+	if it.released {
+		return false
+	}
+	it.stack = it.stack[:0]
+	if it.root == nil {
+		return it.check(dirBackward)
+	}
+	if it.slice != nil && it.slice.Limit != nil {
+		it.seek(it.slice.Limit)
+		if len(it.stack) == 0 {
+			it.descendLast(it.root)
+			return it.check(dirBackward)
+		}
+		return it.prev()
+	}
+	it.descendLast(it.root)
+	return it.check(dirBackward)
+}
+
+func (it *btreeIterator) Seek(key []byte) bool {
+	if it.released {
+		return false
+	}
+	if it.slice != nil && it.slice.Start != nil && bytes.Compare(key, it.slice.Start) < 0 {
+		key = it.slice.Start
+	}
+	it.seek(key)
+	return it.check(dirForward)
+}
+
+func (it *btreeIterator) Next() bool {
+	if it.released {
+		return false
+	}
+	if !it.valid {
+		if it.exhausted == dirForward {
+			return false
+		}
+		return it.First()
+	}
+	top := &it.stack[len(it.stack)-1]
+	if top.n.leaf() {
+		top.i++
+	} else {
+		top.i++
+		it.descendFirst(top.n.children[top.i])
+	}
+	it.ascendForward()
+	return it.check(dirForward)
+}
+
+func (it *btreeIterator) Prev() bool {
+	if it.released {
+		return false
+	}
+	if !it.valid {
+		if it.exhausted == dirBackward {
+			return false
+		}
+		return it.Last()
+	}
+	return it.prev()
+}
+
+func (it *btreeIterator) prev() bool {
+	top := &it.stack[len(it.stack)-1]
+	if top.n.leaf() {
+		top.i--
+	} else {
+		it.descendLast(top.n.children[top.i])
+	}
+	it.ascendBackward()
+	return it.check(dirBackward)
+}
+
+func (it *btreeIterator) Valid() bool {
+	return it.valid
+}
+
+func (it *btreeIterator) Key() []byte {
+	if !it.valid {
+		return nil
+	}
+	return it.current().key
+}
+
+func (it *btreeIterator) Value() []byte {
+	if !it.valid {
+		return nil
+	}
+	return it.current().value
+}
+
+func (it *btreeIterator) Error() error {
+	return nil
+}
+
+func (it *btreeIterator) Release() {
+	it.released = true
+	it.valid = false
+	it.stack = nil
+	it.root = nil
+}
@@ -0,0 +1,461 @@
+package engine
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/errors"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/v2/log15"
+)
+
+// The btree engine keeps all the keys in a copy-on-write b-tree in memory. Every batch is appended to
+// the write-ahead log before it is applied, like leveldb the log is not synced. When the log exceeds
+// checkpointThreshold, it is rotated and the tree is written to a checkpoint in the background, then
+// the older logs are deleted. Opening the engine loads the latest checkpoint and replays the logs after
+// it, a torn record at the tail of the last log is truncated. The whole tree must fit in memory, so the
+// engine is only for the tests and the plugins db, see BTree.
+const (
+	btreeMarkFile        = "BTREE"
+	walFilePrefix        = "wal-"
+	walFileSuffix        = ".log"
+	checkpointFilePrefix = "checkpoint-"
+	tmpFileSuffix        = ".tmp"
+
+	checkpointThreshold = 64 * 1024 * 1024
+	checkpointBatchSize = 4 * 1024 * 1024
+
+	recordHeaderSize = 8
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+type btreeDB struct {
+	dir string
+	log log15.Logger
+
+	// writeMu serializes the writers and guards the log
+	writeMu sync.Mutex
+	// treeMu guards the latest version of the tree
+	treeMu sync.RWMutex
+	tree   *btree
+
+	wal           *os.File
+	walNum        uint64
+	walSize       int64
+	walErr        error
+	checkpointing bool
+	checkpointNum uint64
+	writes        uint64
+	closed        bool
+
+	wg sync.WaitGroup
+}
+
+func newBTree(dir string) *btreeDB {
+	return &btreeDB{
+		dir:  dir,
+		log:  log15.New("module", "btree", "dir", dir),
+		tree: &btree{},
+	}
+}
+
+func openBTree(dir string) (*btreeDB, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, btreeMarkFile), []byte(BTree+"\n"), 0600); err != nil {
+		return nil, err
+	}
+
+	db := newBTree(dir)
+	if err := db.load(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func walFileName(num uint64) string {
+	return walFilePrefix + strconv.FormatUint(num, 10) + walFileSuffix
+}
+
+func checkpointFileName(num uint64) string {
+	return checkpointFilePrefix + strconv.FormatUint(num, 10)
+}
+
+// listFiles returns the numbers of the logs and the checkpoints in the dir, in ascending order
+func (db *btreeDB) listFiles() (wals []uint64, checkpoints []uint64, err error) {
+	files, err := ioutil.ReadDir(db.dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, file := range files {
+		name := file.Name()
+		switch {
+		case strings.HasSuffix(name, tmpFileSuffix):
+			// an unfinished checkpoint
+			os.Remove(filepath.Join(db.dir, name))
+		case strings.HasPrefix(name, walFilePrefix) && strings.HasSuffix(name, walFileSuffix):
+			if num, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(name, walFilePrefix), walFileSuffix), 10, 64); err == nil {
+				wals = append(wals, num)
+			}
+		case strings.HasPrefix(name, checkpointFilePrefix):
+			if num, err := strconv.ParseUint(strings.TrimPrefix(name, checkpointFilePrefix), 10, 64); err == nil {
+				checkpoints = append(checkpoints, num)
+			}
+		}
+	}
+	sort.Slice(wals, func(i, j int) bool { return wals[i] < wals[j] })
+	sort.Slice(checkpoints, func(i, j int) bool { return checkpoints[i] < checkpoints[j] })
+	return wals, checkpoints, nil
+}
+
+// load restores the tree from the checkpoint N and the logs numbered N and above,
+// the checkpoint N contains all the batches of the logs below N
+func (db *btreeDB) load() error {
+	wals, checkpoints, err := db.listFiles()
+	if err != nil {
+		return err
+	}
+
+	w := newBTreeWriter(db.tree)
+	if len(checkpoints) > 0 {
+		db.checkpointNum = checkpoints[len(checkpoints)-1]
+		file := filepath.Join(db.dir, checkpointFileName(db.checkpointNum))
+		if _, torn, err := replayFile(file, w); err != nil {
+			return err
+		} else if torn {
+			return &errors.ErrCorrupted{Err: fmt.Errorf("btree: checkpoint %s is corrupted", file)}
+		}
+	}
+
+	db.walNum = db.checkpointNum
+	var replayed []uint64
+	for _, num := range wals {
+		if num >= db.checkpointNum {
+			replayed = append(replayed, num)
+		}
+	}
+	for i, num := range replayed {
+		file := filepath.Join(db.dir, walFileName(num))
+		size, torn, err := replayFile(file, w)
+		if err != nil {
+			return err
+		}
+		db.walNum, db.walSize = num, size
+		if !torn {
+			continue
+		}
+		if i < len(replayed)-1 {
+			return &errors.ErrCorrupted{Err: fmt.Errorf("btree: log %s is corrupted", file)}
+		}
+		// the record being written when the process crashed
+		db.log.Warn("truncate the torn tail of the log", "file", file, "size", size)
+		if err := os.Truncate(file, size); err != nil {
+			return err
+		}
+	}
+	db.tree = w.tree()
+	db.removeObsoleteFiles(wals, checkpoints, db.checkpointNum)
+
+	db.wal, err = os.OpenFile(filepath.Join(db.dir, walFileName(db.walNum)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	return err
+}
+
+func (db *btreeDB) removeObsoleteFiles(wals []uint64, checkpoints []uint64, checkpointNum uint64) {
+	for _, num := range wals {
+		if num < checkpointNum {
+			os.Remove(filepath.Join(db.dir, walFileName(num)))
+		}
+	}
+	for _, num := range checkpoints {
+		if num < checkpointNum {
+			os.Remove(filepath.Join(db.dir, checkpointFileName(num)))
+		}
+	}
+}
+
+// replayFile applies the records of the file to the writer, it returns the size of the intact records
+// and whether the records after it are torn
+func replayFile(file string, w *btreeWriter) (int64, bool, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, false, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	header := make([]byte, recordHeaderSize)
+	batch := new(leveldb.Batch)
+	var size int64
+	for {
+		if _, err := io.ReadFull(reader, header); err == io.EOF {
+			return size, false, nil
+		} else if err == io.ErrUnexpectedEOF {
+			return size, true, nil
+		} else if err != nil {
+			return size, false, err
+		}
+		data := make([]byte, binary.LittleEndian.Uint32(header[:4]))
+		if _, err := io.ReadFull(reader, data); err == io.EOF || err == io.ErrUnexpectedEOF {
+			return size, true, nil
+		} else if err != nil {
+			return size, false, err
+		}
+		if crc32.Checksum(data, crcTable) != binary.LittleEndian.Uint32(header[4:]) {
+			return size, true, nil
+		}
+		if err := batch.Load(data); err != nil {
+			return size, true, nil
+		}
+		batch.Replay(w)
+		size += int64(recordHeaderSize + len(data))
+	}
+}
+
+func writeRecord(w io.Writer, data []byte) (int, error) {
+	record := make([]byte, recordHeaderSize+len(data))
+	binary.LittleEndian.PutUint32(record[:4], uint32(len(data)))
+	binary.LittleEndian.PutUint32(record[4:8], crc32.Checksum(data, crcTable))
+	copy(record[recordHeaderSize:], data)
+	return w.Write(record)
+}
+
+func (db *btreeDB) current() *btree {
+	db.treeMu.RLock()
+	defer db.treeMu.RUnlock()
+	return db.tree
+}
+
+func (db *btreeDB) Get(key []byte) ([]byte, error) {
+	return btreeGet(db.current(), key)
+}
+
+func (db *btreeDB) Has(key []byte) (bool, error) {
+	_, ok := db.current().get(key)
+	return ok, nil
+}
+
+func (db *btreeDB) NewIterator(slice *util.Range) Iterator {
+	return newBTreeIterator(db.current(), slice)
+}
+
+func (db *btreeDB) Put(key, value []byte) error {
+	batch := new(leveldb.Batch)
+	batch.Put(key, value)
+	return db.Write(batch)
+}
+
+func (db *btreeDB) Delete(key []byte) error {
+	batch := new(leveldb.Batch)
+	batch.Delete(key)
+	return db.Write(batch)
+}
+
+func (db *btreeDB) Write(batch *leveldb.Batch) error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+
+	if db.closed {
+		return leveldb.ErrClosed
+	}
+	if db.walErr != nil {
+		return db.walErr
+	}
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	if db.wal != nil {
+		n, err := writeRecord(db.wal, batch.Dump())
+		if err != nil {
+			// a partial record would hide the records after it
+			db.walErr = err
+			return err
+		}
+		db.walSize += int64(n)
+	}
+
+	w := newBTreeWriter(db.current())
+	batch.Replay(w)
+	tree := w.tree()
+
+	db.treeMu.Lock()
+	db.tree = tree
+	db.treeMu.Unlock()
+	db.writes++
+
+	if db.wal != nil && db.walSize >= checkpointThreshold && !db.checkpointing {
+		db.startCheckpoint(tree)
+	}
+	return nil
+}
+
+// startCheckpoint rotates the log and writes the tree to a checkpoint in the background
+func (db *btreeDB) startCheckpoint(tree *btree) {
+	num := db.walNum + 1
+	wal, err := os.OpenFile(filepath.Join(db.dir, walFileName(num)), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		db.log.Error("create log failed", "err", err)
+		return
+	}
+	db.wal.Close()
+	db.wal, db.walNum, db.walSize = wal, num, 0
+	db.checkpointing = true
+
+	db.wg.Add(1)
+	go func() {
+		defer db.wg.Done()
+
+		err := db.writeCheckpoint(num, tree)
+
+		db.writeMu.Lock()
+		defer db.writeMu.Unlock()
+		db.checkpointing = false
+		if err != nil {
+			db.log.Error("write checkpoint failed", "num", num, "err", err)
+			return
+		}
+		db.checkpointNum = num
+		if wals, checkpoints, err := db.listFiles(); err == nil {
+			db.removeObsoleteFiles(wals, checkpoints, num)
+		}
+	}()
+}
+
+func (db *btreeDB) writeCheckpoint(num uint64, tree *btree) error {
+	file := filepath.Join(db.dir, checkpointFileName(num))
+	f, err := os.OpenFile(file+tmpFileSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(f)
+
+	iter := newBTreeIterator(tree, nil)
+	defer iter.Release()
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Put(iter.Key(), iter.Value())
+		if len(batch.Dump()) >= checkpointBatchSize {
+			if _, err := writeRecord(writer, batch.Dump()); err != nil {
+				f.Close()
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if batch.Len() > 0 {
+		if _, err := writeRecord(writer, batch.Dump()); err != nil {
+			f.Close()
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file+tmpFileSuffix, file)
+}
+
+func (db *btreeDB) GetSnapshot() (Snapshot, error) {
+	return &btreeSnapshot{tree: db.current()}, nil
+}
+
+// CompactRange does nothing, the tree has no garbage to compact
+func (db *btreeDB) CompactRange(r util.Range) error {
+	return nil
+}
+
+type btreeStats struct {
+	Engine        string
+	Keys          int
+	Writes        uint64
+	WalNum        uint64
+	WalSize       int64
+	CheckpointNum uint64
+	Checkpointing bool
+}
+
+func (db *btreeDB) Stats() string {
+	db.writeMu.Lock()
+	s := &btreeStats{
+		Engine:        BTree,
+		Keys:          db.current().length,
+		Writes:        db.writes,
+		WalNum:        db.walNum,
+		WalSize:       db.walSize,
+		CheckpointNum: db.checkpointNum,
+		Checkpointing: db.checkpointing,
+	}
+	db.writeMu.Unlock()
+
+	status, err := json.Marshal(s)
+	if err != nil {
+		return "Error:" + err.Error()
+	}
+	return string(status)
+}
+
+func (db *btreeDB) Close() error {
+	db.writeMu.Lock()
+	if db.closed {
+		db.writeMu.Unlock()
+		return leveldb.ErrClosed
+	}
+	db.closed = true
+	db.writeMu.Unlock()
+
+	db.wg.Wait()
+	if db.wal != nil {
+		return db.wal.Close()
+	}
+	return nil
+}
+
+type btreeSnapshot struct {
+	tree *btree
+}
+
+func (s *btreeSnapshot) Get(key []byte) ([]byte, error) {
+	return btreeGet(s.tree, key)
+}
+
+func (s *btreeSnapshot) Has(key []byte) (bool, error) {
+	_, ok := s.tree.get(key)
+	return ok, nil
+}
+
+func (s *btreeSnapshot) NewIterator(slice *util.Range) Iterator {
+	return newBTreeIterator(s.tree, slice)
+}
+
+// Release does nothing, the version of the tree is freed by the gc
+func (s *btreeSnapshot) Release() {}
+
+func btreeGet(t *btree, key []byte) ([]byte, error) {
+	value, ok := t.get(key)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte{}, value...), nil
+}
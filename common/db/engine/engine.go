@@ -0,0 +1,179 @@
+// Package engine abstracts the embedded key-value storage engines under the chain stores,
+// the onroad db and the p2p node db.
+package engine
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/errors"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/memdb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+)
+
+const (
+	LevelDB = "leveldb"
+	// BTree keeps all the keys in memory, it is not for the stores growing with the ledger. It is only
+	// used by the tests and the plugins db, whose indexes are small and can be rebuilt from the ledger
+	BTree = "btree"
+	// Bolt keeps the keys in the b+tree pages of a mmaped file, like LevelDB it is for any store
+	Bolt = "bolt"
+
+	// Default is the engine of a new data dir if no engine is configured
+	Default = LevelDB
+)
+
+var ErrNotFound = leveldb.ErrNotFound
+
+// Iterator iterates the keys in order, it is the same as the iterator of leveldb
+type Iterator interface {
+	First() bool
+	Last() bool
+	Seek(key []byte) bool
+	Next() bool
+	Prev() bool
+
+	Valid() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+type Reader interface {
+	// Get returns ErrNotFound if the key is not existed
+	Get(key []byte) ([]byte, error)
+	Has(key []byte) (bool, error)
+	// NewIterator iterates the keys in the slice, a nil slice means all the keys
+	NewIterator(slice *util.Range) Iterator
+}
+
+// Snapshot is a frozen view of the engine, it must be released after use
+type Snapshot interface {
+	Reader
+	Release()
+}
+
+type Engine interface {
+	Reader
+
+	Put(key, value []byte) error
+	Delete(key []byte) error
+	// Write applies the batch atomically
+	Write(batch *leveldb.Batch) error
+
+	GetSnapshot() (Snapshot, error)
+	CompactRange(r util.Range) error
+	// Stats returns the json encoded statistics of the engine
+	Stats() string
+	Close() error
+}
+
+// overlayReader is implemented by the engines which read through an in-memory overlay natively
+type overlayReader interface {
+	GetWithOverlay(key []byte, overlay *memdb.DB, seq uint64) ([]byte, error)
+	NewIteratorWithOverlay(slice *util.Range, overlay *memdb.DB, seq uint64) Iterator
+}
+
+// GetWithOverlay reads the key from the overlay first, the entries of the overlay whose sequence is
+// not greater than seq shadow the engine. The overlay is a memdb of internal keys, see common/db.MemDB
+func GetWithOverlay(e Engine, key []byte, overlay *memdb.DB, seq uint64) ([]byte, error) {
+	if r, ok := e.(overlayReader); ok {
+		return r.GetWithOverlay(key, overlay, seq)
+	}
+	return getWithOverlay(e, key, overlay, seq)
+}
+
+// NewIteratorWithOverlay iterates the engine merged with the overlay, see GetWithOverlay
+func NewIteratorWithOverlay(e Engine, slice *util.Range, overlay *memdb.DB, seq uint64) Iterator {
+	if r, ok := e.(overlayReader); ok {
+		return r.NewIteratorWithOverlay(slice, overlay, seq)
+	}
+	return newOverlayIterator(e.NewIterator(slice), overlay, slice, seq)
+}
+
+// Open opens the engine in the dir. The name is the engine of a new dir, an empty name means the
+// Default engine. An existing dir is opened by the engine which created it, it is an error if the name
+// is another engine, see Check
+func Open(name string, dir string) (Engine, error) {
+	name, err := resolve(name, dir)
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case LevelDB:
+		return openLevelDB(dir, false)
+	case BTree:
+		return openBTree(dir)
+	case Bolt:
+		return openBolt(dir)
+	}
+	return nil, fmt.Errorf("unknown db engine %s", name)
+}
+
+// Recover opens the engine like Open, and tries to recover a corrupted dir
+func Recover(name string, dir string) (Engine, error) {
+	name, err := resolve(name, dir)
+	if err != nil {
+		return nil, err
+	}
+	if name == LevelDB {
+		return openLevelDB(dir, true)
+	}
+	return Open(name, dir)
+}
+
+// OpenMemory opens an engine without persistence
+func OpenMemory() Engine {
+	return newBTree("")
+}
+
+func IsCorrupted(err error) bool {
+	return errors.IsCorrupted(err)
+}
+
+// Detect returns the engine which created the dir, false if the dir is new
+func Detect(dir string) (string, bool) {
+	if fileExists(filepath.Join(dir, btreeMarkFile)) {
+		return BTree, true
+	}
+	if fileExists(filepath.Join(dir, boltFile)) {
+		return Bolt, true
+	}
+	if fileExists(filepath.Join(dir, "CURRENT")) {
+		return LevelDB, true
+	}
+	return "", false
+}
+
+// Check returns an error if the name is not an engine, or the dir is created by another engine
+func Check(name string, dir string) error {
+	_, err := resolve(name, dir)
+	return err
+}
+
+func resolve(name string, dir string) (string, error) {
+	switch name {
+	case "", LevelDB, BTree, Bolt:
+	default:
+		return "", fmt.Errorf("unknown db engine %s", name)
+	}
+	existed, ok := Detect(dir)
+	if !ok {
+		if name == "" {
+			return Default, nil
+		}
+		return name, nil
+	}
+	if name != "" && name != existed {
+		return "", fmt.Errorf("%s was created by the db engine %s, not %s", dir, existed, name)
+	}
+	return existed, nil
+}
+
+func fileExists(file string) bool {
+	_, err := os.Stat(file)
+	return err == nil
+}
@@ -0,0 +1,385 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/comparer"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/memdb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+)
+
+var testEngines = []string{LevelDB, BTree, Bolt}
+
+func testKey(i int) []byte {
+	return []byte(fmt.Sprintf("key-%06d", i))
+}
+
+func openTestEngine(t *testing.T, name string) (Engine, string) {
+	dir, err := ioutil.TempDir("", "engine_"+name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := Open(name, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e, dir
+}
+
+// collect iterates the keys forward and backward, and checks both directions agree
+func collect(t *testing.T, iter Iterator) []string {
+	defer iter.Release()
+	var forward, backward []string
+	for iter.Next() {
+		forward = append(forward, string(iter.Key())+"="+string(iter.Value()))
+	}
+	for iter.Prev() {
+		backward = append(backward, string(iter.Key())+"="+string(iter.Value()))
+	}
+	if err := iter.Error(); err != nil {
+		t.Fatal(err)
+	}
+	if len(forward) != len(backward) {
+		t.Fatalf("forward %d keys, backward %d keys", len(forward), len(backward))
+	}
+	for i := range forward {
+		if forward[i] != backward[len(backward)-1-i] {
+			t.Fatalf("forward and backward mismatch at %d, %s %s", i, forward[i], backward[len(backward)-1-i])
+		}
+	}
+	return forward
+}
+
+func expected(m map[string]string, slice *util.Range) []string {
+	var result []string
+	for k, v := range m {
+		if slice != nil && slice.Start != nil && k < string(slice.Start) {
+			continue
+		}
+		if slice != nil && slice.Limit != nil && k >= string(slice.Limit) {
+			continue
+		}
+		result = append(result, k+"="+v)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEngine(t *testing.T) {
+	for _, name := range testEngines {
+		t.Run(name, func(t *testing.T) {
+			e, dir := openTestEngine(t, name)
+			defer os.RemoveAll(dir)
+
+			// random batches over a small key space, so the keys are overwritten and deleted often
+			r := rand.New(rand.NewSource(1))
+			m := make(map[string]string)
+			for i := 0; i < 200; i++ {
+				batch := new(leveldb.Batch)
+				for j := 0; j < 50; j++ {
+					key := testKey(r.Intn(3000))
+					if r.Intn(4) == 0 {
+						batch.Delete(key)
+						delete(m, string(key))
+					} else {
+						value := fmt.Sprintf("v%d-%d", i, j)
+						batch.Put(key, []byte(value))
+						m[string(key)] = value
+					}
+				}
+				if err := e.Write(batch); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			snapshot, err := e.GetSnapshot()
+			if err != nil {
+				t.Fatal(err)
+			}
+			frozen := expected(m, nil)
+			if err := e.Put(testKey(5000), []byte("after")); err != nil {
+				t.Fatal(err)
+			}
+			if err := e.Delete([]byte(frozen[0][:10])); err != nil {
+				t.Fatal(err)
+			}
+			if got := collect(t, snapshot.NewIterator(nil)); !equal(got, frozen) {
+				t.Fatal("the snapshot is changed by the writes")
+			}
+			snapshot.Release()
+			m[string(testKey(5000))] = "after"
+			delete(m, frozen[0][:10])
+
+			for _, slice := range []*util.Range{nil, {Start: testKey(1000), Limit: testKey(2000)}, {Start: testKey(2500)}, {Limit: []byte("key-000500x")}} {
+				if got, want := collect(t, e.NewIterator(slice)), expected(m, slice); !equal(got, want) {
+					t.Fatalf("iterate %v, got %d keys, want %d keys", slice, len(got), len(want))
+				}
+			}
+
+			for i := 0; i < 3000; i++ {
+				value, err := e.Get(testKey(i))
+				if want, ok := m[string(testKey(i))]; ok {
+					if err != nil || string(value) != want {
+						t.Fatalf("get %d, got %s %v, want %s", i, value, err, want)
+					}
+				} else if err != ErrNotFound {
+					t.Fatalf("get deleted key %d, got %s %v", i, value, err)
+				}
+			}
+
+			// reopen the engine without an explicit name
+			if err := e.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Open(otherEngine(name), dir); err == nil {
+				t.Fatal("expect an error to open the dir with another engine")
+			}
+			if e, err = Open("", dir); err != nil {
+				t.Fatal(err)
+			}
+			defer e.Close()
+			if got := collect(t, e.NewIterator(nil)); !equal(got, expected(m, nil)) {
+				t.Fatal("the keys are changed after reopening")
+			}
+		})
+	}
+}
+
+func otherEngine(name string) string {
+	if name == LevelDB {
+		return BTree
+	}
+	return LevelDB
+}
+
+func TestIteratorSeek(t *testing.T) {
+	for _, name := range testEngines {
+		t.Run(name, func(t *testing.T) {
+			e, dir := openTestEngine(t, name)
+			defer os.RemoveAll(dir)
+			defer e.Close()
+
+			batch := new(leveldb.Batch)
+			for i := 0; i < 1000; i += 2 {
+				batch.Put(testKey(i), testKey(i))
+			}
+			e.Write(batch)
+
+			iter := e.NewIterator(&util.Range{Start: testKey(100), Limit: testKey(900)})
+			defer iter.Release()
+			if !iter.Seek(testKey(301)) || !bytes.Equal(iter.Key(), testKey(302)) {
+				t.Fatalf("seek got %s", iter.Key())
+			}
+			if !iter.Prev() || !bytes.Equal(iter.Key(), testKey(300)) {
+				t.Fatalf("prev got %s", iter.Key())
+			}
+			if !iter.Seek(testKey(0)) || !bytes.Equal(iter.Key(), testKey(100)) {
+				t.Fatalf("seek before the slice got %s", iter.Key())
+			}
+			if iter.Prev() {
+				t.Fatalf("prev before the slice got %s", iter.Key())
+			}
+			if !iter.Last() || !bytes.Equal(iter.Key(), testKey(898)) {
+				t.Fatalf("last got %s", iter.Key())
+			}
+			if iter.Seek(testKey(900)) {
+				t.Fatalf("seek after the slice got %s", iter.Key())
+			}
+			if !iter.Prev() || !bytes.Equal(iter.Key(), testKey(898)) {
+				t.Fatalf("prev after exhausted got %s", iter.Key())
+			}
+		})
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	for _, name := range testEngines {
+		t.Run(name, func(t *testing.T) {
+			e, dir := openTestEngine(t, name)
+			defer os.RemoveAll(dir)
+			defer e.Close()
+
+			m := make(map[string]string)
+			batch := new(leveldb.Batch)
+			for i := 0; i < 100; i++ {
+				batch.Put(testKey(i), []byte("base"))
+				m[string(testKey(i))] = "base"
+			}
+			e.Write(batch)
+
+			// the overlay keeps the versions of the keys like common/db.MemDB
+			overlay := memdb.New(leveldb.NewIComparer(comparer.DefaultComparer), 0)
+			seq := leveldb.KeyMaxSeq - 100000000
+			r := rand.New(rand.NewSource(2))
+			for i := 0; i < 300; i++ {
+				seq++
+				key := testKey(r.Intn(120))
+				if r.Intn(3) == 0 {
+					overlay.Put(leveldb.MakeInternalKey(nil, key, seq, leveldb.KeyTypeDel), nil)
+					delete(m, string(key))
+				} else {
+					value := fmt.Sprintf("overlay%d", i)
+					overlay.Put(leveldb.MakeInternalKey(nil, key, seq, leveldb.KeyTypeVal), []byte(value))
+					m[string(key)] = value
+				}
+			}
+			// the versions after seq are invisible
+			overlay.Put(leveldb.MakeInternalKey(nil, testKey(500), seq+1, leveldb.KeyTypeVal), []byte("invisible"))
+
+			for _, slice := range []*util.Range{nil, {Start: testKey(10), Limit: testKey(60)}} {
+				want := expected(m, slice)
+				if got := collect(t, NewIteratorWithOverlay(e, slice, overlay, seq)); !equal(got, want) {
+					t.Fatalf("iterate %v with the overlay, got %v, want %v", slice, got, want)
+				}
+				if got := collect(t, newOverlayIterator(e.NewIterator(slice), overlay, slice, seq)); !equal(got, want) {
+					t.Fatalf("iterate %v with the merged overlay, got %v, want %v", slice, got, want)
+				}
+			}
+			for i := 0; i < 120; i++ {
+				value, err := GetWithOverlay(e, testKey(i), overlay, seq)
+				value2, err2 := getWithOverlay(e, testKey(i), overlay, seq)
+				if want, ok := m[string(testKey(i))]; ok {
+					if err != nil || string(value) != want || err2 != nil || string(value2) != want {
+						t.Fatalf("get %d, got %s %s, want %s", i, value, value2, want)
+					}
+				} else if err != ErrNotFound || err2 != ErrNotFound {
+					t.Fatalf("get deleted key %d, got %v %v", i, err, err2)
+				}
+			}
+
+			// switch direction in the middle
+			iter := newOverlayIterator(e.NewIterator(nil), overlay, nil, seq)
+			defer iter.Release()
+			want := expected(m, nil)
+			for i := 0; i < len(want)/2; i++ {
+				iter.Next()
+			}
+			iter.Prev()
+			iter.Next()
+			if got := string(iter.Key()) + "=" + string(iter.Value()); got != want[len(want)/2-1] {
+				t.Fatalf("switch direction got %s, want %s", got, want[len(want)/2-1])
+			}
+		})
+	}
+}
+
+func TestBTreeRecovery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "engine_btree_recovery")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := openBTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		db.Put(testKey(i), testKey(i))
+	}
+	// write a checkpoint, and continue with the rotated log
+	db.writeMu.Lock()
+	db.startCheckpoint(db.current())
+	db.writeMu.Unlock()
+	db.wg.Wait()
+	for i := 100; i < 200; i++ {
+		db.Put(testKey(i), testKey(i))
+	}
+	db.Delete(testKey(0))
+	db.Close()
+
+	if _, err := os.Stat(filepath.Join(dir, walFileName(0))); !os.IsNotExist(err) {
+		t.Fatal("expect the log before the checkpoint to be removed")
+	}
+
+	// a torn record at the tail of the log
+	wal := filepath.Join(dir, walFileName(1))
+	info, err := os.Stat(wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(wal, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = openBTree(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the delete of key 0 is lost with the torn record
+	if db.current().length != 200 {
+		t.Fatalf("expect 200 keys, got %d", db.current().length)
+	}
+	if err := db.Delete(testKey(1)); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if db, err = openBTree(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if has, _ := db.Has(testKey(1)); has || db.current().length != 199 {
+		t.Fatalf("expect the write after the truncation to be kept, %d keys", db.current().length)
+	}
+}
+
+func TestBTreeDelete(t *testing.T) {
+	e := OpenMemory()
+	defer e.Close()
+
+	r := rand.New(rand.NewSource(3))
+	keys := r.Perm(20000)
+	for _, k := range keys {
+		e.Put(testKey(k), nil)
+	}
+	snapshot, _ := e.GetSnapshot()
+	defer snapshot.Release()
+	for i, k := range keys {
+		if i%3 != 0 {
+			e.Delete(testKey(k))
+		}
+	}
+
+	iter := e.NewIterator(nil)
+	defer iter.Release()
+	count, prev := 0, []byte(nil)
+	for iter.Next() {
+		if prev != nil && bytes.Compare(prev, iter.Key()) >= 0 {
+			t.Fatalf("keys out of order %s %s", prev, iter.Key())
+		}
+		prev = append(prev[:0], iter.Key()...)
+		count++
+	}
+	if count != (len(keys)+2)/3 || e.(*btreeDB).current().length != count {
+		t.Fatalf("expect %d keys, got %d", (len(keys)+2)/3, count)
+	}
+	for i, k := range keys {
+		if has, _ := e.Has(testKey(k)); has != (i%3 == 0) {
+			t.Fatalf("key %d existed %v", k, has)
+		}
+		if has, _ := snapshot.Has(testKey(k)); !has {
+			t.Fatalf("key %d is deleted from the snapshot", k)
+		}
+	}
+}
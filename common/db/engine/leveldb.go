@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"encoding/json"
+
+	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/memdb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+)
+
+type levelDB struct {
+	db *leveldb.DB
+}
+
+func openLevelDB(dir string, recover bool) (*levelDB, error) {
+	db, err := leveldb.OpenFile(dir, nil)
+	if recover && IsCorrupted(err) {
+		db, err = leveldb.RecoverFile(dir, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &levelDB{db: db}, nil
+}
+
+// NewLevelDB wraps an opened leveldb
+func NewLevelDB(db *leveldb.DB) Engine {
+	return &levelDB{db: db}
+}
+
+func (l *levelDB) Get(key []byte) ([]byte, error) {
+	return l.db.Get(key, nil)
+}
+
+func (l *levelDB) Has(key []byte) (bool, error) {
+	return l.db.Has(key, nil)
+}
+
+func (l *levelDB) NewIterator(slice *util.Range) Iterator {
+	return l.db.NewIterator(slice, nil)
+}
+
+func (l *levelDB) GetWithOverlay(key []byte, overlay *memdb.DB, seq uint64) ([]byte, error) {
+	return l.db.Get2(key, nil, overlay, seq)
+}
+
+func (l *levelDB) NewIteratorWithOverlay(slice *util.Range, overlay *memdb.DB, seq uint64) Iterator {
+	return l.db.NewIterator2(slice, nil, overlay, seq)
+}
+
+func (l *levelDB) Put(key, value []byte) error {
+	return l.db.Put(key, value, nil)
+}
+
+func (l *levelDB) Delete(key []byte) error {
+	return l.db.Delete(key, nil)
+}
+
+func (l *levelDB) Write(batch *leveldb.Batch) error {
+	return l.db.Write(batch, nil)
+}
+
+func (l *levelDB) GetSnapshot() (Snapshot, error) {
+	snapshot, err := l.db.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &levelDBSnapshot{snapshot: snapshot}, nil
+}
+
+func (l *levelDB) CompactRange(r util.Range) error {
+	return l.db.CompactRange(r)
+}
+
+func (l *levelDB) Stats() string {
+	s := &leveldb.DBStats{}
+	if err := l.db.Stats(s); err != nil {
+		return "Error:" + err.Error()
+	}
+	status, err := json.Marshal(s)
+	if err != nil {
+		return "Error:" + err.Error()
+	}
+	return string(status)
+}
+
+func (l *levelDB) Close() error {
+	return l.db.Close()
+}
+
+type levelDBSnapshot struct {
+	snapshot *leveldb.Snapshot
+}
+
+func (s *levelDBSnapshot) Get(key []byte) ([]byte, error) {
+	return s.snapshot.Get(key, nil)
+}
+
+func (s *levelDBSnapshot) Has(key []byte) (bool, error) {
+	return s.snapshot.Has(key, nil)
+}
+
+func (s *levelDBSnapshot) NewIterator(slice *util.Range) Iterator {
+	return s.snapshot.NewIterator(slice, nil)
+}
+
+func (s *levelDBSnapshot) Release() {
+	s.snapshot.Release()
+}
@@ -0,0 +1,316 @@
+package engine
+
+import (
+	"bytes"
+	"sort"
+
+	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/memdb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+)
+
+func getWithOverlay(e Engine, key []byte, overlay *memdb.DB, seq uint64) ([]byte, error) {
+	if overlay != nil {
+		ikey, value, err := overlay.Find(leveldb.MakeInternalKey(nil, key, seq, leveldb.KeyTypeSeek))
+		if err == nil {
+			ukey, _, kt, err := leveldb.ParseInternalKey(ikey)
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Equal(ukey, key) {
+				if kt == leveldb.KeyTypeDel {
+					return nil, ErrNotFound
+				}
+				return append([]byte{}, value...), nil
+			}
+		} else if err != ErrNotFound {
+			return nil, err
+		}
+	}
+	return e.Get(key)
+}
+
+type overlayEntry struct {
+	key     []byte
+	value   []byte
+	deleted bool
+}
+
+// overlayEntries collects the latest visible version of every key of the overlay in the slice
+func overlayEntries(overlay *memdb.DB, slice *util.Range, seq uint64) ([]*overlayEntry, error) {
+	if overlay == nil {
+		return nil, nil
+	}
+	var islice *util.Range
+	if slice != nil {
+		islice = &util.Range{}
+		if slice.Start != nil {
+			islice.Start = leveldb.MakeInternalKey(nil, slice.Start, leveldb.KeyMaxSeq, leveldb.KeyTypeSeek)
+		}
+		if slice.Limit != nil {
+			islice.Limit = leveldb.MakeInternalKey(nil, slice.Limit, leveldb.KeyMaxSeq, leveldb.KeyTypeSeek)
+		}
+	}
+
+	iter := overlay.NewIterator(islice)
+	defer iter.Release()
+
+	var entries []*overlayEntry
+	for iter.Next() {
+		ukey, kseq, kt, err := leveldb.ParseInternalKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		// the versions of a key are ordered by sequence descending
+		if kseq > seq || (len(entries) > 0 && bytes.Equal(entries[len(entries)-1].key, ukey)) {
+			continue
+		}
+		entries = append(entries, &overlayEntry{
+			key:     append([]byte{}, ukey...),
+			value:   append([]byte{}, iter.Value()...),
+			deleted: kt == leveldb.KeyTypeDel,
+		})
+	}
+	return entries, iter.Error()
+}
+
+// entriesIterator iterates the overlay entries collected for an iterator
+type entriesIterator struct {
+	entries []*overlayEntry
+	index   int // -1 before the first entry, len(entries) after the last one
+}
+
+func (i *entriesIterator) valid() bool { return i.index >= 0 && i.index < len(i.entries) }
+func (i *entriesIterator) first() bool { i.index = 0; return i.valid() }
+func (i *entriesIterator) last() bool  { i.index = len(i.entries) - 1; return i.valid() }
+func (i *entriesIterator) next() bool {
+	if i.index < len(i.entries) {
+		i.index++
+	}
+	return i.valid()
+}
+func (i *entriesIterator) prev() bool {
+	if i.index >= 0 {
+		i.index--
+	}
+	return i.valid()
+}
+func (i *entriesIterator) seek(key []byte) bool {
+	i.index = sort.Search(len(i.entries), func(n int) bool {
+		return bytes.Compare(i.entries[n].key, key) >= 0
+	})
+	return i.valid()
+}
+func (i *entriesIterator) entry() *overlayEntry { return i.entries[i.index] }
+
+const (
+	dirForward = iota
+	dirBackward
+)
+
+// overlayIterator merges the iterator of an engine with the overlay entries, the overlay shadows the
+// engine and its deleted entries hide the keys of the engine
+type overlayIterator struct {
+	base    Iterator
+	overlay *entriesIterator
+
+	dir       int
+	fromBase  bool // the current key is from the base iterator
+	valid     bool
+	err       error
+	released  bool
+	curKey    []byte
+	curValue  []byte
+	exhausted int // the direction the iterator is exhausted to, -1 if not exhausted
+}
+
+func newOverlayIterator(base Iterator, overlay *memdb.DB, slice *util.Range, seq uint64) Iterator {
+	entries, err := overlayEntries(overlay, slice, seq)
+	if err != nil {
+		base.Release()
+		return &overlayIterator{base: emptyIterator{}, overlay: &entriesIterator{index: -1}, err: err, exhausted: -1}
+	}
+	return &overlayIterator{base: base, overlay: &entriesIterator{entries: entries, index: -1}, exhausted: -1}
+}
+
+func (i *overlayIterator) settle(dir int) bool {
+	i.dir = dir
+	for {
+		baseValid, overValid := i.base.Valid(), i.overlay.valid()
+		if !baseValid && !overValid {
+			i.valid, i.curKey, i.curValue = false, nil, nil
+			i.exhausted = dir
+			return false
+		}
+		var cmp int
+		switch {
+		case !baseValid:
+			cmp = 1
+		case !overValid:
+			cmp = -1
+		default:
+			cmp = bytes.Compare(i.base.Key(), i.overlay.entry().key)
+			if dir == dirBackward {
+				cmp = -cmp
+			}
+		}
+		// cmp < 0 means the base key comes first in the direction
+		if cmp < 0 {
+			i.fromBase, i.valid = true, true
+			i.curKey, i.curValue = i.base.Key(), i.base.Value()
+			i.exhausted = -1
+			return true
+		}
+		entry := i.overlay.entry()
+		if entry.deleted {
+			if cmp == 0 {
+				i.move(i.base, dir)
+			}
+			i.moveOverlay(dir)
+			continue
+		}
+		i.fromBase, i.valid = false, true
+		i.curKey, i.curValue = entry.key, entry.value
+		i.exhausted = -1
+		return true
+	}
+}
+
+func (i *overlayIterator) move(iter Iterator, dir int) {
+	if dir == dirForward {
+		iter.Next()
+	} else {
+		iter.Prev()
+	}
+}
+
+func (i *overlayIterator) moveOverlay(dir int) {
+	if dir == dirForward {
+		i.overlay.next()
+	} else {
+		i.overlay.prev()
+	}
+}
+
+// step moves past the current key in the direction, the iterators are positioned at the current key
+func (i *overlayIterator) step(dir int) bool {
+	key := i.curKey
+	if i.fromBase {
+		i.move(i.base, dir)
+		return i.settle(dir)
+	}
+	if i.base.Valid() && bytes.Equal(i.base.Key(), key) {
+		i.move(i.base, dir)
+	}
+	i.moveOverlay(dir)
+	return i.settle(dir)
+}
+
+func (i *overlayIterator) First() bool {
+	if i.err != nil || i.released {
+		return false
+	}
+	i.base.First()
+	i.overlay.first()
+	return i.settle(dirForward)
+}
+
+func (i *overlayIterator) Last() bool {
+	if i.err != nil || i.released {
+		return false
+	}
+	i.base.Last()
+	i.overlay.last()
+	return i.settle(dirBackward)
+}
+
+func (i *overlayIterator) Seek(key []byte) bool {
+	if i.err != nil || i.released {
+		return false
+	}
+	i.base.Seek(key)
+	i.overlay.seek(key)
+	return i.settle(dirForward)
+}
+
+func (i *overlayIterator) Next() bool {
+	if i.err != nil || i.released {
+		return false
+	}
+	if !i.valid {
+		if i.exhausted == dirForward {
+			return false
+		}
+		return i.First()
+	}
+	if i.dir == dirBackward {
+		// reposition both iterators at the current key for the forward direction
+		key := append([]byte{}, i.curKey...)
+		i.base.Seek(key)
+		i.overlay.seek(key)
+		if !i.settle(dirForward) {
+			return false
+		}
+	}
+	return i.step(dirForward)
+}
+
+func (i *overlayIterator) Prev() bool {
+	if i.err != nil || i.released {
+		return false
+	}
+	if !i.valid {
+		if i.exhausted == dirBackward {
+			return false
+		}
+		return i.Last()
+	}
+	if i.dir == dirForward {
+		// reposition both iterators before the current key
+		key := append([]byte{}, i.curKey...)
+		if i.base.Seek(key) {
+			i.base.Prev()
+		} else {
+			i.base.Last()
+		}
+		if i.overlay.seek(key) {
+			i.overlay.prev()
+		} else {
+			i.overlay.last()
+		}
+		return i.settle(dirBackward)
+	}
+	return i.step(dirBackward)
+}
+
+func (i *overlayIterator) Valid() bool   { return i.valid }
+func (i *overlayIterator) Key() []byte   { return i.curKey }
+func (i *overlayIterator) Value() []byte { return i.curValue }
+
+func (i *overlayIterator) Error() error {
+	if i.err != nil {
+		return i.err
+	}
+	return i.base.Error()
+}
+
+func (i *overlayIterator) Release() {
+	if !i.released {
+		i.released = true
+		i.valid, i.curKey, i.curValue = false, nil, nil
+		i.base.Release()
+	}
+}
+
+type emptyIterator struct{}
+
+func (emptyIterator) First() bool      { return false }
+func (emptyIterator) Last() bool       { return false }
+func (emptyIterator) Seek([]byte) bool { return false }
+func (emptyIterator) Next() bool       { return false }
+func (emptyIterator) Prev() bool       { return false }
+func (emptyIterator) Valid() bool      { return false }
+func (emptyIterator) Key() []byte      { return nil }
+func (emptyIterator) Value() []byte    { return nil }
+func (emptyIterator) Error() error     { return nil }
+func (emptyIterator) Release()         {}
@@ -32,15 +32,21 @@ const (
 )
 
 var (
-	AddressQuota, _      = BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, ContractAddrByte})
-	AddressGovernance, _ = BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4, ContractAddrByte})
-	AddressAsset, _      = BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 5, ContractAddrByte})
-	AddressDexFund, _    = BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 6, ContractAddrByte})
-	AddressDexTrade, _   = BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 7, ContractAddrByte})
+	AddressQuota, _         = BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, ContractAddrByte})
+	AddressGovernance, _    = BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4, ContractAddrByte})
+	AddressAsset, _         = BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 5, ContractAddrByte})
+	AddressDexFund, _       = BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 6, ContractAddrByte})
+	AddressDexTrade, _      = BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 7, ContractAddrByte})
+	AddressVesting, _       = BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 8, ContractAddrByte})
+	AddressAccountPolicy, _ = BytesToAddress([]byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 9, ContractAddrByte})
 
 	BuiltinContracts                = []Address{AddressQuota, AddressGovernance, AddressAsset, AddressDexFund, AddressDexTrade}
 	BuiltinContractsWithoutQuota    = []Address{AddressQuota, AddressGovernance, AddressAsset, AddressDexTrade}
 	BuiltinContractsWithSendConfirm = []Address{AddressQuota, AddressGovernance, AddressAsset}
+
+	// VersionXBuiltinContracts are activated by the VersionX upgrade, they receive without quota and with send
+	// confirm. Before the upgrade a send to them is a user transfer, see core.IsBuiltinContractAddrInUse.
+	VersionXBuiltinContracts = []Address{AddressVesting, AddressAccountPolicy}
 )
 
 func IsContractAddr(addr Address) bool {
@@ -54,27 +60,11 @@ func IsBuiltinContractAddr(addr Address) bool {
 	}
 	return false
 }
-func IsBuiltinContractAddrInUse(addr Address) bool {
-	for _, cAddr := range BuiltinContracts {
-		if cAddr == addr {
-			return true
-		}
-	}
-	return false
-}
-
-func IsBuiltinContractAddrInUseWithoutQuota(addr Address) bool {
-	for _, cAddr := range BuiltinContractsWithoutQuota {
-		if cAddr == addr {
-			return true
-		}
-	}
-	return false
-}
 
-func IsBuiltinContractAddrInUseWithSendConfirm(addr Address) bool {
-	for _, cAddr := range BuiltinContractsWithSendConfirm {
-		if cAddr == addr {
+// IsAddrInList returns whether the address is in the list, e.g. BuiltinContracts
+func IsAddrInList(addr Address, list []Address) bool {
+	for _, a := range list {
+		if a == addr {
 			return true
 		}
 	}
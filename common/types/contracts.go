@@ -70,3 +70,42 @@ type StakeInfo struct {
 	StakeAddress     Address  `json:"pledgeAddr"`
 	Id               *Hash    `json:"id"`
 }
+
+// AccountPolicy is the spending policy registered by an account in the account policy contract
+type AccountPolicy struct {
+	TokenIds        []TokenTypeId
+	DailyLimits     []*big.Int
+	Allowlist       []Address
+	RecoveryAddress Address
+	EffectiveHeight uint64
+	RecoveryHeight  uint64 // snapshot height the recovery takes effect, 0 if not initiated
+}
+
+// DailyLimit returns the daily spending limit of the token, nil if not limited
+func (p *AccountPolicy) DailyLimit(tokenId TokenTypeId) *big.Int {
+	for i, id := range p.TokenIds {
+		if id == tokenId {
+			return p.DailyLimits[i]
+		}
+	}
+	return nil
+}
+
+// IsAllowed checks whether the account is allowed to send to the address, the recovery
+// address is always allowed
+func (p *AccountPolicy) IsAllowed(addr Address) bool {
+	if len(p.Allowlist) == 0 || (!p.RecoveryAddress.IsZero() && addr == p.RecoveryAddress) {
+		return true
+	}
+	for _, allowed := range p.Allowlist {
+		if allowed == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRecovered checks whether the account is taken over by the recovery address
+func (p *AccountPolicy) IsRecovered(sbHeight uint64) bool {
+	return p.RecoveryHeight > 0 && sbHeight >= p.RecoveryHeight
+}
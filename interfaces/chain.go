@@ -89,6 +89,43 @@ func (list SegmentList) Less(i, j int) bool {
 	return list[i].From < list[j].To
 }
 
+// StateSnapshotMeta describes a state snapshot taken at a snapshot block
+type StateSnapshotMeta struct {
+	Height uint64
+	Hash   types.Hash // hash of the snapshot block at Height
+	Root   types.Hash // commitment of all records in the snapshot
+	Size   int64
+	Items  uint64
+}
+
+func (meta StateSnapshotMeta) String() string {
+	return strconv.FormatUint(meta.Height, 10) + " " + meta.Hash.String() + " " + meta.Root.String()
+}
+
+// CheckpointManifest describes a checkpoint of the ledger created at a snapshot block,
+// the directory of the checkpoint can be used as the DataDir of a node
+type CheckpointManifest struct {
+	Height    uint64
+	Hash      types.Hash // hash of the snapshot block at Height
+	Timestamp int64      // unix time when the checkpoint is created
+	Files     []CheckpointFile
+	Stores    []CheckpointStore
+}
+
+// CheckpointFile is a block file in a checkpoint
+type CheckpointFile struct {
+	Name   string
+	Size   int64
+	Linked bool // hard-linked to the block file of the node instead of copied
+}
+
+// CheckpointStore is a database copied to a checkpoint
+type CheckpointStore struct {
+	Name   string
+	Engine string
+	Items  uint64
+}
+
 type ChunkReader interface {
 	// Read a block, return io.EOF if reach end, the block maybe a accountBlock or a snapshotBlock
 	Read() (accountBlock *core.AccountBlock, snapshotBlock *core.SnapshotBlock, err error)
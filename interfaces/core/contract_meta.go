@@ -1,6 +1,9 @@
 package core
 
-import "github.com/vitelabs/go-vite/v2/common/types"
+import (
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/common/upgrade"
+)
 
 type ContractMeta struct {
 	Gid types.Gid // belong to the consensus group id
@@ -55,14 +58,44 @@ func (cm *ContractMeta) Deserialize(buf []byte) error {
 	return nil
 }
 
-func GetBuiltinContractMeta(addr types.Address) *ContractMeta {
-	if types.IsBuiltinContractAddrInUseWithSendConfirm(addr) {
+// GetBuiltinContractMeta returns the meta of the built-in contract at the snapshot height, nil if the address
+// is not a built-in contract at the height
+func GetBuiltinContractMeta(addr types.Address, sbHeight uint64) *ContractMeta {
+	if IsBuiltinContractAddrInUseWithSendConfirm(addr, sbHeight) {
 		return &ContractMeta{types.DELEGATE_GID, 1, types.Hash{}, getBuiltinContractQuotaRatio(addr), 0}
-	} else if types.IsBuiltinContractAddrInUse(addr) {
+	} else if IsBuiltinContractAddrInUse(addr, sbHeight) {
 		return &ContractMeta{types.DELEGATE_GID, 0, types.Hash{}, getBuiltinContractQuotaRatio(addr), 0}
 	}
 	return nil
 }
+
+// IsBuiltinContractAddrInUse returns whether the address is a built-in contract at the snapshot height,
+// the addresses of types.VersionXBuiltinContracts are user transfer targets before the VersionX upgrade
+func IsBuiltinContractAddrInUse(addr types.Address, sbHeight uint64) bool {
+	return types.IsAddrInList(addr, types.BuiltinContracts) || isVersionXBuiltinContract(addr, sbHeight)
+}
+
+func IsBuiltinContractAddrInUseWithoutQuota(addr types.Address, sbHeight uint64) bool {
+	return types.IsAddrInList(addr, types.BuiltinContractsWithoutQuota) || isVersionXBuiltinContract(addr, sbHeight)
+}
+
+func IsBuiltinContractAddrInUseWithSendConfirm(addr types.Address, sbHeight uint64) bool {
+	return types.IsAddrInList(addr, types.BuiltinContractsWithSendConfirm) || isVersionXBuiltinContract(addr, sbHeight)
+}
+
+// GetBuiltinContractList returns the built-in contracts at the snapshot height
+func GetBuiltinContractList(sbHeight uint64) []types.Address {
+	list := append([]types.Address{}, types.BuiltinContracts...)
+	if upgrade.IsVersionXUpgrade(sbHeight) {
+		list = append(list, types.VersionXBuiltinContracts...)
+	}
+	return list
+}
+
+func isVersionXBuiltinContract(addr types.Address, sbHeight uint64) bool {
+	return types.IsAddrInList(addr, types.VersionXBuiltinContracts) && upgrade.IsVersionXUpgrade(sbHeight)
+}
+
 func getBuiltinContractQuotaRatio(addr types.Address) uint8 {
 	// TODO use special quota ratio for dex contracts
 	return 10
@@ -94,6 +94,10 @@ type VmDb interface {
 	// ====== built-in contract ======
 	GetStakeBeneficialAmount(addr *types.Address) (*big.Int, error)
 
+	GetGovernanceParam(id uint8, sbHeight uint64) (*big.Int, error)
+
+	GetAccountPolicy(addr *types.Address, sbHeight uint64) (*types.AccountPolicy, error)
+
 	// ====== debug ======
 	DebugGetStorage() (map[string][]byte, error)
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/vitelabs/go-vite/v2/common/types"
 	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_block "github.com/vitelabs/go-vite/v2/ledger/chain/block"
 	chain_file_manager "github.com/vitelabs/go-vite/v2/ledger/chain/file_manager"
 )
 
@@ -103,6 +104,9 @@ func (c *chain) GetCompleteBlockByHash(blockHash types.Hash) (*ledger.AccountBlo
 	block, err := c.blockDB.GetAccountBlock(location)
 
 	if err != nil {
+		if err == chain_block.ErrPruned {
+			return nil, err
+		}
 		cErr := fmt.Errorf("c.blockDB.GetAccountBlock failed, hash is %s, location is %+v. Error: %s",
 			blockHash, location, err.Error())
 		c.log.Error(cErr.Error(), "method", "GetCompleteBlockByHash")
@@ -355,6 +359,9 @@ func (c *chain) getAccountBlocks(addr types.Address, locations []*chain_file_man
 			var err error
 			block, err = c.blockDB.GetAccountBlock(locations[index])
 			if err != nil {
+				if err == chain_block.ErrPruned {
+					return nil, err
+				}
 				cErr := fmt.Errorf("c.blockDB.GetAccountBlock failed, locations is %+v. Error: %s",
 					locations[index], err.Error())
 				c.log.Error(cErr.Error(), "method", "getAccountBlocks")
@@ -9,6 +9,7 @@ import (
 
 	"github.com/golang/snappy"
 	"github.com/pkg/errors"
+	"github.com/syndtr/goleveldb/leveldb"
 
 	"github.com/vitelabs/go-vite/v2/common/types"
 	"github.com/vitelabs/go-vite/v2/crypto"
@@ -36,6 +37,13 @@ type BlockDB struct {
 	flushTargetLocation *chain_file_manager.Location
 	flushBuf            *BufWriter
 
+	chainDir       string
+	prunedDB       *leveldb.DB
+	prunedLocation *chain_file_manager.Location
+	pruneMu        sync.RWMutex
+	pruningMu      sync.Mutex
+	pruneStopped   int32
+
 	log log15.Logger
 }
 
@@ -53,11 +61,19 @@ func NewBlockDBFixedSize(chainDir string, fileSize int64) (*BlockDB, error) {
 		return nil, err
 	}
 
+	prunedDB, prunedLocation, err := openPrunedDB(chainDir, false)
+	if err != nil {
+		return nil, err
+	}
+
 	return &BlockDB{
 		fm:                fm,
 		fileSize:          fileSize,
 		snappyWriteBuffer: make([]byte, fileSize),
 		id:                id,
+		chainDir:          chainDir,
+		prunedDB:          prunedDB,
+		prunedLocation:    prunedLocation,
 		log:               log15.New("module", "blockDB"),
 	}, nil
 }
@@ -73,6 +89,10 @@ func (bDB *BlockDB) Close() error {
 		return fmt.Errorf("bDB.fm.Close failed, error is %s", err)
 	}
 
+	if err := bDB.closePrunedDB(); err != nil {
+		return fmt.Errorf("bDB.closePrunedDB failed, error is %s", err)
+	}
+
 	bDB.fm = nil
 	return nil
 }
@@ -110,6 +130,10 @@ func (bDB *BlockDB) Write(ss *ledger.SnapshotChunk) (map[types.Hash]*chain_file_
 }
 
 func (bDB *BlockDB) Read(location *chain_file_manager.Location) ([]byte, error) {
+	if bDB.IsPruned(location) {
+		return bDB.readRetained(location, retainedAccountBlock, retainedSnapshotBlock)
+	}
+
 	buf, _, err := bDB.fm.Read(location)
 	if err != nil {
 		return nil, err
@@ -126,10 +150,16 @@ func (bDB *BlockDB) Read(location *chain_file_manager.Location) ([]byte, error)
 }
 
 func (bDB *BlockDB) ReadRaw(startLocation *chain_file_manager.Location, buf []byte) (*chain_file_manager.Location, int, error) {
+	if bDB.IsPruned(startLocation) {
+		return startLocation, 0, ErrPruned
+	}
 	return bDB.fm.ReadRaw(startLocation, buf)
 }
 
 func (bDB *BlockDB) ReadUnitBytes(location *chain_file_manager.Location) ([]byte, *chain_file_manager.Location, error) {
+	if bDB.IsPruned(location) {
+		return nil, nil, ErrPruned
+	}
 	buf, nextLocation, err := bDB.fm.Read(location)
 	if err != nil {
 		return nil, nil, err
@@ -145,6 +175,9 @@ func (bDB *BlockDB) ReadUnitBytes(location *chain_file_manager.Location) ([]byte
 }
 
 func (bDB *BlockDB) ReadUnit(location *chain_file_manager.Location) (*ledger.SnapshotBlock, *ledger.AccountBlock, *chain_file_manager.Location, error) {
+	if bDB.IsPruned(location) {
+		return nil, nil, nil, ErrPruned
+	}
 	buf, nextLocation, err := bDB.fm.Read(location)
 	if err != nil {
 		return nil, nil, nil, err
@@ -200,6 +233,10 @@ func (bDB *BlockDB) ReadChunk(location chain_file_manager.Location) (*ledger.Sna
 }
 
 func (bDB *BlockDB) ReadRange(startLocation *chain_file_manager.Location, endLocation *chain_file_manager.Location) ([]*ledger.SnapshotChunk, error) {
+	if bDB.IsPruned(startLocation) {
+		return nil, ErrPruned
+	}
+
 	bfp := newBlockFileParser()
 
 	endLocation = bDB.maxLocation(endLocation)
@@ -0,0 +1,98 @@
+package chain_block
+
+import (
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	chain_file_manager "github.com/vitelabs/go-vite/v2/ledger/chain/file_manager"
+)
+
+// LockPrune waits for the running pruning and blocks pruning until UnlockPrune,
+// so the block files and the pruned database are not changed by pruning in the meantime
+func (bDB *BlockDB) LockPrune() {
+	bDB.pruningMu.Lock()
+}
+
+func (bDB *BlockDB) UnlockPrune() {
+	bDB.pruningMu.Unlock()
+}
+
+// FlushedLocation returns the location after the blocks flushed to the files, assume locking writing
+func (bDB *BlockDB) FlushedLocation() *chain_file_manager.Location {
+	return bDB.fm.NextFlushStartLocation()
+}
+
+// LinkFiles writes the blocks before location to dir, the files before linkBefore are hard-linked and the
+// others are copied. Returns the ids of the files before linkBefore which can't be linked, they should be
+// copied by CopyFiles later. Assume locking writing and pruning.
+func (bDB *BlockDB) LinkFiles(dir string, location *chain_file_manager.Location,
+	linkBefore *chain_file_manager.Location) ([]interfaces.CheckpointFile, []uint64, error) {
+	fileIds, err := bDB.fm.FileIds()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var files []interfaces.CheckpointFile
+	var unlinked []uint64
+	for _, fileId := range fileIds {
+		if fileId > location.FileId {
+			break
+		}
+
+		if fileId < linkBefore.FileId && fileId < location.FileId {
+			if !bDB.fm.LinkFile(fileId, dir) {
+				unlinked = append(unlinked, fileId)
+				continue
+			}
+			files = append(files, interfaces.CheckpointFile{
+				Name:   bDB.fm.FileName(fileId),
+				Size:   bDB.fileSize,
+				Linked: true,
+			})
+			continue
+		}
+
+		// the last file is being appended
+		size := int64(-1)
+		if fileId == location.FileId {
+			size = location.Offset
+		}
+		n, err := bDB.fm.CopyFile(fileId, dir, size)
+		if err != nil {
+			return nil, nil, err
+		}
+		files = append(files, interfaces.CheckpointFile{
+			Name: bDB.fm.FileName(fileId),
+			Size: n,
+		})
+	}
+	return files, unlinked, nil
+}
+
+// CopyFiles copies the whole files to dir, assume locking pruning
+func (bDB *BlockDB) CopyFiles(dir string, fileIds []uint64) ([]interfaces.CheckpointFile, error) {
+	files := make([]interfaces.CheckpointFile, 0, len(fileIds))
+	for _, fileId := range fileIds {
+		n, err := bDB.fm.CopyFile(fileId, dir, -1)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, interfaces.CheckpointFile{
+			Name: bDB.fm.FileName(fileId),
+			Size: n,
+		})
+	}
+	return files, nil
+}
+
+// GetPrunedSnapshot returns the snapshot of the pruned database, nil if the blocks have never been pruned.
+// Assume locking pruning.
+func (bDB *BlockDB) GetPrunedSnapshot() (*leveldb.Snapshot, error) {
+	bDB.pruneMu.RLock()
+	defer bDB.pruneMu.RUnlock()
+
+	if bDB.prunedDB == nil {
+		return nil, nil
+	}
+	return bDB.prunedDB.GetSnapshot()
+}
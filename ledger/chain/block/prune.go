@@ -0,0 +1,269 @@
+package chain_block
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync/atomic"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_file_manager "github.com/vitelabs/go-vite/v2/ledger/chain/file_manager"
+	chain_utils "github.com/vitelabs/go-vite/v2/ledger/chain/utils"
+)
+
+// ErrPruned is returned when reading a block whose file has been pruned
+var ErrPruned = errors.New("the block has been pruned")
+
+var ErrPruneStopped = errors.New("pruning is stopped")
+
+const (
+	// PrunedDirName is the directory of the pruned database in chainDir
+	PrunedDirName = "blocks_pruned"
+
+	prunedLocationKey = byte(0)
+	retainedBlockKey  = byte(1)
+
+	retainedAccountBlock   = byte(1)
+	retainedSnapshotHeader = byte(2)
+	retainedSnapshotBlock  = byte(3)
+
+	pruneBatchSize = 10000
+)
+
+// RetainFunc decides whether the body of an account block in pruned files should be retained
+type RetainFunc func(block *ledger.AccountBlock) bool
+
+func openPrunedDB(chainDir string, create bool) (*leveldb.DB, *chain_file_manager.Location, error) {
+	dirName := path.Join(chainDir, PrunedDirName)
+	if !create {
+		if _, err := os.Stat(dirName); os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+	}
+
+	db, err := leveldb.OpenFile(dirName, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value, err := db.Get([]byte{prunedLocationKey}, nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return db, nil, nil
+		}
+		db.Close()
+		return nil, nil, err
+	}
+
+	return db, chain_utils.DeserializeLocation(value), nil
+}
+
+func createRetainedKey(location *chain_file_manager.Location) []byte {
+	return append([]byte{retainedBlockKey}, chain_utils.SerializeLocation(location)...)
+}
+
+// PrunedLocation returns the location before which all blocks are pruned, nil means never pruned
+func (bDB *BlockDB) PrunedLocation() *chain_file_manager.Location {
+	bDB.pruneMu.RLock()
+	defer bDB.pruneMu.RUnlock()
+
+	if bDB.prunedLocation == nil {
+		return nil
+	}
+	return chain_file_manager.NewLocation(bDB.prunedLocation.FileId, bDB.prunedLocation.Offset)
+}
+
+// IsPruned returns true if the block at location has been pruned
+func (bDB *BlockDB) IsPruned(location *chain_file_manager.Location) bool {
+	bDB.pruneMu.RLock()
+	defer bDB.pruneMu.RUnlock()
+
+	return bDB.prunedLocation != nil && location.Compare(bDB.prunedLocation) < 0
+}
+
+// Prune delete the block files before fileId. The headers of snapshot blocks and the bodies of
+// account blocks chosen by retain are moved into a separate database before deleting.
+func (bDB *BlockDB) Prune(fileId uint64, retain RetainFunc) error {
+	bDB.pruningMu.Lock()
+	defer bDB.pruningMu.Unlock()
+
+	if atomic.LoadInt32(&bDB.pruneStopped) == 1 {
+		return ErrPruneStopped
+	}
+
+	bDB.pruneMu.Lock()
+	if bDB.prunedDB == nil {
+		var err error
+		if bDB.prunedDB, _, err = openPrunedDB(bDB.chainDir, true); err != nil {
+			bDB.pruneMu.Unlock()
+			return err
+		}
+	}
+	bDB.pruneMu.Unlock()
+
+	current := chain_file_manager.NewLocation(1, 0)
+	if prunedLocation := bDB.PrunedLocation(); prunedLocation != nil {
+		current = prunedLocation
+	}
+
+	latestLocation := bDB.fm.LatestLocation()
+	if fileId > latestLocation.FileId {
+		fileId = latestLocation.FileId
+	}
+
+	if current.FileId >= fileId {
+		return nil
+	}
+
+	batch := new(leveldb.Batch)
+	for current.FileId < fileId {
+		sb, ab, next, err := bDB.ReadUnit(current)
+		if err != nil {
+			return fmt.Errorf("bDB.ReadUnit failed, location is %s. Error: %s", current, err)
+		}
+		if next == nil {
+			return fmt.Errorf("next location is nil, location is %s", current)
+		}
+
+		if sb != nil {
+			// the genesis snapshot block is always checked at starting
+			retainedType := retainedSnapshotBlock
+			if sb.Height != types.GenesisHeight {
+				sb.SnapshotContent = nil
+				retainedType = retainedSnapshotHeader
+			}
+
+			buf, err := sb.Serialize()
+			if err != nil {
+				return err
+			}
+			batch.Put(createRetainedKey(current), append([]byte{retainedType}, buf...))
+		} else if ab != nil && retain(ab) {
+			buf, err := ab.Serialize()
+			if err != nil {
+				return err
+			}
+			batch.Put(createRetainedKey(current), append([]byte{retainedAccountBlock}, buf...))
+		}
+
+		current = next
+
+		if batch.Len() >= pruneBatchSize {
+			if err := bDB.writePruned(batch, current); err != nil {
+				return err
+			}
+			batch.Reset()
+
+			if atomic.LoadInt32(&bDB.pruneStopped) == 1 {
+				return ErrPruneStopped
+			}
+		}
+	}
+
+	if err := bDB.writePruned(batch, current); err != nil {
+		return err
+	}
+
+	return bDB.fm.DeleteFilesBefore(current.FileId)
+}
+
+// StopPrune stops the running and later pruning, it is called before closing
+func (bDB *BlockDB) StopPrune() {
+	atomic.StoreInt32(&bDB.pruneStopped, 1)
+}
+
+// writePruned writes the retained blocks before location, then marks the blocks before location pruned
+func (bDB *BlockDB) writePruned(batch *leveldb.Batch, location *chain_file_manager.Location) error {
+	batch.Put([]byte{prunedLocationKey}, chain_utils.SerializeLocation(location))
+	if err := bDB.prunedDB.Write(batch, &opt.WriteOptions{Sync: true}); err != nil {
+		return err
+	}
+
+	bDB.pruneMu.Lock()
+	bDB.prunedLocation = location
+	bDB.pruneMu.Unlock()
+	return nil
+}
+
+// CleanRetained delete the retained account blocks which need not be retained any more
+func (bDB *BlockDB) CleanRetained(retain RetainFunc) error {
+	bDB.pruningMu.Lock()
+	defer bDB.pruningMu.Unlock()
+
+	if bDB.prunedDB == nil || atomic.LoadInt32(&bDB.pruneStopped) == 1 {
+		return nil
+	}
+
+	iter := bDB.prunedDB.NewIterator(util.BytesPrefix([]byte{retainedBlockKey}), nil)
+	defer iter.Release()
+
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		value := iter.Value()
+		if len(value) <= 0 || value[0] != retainedAccountBlock {
+			continue
+		}
+
+		ab := &ledger.AccountBlock{}
+		if err := ab.Deserialize(value[1:]); err != nil {
+			return err
+		}
+		if retain(ab) {
+			continue
+		}
+
+		batch.Delete(append([]byte{}, iter.Key()...))
+		if batch.Len() >= pruneBatchSize {
+			if err := bDB.prunedDB.Write(batch, nil); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	return bDB.prunedDB.Write(batch, nil)
+}
+
+// readRetained reads the retained data at location whose type is one of retainedTypes
+func (bDB *BlockDB) readRetained(location *chain_file_manager.Location, retainedTypes ...byte) ([]byte, error) {
+	bDB.pruneMu.RLock()
+	defer bDB.pruneMu.RUnlock()
+
+	value, err := bDB.prunedDB.Get(createRetainedKey(location), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, ErrPruned
+		}
+		return nil, err
+	}
+
+	if len(value) > 0 {
+		for _, retainedType := range retainedTypes {
+			if value[0] == retainedType {
+				return value[1:], nil
+			}
+		}
+	}
+	return nil, ErrPruned
+}
+
+func (bDB *BlockDB) closePrunedDB() error {
+	bDB.pruneMu.Lock()
+	defer bDB.pruneMu.Unlock()
+
+	if bDB.prunedDB == nil {
+		return nil
+	}
+	err := bDB.prunedDB.Close()
+	bDB.prunedDB = nil
+	return err
+}
@@ -26,6 +26,10 @@ func (bDB *BlockDB) GetSnapshotBlock(location *chain_file_manager.Location) (*le
 
 // TODO optimize
 func (bDB *BlockDB) GetSnapshotHeader(location *chain_file_manager.Location) (*ledger.SnapshotBlock, error) {
+	if bDB.IsPruned(location) {
+		return bDB.getRetainedSnapshotHeader(location)
+	}
+
 	sb, err := bDB.GetSnapshotBlock(location)
 	if err != nil {
 		return nil, err
@@ -36,3 +40,18 @@ func (bDB *BlockDB) GetSnapshotHeader(location *chain_file_manager.Location) (*l
 	sb.SnapshotContent = nil
 	return sb, nil
 }
+
+func (bDB *BlockDB) getRetainedSnapshotHeader(location *chain_file_manager.Location) (*ledger.SnapshotBlock, error) {
+	buf, err := bDB.readRetained(location, retainedSnapshotHeader, retainedSnapshotBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := &ledger.SnapshotBlock{}
+	if err := sb.Deserialize(buf); err != nil {
+		return nil, fmt.Errorf("sb.Deserialize failed, Error: %s", err.Error())
+	}
+	sb.SnapshotContent = nil
+
+	return sb, nil
+}
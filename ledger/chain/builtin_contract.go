@@ -93,6 +93,54 @@ func (c *chain) GetStakeBeneficialAmount(addr types.Address) (*big.Int, error) {
 	return abi.GetStakeBeneficialAmount(sd, addr)
 }
 
+func (c *chain) GetGovernanceParam(id uint8, sbHeight uint64) (*big.Int, error) {
+	latestSb := c.GetLatestSnapshotBlock()
+	snapshotHash := latestSb.Hash
+	if sbHeight < latestSb.Height {
+		hash, err := c.GetSnapshotHashByHeight(sbHeight)
+		if err != nil {
+			cErr := fmt.Errorf("c.GetSnapshotHashByHeight failed, height is %d. Error: %s", sbHeight, err)
+			c.log.Error(cErr.Error(), "method", "GetGovernanceParam")
+			return nil, cErr
+		}
+		if hash != nil {
+			snapshotHash = *hash
+		}
+	}
+	sd, err := c.stateDB.NewStorageDatabase(snapshotHash, types.AddressGovernance)
+	if err != nil {
+		cErr := fmt.Errorf("c.stateDB.NewStorageDatabase failed")
+		c.log.Error(cErr.Error(), "method", "GetGovernanceParam")
+		return nil, cErr
+	}
+
+	return abi.GetGovernanceParam(sd, id, sbHeight)
+}
+
+func (c *chain) GetAccountPolicy(addr types.Address, sbHeight uint64) (*types.AccountPolicy, error) {
+	latestSb := c.GetLatestSnapshotBlock()
+	snapshotHash := latestSb.Hash
+	if sbHeight < latestSb.Height {
+		hash, err := c.GetSnapshotHashByHeight(sbHeight)
+		if err != nil {
+			cErr := fmt.Errorf("c.GetSnapshotHashByHeight failed, height is %d. Error: %s", sbHeight, err)
+			c.log.Error(cErr.Error(), "method", "GetAccountPolicy")
+			return nil, cErr
+		}
+		if hash != nil {
+			snapshotHash = *hash
+		}
+	}
+	sd, err := c.stateDB.NewStorageDatabase(snapshotHash, types.AddressAccountPolicy)
+	if err != nil {
+		cErr := fmt.Errorf("c.stateDB.NewStorageDatabase failed")
+		c.log.Error(cErr.Error(), "method", "GetAccountPolicy")
+		return nil, cErr
+	}
+
+	return abi.GetAccountPolicy(sd, addr, sbHeight)
+}
+
 // total
 func (c *chain) GetStakeQuota(addr types.Address) (*big.Int, *types.Quota, error) {
 
@@ -2,16 +2,20 @@ package chain
 
 import (
 	"bytes"
+	rand2 "crypto/rand"
 	"fmt"
+	"math/big"
 	"math/rand"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/crypto/ed25519"
 	"github.com/vitelabs/go-vite/v2/interfaces"
 	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
 	chain_utils "github.com/vitelabs/go-vite/v2/ledger/chain/utils"
+	"github.com/vitelabs/go-vite/v2/vm/contracts/abi"
 )
 
 func TestChain_builtInContract(t *testing.T) {
@@ -97,6 +101,69 @@ func testBuiltInContractNoTesting(chainInstance *chain, accounts map[types.Addre
 
 }
 
+func TestChain_GetAccountPolicy(t *testing.T) {
+	chainInstance, accounts, _ := SetUp(t, 2, 10, 5)
+	defer TearDown(chainInstance)
+
+	// write the policy storage through a mocked account of the policy contract
+	pub, pri, err := ed25519.GenerateKey(rand2.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policyAccount := NewAccount(chainInstance, pub, pri)
+	policyAccount.Addr = types.AddressAccountPolicy
+	policyAccount.LatestBlock, err = chainInstance.GetLatestAccountBlock(types.AddressAccountPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	accounts[policyAccount.Addr] = policyAccount
+	target := getRandomAccount(accounts)
+	for target == policyAccount {
+		target = getRandomAccount(accounts)
+	}
+
+	setLimit := func(limit int64) *ledger.SnapshotBlock {
+		data, err := abi.ABIAccountPolicy.PackVariable(abi.VariableNameAccountPolicy,
+			[]types.TokenTypeId{ledger.ViteTokenId}, []*big.Int{big.NewInt(limit)}, []types.Address{}, types.ZERO_ADDRESS, uint64(0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		vmBlock, err := policyAccount.CreateSendBlock(target, &CreateTxOptions{
+			MockSignature: true,
+			KeyValue:      map[string][]byte{string(abi.GetAccountPolicyKey(target.Addr)): data},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		policyAccount.InsertBlock(vmBlock, accounts)
+		if err := chainInstance.InsertAccountBlock(vmBlock); err != nil {
+			t.Fatal(err)
+		}
+		sb, invalidBlocks, err := InsertSnapshotBlock(chainInstance, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		Snapshot(accounts, sb)
+		DeleteInvalidBlocks(accounts, invalidBlocks)
+		return sb
+	}
+
+	sb1 := setLimit(100)
+	sb2 := setLimit(200)
+	for _, c := range []struct {
+		height uint64
+		limit  int64
+	}{{sb1.Height, 100}, {sb2.Height, 200}, {sb2.Height + 1, 200}} {
+		policy, err := chainInstance.GetAccountPolicy(target.Addr, c.height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if policy == nil || policy.DailyLimit(ledger.ViteTokenId).Cmp(big.NewInt(c.limit)) != 0 {
+			t.Fatalf("policy at height %d, expected daily limit %d, got %+v", c.height, c.limit, policy)
+		}
+	}
+}
+
 func TestVoteList(t *testing.T) {
 	t.Skip("Skipped by default. This test can be used to inspect ledger vote data.")
 
@@ -11,6 +11,7 @@ import (
 
 	"github.com/vitelabs/go-vite/v2/common"
 	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
 	"github.com/vitelabs/go-vite/v2/common/types"
 	"github.com/vitelabs/go-vite/v2/common/upgrade"
 	"github.com/vitelabs/go-vite/v2/interfaces"
@@ -66,7 +67,18 @@ type chain struct {
 
 	plugins *chain_plugins.Plugins
 
+	// onroadDB is written by the onroad manager in the events of inserting and deleting blocks
+	onroadDB engine.Engine
+
 	status uint32
+
+	stateSnapshotExporting int32
+	stateSnapshotWg        sync.WaitGroup
+
+	checkpointCreating int32
+
+	pruning int32
+	pruneWg sync.WaitGroup
 }
 
 /*
@@ -115,6 +127,14 @@ func (c *chain) Init() error {
 		return err
 	}
 
+	// check state snapshot importing
+	if importing, err := c.QueryStateImporting(); err != nil {
+		return err
+	} else if importing {
+		return fmt.Errorf("The importing of state snapshot was interrupted. You can fix the problem by removing the database manually."+
+			"The directory of database is %s.", c.chainDir)
+	}
+
 	// check ledger
 	status, err := c.checkAndInitData()
 	if err != nil {
@@ -172,6 +192,13 @@ func (c *chain) Stop() error {
 func (c *chain) Destroy() error {
 	c.log.Info("Begin to destroy", "method", "Close")
 
+	// wait for exporting state snapshot
+	c.stateSnapshotWg.Wait()
+
+	// stop pruning
+	c.blockDB.StopPrune()
+	c.pruneWg.Wait()
+
 	c.cache.Destroy()
 	c.log.Info("Close cache", "method", "Close")
 
@@ -230,7 +257,7 @@ func (c *chain) NewDb(dirName string) (*leveldb.DB, error) {
 	return db, nil
 }
 
-func (c *chain) PrepareOnroadDb() (*leveldb.DB, error) {
+func (c *chain) PrepareOnroadDb() (engine.Engine, error) {
 	dirName := "onroad"
 	absoluteDirName := path.Join(c.chainDir, dirName)
 	c.log.Info("clear onroad db", "dir", absoluteDirName)
@@ -239,7 +266,10 @@ func (c *chain) PrepareOnroadDb() (*leveldb.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	return c.NewDb(dirName)
+	if c.onroadDB, err = engine.Open(engine.LevelDB, absoluteDirName); err != nil {
+		return nil, err
+	}
+	return c.onroadDB, nil
 }
 
 func (c *chain) SetConsensus(verifier interfaces.ConsensusVerifier, periodTimeIndex interfaces.TimeIndex) {
@@ -254,6 +284,11 @@ func (c *chain) SetConsensus(verifier interfaces.ConsensusVerifier, periodTimeIn
 
 func (c *chain) newDbAndRecover() error {
 	var err error
+	if err = CheckDBEngines(c.dataDir, c.chainCfg); err != nil {
+		c.log.Error(fmt.Sprintf("CheckDBEngines failed, error is %s, chainDir is %s", err, c.chainDir), "method", "newDbAndRecover")
+		return err
+	}
+
 	// new metaDB
 	c.metaDB, err = c.NewDb("chain_meta")
 	if err != nil {
@@ -262,7 +297,7 @@ func (c *chain) newDbAndRecover() error {
 	}
 
 	// new ledger db
-	if c.indexDB, err = chain_index.NewIndexDB(c.chainDir); err != nil {
+	if c.indexDB, err = chain_index.NewIndexDB(c.chainDir, c.chainCfg.DBEngine); err != nil {
 		c.log.Error(fmt.Sprintf("chain_index.NewIndexDB failed, error is %s, chainDir is %s", err, c.chainDir), "method", "newDbAndRecover")
 		return err
 	}
@@ -284,7 +319,7 @@ func (c *chain) newDbAndRecover() error {
 	// init plugins
 	if c.chainCfg.OpenPlugins {
 		var err error
-		if c.plugins, err = chain_plugins.NewPlugins(c.chainDir, c); err != nil {
+		if c.plugins, err = chain_plugins.NewPlugins(c.chainDir, c, c.chainCfg.PluginDBEngine); err != nil {
 			cErr := fmt.Errorf("chain_plugins.NewPlugins failed. Error: %s", err)
 			c.log.Error(cErr.Error(), "method", "newDbAndRecover")
 			return cErr
@@ -480,6 +515,21 @@ func defaultConfig() *config.Chain {
 	}
 }
 
+// CheckDBEngines checks the db engines of the config before the chain in the dataDir is opened,
+// a db created by another engine is an error
+func CheckDBEngines(dataDir string, chainCfg *config.Chain) error {
+	if chainCfg.DBEngine == engine.BTree {
+		return fmt.Errorf("the db engine %s is only for the plugins db", engine.BTree)
+	}
+	chainDir := path.Join(dataDir, "ledger")
+	for _, name := range []string{"index", "state", "state_redo"} {
+		if err := engine.Check(chainCfg.DBEngine, path.Join(chainDir, name)); err != nil {
+			return err
+		}
+	}
+	return engine.Check(chainCfg.PluginDBEngine, path.Join(chainDir, "plugins"))
+}
+
 func (c *chain) DBs() (*chain_index.IndexDB, *chain_block.BlockDB, *chain_state.StateDB) {
 	return c.indexDB, c.blockDB, c.stateDB
 }
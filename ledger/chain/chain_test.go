@@ -13,7 +13,9 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
 	"github.com/vitelabs/go-vite/v2/common/types"
 	"github.com/vitelabs/go-vite/v2/common/upgrade"
 	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
@@ -161,6 +163,12 @@ var GenesisJson = `{
 `
 
 func NewChainInstance(t gomock.TestReporter, dirName string, clear bool) (*chain, error) {
+	return newChainInstanceWithConfig(t, dirName, clear, &config.Chain{
+		VmLogAll: true,
+	})
+}
+
+func newChainInstanceWithConfig(t gomock.TestReporter, dirName string, clear bool, chainCfg *config.Chain) (*chain, error) {
 	var dataDir string
 
 	if path.IsAbs(dirName) {
@@ -176,9 +184,6 @@ func NewChainInstance(t gomock.TestReporter, dirName string, clear bool) (*chain
 
 	json.Unmarshal([]byte(GenesisJson), genesisConfig)
 
-	chainCfg := &config.Chain{
-		VmLogAll: true,
-	}
 	chainInstance := NewChain(dataDir, chainCfg, genesisConfig)
 
 	if err := chainInstance.Init(); err != nil {
@@ -228,6 +233,52 @@ func TearDown(chainInstance *chain) {
 	chainInstance.Destroy()
 }
 
+func TestChain_DBEngine(t *testing.T) {
+	upgrade.CleanupUpgradeBox()
+	upgrade.InitUpgradeBox(upgrade.NewEmptyUpgradeBox().AddPoint(1, 10000000))
+	quota.InitQuotaConfig(true, true)
+
+	chainInstance, err := newChainInstanceWithConfig(t, t.Name(), true, &config.Chain{VmLogAll: true, DBEngine: engine.Bolt})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Clear(chainInstance)
+
+	accounts := MakeAccounts(chainInstance, 10)
+	InsertAccountBlockAndSnapshot(chainInstance, accounts, 100, 5, false)
+	latestSb := chainInstance.GetLatestSnapshotBlock()
+	balances := make(map[types.Address]string, len(accounts))
+	for addr := range accounts {
+		balance, err := chainInstance.GetBalance(addr, ledger.ViteTokenId)
+		assert.NoError(t, err)
+		balances[addr] = balance.String()
+	}
+	TearDown(chainInstance)
+	chainInstance.metaDB.Close()
+
+	for _, name := range []string{"index", "state", "state_redo"} {
+		engineName, _ := engine.Detect(path.Join(chainInstance.chainDir, name))
+		assert.Equal(t, engine.Bolt, engineName, name)
+	}
+	assert.NoError(t, CheckDBEngines(chainInstance.dataDir, &config.Chain{}))
+	assert.NoError(t, CheckDBEngines(chainInstance.dataDir, &config.Chain{DBEngine: engine.Bolt}))
+	assert.Error(t, CheckDBEngines(chainInstance.dataDir, &config.Chain{DBEngine: engine.LevelDB}))
+	assert.Error(t, CheckDBEngines(chainInstance.dataDir, &config.Chain{DBEngine: engine.BTree}))
+
+	// an existing db is reopened by its engine
+	chainInstance, err = newChainInstanceWithConfig(t, chainInstance.dataDir, false, &config.Chain{VmLogAll: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer TearDown(chainInstance)
+	assert.Equal(t, latestSb.Hash, chainInstance.GetLatestSnapshotBlock().Hash)
+	for addr, balance := range balances {
+		reopenedBalance, err := chainInstance.GetBalance(addr, ledger.ViteTokenId)
+		assert.NoError(t, err)
+		assert.Equal(t, balance, reopenedBalance.String())
+	}
+}
+
 func TestChain(t *testing.T) {
 	go func() {
 		log.Println(http.ListenAndServe("localhost:6060", nil))
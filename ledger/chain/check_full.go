@@ -0,0 +1,458 @@
+package chain
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_file_manager "github.com/vitelabs/go-vite/v2/ledger/chain/file_manager"
+	chain_utils "github.com/vitelabs/go-vite/v2/ledger/chain/utils"
+)
+
+const (
+	CheckIssueBlock     = "block"
+	CheckIssueSignature = "signature"
+	CheckIssueIndex     = "index"
+	CheckIssueBalance   = "balance"
+	CheckIssueOnRoad    = "onroad"
+
+	// the issues after maxCheckIssues are counted but not recorded
+	maxCheckIssues = 1000
+)
+
+// CheckIssue is an inconsistency found by CheckFull
+type CheckIssue struct {
+	Kind string `json:"kind"`
+
+	// the snapshot height from which the ledger is inconsistent, 0 means unknown
+	SnapshotHeight uint64 `json:"snapshotHeight"`
+
+	Address *types.Address `json:"address,omitempty"`
+	Hash    *types.Hash    `json:"hash,omitempty"`
+	Message string         `json:"message"`
+}
+
+// FullCheckReport is the result of CheckFull
+type FullCheckReport struct {
+	LatestSnapshotHeight uint64 `json:"latestSnapshotHeight"`
+
+	SnapshotBlocks uint64 `json:"snapshotBlocks"`
+	AccountBlocks  uint64 `json:"accountBlocks"`
+	Accounts       uint64 `json:"accounts"`
+	OnRoadBlocks   uint64 `json:"onRoadBlocks"`
+
+	// balances and onroad blocks can't be recomputed if the old blocks have been pruned
+	Pruned         bool `json:"pruned"`
+	BalanceChecked bool `json:"balanceChecked"`
+	OnRoadChecked  bool `json:"onRoadChecked"`
+
+	IssueCount uint64        `json:"issueCount"`
+	Issues     []*CheckIssue `json:"issues"`
+
+	// UnlocatedIssueCount counts the issues whose snapshot height is unknown, such as the latest height of an
+	// account in index and the balances, which are only compared with the blocks at the latest snapshot block
+	UnlocatedIssueCount uint64 `json:"unlocatedIssueCount,omitempty"`
+
+	// FirstInconsistentHeight is the lowest snapshot height of the located issues, 0 if none is located
+	FirstInconsistentHeight uint64 `json:"firstInconsistentHeight,omitempty"`
+}
+
+func (report *FullCheckReport) addIssue(kind string, snapshotHeight uint64, addr *types.Address, hash *types.Hash, format string, args ...interface{}) {
+	report.IssueCount++
+	if snapshotHeight == 0 {
+		report.UnlocatedIssueCount++
+	} else if report.FirstInconsistentHeight == 0 || snapshotHeight < report.FirstInconsistentHeight {
+		report.FirstInconsistentHeight = snapshotHeight
+	}
+
+	if len(report.Issues) >= maxCheckIssues {
+		return
+	}
+	report.Issues = append(report.Issues, &CheckIssue{
+		Kind:           kind,
+		SnapshotHeight: snapshotHeight,
+		Address:        addr,
+		Hash:           hash,
+		Message:        fmt.Sprintf(format, args...),
+	})
+}
+
+type checkingAccount struct {
+	latest ledger.HashHeight
+
+	// only the balances of user accounts are recomputed, the balances of contracts are changed by vm
+	balances map[types.TokenTypeId]*big.Int
+}
+
+type checkingSend struct {
+	toAddr  types.Address
+	tokenId types.TokenTypeId
+	amount  *big.Int
+	height  uint64
+}
+
+type fullChecker struct {
+	c      *chain
+	report *FullCheckReport
+
+	accounts map[types.Address]*checkingAccount
+
+	// the send blocks which are not received
+	sends map[types.Hash]*checkingSend
+
+	prevSb *ledger.SnapshotBlock
+}
+
+// CheckFull walks all the blocks in block files, verifies the hashes and the signatures,
+// cross-checks the index, recomputes the balances of user accounts and the onroad blocks.
+func (c *chain) CheckFull() (*FullCheckReport, error) {
+	checker := &fullChecker{
+		c: c,
+		report: &FullCheckReport{
+			Issues: make([]*CheckIssue, 0),
+		},
+		accounts: make(map[types.Address]*checkingAccount),
+		sends:    make(map[types.Hash]*checkingSend),
+	}
+	return checker.check()
+}
+
+func (checker *fullChecker) check() (*FullCheckReport, error) {
+	c := checker.c
+	report := checker.report
+
+	latestSb := c.GetLatestSnapshotBlock()
+	report.LatestSnapshotHeight = latestSb.Height
+
+	location := chain_file_manager.NewLocation(1, 0)
+	if prunedLocation := c.blockDB.PrunedLocation(); prunedLocation != nil {
+		location = prunedLocation
+		report.Pruned = true
+	} else {
+		checker.initGenesisBalances()
+	}
+
+	// the account blocks are followed by the snapshot block confirming them in block files
+	var accountBlocks []*ledger.AccountBlock
+	var accountBlockLocations []*chain_file_manager.Location
+
+	for location != nil && (checker.prevSb == nil || checker.prevSb.Height < latestSb.Height) {
+		sb, ab, next, err := c.blockDB.ReadUnit(location)
+		if err != nil {
+			return nil, fmt.Errorf("c.blockDB.ReadUnit failed, location is %s. Error: %s", location, err)
+		}
+
+		if ab != nil {
+			accountBlocks = append(accountBlocks, ab)
+			accountBlockLocations = append(accountBlockLocations, location)
+		} else if sb != nil {
+			for i, ab := range accountBlocks {
+				if err := checker.checkAccountBlock(sb.Height, accountBlockLocations[i], ab); err != nil {
+					return nil, err
+				}
+			}
+			if err := checker.checkSnapshotBlock(location, sb, accountBlocks); err != nil {
+				return nil, err
+			}
+			accountBlocks = nil
+			accountBlockLocations = nil
+
+			if sb.Height%10000 == 0 {
+				c.log.Info(fmt.Sprintf("check snapshot block %d, %d account blocks", sb.Height, report.AccountBlocks), "method", "CheckFull")
+			}
+		}
+
+		location = next
+	}
+
+	if checker.prevSb == nil || checker.prevSb.Hash != latestSb.Hash {
+		report.addIssue(CheckIssueBlock, latestSb.Height, nil, &latestSb.Hash, "the latest snapshot block %d is not in block files", latestSb.Height)
+	}
+
+	// the unconfirmed blocks have changed the state
+	for _, ab := range c.GetAllUnconfirmedBlocks() {
+		if err := checker.checkAccountBlock(latestSb.Height+1, nil, ab); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checker.checkAccounts(); err != nil {
+		return nil, err
+	}
+
+	if !report.Pruned {
+		if err := checker.checkOnRoad(); err != nil {
+			return nil, err
+		}
+		report.BalanceChecked = true
+		report.OnRoadChecked = true
+	}
+
+	report.Accounts = uint64(len(checker.accounts))
+	return report, nil
+}
+
+func (checker *fullChecker) initGenesisBalances() {
+	for _, vmBlock := range checker.c.genesisAccountBlocks {
+		addr := vmBlock.AccountBlock.AccountAddress
+		if types.IsContractAddr(addr) {
+			continue
+		}
+		account := checker.getAccount(addr)
+		for tokenId, balance := range vmBlock.VmDb.GetUnsavedBalanceMap() {
+			account.balances[tokenId] = new(big.Int).Set(balance)
+		}
+	}
+}
+
+func (checker *fullChecker) getAccount(addr types.Address) *checkingAccount {
+	account, ok := checker.accounts[addr]
+	if !ok {
+		account = &checkingAccount{}
+		if !types.IsContractAddr(addr) {
+			account.balances = make(map[types.TokenTypeId]*big.Int)
+		}
+		checker.accounts[addr] = account
+	}
+	return account
+}
+
+func (checker *fullChecker) checkSnapshotBlock(location *chain_file_manager.Location, sb *ledger.SnapshotBlock, accountBlocks []*ledger.AccountBlock) error {
+	c := checker.c
+	report := checker.report
+	report.SnapshotBlocks++
+
+	if sb.ComputeHash() != sb.Hash {
+		report.addIssue(CheckIssueBlock, sb.Height, nil, &sb.Hash, "the hash of snapshot block %d is not %s", sb.Height, sb.ComputeHash())
+	}
+	if sb.Height != c.genesisSnapshotBlock.Height && !sb.VerifySignature() {
+		report.addIssue(CheckIssueSignature, sb.Height, nil, &sb.Hash, "the signature of snapshot block %d is invalid", sb.Height)
+	}
+
+	if prevSb := checker.prevSb; prevSb != nil && (prevSb.Height+1 != sb.Height || prevSb.Hash != sb.PrevHash) {
+		report.addIssue(CheckIssueBlock, sb.Height, nil, &sb.Hash, "snapshot block %d is not next to %d %s", sb.Height, prevSb.Height, prevSb.Hash)
+	}
+	checker.prevSb = sb
+
+	hash, indexLocation, err := c.indexDB.GetSnapshotBlockByHeight(sb.Height)
+	if err != nil {
+		return fmt.Errorf("c.indexDB.GetSnapshotBlockByHeight failed, height is %d. Error: %s", sb.Height, err)
+	}
+	if hash == nil || *hash != sb.Hash || indexLocation == nil || *indexLocation != *location {
+		report.addIssue(CheckIssueIndex, sb.Height, nil, &sb.Hash, "the index of snapshot block %d is %s %s, the block is at %s", sb.Height, hash, indexLocation, location)
+	}
+
+	for addr, hashHeight := range sb.SnapshotContent {
+		var latest ledger.HashHeight
+		if account, ok := checker.accounts[addr]; ok {
+			latest = account.latest
+		}
+		if latest != *hashHeight {
+			addr := addr
+			report.addIssue(CheckIssueBlock, sb.Height, &addr, &hashHeight.Hash, "snapshot block %d confirms %d %s, but the latest block is %d %s", sb.Height, hashHeight.Height, hashHeight.Hash, latest.Height, latest.Hash)
+		}
+	}
+
+	for _, ab := range accountBlocks {
+		confirmHeight, err := c.indexDB.GetConfirmHeightByHash(&ab.Hash)
+		if err != nil {
+			return fmt.Errorf("c.indexDB.GetConfirmHeightByHash failed, hash is %s. Error: %s", ab.Hash, err)
+		}
+		if confirmHeight != sb.Height {
+			report.addIssue(CheckIssueIndex, sb.Height, &ab.AccountAddress, &ab.Hash, "account block %d is confirmed by snapshot block %d, but the index is %d", ab.Height, sb.Height, confirmHeight)
+		}
+	}
+	return nil
+}
+
+// checkAccountBlock checks the account block at location, location is nil if the block is unconfirmed
+func (checker *fullChecker) checkAccountBlock(snapshotHeight uint64, location *chain_file_manager.Location, ab *ledger.AccountBlock) error {
+	c := checker.c
+	report := checker.report
+	report.AccountBlocks++
+
+	addr := ab.AccountAddress
+	_, isGenesis := c.genesisAccountBlockHash[ab.Hash]
+
+	if ab.ComputeHash() != ab.Hash {
+		report.addIssue(CheckIssueBlock, snapshotHeight, &addr, &ab.Hash, "the hash of account block %d is not %s", ab.Height, ab.ComputeHash())
+	}
+	if !isGenesis && !ab.VerifySignature() {
+		report.addIssue(CheckIssueSignature, snapshotHeight, &addr, &ab.Hash, "the signature of account block %d is invalid", ab.Height)
+	}
+
+	account := checker.getAccount(addr)
+	if !report.Pruned || account.latest.Height > 0 {
+		if account.latest.Height+1 != ab.Height || account.latest.Hash != ab.PrevHash {
+			report.addIssue(CheckIssueBlock, snapshotHeight, &addr, &ab.Hash, "account block %d is not next to %d %s", ab.Height, account.latest.Height, account.latest.Hash)
+		}
+	}
+	account.latest = ab.HashHeight()
+
+	if location != nil {
+		hashLocation, err := c.indexDB.GetAccountBlockLocationByHash(&ab.Hash)
+		if err != nil {
+			return fmt.Errorf("c.indexDB.GetAccountBlockLocationByHash failed, hash is %s. Error: %s", ab.Hash, err)
+		}
+		hash, heightLocation, err := c.indexDB.GetAccountBlockLocationByHeight(&addr, ab.Height)
+		if err != nil {
+			return fmt.Errorf("c.indexDB.GetAccountBlockLocationByHeight failed, addr is %s, height is %d. Error: %s", addr, ab.Height, err)
+		}
+		if hashLocation == nil || *hashLocation != *location || hash == nil || *hash != ab.Hash || heightLocation == nil || *heightLocation != *location {
+			report.addIssue(CheckIssueIndex, snapshotHeight, &addr, &ab.Hash, "account block %d is at %s, but the index is %s, %s %s", ab.Height, location, hashLocation, hash, heightLocation)
+		}
+	}
+
+	if ab.IsSendBlock() {
+		checker.send(snapshotHeight, ab, account)
+		return nil
+	}
+
+	for idx, sendBlock := range ab.SendBlockList {
+		if sendBlock.ComputeSendHash(ab, uint8(idx)) != sendBlock.Hash {
+			report.addIssue(CheckIssueBlock, snapshotHeight, &addr, &sendBlock.Hash, "the hash of send block %d in account block %d is invalid", idx, ab.Height)
+		}
+		sendLocation, err := c.indexDB.GetAccountBlockLocationByHash(&sendBlock.Hash)
+		if err != nil {
+			return fmt.Errorf("c.indexDB.GetAccountBlockLocationByHash failed, hash is %s. Error: %s", sendBlock.Hash, err)
+		}
+		if location != nil && (sendLocation == nil || *sendLocation != *location) {
+			report.addIssue(CheckIssueIndex, snapshotHeight, &addr, &sendBlock.Hash, "the index of send block %d in account block %d is %s", idx, ab.Height, sendLocation)
+		}
+		checker.send(snapshotHeight, sendBlock, account)
+	}
+
+	if ab.IsGenesisBlock() {
+		return nil
+	}
+	send, ok := checker.sends[ab.FromBlockHash]
+	if !ok {
+		if !report.Pruned {
+			report.addIssue(CheckIssueOnRoad, snapshotHeight, &addr, &ab.Hash, "account block %d receives %s which is not an onroad block", ab.Height, ab.FromBlockHash)
+		}
+		return nil
+	}
+	delete(checker.sends, ab.FromBlockHash)
+
+	if send.toAddr != addr {
+		report.addIssue(CheckIssueOnRoad, snapshotHeight, &addr, &ab.Hash, "account block %d receives %s which is sent to %s", ab.Height, ab.FromBlockHash, send.toAddr)
+	}
+	if account.balances != nil {
+		addBalance(account.balances, send.tokenId, send.amount)
+	}
+	return nil
+}
+
+func (checker *fullChecker) send(snapshotHeight uint64, sendBlock *ledger.AccountBlock, account *checkingAccount) {
+	checker.sends[sendBlock.Hash] = &checkingSend{
+		toAddr:  sendBlock.ToAddress,
+		tokenId: sendBlock.TokenId,
+		amount:  sendBlock.Amount,
+		height:  snapshotHeight,
+	}
+
+	if account.balances != nil {
+		if sendBlock.Amount != nil {
+			addBalance(account.balances, sendBlock.TokenId, new(big.Int).Neg(sendBlock.Amount))
+		}
+		if sendBlock.Fee != nil {
+			addBalance(account.balances, ledger.ViteTokenId, new(big.Int).Neg(sendBlock.Fee))
+		}
+	}
+}
+
+func addBalance(balances map[types.TokenTypeId]*big.Int, tokenId types.TokenTypeId, amount *big.Int) {
+	if amount == nil {
+		return
+	}
+	balance, ok := balances[tokenId]
+	if !ok {
+		balance = new(big.Int)
+		balances[tokenId] = balance
+	}
+	balance.Add(balance, amount)
+}
+
+func (checker *fullChecker) checkAccounts() error {
+	c := checker.c
+	report := checker.report
+
+	for addr, account := range checker.accounts {
+		addr := addr
+
+		height, _, err := c.indexDB.GetLatestAccountBlock(&addr)
+		if err != nil {
+			return fmt.Errorf("c.indexDB.GetLatestAccountBlock failed, addr is %s. Error: %s", addr, err)
+		}
+		if height != account.latest.Height {
+			report.addIssue(CheckIssueIndex, 0, &addr, nil, "the latest height of the account is %d in index, but is %d in block files", height, account.latest.Height)
+		}
+
+		if account.balances == nil || report.Pruned {
+			continue
+		}
+
+		balances, err := c.stateDB.GetBalanceMap(addr)
+		if err != nil {
+			return fmt.Errorf("c.stateDB.GetBalanceMap failed, addr is %s. Error: %s", addr, err)
+		}
+		for tokenId, balance := range account.balances {
+			if stateBalance, ok := balances[tokenId]; (ok && stateBalance.Cmp(balance) != 0) || (!ok && balance.Sign() != 0) {
+				report.addIssue(CheckIssueBalance, 0, &addr, nil, "the balance of %s is %s, but is %s computed from blocks", tokenId, stateBalance, balance)
+			}
+		}
+		for tokenId, stateBalance := range balances {
+			if _, ok := account.balances[tokenId]; !ok && stateBalance.Sign() != 0 {
+				report.addIssue(CheckIssueBalance, 0, &addr, nil, "the balance of %s is %s, but is 0 computed from blocks", tokenId, stateBalance)
+			}
+		}
+	}
+	return nil
+}
+
+func (checker *fullChecker) checkOnRoad() error {
+	c := checker.c
+	report := checker.report
+
+	iter := c.indexDB.Store().NewIterator(util.BytesPrefix([]byte{chain_utils.OnRoadKeyPrefix}))
+	defer iter.Release()
+
+	indexed := make(map[types.Hash]struct{})
+	for iter.Next() {
+		key := iter.Key()
+		toAddr, err := types.BytesToAddress(key[1 : 1+types.AddressSize])
+		if err != nil {
+			return fmt.Errorf("types.BytesToAddress failed, key is %d. Error: %s", key, err)
+		}
+		hash, err := types.BytesToHash(key[1+types.AddressSize:])
+		if err != nil {
+			return fmt.Errorf("types.BytesToHash failed, key is %d. Error: %s", key, err)
+		}
+		report.OnRoadBlocks++
+		indexed[hash] = struct{}{}
+
+		send, ok := checker.sends[hash]
+		if !ok {
+			confirmHeight, err := c.indexDB.GetConfirmHeightByHash(&hash)
+			if err != nil {
+				return fmt.Errorf("c.indexDB.GetConfirmHeightByHash failed, hash is %s. Error: %s", hash, err)
+			}
+			report.addIssue(CheckIssueOnRoad, confirmHeight, &toAddr, &hash, "the onroad block is received or not existed")
+		} else if send.toAddr != toAddr {
+			report.addIssue(CheckIssueOnRoad, send.height, &toAddr, &hash, "the onroad block is sent to %s", send.toAddr)
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	for hash, send := range checker.sends {
+		if _, ok := indexed[hash]; !ok {
+			hash := hash
+			report.addIssue(CheckIssueOnRoad, send.height, &send.toAddr, &hash, "the send block is not received, but is not in onroad index")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	chain_utils "github.com/vitelabs/go-vite/v2/ledger/chain/utils"
+)
+
+func TestCheckFull(t *testing.T) {
+	chainInstance, accounts, _ := SetUp(t, 10, 300, 5)
+	defer func() {
+		TearDown(chainInstance)
+		Clear(chainInstance)
+	}()
+
+	report, err := chainInstance.CheckFull()
+	assert.NoError(t, err)
+	assert.Equal(t, chainInstance.GetLatestSnapshotBlock().Height, report.LatestSnapshotHeight)
+	assert.Equal(t, report.LatestSnapshotHeight, report.SnapshotBlocks)
+	assert.True(t, report.Accounts >= uint64(len(accounts)))
+	assert.True(t, report.OnRoadChecked)
+
+	// the mock accounts have mock signatures and mock balances
+	for _, issue := range report.Issues {
+		if issue.Kind != CheckIssueSignature && issue.Kind != CheckIssueBalance {
+			t.Fatalf("unexpected issue: %+v", issue)
+		}
+		// the balances are compared at the latest snapshot block, the inconsistent height is unknown
+		if issue.Kind == CheckIssueBalance {
+			assert.Equal(t, uint64(0), issue.SnapshotHeight)
+		}
+	}
+	assert.True(t, report.UnlocatedIssueCount > 0)
+
+	// delete an onroad block from index
+	iter := chainInstance.indexDB.Store().NewIterator(util.BytesPrefix([]byte{chain_utils.OnRoadKeyPrefix}))
+	assert.True(t, iter.Next())
+	key := append([]byte{}, iter.Key()...)
+	iter.Release()
+
+	hash, err := types.BytesToHash(key[1+types.AddressSize:])
+	assert.NoError(t, err)
+	confirmHeight, err := chainInstance.indexDB.GetConfirmHeightByHash(&hash)
+	assert.NoError(t, err)
+
+	batch := chainInstance.indexDB.Store().NewBatch()
+	batch.Delete(key)
+	chainInstance.indexDB.Store().WriteDirectly(batch)
+
+	report, err = chainInstance.CheckFull()
+	assert.NoError(t, err)
+
+	var found bool
+	for _, issue := range report.Issues {
+		if issue.Kind == CheckIssueOnRoad && issue.Hash != nil && *issue.Hash == hash {
+			found = true
+			assert.Equal(t, confirmHeight, issue.SnapshotHeight)
+		}
+	}
+	assert.True(t, found)
+	assert.True(t, report.FirstInconsistentHeight > 0 && report.FirstInconsistentHeight <= confirmHeight)
+}
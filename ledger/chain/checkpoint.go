@@ -0,0 +1,307 @@
+package chain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync/atomic"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
+	xleveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	chain_block "github.com/vitelabs/go-vite/v2/ledger/chain/block"
+	chain_file_manager "github.com/vitelabs/go-vite/v2/ledger/chain/file_manager"
+)
+
+const (
+	// the block files of the latest checkpointCopyWindow snapshot blocks are copied to the checkpoint instead
+	// of hard-linked, because rolling back truncates the block files in place.
+	checkpointCopyWindow = 3600
+
+	// write the copied database every checkpointBatchSize items
+	checkpointBatchSize = 10000
+
+	// CheckpointManifestName is the name of the manifest file in the directory of the checkpoint
+	CheckpointManifestName = "checkpoint.json"
+)
+
+type checkpointIterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Release()
+	Error() error
+}
+
+// the databases on the engine, chain_db.Store and engine.Engine
+type engineSnapshotter interface {
+	GetSnapshot() (engine.Snapshot, error)
+}
+
+// a snapshot of the database to be copied to the checkpoint
+type checkpointStore struct {
+	name        string
+	engine      string
+	newIterator func() checkpointIterator
+	release     func()
+}
+
+// CreateCheckpoint writes a consistent copy of the ledger at the latest snapshot block to dir, then dir can be used
+// as the DataDir of a node. The writing is paused while flushing, linking the block files and taking the snapshots
+// of the databases, the databases are copied after the writing is resumed. The onroad database is copied at the same
+// snapshot block as the ledger, the consensus database is not copied, it is rebuilt when the node starts.
+//
+// The block files before the latest checkpointCopyWindow snapshot blocks are hard-linked if dir is on the same
+// file system as the ledger, rolling back deeper than that changes the block files of the checkpoint too.
+func (c *chain) CreateCheckpoint(dir string) (*interfaces.CheckpointManifest, error) {
+	if !atomic.CompareAndSwapInt32(&c.checkpointCreating, 0, 1) {
+		return nil, errors.New("the previous checkpoint is being created")
+	}
+	defer atomic.StoreInt32(&c.checkpointCreating, 0)
+
+	if err := prepareCheckpointDir(dir); err != nil {
+		return nil, err
+	}
+
+	manifest, err := c.createCheckpoint(dir)
+	if err != nil {
+		if rmErr := os.RemoveAll(dir); rmErr != nil {
+			c.log.Error(fmt.Sprintf("remove checkpoint dir %s failed. Error: %s", dir, rmErr), "method", "CreateCheckpoint")
+		}
+		return nil, err
+	}
+
+	c.log.Info(fmt.Sprintf("create checkpoint %d %s in %s", manifest.Height, manifest.Hash, dir), "method", "CreateCheckpoint")
+	return manifest, nil
+}
+
+func (c *chain) createCheckpoint(dir string) (*interfaces.CheckpointManifest, error) {
+	ledgerDir := path.Join(dir, "ledger")
+	blocksDir := path.Join(ledgerDir, "blocks")
+	if err := os.MkdirAll(blocksDir, 0700); err != nil {
+		return nil, err
+	}
+
+	// the block files and the pruned database are not changed by pruning until the checkpoint is created
+	c.blockDB.LockPrune()
+	defer c.blockDB.UnlockPrune()
+
+	manifest := &interfaces.CheckpointManifest{
+		Timestamp: time.Now().Unix(),
+	}
+
+	var stores []*checkpointStore
+	defer func() {
+		for _, store := range stores {
+			store.release()
+		}
+	}()
+
+	var unlinked []uint64
+	if err := c.flusher.FlushAndRun(func() error {
+		sb := c.GetLatestSnapshotBlock()
+		manifest.Height = sb.Height
+		manifest.Hash = sb.Hash
+
+		linkBefore := chain_file_manager.NewLocation(0, 0)
+		if sb.Height > checkpointCopyWindow {
+			location, err := c.indexDB.GetSnapshotBlockLocation(sb.Height - checkpointCopyWindow)
+			if err != nil {
+				return err
+			}
+			if location != nil {
+				linkBefore = location
+			}
+		}
+
+		var err error
+		if manifest.Files, unlinked, err = c.blockDB.LinkFiles(blocksDir, c.blockDB.FlushedLocation(), linkBefore); err != nil {
+			return fmt.Errorf("c.blockDB.LinkFiles failed. Error: %s", err)
+		}
+
+		stores, err = c.captureCheckpointStores()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	// the files before the window are not changed after resuming writing
+	if len(unlinked) > 0 {
+		copied, err := c.blockDB.CopyFiles(blocksDir, unlinked)
+		if err != nil {
+			return nil, fmt.Errorf("c.blockDB.CopyFiles failed. Error: %s", err)
+		}
+		manifest.Files = append(copied, manifest.Files...)
+	}
+
+	for _, store := range stores {
+		items, err := copyCheckpointStore(store, path.Join(ledgerDir, store.name))
+		if err != nil {
+			return nil, fmt.Errorf("copy %s failed. Error: %s", store.name, err)
+		}
+		manifest.Stores = append(manifest.Stores, interfaces.CheckpointStore{
+			Name:   store.name,
+			Engine: store.engine,
+			Items:  items,
+		})
+	}
+
+	// the snapshot block is rolled back while copying, the hard-linked block files may be truncated
+	sb, err := c.GetSnapshotBlockByHeight(manifest.Height)
+	if err != nil {
+		return nil, err
+	}
+	if sb == nil || sb.Hash != manifest.Hash {
+		return nil, fmt.Errorf("snapshot block %d %s is rolled back while creating the checkpoint", manifest.Height, manifest.Hash)
+	}
+
+	if err := writeCheckpointManifest(dir, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// captureCheckpointStores takes the snapshots of the databases on disk, assume locking writing and pruning. The onroad
+// database is written in the events of inserting and deleting blocks, so it is also consistent while writing is locked
+func (c *chain) captureCheckpointStores() ([]*checkpointStore, error) {
+	names := []string{"index", "state", "state_redo"}
+	engineStores := []engineSnapshotter{c.indexDB.Store(), c.stateDB.Store(), c.stateDB.RedoStore()}
+	if c.plugins != nil {
+		names = append(names, "plugins")
+		engineStores = append(engineStores, c.plugins.Store())
+	}
+	if c.onroadDB != nil {
+		names = append(names, "onroad")
+		engineStores = append(engineStores, c.onroadDB)
+	}
+
+	var stores []*checkpointStore
+	release := func() {
+		for _, store := range stores {
+			store.release()
+		}
+	}
+
+	for i, name := range names {
+		engineName, _ := engine.Detect(path.Join(c.chainDir, name))
+		snap, err := engineStores[i].GetSnapshot()
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("get snapshot of %s failed. Error: %s", name, err)
+		}
+		stores = append(stores, &checkpointStore{
+			name:   name,
+			engine: engineName,
+			newIterator: func() checkpointIterator {
+				return snap.NewIterator(nil)
+			},
+			release: snap.Release,
+		})
+	}
+
+	// the meta database and the pruned database are always leveldb
+	metaSnap, err := c.metaDB.GetSnapshot()
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("get snapshot of chain_meta failed. Error: %s", err)
+	}
+	stores = append(stores, newLevelDBCheckpointStore("chain_meta", metaSnap))
+
+	prunedSnap, err := c.blockDB.GetPrunedSnapshot()
+	if err != nil {
+		release()
+		return nil, fmt.Errorf("get snapshot of %s failed. Error: %s", chain_block.PrunedDirName, err)
+	}
+	if prunedSnap != nil {
+		stores = append(stores, newLevelDBCheckpointStore(chain_block.PrunedDirName, prunedSnap))
+	}
+
+	return stores, nil
+}
+
+func newLevelDBCheckpointStore(name string, snap *leveldb.Snapshot) *checkpointStore {
+	return &checkpointStore{
+		name:   name,
+		engine: engine.LevelDB,
+		newIterator: func() checkpointIterator {
+			return snap.NewIterator(nil, nil)
+		},
+		release: snap.Release,
+	}
+}
+
+func copyCheckpointStore(store *checkpointStore, dir string) (uint64, error) {
+	db, err := engine.Open(store.engine, dir)
+	if err != nil {
+		return 0, err
+	}
+
+	items, err := writeCheckpointStore(store, db)
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	return items, err
+}
+
+func writeCheckpointStore(store *checkpointStore, db engine.Engine) (uint64, error) {
+	iter := store.newIterator()
+	defer iter.Release()
+
+	items := uint64(0)
+	batch := new(xleveldb.Batch)
+	for iter.Next() {
+		batch.Put(iter.Key(), iter.Value())
+		items++
+
+		if batch.Len() >= checkpointBatchSize {
+			if err := db.Write(batch); err != nil {
+				return 0, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return 0, err
+	}
+
+	if err := db.Write(batch); err != nil {
+		return 0, err
+	}
+	return items, nil
+}
+
+// prepareCheckpointDir creates dir, dir must be new or empty
+func prepareCheckpointDir(dir string) error {
+	if dir == "" {
+		return errors.New("the dir of the checkpoint is empty")
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(infos) > 0 {
+		return fmt.Errorf("%s is not empty", dir)
+	}
+	return os.MkdirAll(dir, 0700)
+}
+
+// writeCheckpointManifest writes the manifest last, the checkpoint is completed if the manifest exists
+func writeCheckpointManifest(dir string, manifest *interfaces.CheckpointManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	filename := path.Join(dir, CheckpointManifestName)
+	if err := ioutil.WriteFile(filename+".tmp", data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(filename+".tmp", filename)
+}
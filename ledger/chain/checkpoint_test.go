@@ -0,0 +1,74 @@
+package chain
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+)
+
+func TestCreateCheckpoint(t *testing.T) {
+	chainInstance, accounts, _ := SetUp(t, 10, 100, 5)
+	defer func() {
+		TearDown(chainInstance)
+		Clear(chainInstance)
+	}()
+
+	// confirm all account blocks
+	_, _, err := InsertSnapshotBlock(chainInstance, true)
+	assert.NoError(t, err)
+
+	balances := make(map[types.Address]string, len(accounts))
+	for addr := range accounts {
+		balance, err := chainInstance.GetBalance(addr, ledger.ViteTokenId)
+		assert.NoError(t, err)
+		balances[addr] = balance.String()
+	}
+
+	onroadDB, err := chainInstance.PrepareOnroadDb()
+	assert.NoError(t, err)
+	defer onroadDB.Close()
+	assert.NoError(t, onroadDB.Put([]byte("onroad"), []byte{1}))
+
+	tmpDir, err := ioutil.TempDir("", "checkpoint")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	dir := path.Join(tmpDir, "data")
+
+	manifest, err := chainInstance.CreateCheckpoint(dir)
+	assert.NoError(t, err)
+
+	latestSb := chainInstance.GetLatestSnapshotBlock()
+	assert.Equal(t, latestSb.Height, manifest.Height)
+	assert.Equal(t, latestSb.Hash, manifest.Hash)
+	assert.NotEmpty(t, manifest.Files)
+	assert.FileExists(t, path.Join(dir, CheckpointManifestName))
+	assert.Contains(t, manifest.Stores, interfaces.CheckpointStore{Name: "onroad", Engine: engine.LevelDB, Items: 1})
+
+	// the dir must be empty
+	_, err = chainInstance.CreateCheckpoint(dir)
+	assert.Error(t, err)
+
+	// insert more blocks after creating the checkpoint
+	_, _, err = InsertSnapshotBlock(chainInstance, true)
+	assert.NoError(t, err)
+
+	checkpointChain, err := NewChainInstance(t, dir, false)
+	assert.NoError(t, err)
+	defer TearDown(checkpointChain)
+
+	assert.Equal(t, manifest.Hash, checkpointChain.GetLatestSnapshotBlock().Hash)
+
+	for addr, balance := range balances {
+		checkpointBalance, err := checkpointChain.GetBalance(addr, ledger.ViteTokenId)
+		assert.NoError(t, err)
+		assert.Equal(t, balance, checkpointBalance.String())
+	}
+}
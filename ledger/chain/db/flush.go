@@ -47,7 +47,7 @@ func (store *Store) RedoLog() ([]byte, error) {
 }
 
 func (store *Store) Commit() error {
-	if err := store.db.Write(store.flushingBatch, nil); err != nil {
+	if err := store.db.Write(store.flushingBatch); err != nil {
 		return err
 	}
 	return nil
@@ -60,7 +60,7 @@ func (store *Store) PatchRedoLog(redoLog []byte) error {
 		return err
 	}
 
-	if err := store.db.Write(batch, nil); err != nil {
+	if err := store.db.Write(batch); err != nil {
 		return err
 	}
 
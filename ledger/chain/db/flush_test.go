@@ -8,13 +8,30 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
 	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
 	chain_flusher "github.com/vitelabs/go-vite/v2/ledger/chain/flusher"
 	"github.com/vitelabs/go-vite/v2/ledger/chain/test_tools"
 )
 
+// the flusher tests run on every db engine
+var testEngines = []string{engine.LevelDB, engine.BTree, engine.Bolt}
+
+func runWithEngines(t *testing.T, f func(t *testing.T, engineName string)) {
+	for _, engineName := range testEngines {
+		engineName := engineName
+		t.Run(engineName, func(t *testing.T) {
+			f(t, engineName)
+		})
+	}
+}
+
 func TestRedoLog(t *testing.T) {
-	store, tempDir := NewTestStore(t.Name(), true)
+	runWithEngines(t, testRedoLog)
+}
+
+func testRedoLog(t *testing.T, engineName string) {
+	store, tempDir := NewTestStoreWithEngine(t.Name(), true, engineName)
 	defer ClearTestStore(tempDir)
 
 	var mu sync.RWMutex
@@ -25,7 +42,11 @@ func TestRedoLog(t *testing.T) {
 }
 
 func TestFlush(t *testing.T) {
-	store, tempDir := NewTestStore(t.Name(), true)
+	runWithEngines(t, testFlush)
+}
+
+func testFlush(t *testing.T, engineName string) {
+	store, tempDir := NewTestStoreWithEngine(t.Name(), true, engineName)
 	defer ClearTestStore(tempDir)
 
 	batch := store.NewBatch()
@@ -92,15 +113,15 @@ func TestFlush(t *testing.T) {
 	assert.True(t, store.snapshotBatch == nil || store.snapshotBatch.Len() <= 0)
 
 	// check value
-	v1, err := store.db.Get([]byte("key1"), nil)
+	v1, err := store.db.Get([]byte("key1"))
 	assert.NoError(t, err)
 	assert.Equal(t, v1, []byte("value1"))
 
-	v2, err := store.db.Get([]byte("key2"), nil)
+	v2, err := store.db.Get([]byte("key2"))
 	assert.NoError(t, err)
 	assert.Equal(t, v2, []byte("value2"))
 
-	v3, err := store.db.Get([]byte("key3"), nil)
+	v3, err := store.db.Get([]byte("key3"))
 	assert.NoError(t, err)
 	assert.Equal(t, v3, []byte("value3"))
 
@@ -113,7 +134,11 @@ func TestFlush(t *testing.T) {
 }
 
 func TestRecover(t *testing.T) {
-	store, tempDir := NewTestStore(t.Name(), true)
+	runWithEngines(t, testRecover)
+}
+
+func testRecover(t *testing.T, engineName string) {
+	store, tempDir := NewTestStoreWithEngine(t.Name(), true, engineName)
 	defer ClearTestStore(tempDir)
 
 	batch := store.NewBatch()
@@ -175,19 +200,62 @@ func TestRecover(t *testing.T) {
 	assert.Equal(t, callAfterRecover, true)
 
 	// check value
-	v1, err := store.db.Get([]byte("key1"), nil)
+	v1, err := store.db.Get([]byte("key1"))
 	assert.NoError(t, err)
 	assert.Equal(t, v1, []byte("value1"))
 
-	v2, err := store.db.Get([]byte("key2"), nil)
+	v2, err := store.db.Get([]byte("key2"))
 	assert.NoError(t, err)
 	assert.Equal(t, v2, []byte("value2"))
 
-	v3, err := store.db.Get([]byte("key3"), nil)
+	v3, err := store.db.Get([]byte("key3"))
 	assert.NoError(t, err)
 	assert.Equal(t, v3, []byte("value3"))
 }
 
+func TestRecoverAfterRestart(t *testing.T) {
+	runWithEngines(t, testRecoverAfterRestart)
+}
+
+// testRecoverAfterRestart crashes after the redo log is written and before the commit,
+// then patches the redo log to the reopened store
+func testRecoverAfterRestart(t *testing.T, engineName string) {
+	store, tempDir := NewTestStoreWithEngine(t.Name(), true, engineName)
+	defer ClearTestStore(tempDir)
+
+	batch := store.NewBatch()
+	batch.Put([]byte("key1"), []byte("value1"))
+	batch.Put([]byte("key2"), []byte("value2"))
+	batch.Delete([]byte("key3"))
+	store.WriteDirectly(batch)
+
+	store.Prepare()
+	log, err := store.RedoLog()
+	assert.NoError(t, err)
+	assert.NoError(t, store.Close())
+
+	store, _ = NewTestStoreWithEngine(t.Name(), false, engineName)
+	value, err := store.Get([]byte("key1"))
+	assert.NoError(t, err)
+	assert.Nil(t, value)
+
+	store.BeforeRecover(log)
+	assert.NoError(t, store.PatchRedoLog(log))
+	store.AfterRecover()
+	assert.NoError(t, store.Close())
+
+	store, _ = NewTestStoreWithEngine(t.Name(), false, engineName)
+	defer store.Close()
+	for _, key := range []string{"key1", "key2"} {
+		value, err := store.Get([]byte(key))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("value"+key[3:]), value)
+	}
+	has, err := store.Has([]byte("key3"))
+	assert.NoError(t, err)
+	assert.False(t, has)
+}
+
 const (
 	putFlag    = 1
 	deleteFlag = 2
@@ -1,12 +1,12 @@
 package chain_db
 
 import (
-	"encoding/json"
 	"errors"
 	"os"
 	"sync"
 
 	"github.com/vitelabs/go-vite/v2/common/db"
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
 	"github.com/vitelabs/go-vite/v2/common/db/xleveldb"
 	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/memdb"
 	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
@@ -28,13 +28,19 @@ type Store struct {
 	unconfirmedBatchs *UnconfirmedBatchs
 
 	dbDir string
-	db    *leveldb.DB
+	db    engine.Engine
 
 	afterRecoverFuncs []func()
 }
 
 func NewStore(dataDir string, name string) (*Store, error) {
-	diskStore, err := leveldb.OpenFile(dataDir, nil)
+	return NewStoreWithEngine(dataDir, name, "")
+}
+
+// NewStoreWithEngine opens the store with the db engine, an empty engine name opens the engine
+// which created the dataDir, see engine.Open
+func NewStoreWithEngine(dataDir string, name string, engineName string) (*Store, error) {
+	diskStore, err := engine.Open(engineName, dataDir)
 
 	if err != nil {
 		return nil, err
@@ -44,7 +50,7 @@ func NewStore(dataDir string, name string) (*Store, error) {
 
 }
 
-func NewStoreWithDb(dataDir string, name string, diskStore *leveldb.DB) (*Store, error) {
+func NewStoreWithDb(dataDir string, name string, diskStore engine.Engine) (*Store, error) {
 	id, _ := types.BytesToHash(crypto.Hash256([]byte(name)))
 
 	store := &Store{
@@ -74,7 +80,7 @@ func (store *Store) NewBatch() *leveldb.Batch {
 func (store *Store) Get(key []byte) ([]byte, error) {
 	mdb, seq := store.getSnapshotMemDb()
 
-	value, err := store.db.Get2(key, nil, mdb, seq)
+	value, err := engine.GetWithOverlay(store.db, key, mdb, seq)
 	if err != nil {
 		if err == leveldb.ErrNotFound {
 			return nil, nil
@@ -87,13 +93,13 @@ func (store *Store) Get(key []byte) ([]byte, error) {
 
 func (store *Store) GetOriginal(key []byte) ([]byte, error) {
 	mdb, seq := store.getSnapshotMemDb()
-	return store.db.Get2(key, nil, mdb, seq)
+	return engine.GetWithOverlay(store.db, key, mdb, seq)
 }
 
 func (store *Store) Has(key []byte) (bool, error) {
 	mdb, seq := store.getSnapshotMemDb()
 
-	_, err := store.db.Get2(key, nil, mdb, seq)
+	_, err := engine.GetWithOverlay(store.db, key, mdb, seq)
 
 	if err != nil {
 		if err == leveldb.ErrNotFound {
@@ -109,7 +115,7 @@ func (store *Store) Has(key []byte) (bool, error) {
 func (store *Store) NewIterator(slice *util.Range) interfaces.StorageIterator {
 	mdb, seq := store.getSnapshotMemDb()
 
-	return store.db.NewIterator2(slice, nil, mdb, seq)
+	return engine.NewIteratorWithOverlay(store.db, slice, mdb, seq)
 }
 
 func (store *Store) Close() error {
@@ -155,14 +161,6 @@ func (store *Store) GetStatus() []interfaces.DBStatus {
 		size += store.snapshotBatch.Size()
 	}
 
-	s := &leveldb.DBStats{}
-	store.db.Stats(s)
-
-	status, err := json.Marshal(s)
-	if err != nil {
-		status = []byte("Error:" + err.Error())
-	}
-
 	return []interfaces.DBStatus{{
 		Name:   "mem",
 		Count:  uint64(count),
@@ -172,7 +170,7 @@ func (store *Store) GetStatus() []interfaces.DBStatus {
 		Name:   "levelDB",
 		Count:  0,
 		Size:   0,
-		Status: string(status),
+		Status: store.db.Stats(),
 	}}
 }
 
@@ -188,3 +186,8 @@ func (store *Store) getSnapshotMemDb() (*memdb.DB, uint64) {
 func (store *Store) putMemDb(batch *leveldb.Batch) {
 	batch.Replay(store.memDb)
 }
+
+// GetSnapshot returns a snapshot of the data on disk, the data in memory is not included.
+func (store *Store) GetSnapshot() (engine.Snapshot, error) {
+	return store.db.GetSnapshot()
+}
@@ -21,13 +21,17 @@ import (
 )
 
 func NewTestStore(dirName string, clear bool) (*Store, string) {
+	return NewTestStoreWithEngine(dirName, clear, "")
+}
+
+func NewTestStoreWithEngine(dirName string, clear bool, engineName string) (*Store, string) {
 	tempDir := path.Join(test_tools.DefaultDataDir(), dirName)
 	fmt.Printf("tempDir: %s\n", tempDir)
 	if clear {
 		os.RemoveAll(tempDir)
 	}
 	dataDir := path.Join(tempDir, "test_store")
-	store, err := NewStore(dataDir, "test_store")
+	store, err := NewStoreWithEngine(dataDir, "test_store", engineName)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,80 @@
+package chain_file_manager
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+)
+
+// FileIds returns the ids of the files on disk in ascending order
+func (fm *FileManager) FileIds() ([]uint64, error) {
+	allFile, err := ioutil.ReadDir(fm.fdSet.dirName)
+	if err != nil {
+		return nil, fmt.Errorf("ioutil.ReadDir failed, error is %s, dirName is %s", err.Error(), fm.fdSet.dirName)
+	}
+
+	var ids []uint64
+	for _, file := range allFile {
+		filename := file.Name()
+		if !fm.fdSet.isCorrectFile(filename) {
+			continue
+		}
+
+		fileId, err := fm.fdSet.filenameToFileId(filename)
+		if err != nil {
+			continue
+		}
+		ids = append(ids, fileId)
+	}
+
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+// FileName returns the name of the file in the directory of the file manager
+func (fm *FileManager) FileName(fileId uint64) string {
+	return path.Base(fm.fdSet.fileIdToAbsoluteFilename(fileId))
+}
+
+// LinkFile hard-links the file to dir, returns false if the file can't be linked,
+// e.g. dir is on another file system
+func (fm *FileManager) LinkFile(fileId uint64, dir string) bool {
+	return os.Link(fm.fdSet.fileIdToAbsoluteFilename(fileId), path.Join(dir, fm.FileName(fileId))) == nil
+}
+
+// CopyFile copies the first size bytes of the file to dir, the whole file is copied if size is negative
+func (fm *FileManager) CopyFile(fileId uint64, dir string, size int64) (int64, error) {
+	src, err := os.Open(fm.fdSet.fileIdToAbsoluteFilename(fileId))
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	var reader io.Reader = src
+	if size >= 0 {
+		reader = io.LimitReader(src, size)
+	}
+
+	dst, err := os.OpenFile(path.Join(dir, fm.FileName(fileId)), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(dst, reader)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	if size >= 0 && n != size {
+		return 0, fmt.Errorf("copy %d bytes of file %d, expected %d bytes", n, fileId, size)
+	}
+	return n, nil
+}
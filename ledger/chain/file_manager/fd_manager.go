@@ -3,6 +3,7 @@ package chain_file_manager
 import (
 	"container/list"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path"
 	"strconv"
@@ -204,6 +205,39 @@ func (fdSet *fdManager) DiskDelete(highLocation *Location, lowLocation *Location
 	return nil
 }
 
+// DiskDeleteBefore remove the files whose id is lower than fileId, the cached files are skipped
+func (fdSet *fdManager) DiskDeleteBefore(fileId uint64) error {
+	fdSet.changeFdMu.RLock()
+	defer fdSet.changeFdMu.RUnlock()
+
+	allFile, err := ioutil.ReadDir(fdSet.dirName)
+	if err != nil {
+		return fmt.Errorf("ioutil.ReadDir failed, error is %s, dirName is %s", err.Error(), fdSet.dirName)
+	}
+
+	for _, file := range allFile {
+		filename := file.Name()
+		if !fdSet.isCorrectFile(filename) {
+			continue
+		}
+
+		id, err := fdSet.filenameToFileId(filename)
+		if err != nil || id >= fileId {
+			continue
+		}
+
+		if _, ok := fdSet.fileFdCache[id]; ok {
+			continue
+		}
+
+		if err := os.Remove(fdSet.fileIdToAbsoluteFilename(id)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (fdSet *fdManager) CreateNextFd() error {
 	fdSet.changeFdMu.Lock()
 	defer fdSet.changeFdMu.Unlock()
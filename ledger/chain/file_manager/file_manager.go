@@ -91,6 +91,11 @@ func (fm *FileManager) DeleteTo(location *Location) error {
 	return nil
 }
 
+// DeleteFilesBefore delete the files before fileId from disk, used by pruning the old blocks
+func (fm *FileManager) DeleteFilesBefore(fileId uint64) error {
+	return fm.fdSet.DiskDeleteBefore(fileId)
+}
+
 func (fm *FileManager) Flush(startLocation *Location, targetLocation *Location, buf []byte) error {
 	// flush
 	flushLocation := NewLocation(startLocation.FileId, startLocation.Offset)
@@ -154,6 +154,56 @@ func (flusher *Flusher) Flush() {
 	flusher.flush()
 }
 
+// FlushAndRun flushes synchronously and runs fn before the write is unlocked,
+// so fn can read the stores on disk that contain exactly the inserted snapshot blocks.
+func (flusher *Flusher) FlushAndRun(fn func() error) error {
+	flusher.flushingMu.Lock()
+	defer flusher.flushingMu.Unlock()
+
+	flusher.mu.Lock()
+	defer flusher.mu.Unlock()
+
+	status := atomic.LoadInt32(&flusher.flusherStatus)
+	if status == aborted {
+		return errors.New("flusher is aborted")
+	}
+
+	for _, store := range flusher.storeList {
+		store.Prepare()
+	}
+
+	if err := flusher.writeRedoLog(); err != nil {
+		for _, store := range flusher.storeList {
+			store.CancelPrepare()
+		}
+		return err
+	}
+
+	if err := flusher.fd.Sync(); err != nil {
+		for _, store := range flusher.storeList {
+			store.CancelPrepare()
+		}
+		flusher.cleanRedoLog()
+		return err
+	}
+
+	commitErr := flusher.commit()
+
+	for _, store := range flusher.storeList {
+		store.AfterCommit()
+	}
+
+	if commitErr != nil {
+		if err := flusher.commitRedo(); err != nil {
+			panic(err)
+		}
+	}
+
+	flusher.cleanRedoLog()
+
+	return fn()
+}
+
 func (flusher *Flusher) Recover() error {
 	flusher.mu.Lock()
 	defer flusher.mu.Unlock()
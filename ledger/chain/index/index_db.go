@@ -28,9 +28,9 @@ type IndexDB struct {
 	log log15.Logger
 }
 
-func NewIndexDB(chainDir string) (*IndexDB, error) {
+func NewIndexDB(chainDir string, engineName string) (*IndexDB, error) {
 
-	store, err := chain_db.NewStore(path.Join(chainDir, "index"), "indexDb")
+	store, err := chain_db.NewStoreWithEngine(path.Join(chainDir, "index"), "indexDb", engineName)
 	if err != nil {
 		return nil, err
 	}
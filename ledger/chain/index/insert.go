@@ -161,3 +161,10 @@ func (iDB *IndexDB) insertReceiveInfo(batch interfaces.Batch, sendBlockHash type
 	iDB.cache.Set(key.String(), value)
 	batch.Put(key.Bytes(), value)
 }
+
+// WriteRaw writes raw key-values into the snapshot batch directly, used by importing a state snapshot.
+// The cache is reset since the written keys may be cached.
+func (iDB *IndexDB) WriteRaw(batch *leveldb.Batch) {
+	iDB.store.WriteDirectly(batch)
+	iDB.cache.Reset()
+}
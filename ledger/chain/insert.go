@@ -63,6 +63,10 @@ func (c *chain) InsertSnapshotBlock(snapshotBlock *ledger.SnapshotBlock) ([]*led
 
 	c.cache.ResetUnconfirmedQuotas(c.GetAllUnconfirmedBlocks())
 
+	c.exportStateSnapshotAt(snapshotBlock)
+
+	c.pruneAt(snapshotBlock)
+
 	// only trigger
 	return invalidBlocks, nil
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/syndtr/goleveldb/leveldb"
 
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
 	"github.com/vitelabs/go-vite/v2/common/types"
 	"github.com/vitelabs/go-vite/v2/interfaces"
 	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
@@ -237,6 +238,12 @@ type Chain interface {
 
 	GetStakeBeneficialAmount(addr types.Address) (*big.Int, error)
 
+	// GetGovernanceParam returns the approved value of a governance param taking effect at the snapshot height
+	GetGovernanceParam(id uint8, sbHeight uint64) (*big.Int, error)
+
+	// GetAccountPolicy returns the policy of the account registered in the account policy contract
+	GetAccountPolicy(addr types.Address, sbHeight uint64) (*types.AccountPolicy, error)
+
 	// total
 	GetStakeQuota(addr types.Address) (*big.Int, *types.Quota, error)
 
@@ -260,6 +267,16 @@ type Chain interface {
 
 	GetSyncCache() interfaces.SyncCache
 
+	// ====== State sync ======
+	ExportStateSnapshot(dir string) (*interfaces.StateSnapshotMeta, error)
+
+	ImportStateSnapshot(filename string, meta *interfaces.StateSnapshotMeta) error
+
+	StateSnapshotDir() string
+
+	// ====== Checkpoint ======
+	CreateCheckpoint(dir string) (*interfaces.CheckpointManifest, error)
+
 	// ====== OnRoad ======
 	LoadOnRoadRange(gid types.Gid, fn interfaces.LoadOnroadFn) error
 
@@ -282,7 +299,7 @@ type Chain interface {
 
 	NewDb(dirName string) (*leveldb.DB, error)
 
-	PrepareOnroadDb() (*leveldb.DB, error)
+	PrepareOnroadDb() (engine.Engine, error)
 
 	Plugins() *chain_plugins.Plugins
 
@@ -300,6 +317,8 @@ type Chain interface {
 
 	QueryGenesisCheckSum() (*types.Hash, error)
 
+	QueryStateImporting() (bool, error)
+
 	// ====== Check ======
 	CheckRedo() error
 
@@ -307,5 +326,7 @@ type Chain interface {
 
 	CheckOnRoad() error
 
+	CheckFull() (*FullCheckReport, error)
+
 	GetStatus() []interfaces.DBStatus
 }
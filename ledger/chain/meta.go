@@ -8,6 +8,9 @@ import (
 
 const (
 	GenesisKey = byte(0)
+
+	// exists while a state snapshot is importing
+	StateImportingKey = byte(1)
 )
 
 func (c *chain) WriteGenesisCheckSum(hash types.Hash) error {
@@ -35,3 +38,7 @@ func (c *chain) QueryGenesisCheckSum() (*types.Hash, error) {
 	}
 	return &checkSum, nil
 }
+
+func (c *chain) QueryStateImporting() (bool, error) {
+	return c.metaDB.Has([]byte{StateImportingKey}, nil)
+}
@@ -0,0 +1,263 @@
+package chain_plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/v2/common/helper"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_db "github.com/vitelabs/go-vite/v2/ledger/chain/db"
+)
+
+// AccountSummary is the aggregates of the confirmed account blocks of an account. The account blocks have no
+// timestamp, the times are the timestamps of the snapshot blocks which confirm the account blocks
+type AccountSummary struct {
+	FirstHeight       uint64
+	FirstTime         int64
+	LastHeight        uint64
+	LastTime          int64
+	Counterparties    uint64 // count of the distinct addresses sent to or received from
+	ContractCreations uint64
+
+	Tokens []*AccountTokenSummary `json:",omitempty"`
+}
+
+// AccountTokenSummary is the transfers of a token of an account
+type AccountTokenSummary struct {
+	TokenId      types.TokenTypeId
+	SendCount    uint64
+	ReceiveCount uint64
+	In           *big.Int
+	Out          *big.Int
+}
+
+// AccountSummaries maintains the summary of each account. Only the confirmed blocks are counted,
+// the changes of each snapshot block are journaled so that they can be reverted on rollback.
+type AccountSummaries struct {
+	store *chain_db.Store
+	chain Chain
+}
+
+func newAccountSummaries(store *chain_db.Store, chain Chain) Plugin {
+	return &AccountSummaries{
+		store: store,
+		chain: chain,
+	}
+}
+
+func (as *AccountSummaries) SetStore(store *chain_db.Store) {
+	as.store = store
+}
+
+func (as *AccountSummaries) InsertAccountBlock(batch *leveldb.Batch, accountBlock *ledger.AccountBlock) error {
+	return nil
+}
+
+func (as *AccountSummaries) InsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	writer := &accountSummaryWriter{
+		journalWriter: newJournalWriter(as.store, batch, AccountSummaryUndoKeyPrefix, snapshotBlock.Height),
+		chain:         as.chain,
+		timestamp:     snapshotBlock.Timestamp.Unix(),
+	}
+	for _, block := range confirmedBlocks {
+		if err := writer.handleBlock(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteAccountBlocks does nothing, the unconfirmed blocks are not counted
+func (as *AccountSummaries) DeleteAccountBlocks(batch *leveldb.Batch, accountBlocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (as *AccountSummaries) DeleteSnapshotBlocks(batch *leveldb.Batch, chunks []*ledger.SnapshotChunk) error {
+	return revertJournal(as.store, batch, AccountSummaryUndoKeyPrefix, chunks)
+}
+
+func (as *AccountSummaries) RemoveNewUnconfirmed(batch *leveldb.Batch, allUnconfirmedBlocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+// GetAccountSummary returns the summary of the account with the summary of each token, nil if the account
+// has no confirmed block
+func (as *AccountSummaries) GetAccountSummary(addr types.Address) (*AccountSummary, error) {
+	value, err := as.store.Get(createAccountSummaryKey(addr))
+	if err != nil || value == nil {
+		return nil, err
+	}
+	summary := &AccountSummary{}
+	if err := json.Unmarshal(value, summary); err != nil {
+		return nil, err
+	}
+
+	iter := as.store.NewIterator(util.BytesPrefix(createAccountTokenSummaryPrefixKey(addr)))
+	defer iter.Release()
+
+	summary.Tokens = make([]*AccountTokenSummary, 0)
+	for iter.Next() {
+		tokenSummary := &AccountTokenSummary{}
+		if err := json.Unmarshal(iter.Value(), tokenSummary); err != nil {
+			return nil, err
+		}
+		summary.Tokens = append(summary.Tokens, tokenSummary)
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// accountSummaryWriter applies the account blocks confirmed by a snapshot block
+type accountSummaryWriter struct {
+	*journalWriter
+
+	chain     Chain
+	timestamp int64
+}
+
+func (w *accountSummaryWriter) handleBlock(block *ledger.AccountBlock) error {
+	summary, err := w.getSummary(block.AccountAddress)
+	if err != nil {
+		return err
+	}
+	if summary == nil {
+		summary = &AccountSummary{
+			FirstHeight: block.Height,
+			FirstTime:   w.timestamp,
+		}
+	}
+	if block.Height >= summary.LastHeight {
+		summary.LastHeight = block.Height
+		summary.LastTime = w.timestamp
+	}
+
+	if block.IsSendBlock() {
+		if err := w.handleSend(summary, block.AccountAddress, block); err != nil {
+			return err
+		}
+		return w.setSummary(block.AccountAddress, summary)
+	}
+
+	// the genesis receive blocks have no send block
+	if block.BlockType != ledger.BlockTypeGenesisReceive {
+		sendBlock, err := w.chain.GetAccountBlockByHash(block.FromBlockHash)
+		if err != nil {
+			return fmt.Errorf("w.chain.GetAccountBlockByHash failed, block is %s. Error: %s", block.FromBlockHash, err)
+		}
+		if sendBlock == nil {
+			return fmt.Errorf("send block %s is nil", block.FromBlockHash)
+		}
+
+		if err := w.addTokenTransfer(block.AccountAddress, sendBlock.TokenId, false, sendBlock.Amount); err != nil {
+			return err
+		}
+		if err := w.addCounterparty(summary, block.AccountAddress, sendBlock.AccountAddress); err != nil {
+			return err
+		}
+	}
+
+	for _, sendBlock := range block.SendBlockList {
+		if err := w.handleSend(summary, block.AccountAddress, sendBlock); err != nil {
+			return err
+		}
+	}
+	return w.setSummary(block.AccountAddress, summary)
+}
+
+func (w *accountSummaryWriter) handleSend(summary *AccountSummary, addr types.Address, sendBlock *ledger.AccountBlock) error {
+	if sendBlock.BlockType == ledger.BlockTypeSendCreate {
+		summary.ContractCreations++
+	}
+	if err := w.addTokenTransfer(addr, sendBlock.TokenId, true, sendBlock.Amount); err != nil {
+		return err
+	}
+	return w.addCounterparty(summary, addr, sendBlock.ToAddress)
+}
+
+func (w *accountSummaryWriter) addTokenTransfer(addr types.Address, tokenId types.TokenTypeId, send bool, amount *big.Int) error {
+	key := createAccountTokenSummaryKey(addr, tokenId)
+	value, err := w.get(key)
+	if err != nil {
+		return err
+	}
+	tokenSummary := &AccountTokenSummary{
+		TokenId: tokenId,
+		In:      big.NewInt(0),
+		Out:     big.NewInt(0),
+	}
+	if value != nil {
+		if err := json.Unmarshal(value, tokenSummary); err != nil {
+			return err
+		}
+	}
+
+	if send {
+		tokenSummary.SendCount++
+		if amount != nil {
+			tokenSummary.Out.Add(tokenSummary.Out, amount)
+		}
+	} else {
+		tokenSummary.ReceiveCount++
+		if amount != nil {
+			tokenSummary.In.Add(tokenSummary.In, amount)
+		}
+	}
+
+	data, err := json.Marshal(tokenSummary)
+	if err != nil {
+		return err
+	}
+	return w.set(key, data)
+}
+
+func (w *accountSummaryWriter) addCounterparty(summary *AccountSummary, addr types.Address, counterparty types.Address) error {
+	key := createAccountCounterpartyKey(addr, counterparty)
+	value, err := w.get(key)
+	if err != nil || value != nil {
+		return err
+	}
+	summary.Counterparties++
+	return w.set(key, []byte{1})
+}
+
+func (w *accountSummaryWriter) getSummary(addr types.Address) (*AccountSummary, error) {
+	value, err := w.get(createAccountSummaryKey(addr))
+	if err != nil || value == nil {
+		return nil, err
+	}
+	summary := &AccountSummary{}
+	if err := json.Unmarshal(value, summary); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+func (w *accountSummaryWriter) setSummary(addr types.Address, summary *AccountSummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+	return w.set(createAccountSummaryKey(addr), data)
+}
+
+func createAccountSummaryKey(addr types.Address) []byte {
+	return helper.JoinBytes([]byte{AccountSummaryKeyPrefix}, addr.Bytes())
+}
+
+func createAccountTokenSummaryPrefixKey(addr types.Address) []byte {
+	return helper.JoinBytes([]byte{AccountTokenSummaryKeyPrefix}, addr.Bytes())
+}
+
+func createAccountTokenSummaryKey(addr types.Address, tokenId types.TokenTypeId) []byte {
+	return helper.JoinBytes(createAccountTokenSummaryPrefixKey(addr), tokenId.Bytes())
+}
+
+func createAccountCounterpartyKey(addr types.Address, counterparty types.Address) []byte {
+	return helper.JoinBytes([]byte{AccountCounterpartyKeyPrefix}, addr.Bytes(), counterparty.Bytes())
+}
@@ -0,0 +1,127 @@
+package chain_plugins
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_db "github.com/vitelabs/go-vite/v2/ledger/chain/db"
+)
+
+type accountSummaryTestChain struct {
+	Chain
+	blocks map[types.Hash]*ledger.AccountBlock
+}
+
+func (c *accountSummaryTestChain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	return c.blocks[blockHash], nil
+}
+
+func TestAccountSummaries(t *testing.T) {
+	store, err := chain_db.NewStore(t.TempDir(), "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	chain := &accountSummaryTestChain{blocks: make(map[types.Hash]*ledger.AccountBlock)}
+	as := newAccountSummaries(store, chain).(*AccountSummaries)
+
+	addrA := types.AddressDexFund
+	addrB := types.AddressDexTrade
+	contract := types.AddressAsset
+	tokenId := ledger.ViteTokenId
+
+	seq := int64(0)
+	newBlock := func(blockType byte, addr types.Address, height uint64) *ledger.AccountBlock {
+		seq++
+		block := &ledger.AccountBlock{
+			BlockType:      blockType,
+			AccountAddress: addr,
+			Height:         height,
+			Hash:           types.DataHash(big.NewInt(seq).Bytes()),
+			TokenId:        tokenId,
+			Amount:         big.NewInt(0),
+		}
+		chain.blocks[block.Hash] = block
+		return block
+	}
+	send := func(from types.Address, height uint64, to types.Address, amount int64) *ledger.AccountBlock {
+		block := newBlock(ledger.BlockTypeSendCall, from, height)
+		block.ToAddress = to
+		block.Amount = big.NewInt(amount)
+		return block
+	}
+	receive := func(addr types.Address, height uint64, sendBlock *ledger.AccountBlock) *ledger.AccountBlock {
+		block := newBlock(ledger.BlockTypeReceive, addr, height)
+		block.FromBlockHash = sendBlock.Hash
+		return block
+	}
+	insert := func(height uint64, blocks ...*ledger.AccountBlock) *ledger.SnapshotChunk {
+		timestamp := time.Unix(int64(height)*100, 0)
+		chunk := &ledger.SnapshotChunk{
+			SnapshotBlock: &ledger.SnapshotBlock{Height: height, Timestamp: &timestamp},
+			AccountBlocks: blocks,
+		}
+		for _, block := range blocks {
+			store.WriteAccountBlock(store.NewBatch(), block)
+		}
+		batch := store.NewBatch()
+		if err := as.InsertSnapshotBlock(batch, chunk.SnapshotBlock, chunk.AccountBlocks); err != nil {
+			t.Fatal(err)
+		}
+		store.WriteSnapshot(batch, chunk.AccountBlocks)
+		return chunk
+	}
+
+	sendA1 := send(addrA, 1, addrB, 100)
+	insert(10, sendA1, receive(addrB, 1, sendA1))
+
+	create := newBlock(ledger.BlockTypeSendCreate, addrA, 2)
+	create.ToAddress = contract
+	sendA3 := send(addrA, 3, addrB, 50)
+	sendB2 := send(addrB, 2, addrA, 30)
+	chunk := insert(11, create, sendA3, receive(addrB, 3, sendA3), sendB2, receive(addrA, 4, sendB2))
+
+	summary, err := as.GetAccountSummary(addrA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.FirstHeight != 1 || summary.FirstTime != 1000 || summary.LastHeight != 4 || summary.LastTime != 1100 ||
+		summary.Counterparties != 2 || summary.ContractCreations != 1 || len(summary.Tokens) != 1 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+	if token := summary.Tokens[0]; token.SendCount != 3 || token.ReceiveCount != 1 || token.Out.Int64() != 150 || token.In.Int64() != 30 {
+		t.Fatalf("unexpected token summary %+v", token)
+	}
+
+	batch := store.NewBatch()
+	if err := as.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{chunk}); err != nil {
+		t.Fatal(err)
+	}
+	store.RollbackSnapshot(batch)
+
+	summary, err = as.GetAccountSummary(addrA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.LastHeight != 1 || summary.LastTime != 1000 || summary.Counterparties != 1 || summary.ContractCreations != 0 {
+		t.Fatalf("unexpected summary after rollback %+v", summary)
+	}
+	if token := summary.Tokens[0]; token.SendCount != 1 || token.ReceiveCount != 0 || token.Out.Int64() != 100 || token.In.Int64() != 0 {
+		t.Fatalf("unexpected token summary after rollback %+v", token)
+	}
+	summary, err = as.GetAccountSummary(addrB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.LastHeight != 1 || summary.Counterparties != 1 || summary.Tokens[0].In.Int64() != 100 {
+		t.Fatalf("unexpected summary of B after rollback %+v", summary)
+	}
+
+	// the contract has no account block
+	if summary, err := as.GetAccountSummary(contract); err != nil || summary != nil {
+		t.Fatalf("unexpected summary %+v, err %v", summary, err)
+	}
+}
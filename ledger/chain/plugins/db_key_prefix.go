@@ -6,6 +6,30 @@ const (
 	OnRoadInfoKeyPrefix = byte(1)
 
 	DiffTokenHash = byte(2)
+
+	DexDepthKeyPrefix = byte(3)
+
+	DexTradeKeyPrefix = byte(4)
+
+	DexKlineKeyPrefix = byte(5)
+
+	DexMarketUndoKeyPrefix = byte(6)
+
+	AccountSummaryKeyPrefix = byte(7)
+
+	AccountTokenSummaryKeyPrefix = byte(8)
+
+	AccountCounterpartyKeyPrefix = byte(9)
+
+	AccountSummaryUndoKeyPrefix = byte(10)
+
+	TokenHolderKeyPrefix = byte(11)
+
+	TokenHolderBalanceKeyPrefix = byte(12)
+
+	TokenHolderStatsKeyPrefix = byte(13)
+
+	TokenHolderUndoKeyPrefix = byte(14)
 )
 
 func CreateOnRoadInfoKey(addr *types.Address, tId *types.TokenTypeId) []byte {
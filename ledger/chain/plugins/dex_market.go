@@ -0,0 +1,370 @@
+package chain_plugins
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/golang/protobuf/proto"
+
+	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/v2/common/helper"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_db "github.com/vitelabs/go-vite/v2/ledger/chain/db"
+	chain_utils "github.com/vitelabs/go-vite/v2/ledger/chain/utils"
+	"github.com/vitelabs/go-vite/v2/vm/contracts/dex"
+	dexproto "github.com/vitelabs/go-vite/v2/vm/contracts/dex/proto"
+)
+
+const (
+	dexMarketIdSize    = 3
+	dexDepthAmountSize = 32
+)
+
+var (
+	dexNewOrderTopic    = (&dex.NewOrderEvent{}).GetTopicId()
+	dexOrderUpdateTopic = (&dex.OrderUpdateEvent{}).GetTopicId()
+	dexTxTopic          = (&dex.TransactionEvent{}).GetTopicId()
+
+	// DexKlineIntervals are the names of the candle intervals kept by the dex market plugin,
+	// the index of an interval is part of the db key, so new intervals can only be appended
+	DexKlineIntervals = []string{"minute", "minute5", "minute15", "minute30", "hour", "hour4", "day", "week"}
+
+	dexKlineIntervalSeconds = []int64{60, 300, 900, 1800, 3600, 14400, 86400, 604800}
+)
+
+// DexDepthLevel is the total remaining quantity of the open orders at a price
+type DexDepthLevel struct {
+	Price      []byte
+	Quantity   *big.Int
+	OrderCount int
+}
+
+// DexKline is the candle of a market in an interval which starts at Time
+type DexKline struct {
+	Time   int64
+	Open   []byte
+	High   []byte
+	Low    []byte
+	Close  []byte
+	Volume *big.Int
+	Amount *big.Int
+	Count  uint64
+}
+
+// DexMarket aggregates the order, update and transaction logs of the dex trade contract into
+// order book depth, candles and trade history per market. Only the confirmed blocks are indexed,
+// the changes of each snapshot block are journaled so that they can be reverted on rollback.
+type DexMarket struct {
+	store *chain_db.Store
+	chain Chain
+}
+
+func newDexMarket(store *chain_db.Store, chain Chain) Plugin {
+	return &DexMarket{
+		store: store,
+		chain: chain,
+	}
+}
+
+func (dm *DexMarket) SetStore(store *chain_db.Store) {
+	dm.store = store
+}
+
+func (dm *DexMarket) InsertAccountBlock(batch *leveldb.Batch, accountBlock *ledger.AccountBlock) error {
+	return nil
+}
+
+func (dm *DexMarket) InsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	writer := newDexMarketWriter(dm.store, batch, snapshotBlock)
+	for _, block := range confirmedBlocks {
+		if block.AccountAddress != types.AddressDexTrade || block.LogHash == nil {
+			continue
+		}
+		logList, err := dm.chain.GetVmLogList(block.LogHash)
+		if err != nil {
+			return fmt.Errorf("dm.chain.GetVmLogList failed, block is %s. Error: %s", block.Hash, err)
+		}
+		for _, log := range logList {
+			if len(log.Topics) == 0 {
+				continue
+			}
+			if err := writer.handleLog(log); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (dm *DexMarket) DeleteAccountBlocks(batch *leveldb.Batch, accountBlocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (dm *DexMarket) DeleteSnapshotBlocks(batch *leveldb.Batch, chunks []*ledger.SnapshotChunk) error {
+	return revertJournal(dm.store, batch, DexMarketUndoKeyPrefix, chunks)
+}
+
+func (dm *DexMarket) RemoveNewUnconfirmed(batch *leveldb.Batch, allUnconfirmedBlocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+// GetDepth returns at most limit price levels of each side of the order book of a market,
+// the best price comes first
+func (dm *DexMarket) GetDepth(marketId int32, limit int) (asks []*DexDepthLevel, bids []*DexDepthLevel, err error) {
+	if asks, err = dm.getDepthSide(marketId, true, limit); err != nil {
+		return nil, nil, err
+	}
+	if bids, err = dm.getDepthSide(marketId, false, limit); err != nil {
+		return nil, nil, err
+	}
+	return asks, bids, nil
+}
+
+func (dm *DexMarket) getDepthSide(marketId int32, side bool, limit int) ([]*DexDepthLevel, error) {
+	// the price in the order id of a buy order is bitwise inverted, so both sides iterate from the best price
+	iter := dm.store.NewIterator(util.BytesPrefix(createDexDepthSidePrefixKey(marketId, side)))
+	defer iter.Release()
+
+	levels := make([]*DexDepthLevel, 0)
+	for iter.Next() {
+		_, _, price, _, err := dex.DeComposeOrderId(iter.Key()[1:])
+		if err != nil {
+			return nil, err
+		}
+		quantity, executed := parseDexDepthValue(iter.Value())
+		remaining := new(big.Int).Sub(quantity, executed)
+
+		if len(levels) > 0 && bytes.Equal(levels[len(levels)-1].Price, price) {
+			level := levels[len(levels)-1]
+			level.Quantity.Add(level.Quantity, remaining)
+			level.OrderCount++
+			continue
+		}
+		if len(levels) >= limit {
+			break
+		}
+		levels = append(levels, &DexDepthLevel{Price: price, Quantity: remaining, OrderCount: 1})
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// GetKlines returns at most limit candles of a market in the interval, which start between
+// startTime and endTime, in ascending order of time
+func (dm *DexMarket) GetKlines(marketId int32, interval int, startTime, endTime int64, limit int) ([]*DexKline, error) {
+	if interval < 0 || interval >= len(dexKlineIntervalSeconds) {
+		return nil, fmt.Errorf("unknown kline interval %d", interval)
+	}
+	if startTime < 0 || endTime < startTime {
+		return nil, fmt.Errorf("invalid time range [%d, %d]", startTime, endTime)
+	}
+	iter := dm.store.NewIterator(&util.Range{
+		Start: createDexKlineKey(marketId, interval, startTime),
+		Limit: createDexKlineKey(marketId, interval, endTime+1),
+	})
+	defer iter.Release()
+
+	klines := make([]*DexKline, 0)
+	for iter.Next() && len(klines) < limit {
+		kline := &DexKline{}
+		if err := json.Unmarshal(iter.Value(), kline); err != nil {
+			return nil, err
+		}
+		klines = append(klines, kline)
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+	return klines, nil
+}
+
+// GetTrades returns the latest limit transactions of a market, the newest comes first
+func (dm *DexMarket) GetTrades(marketId int32, limit int) ([]*dexproto.Transaction, error) {
+	iter := dm.store.NewIterator(util.BytesPrefix(createDexTradePrefixKey(marketId)))
+	defer iter.Release()
+
+	trades := make([]*dexproto.Transaction, 0)
+	for ok := iter.Last(); ok && len(trades) < limit; ok = iter.Prev() {
+		trade := &dexproto.Transaction{}
+		if err := proto.Unmarshal(iter.Value(), trade); err != nil {
+			return nil, err
+		}
+		trades = append(trades, trade)
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+	return trades, nil
+}
+
+// dexMarketWriter applies the logs confirmed by a snapshot block
+type dexMarketWriter struct {
+	*journalWriter
+
+	snapshotBlock *ledger.SnapshotBlock
+	tradeSeq      uint32
+}
+
+func newDexMarketWriter(store *chain_db.Store, batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock) *dexMarketWriter {
+	return &dexMarketWriter{
+		journalWriter: newJournalWriter(store, batch, DexMarketUndoKeyPrefix, snapshotBlock.Height),
+		snapshotBlock: snapshotBlock,
+	}
+}
+
+func (w *dexMarketWriter) handleLog(log *ledger.VmLog) error {
+	switch log.Topics[0] {
+	case dexNewOrderTopic:
+		event := &dex.NewOrderEvent{}
+		if err := event.FromBytes(log.Data); err != nil {
+			return err
+		}
+		return w.handleNewOrder(event.Order)
+	case dexOrderUpdateTopic:
+		event := &dex.OrderUpdateEvent{}
+		if err := event.FromBytes(log.Data); err != nil {
+			return err
+		}
+		return w.handleOrderUpdate(&event.OrderUpdateInfo)
+	case dexTxTopic:
+		event := &dex.TransactionEvent{}
+		if err := event.FromBytes(log.Data); err != nil {
+			return err
+		}
+		return w.handleTransaction(&event.Transaction)
+	}
+	return nil
+}
+
+func (w *dexMarketWriter) handleNewOrder(order *dexproto.Order) error {
+	if order == nil || len(order.Id) != dex.OrderIdBytesLength || order.Type == dex.Market ||
+		(order.Status != dex.Pending && order.Status != dex.PartialExecuted) {
+		return nil
+	}
+	return w.set(createDexDepthKey(order.Id), createDexDepthValue(order.Quantity, order.ExecutedQuantity))
+}
+
+func (w *dexMarketWriter) handleOrderUpdate(info *dexproto.OrderUpdateInfo) error {
+	if len(info.Id) != dex.OrderIdBytesLength {
+		return nil
+	}
+	key := createDexDepthKey(info.Id)
+	value, err := w.get(key)
+	if err != nil || value == nil {
+		return err
+	}
+	if info.Status != dex.Pending && info.Status != dex.PartialExecuted {
+		return w.set(key, nil)
+	}
+	quantity, _ := parseDexDepthValue(value)
+	return w.set(key, createDexDepthValue(quantity.Bytes(), info.ExecutedQuantity))
+}
+
+func (w *dexMarketWriter) handleTransaction(tx *dexproto.Transaction) error {
+	marketId, _, _, _, err := dex.DeComposeOrderId(tx.TakerId)
+	if err != nil {
+		return nil
+	}
+	if tx.Timestamp == 0 {
+		tx.Timestamp = w.snapshotBlock.Timestamp.Unix()
+	}
+	data, err := proto.Marshal(tx)
+	if err != nil {
+		return err
+	}
+	if err := w.set(createDexTradeKey(marketId, w.snapshotBlock.Height, w.tradeSeq), data); err != nil {
+		return err
+	}
+	w.tradeSeq++
+
+	for interval, seconds := range dexKlineIntervalSeconds {
+		if err := w.updateKline(marketId, interval, tx.Timestamp-tx.Timestamp%seconds, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *dexMarketWriter) updateKline(marketId int32, interval int, startTime int64, tx *dexproto.Transaction) error {
+	key := createDexKlineKey(marketId, interval, startTime)
+	value, err := w.get(key)
+	if err != nil {
+		return err
+	}
+	kline := &DexKline{}
+	if value == nil {
+		kline = &DexKline{
+			Time:   startTime,
+			Open:   tx.Price,
+			High:   tx.Price,
+			Low:    tx.Price,
+			Volume: big.NewInt(0),
+			Amount: big.NewInt(0),
+		}
+	} else if err := json.Unmarshal(value, kline); err != nil {
+		return err
+	}
+	if bytes.Compare(tx.Price, kline.High) > 0 {
+		kline.High = tx.Price
+	}
+	if bytes.Compare(tx.Price, kline.Low) < 0 {
+		kline.Low = tx.Price
+	}
+	kline.Close = tx.Price
+	kline.Volume.Add(kline.Volume, new(big.Int).SetBytes(tx.Quantity))
+	kline.Amount.Add(kline.Amount, new(big.Int).SetBytes(tx.Amount))
+	kline.Count++
+
+	data, err := json.Marshal(kline)
+	if err != nil {
+		return err
+	}
+	return w.set(key, data)
+}
+
+func dexMarketIdBytes(marketId int32) []byte {
+	idBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(idBytes, uint32(marketId))
+	return idBytes[4-dexMarketIdSize:]
+}
+
+func createDexDepthKey(orderId []byte) []byte {
+	return helper.JoinBytes([]byte{DexDepthKeyPrefix}, orderId)
+}
+
+func createDexDepthSidePrefixKey(marketId int32, side bool) []byte {
+	sideByte := byte(0)
+	if side {
+		sideByte = 1
+	}
+	return helper.JoinBytes([]byte{DexDepthKeyPrefix}, dexMarketIdBytes(marketId), []byte{sideByte})
+}
+
+func createDexDepthValue(quantity []byte, executedQuantity []byte) []byte {
+	return helper.JoinBytes(helper.LeftPadBytes(quantity, dexDepthAmountSize), helper.LeftPadBytes(executedQuantity, dexDepthAmountSize))
+}
+
+func parseDexDepthValue(value []byte) (quantity *big.Int, executedQuantity *big.Int) {
+	return new(big.Int).SetBytes(value[:dexDepthAmountSize]), new(big.Int).SetBytes(value[dexDepthAmountSize:])
+}
+
+func createDexTradeKey(marketId int32, sbHeight uint64, seq uint32) []byte {
+	seqBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(seqBytes, seq)
+	return helper.JoinBytes(createDexTradePrefixKey(marketId), chain_utils.Uint64ToBytes(sbHeight), seqBytes)
+}
+
+func createDexTradePrefixKey(marketId int32) []byte {
+	return helper.JoinBytes([]byte{DexTradeKeyPrefix}, dexMarketIdBytes(marketId))
+}
+
+func createDexKlineKey(marketId int32, interval int, startTime int64) []byte {
+	return helper.JoinBytes([]byte{DexKlineKeyPrefix}, dexMarketIdBytes(marketId), []byte{byte(interval)}, chain_utils.Uint64ToBytes(uint64(startTime)))
+}
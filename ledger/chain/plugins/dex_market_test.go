@@ -0,0 +1,124 @@
+package chain_plugins
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_db "github.com/vitelabs/go-vite/v2/ledger/chain/db"
+	"github.com/vitelabs/go-vite/v2/vm/contracts/dex"
+	dexproto "github.com/vitelabs/go-vite/v2/vm/contracts/dex/proto"
+)
+
+type dexMarketTestChain struct {
+	Chain
+	logs map[types.Hash]ledger.VmLogList
+}
+
+func (c *dexMarketTestChain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
+	return c.logs[*logListHash], nil
+}
+
+func dexTestOrderId(marketId int32, side bool, price string, serial byte) []byte {
+	id := make([]byte, dex.OrderIdBytesLength)
+	copy(id[:3], dexMarketIdBytes(marketId))
+	priceBytes := dex.PriceToBytes(price)
+	if side {
+		id[3] = 1
+	} else {
+		dex.BitwiseNotBytes(priceBytes)
+	}
+	copy(id[4:14], priceBytes)
+	id[21] = serial
+	return id
+}
+
+func dexTestLog(topic types.Hash, msg proto.Message) *ledger.VmLog {
+	data, _ := proto.Marshal(msg)
+	return &ledger.VmLog{Topics: []types.Hash{topic}, Data: data}
+}
+
+func TestDexMarket(t *testing.T) {
+	store, err := chain_db.NewStore(t.TempDir(), "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	chain := &dexMarketTestChain{logs: make(map[types.Hash]ledger.VmLogList)}
+	dm := newDexMarket(store, chain).(*DexMarket)
+
+	insert := func(height uint64, logs ...*ledger.VmLog) *ledger.SnapshotChunk {
+		logHash := types.DataHash(big.NewInt(int64(height)).Bytes())
+		chain.logs[logHash] = logs
+		chunk := &ledger.SnapshotChunk{
+			SnapshotBlock: &ledger.SnapshotBlock{Height: height, Timestamp: &time.Time{}},
+			AccountBlocks: []*ledger.AccountBlock{{AccountAddress: types.AddressDexTrade, Hash: logHash, LogHash: &logHash}},
+		}
+		store.WriteAccountBlock(store.NewBatch(), chunk.AccountBlocks[0])
+		batch := store.NewBatch()
+		if err := dm.InsertSnapshotBlock(batch, chunk.SnapshotBlock, chunk.AccountBlocks); err != nil {
+			t.Fatal(err)
+		}
+		store.WriteSnapshot(batch, chunk.AccountBlocks)
+		return chunk
+	}
+
+	sell1 := dexTestOrderId(1, true, "1.5", 1)
+	sell2 := dexTestOrderId(1, true, "1.5", 2)
+	sell3 := dexTestOrderId(1, true, "2", 3)
+	buy1 := dexTestOrderId(1, false, "1", 4)
+	buy2 := dexTestOrderId(1, false, "1.2", 5)
+	newOrder := func(id []byte, quantity int64) *ledger.VmLog {
+		return dexTestLog(dexNewOrderTopic, &dexproto.NewOrderInfo{Order: &dexproto.Order{Id: id, Quantity: big.NewInt(quantity).Bytes()}})
+	}
+
+	insert(10, newOrder(sell1, 100), newOrder(sell2, 50), newOrder(sell3, 10), newOrder(buy1, 30))
+	chunk := insert(11,
+		newOrder(buy2, 20),
+		dexTestLog(dexOrderUpdateTopic, &dexproto.OrderUpdateInfo{Id: sell1, Status: dex.PartialExecuted, ExecutedQuantity: big.NewInt(40).Bytes()}),
+		dexTestLog(dexOrderUpdateTopic, &dexproto.OrderUpdateInfo{Id: sell3, Status: dex.Cancelled}),
+		dexTestLog(dexTxTopic, &dexproto.Transaction{TakerId: buy1, MakerId: sell1, Price: dex.PriceToBytes("1.5"), Quantity: big.NewInt(40).Bytes(), Amount: big.NewInt(60).Bytes(), Timestamp: 1000}))
+
+	asks, bids, err := dm.GetDepth(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(asks) != 1 || dex.BytesToPrice(asks[0].Price) != "1.5" || asks[0].Quantity.Int64() != 110 || asks[0].OrderCount != 2 {
+		t.Fatalf("unexpected asks %+v", asks)
+	}
+	if len(bids) != 2 || dex.BytesToPrice(bids[0].Price) != "1.2" || dex.BytesToPrice(bids[1].Price) != "1" {
+		t.Fatalf("unexpected bids %+v", bids)
+	}
+	trades, err := dm.GetTrades(1, 10)
+	if err != nil || len(trades) != 1 {
+		t.Fatalf("unexpected trades %v, err %v", trades, err)
+	}
+	klines, err := dm.GetKlines(1, 0, 0, 2000, 10)
+	if err != nil || len(klines) != 1 || klines[0].Time != 960 || klines[0].Volume.Int64() != 40 || klines[0].Count != 1 {
+		t.Fatalf("unexpected klines %+v, err %v", klines, err)
+	}
+
+	batch := store.NewBatch()
+	if err := dm.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{chunk}); err != nil {
+		t.Fatal(err)
+	}
+	store.RollbackSnapshot(batch)
+
+	asks, bids, err = dm.GetDepth(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(asks) != 2 || asks[0].Quantity.Int64() != 150 || asks[1].Quantity.Int64() != 10 || len(bids) != 1 {
+		t.Fatalf("unexpected depth after rollback, asks %+v, bids %+v", asks, bids)
+	}
+	if trades, _ := dm.GetTrades(1, 10); len(trades) != 0 {
+		t.Fatalf("unexpected trades after rollback %v", trades)
+	}
+	if klines, _ := dm.GetKlines(1, 0, 0, 2000, 10); len(klines) != 0 {
+		t.Fatalf("unexpected klines after rollback %v", klines)
+	}
+}
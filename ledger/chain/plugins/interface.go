@@ -1,6 +1,8 @@
 package chain_plugins
 
 import (
+	"math/big"
+
 	"github.com/vitelabs/go-vite/v2/common/db/xleveldb"
 	"github.com/vitelabs/go-vite/v2/common/types"
 	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
@@ -15,6 +17,8 @@ type Chain interface {
 	GetSubLedgerAfterHeight(height uint64) ([]*ledger.SnapshotChunk, error)
 	GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error)
 	GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error)
+	GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error)
+	GetConfirmedBalanceList(addrList []types.Address, tokenId types.TokenTypeId, sbHash types.Hash) (map[types.Address]*big.Int, error)
 
 	IsAccountBlockExisted(hash types.Hash) (bool, error)
 	IsGenesisAccountBlock(hash types.Hash) bool
@@ -37,3 +41,9 @@ type Plugin interface {
 
 	RemoveNewUnconfirmed(*leveldb.Batch, []*ledger.AccountBlock) error
 }
+
+// AfterInsertSnapshotPlugin is implemented by the plugins which read the state confirmed by the snapshot block,
+// AfterInsertSnapshotBlock is called after the state of the snapshot block is written
+type AfterInsertSnapshotPlugin interface {
+	AfterInsertSnapshotBlock(*leveldb.Batch, *ledger.SnapshotBlock, []*ledger.AccountBlock) error
+}
@@ -0,0 +1,141 @@
+package chain_plugins
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/v2/common/helper"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_db "github.com/vitelabs/go-vite/v2/ledger/chain/db"
+	chain_utils "github.com/vitelabs/go-vite/v2/ledger/chain/utils"
+)
+
+// journalWriter writes the changes of a plugin confirmed by a snapshot block. The batch is not readable
+// before it is written, so the values written in the snapshot block are cached, and the value before
+// the snapshot block of each changed key is journaled under journalPrefix, see revertJournal
+type journalWriter struct {
+	store *chain_db.Store
+	batch *leveldb.Batch
+
+	journalPrefix byte
+	height        uint64
+	cache         map[string][]byte
+	seq           uint32
+}
+
+func newJournalWriter(store *chain_db.Store, batch *leveldb.Batch, journalPrefix byte, height uint64) *journalWriter {
+	return &journalWriter{
+		store:         store,
+		batch:         batch,
+		journalPrefix: journalPrefix,
+		height:        height,
+		cache:         make(map[string][]byte),
+	}
+}
+
+func (w *journalWriter) get(key []byte) ([]byte, error) {
+	if value, ok := w.cache[string(key)]; ok {
+		return value, nil
+	}
+	return w.store.Get(key)
+}
+
+// set puts the value of the key, or deletes the key if value is nil
+func (w *journalWriter) set(key []byte, value []byte) error {
+	if _, ok := w.cache[string(key)]; !ok {
+		prev, err := w.store.Get(key)
+		if err != nil {
+			return err
+		}
+		w.batch.Put(createJournalKey(w.journalPrefix, w.height, w.seq), createJournalValue(key, prev))
+		w.seq++
+	}
+	w.cache[string(key)] = value
+	if value == nil {
+		w.batch.Delete(key)
+	} else {
+		w.batch.Put(key, value)
+	}
+	return nil
+}
+
+// revertJournal restores the values before the snapshot blocks of the chunks which are journaled under journalPrefix
+func revertJournal(store *chain_db.Store, batch *leveldb.Batch, journalPrefix byte, chunks []*ledger.SnapshotChunk) error {
+	heights := make([]uint64, 0, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock != nil {
+			heights = append(heights, chunk.SnapshotBlock.Height)
+		}
+	}
+	// revert the newest snapshot block first, so the value before the oldest one is restored at last
+	sort.Slice(heights, func(i, j int) bool { return heights[i] > heights[j] })
+
+	for _, height := range heights {
+		keys, values, err := getJournal(store, journalPrefix, height)
+		if err != nil {
+			return err
+		}
+		for i := len(keys) - 1; i >= 0; i-- {
+			key, prev, err := parseJournalValue(values[i])
+			if err != nil {
+				return err
+			}
+			if prev == nil {
+				batch.Delete(key)
+			} else {
+				batch.Put(key, prev)
+			}
+			batch.Delete(keys[i])
+		}
+	}
+	return nil
+}
+
+func getJournal(store *chain_db.Store, journalPrefix byte, height uint64) ([][]byte, [][]byte, error) {
+	iter := store.NewIterator(util.BytesPrefix(createJournalPrefixKey(journalPrefix, height)))
+	defer iter.Release()
+
+	var keys, values [][]byte
+	for iter.Next() {
+		keys = append(keys, helper.JoinBytes(iter.Key()))
+		values = append(values, helper.JoinBytes(iter.Value()))
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, nil, err
+	}
+	return keys, values, nil
+}
+
+func createJournalPrefixKey(journalPrefix byte, sbHeight uint64) []byte {
+	return helper.JoinBytes([]byte{journalPrefix}, chain_utils.Uint64ToBytes(sbHeight))
+}
+
+func createJournalKey(journalPrefix byte, sbHeight uint64, seq uint32) []byte {
+	seqBytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(seqBytes, seq)
+	return helper.JoinBytes(createJournalPrefixKey(journalPrefix, sbHeight), seqBytes)
+}
+
+// createJournalValue serializes the key with its previous value, an absent previous value is marked by 0
+func createJournalValue(key []byte, prev []byte) []byte {
+	existed := byte(0)
+	if prev != nil {
+		existed = 1
+	}
+	return helper.JoinBytes([]byte{byte(len(key))}, key, []byte{existed}, prev)
+}
+
+func parseJournalValue(value []byte) (key []byte, prev []byte, err error) {
+	if len(value) == 0 || len(value) < int(value[0])+2 {
+		return nil, nil, fmt.Errorf("invalid journal value %x", value)
+	}
+	keyLen := int(value[0])
+	key = value[1 : 1+keyLen]
+	if value[1+keyLen] == 1 {
+		prev = helper.JoinBytes(value[2+keyLen:])
+	}
+	return key, prev, nil
+}
@@ -22,7 +22,8 @@ const (
 )
 
 type Plugins struct {
-	dataDir string
+	dataDir    string
+	engineName string
 
 	log     log15.Logger
 	chain   Chain
@@ -33,23 +34,27 @@ type Plugins struct {
 	mu          sync.RWMutex
 }
 
-func NewPlugins(chainDir string, chain Chain) (*Plugins, error) {
+func NewPlugins(chainDir string, chain Chain, engineName string) (*Plugins, error) {
 	var err error
 
 	dataDir := path.Join(chainDir, "plugins")
 
-	store, err := chain_db.NewStore(dataDir, "plugins")
+	store, err := chain_db.NewStoreWithEngine(dataDir, "plugins", engineName)
 	if err != nil {
 		return nil, err
 	}
 
 	plugins := map[string]Plugin{
-		"filterToken": newFilterToken(store, chain),
-		"onRoadInfo":  newOnRoadInfo(store, chain),
+		"filterToken":    newFilterToken(store, chain),
+		"onRoadInfo":     newOnRoadInfo(store, chain),
+		"dexMarket":      newDexMarket(store, chain),
+		"accountSummary": newAccountSummaries(store, chain),
+		"tokenHolders":   newTokenHolders(store, chain),
 	}
 
 	return &Plugins{
 		dataDir:     dataDir,
+		engineName:  engineName,
 		chain:       chain,
 		store:       store,
 		plugins:     plugins,
@@ -88,7 +93,7 @@ func (p *Plugins) RebuildData() error {
 	os.RemoveAll(p.dataDir)
 
 	// set new store
-	store, err := chain_db.NewStore(p.dataDir, "plugins")
+	store, err := chain_db.NewStoreWithEngine(p.dataDir, "plugins", p.engineName)
 	if err != nil {
 		return err
 	}
@@ -159,6 +164,11 @@ func (p *Plugins) RebuildData() error {
 
 			p.store.WriteSnapshot(batch, chunk.AccountBlocks)
 
+			if err := p.afterInsertSnapshotBlock(chunk); err != nil {
+				pErr := fmt.Errorf("AfterInsertSnapshotBlock fail, err:%v, sb[%v, %v,len=%v] ", err, chunk.SnapshotBlock.Height, chunk.SnapshotBlock.Hash, len(chunk.AccountBlocks))
+				p.log.Error(pErr.Error(), "method", "RebuildData")
+				return pErr
+			}
 		}
 
 		// flush to disk
@@ -296,6 +306,32 @@ func (p *Plugins) InsertAccountBlocks(blocks []*interfaces.VmAccountBlock) error
 	return nil
 }
 func (p *Plugins) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, chunk := range chunks {
+		if err := p.afterInsertSnapshotBlock(chunk); err != nil {
+			p.log.Error(fmt.Sprintf("AfterInsertSnapshotBlock fail, err:%v, sb[%v, %v]", err, chunk.SnapshotBlock.Height, chunk.SnapshotBlock.Hash), "method", "InsertSnapshotBlocks")
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *Plugins) afterInsertSnapshotBlock(chunk *ledger.SnapshotChunk) error {
+	if chunk.SnapshotBlock == nil {
+		return nil
+	}
+	batch := p.store.NewBatch()
+
+	for _, plugin := range p.plugins {
+		if afterPlugin, ok := plugin.(AfterInsertSnapshotPlugin); ok {
+			if err := afterPlugin.AfterInsertSnapshotBlock(batch, chunk.SnapshotBlock, chunk.AccountBlocks); err != nil {
+				return err
+			}
+		}
+	}
+	p.store.WriteSnapshot(batch, nil)
 	return nil
 }
 func (p *Plugins) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
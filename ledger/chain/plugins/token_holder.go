@@ -0,0 +1,288 @@
+package chain_plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/v2/common/helper"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_db "github.com/vitelabs/go-vite/v2/ledger/chain/db"
+)
+
+const tokenHolderBalanceSize = 32
+
+// TokenHolder is the confirmed balance of an account of a token
+type TokenHolder struct {
+	Address types.Address
+	Balance *big.Int
+}
+
+// TokenHolderStats is the count of the accounts holding a token and the sum of their confirmed balances
+type TokenHolderStats struct {
+	HolderCount uint64
+	Supply      *big.Int
+}
+
+// TokenHolders indexes the confirmed balances of each token in descending order. The balances are read
+// from the state after the snapshot block is inserted, the changes of each snapshot block are journaled
+// so that they can be reverted on rollback.
+type TokenHolders struct {
+	store *chain_db.Store
+	chain Chain
+}
+
+func newTokenHolders(store *chain_db.Store, chain Chain) Plugin {
+	return &TokenHolders{
+		store: store,
+		chain: chain,
+	}
+}
+
+func (th *TokenHolders) SetStore(store *chain_db.Store) {
+	th.store = store
+}
+
+func (th *TokenHolders) InsertAccountBlock(batch *leveldb.Batch, accountBlock *ledger.AccountBlock) error {
+	return nil
+}
+
+// InsertSnapshotBlock does nothing, the balances confirmed by the snapshot block are not written yet,
+// see AfterInsertSnapshotBlock
+func (th *TokenHolders) InsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (th *TokenHolders) AfterInsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	changes, err := th.getChangedAddresses(confirmedBlocks)
+	if err != nil {
+		return err
+	}
+
+	writer := &tokenHolderWriter{
+		journalWriter: newJournalWriter(th.store, batch, TokenHolderUndoKeyPrefix, snapshotBlock.Height),
+	}
+	for tokenId, addrSet := range changes {
+		addrList := make([]types.Address, 0, len(addrSet))
+		for addr := range addrSet {
+			addrList = append(addrList, addr)
+		}
+		balanceMap, err := th.chain.GetConfirmedBalanceList(addrList, tokenId, snapshotBlock.Hash)
+		if err != nil {
+			return fmt.Errorf("th.chain.GetConfirmedBalanceList failed, sb is %s. Error: %s", snapshotBlock.Hash, err)
+		}
+		for _, addr := range addrList {
+			if err := writer.setBalance(tokenId, addr, balanceMap[addr]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteAccountBlocks does nothing, the unconfirmed blocks are not indexed
+func (th *TokenHolders) DeleteAccountBlocks(batch *leveldb.Batch, accountBlocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (th *TokenHolders) DeleteSnapshotBlocks(batch *leveldb.Batch, chunks []*ledger.SnapshotChunk) error {
+	return revertJournal(th.store, batch, TokenHolderUndoKeyPrefix, chunks)
+}
+
+func (th *TokenHolders) RemoveNewUnconfirmed(batch *leveldb.Batch, allUnconfirmedBlocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+// GetTokenHolders returns the holders of the token in descending order of balance
+func (th *TokenHolders) GetTokenHolders(tokenId types.TokenTypeId, offset, limit int) ([]*TokenHolder, error) {
+	iter := th.store.NewIterator(util.BytesPrefix(createTokenHolderPrefixKey(tokenId)))
+	defer iter.Release()
+
+	holders := make([]*TokenHolder, 0)
+	for i := 0; len(holders) < limit && iter.Next(); i++ {
+		if i < offset {
+			continue
+		}
+		holder, err := parseTokenHolderKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		holders = append(holders, holder)
+	}
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+	return holders, nil
+}
+
+// GetTokenHolderStats returns the stats of the holders of the token, the stats are empty if the token has no holder
+func (th *TokenHolders) GetTokenHolderStats(tokenId types.TokenTypeId) (*TokenHolderStats, error) {
+	value, err := th.store.Get(createTokenHolderStatsKey(tokenId))
+	if err != nil {
+		return nil, err
+	}
+	return parseTokenHolderStats(value)
+}
+
+// getChangedAddresses returns the addresses whose balance of each token may be changed by the account blocks
+func (th *TokenHolders) getChangedAddresses(confirmedBlocks []*ledger.AccountBlock) (map[types.TokenTypeId]map[types.Address]struct{}, error) {
+	changes := make(map[types.TokenTypeId]map[types.Address]struct{})
+	add := func(tokenId types.TokenTypeId, addr types.Address) {
+		addrSet, ok := changes[tokenId]
+		if !ok {
+			addrSet = make(map[types.Address]struct{})
+			changes[tokenId] = addrSet
+		}
+		addrSet[addr] = struct{}{}
+	}
+
+	for _, block := range confirmedBlocks {
+		if block.Fee != nil && block.Fee.Sign() > 0 {
+			add(ledger.ViteTokenId, block.AccountAddress)
+		}
+
+		if block.IsSendBlock() {
+			add(block.TokenId, block.AccountAddress)
+			continue
+		}
+
+		// the genesis receive blocks have no send block
+		if block.BlockType == ledger.BlockTypeGenesisReceive {
+			add(ledger.ViteTokenId, block.AccountAddress)
+			add(ledger.VCPTokenId, block.AccountAddress)
+		} else {
+			sendBlock, err := th.chain.GetAccountBlockByHash(block.FromBlockHash)
+			if err != nil {
+				return nil, fmt.Errorf("th.chain.GetAccountBlockByHash failed, block is %s. Error: %s", block.FromBlockHash, err)
+			}
+			if sendBlock == nil {
+				return nil, fmt.Errorf("send block %s is nil", block.FromBlockHash)
+			}
+			add(sendBlock.TokenId, block.AccountAddress)
+		}
+
+		for _, sendBlock := range block.SendBlockList {
+			add(sendBlock.TokenId, block.AccountAddress)
+		}
+	}
+	return changes, nil
+}
+
+// tokenHolderWriter applies the balances confirmed by a snapshot block
+type tokenHolderWriter struct {
+	*journalWriter
+}
+
+func (w *tokenHolderWriter) setBalance(tokenId types.TokenTypeId, addr types.Address, balance *big.Int) error {
+	if balance == nil {
+		balance = big.NewInt(0)
+	}
+	balanceKey := createTokenHolderBalanceKey(tokenId, addr)
+	value, err := w.get(balanceKey)
+	if err != nil {
+		return err
+	}
+	prevBalance := new(big.Int).SetBytes(value)
+	if prevBalance.Cmp(balance) == 0 {
+		return nil
+	}
+
+	stats, err := w.getStats(tokenId)
+	if err != nil {
+		return err
+	}
+	stats.Supply.Add(stats.Supply, balance)
+	stats.Supply.Sub(stats.Supply, prevBalance)
+
+	if prevBalance.Sign() > 0 {
+		stats.HolderCount--
+		if err := w.set(createTokenHolderKey(tokenId, prevBalance, addr), nil); err != nil {
+			return err
+		}
+	}
+	if balance.Sign() > 0 {
+		stats.HolderCount++
+		if err := w.set(createTokenHolderKey(tokenId, balance, addr), []byte{1}); err != nil {
+			return err
+		}
+		if err := w.set(balanceKey, balance.Bytes()); err != nil {
+			return err
+		}
+	} else if err := w.set(balanceKey, nil); err != nil {
+		return err
+	}
+
+	if stats.HolderCount == 0 && stats.Supply.Sign() == 0 {
+		return w.set(createTokenHolderStatsKey(tokenId), nil)
+	}
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	return w.set(createTokenHolderStatsKey(tokenId), data)
+}
+
+func (w *tokenHolderWriter) getStats(tokenId types.TokenTypeId) (*TokenHolderStats, error) {
+	value, err := w.get(createTokenHolderStatsKey(tokenId))
+	if err != nil {
+		return nil, err
+	}
+	return parseTokenHolderStats(value)
+}
+
+func parseTokenHolderStats(value []byte) (*TokenHolderStats, error) {
+	stats := &TokenHolderStats{}
+	if value != nil {
+		if err := json.Unmarshal(value, stats); err != nil {
+			return nil, err
+		}
+	}
+	if stats.Supply == nil {
+		stats.Supply = big.NewInt(0)
+	}
+	return stats, nil
+}
+
+func createTokenHolderPrefixKey(tokenId types.TokenTypeId) []byte {
+	return helper.JoinBytes([]byte{TokenHolderKeyPrefix}, tokenId.Bytes())
+}
+
+// createTokenHolderKey orders the holders by balance in descending order, the balance is inverted
+// so that a larger balance is sorted before a smaller one
+func createTokenHolderKey(tokenId types.TokenTypeId, balance *big.Int, addr types.Address) []byte {
+	balanceBytes := helper.LeftPadBytes(balance.Bytes(), tokenHolderBalanceSize)
+	for i := range balanceBytes {
+		balanceBytes[i] = ^balanceBytes[i]
+	}
+	return helper.JoinBytes(createTokenHolderPrefixKey(tokenId), balanceBytes, addr.Bytes())
+}
+
+func parseTokenHolderKey(key []byte) (*TokenHolder, error) {
+	offset := 1 + types.TokenTypeIdSize
+	if len(key) != offset+tokenHolderBalanceSize+types.AddressSize {
+		return nil, fmt.Errorf("invalid token holder key %x", key)
+	}
+	balanceBytes := helper.JoinBytes(key[offset : offset+tokenHolderBalanceSize])
+	for i := range balanceBytes {
+		balanceBytes[i] = ^balanceBytes[i]
+	}
+	addr, err := types.BytesToAddress(key[offset+tokenHolderBalanceSize:])
+	if err != nil {
+		return nil, err
+	}
+	return &TokenHolder{
+		Address: addr,
+		Balance: new(big.Int).SetBytes(balanceBytes),
+	}, nil
+}
+
+func createTokenHolderBalanceKey(tokenId types.TokenTypeId, addr types.Address) []byte {
+	return helper.JoinBytes([]byte{TokenHolderBalanceKeyPrefix}, tokenId.Bytes(), addr.Bytes())
+}
+
+func createTokenHolderStatsKey(tokenId types.TokenTypeId) []byte {
+	return helper.JoinBytes([]byte{TokenHolderStatsKeyPrefix}, tokenId.Bytes())
+}
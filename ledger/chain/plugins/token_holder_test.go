@@ -0,0 +1,130 @@
+package chain_plugins
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_db "github.com/vitelabs/go-vite/v2/ledger/chain/db"
+)
+
+type tokenHolderTestChain struct {
+	Chain
+	blocks   map[types.Hash]*ledger.AccountBlock
+	balances map[types.Address]*big.Int
+}
+
+func (c *tokenHolderTestChain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	return c.blocks[blockHash], nil
+}
+
+func (c *tokenHolderTestChain) GetConfirmedBalanceList(addrList []types.Address, tokenId types.TokenTypeId, sbHash types.Hash) (map[types.Address]*big.Int, error) {
+	balanceMap := make(map[types.Address]*big.Int, len(addrList))
+	for _, addr := range addrList {
+		if balance, ok := c.balances[addr]; ok {
+			balanceMap[addr] = new(big.Int).Set(balance)
+		}
+	}
+	return balanceMap, nil
+}
+
+func TestTokenHolders(t *testing.T) {
+	store, err := chain_db.NewStore(t.TempDir(), "plugins")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	chain := &tokenHolderTestChain{
+		blocks:   make(map[types.Hash]*ledger.AccountBlock),
+		balances: make(map[types.Address]*big.Int),
+	}
+	th := newTokenHolders(store, chain).(*TokenHolders)
+
+	addrA := types.AddressDexFund
+	addrB := types.AddressDexTrade
+	addrC := types.AddressAsset
+	tokenId := ledger.ViteTokenId
+
+	seq := int64(0)
+	send := func(from types.Address, to types.Address) *ledger.AccountBlock {
+		seq++
+		block := &ledger.AccountBlock{
+			BlockType:      ledger.BlockTypeSendCall,
+			AccountAddress: from,
+			ToAddress:      to,
+			Hash:           types.DataHash(big.NewInt(seq).Bytes()),
+			TokenId:        tokenId,
+			Amount:         big.NewInt(0),
+		}
+		chain.blocks[block.Hash] = block
+		return block
+	}
+	receive := func(sendBlock *ledger.AccountBlock) *ledger.AccountBlock {
+		seq++
+		return &ledger.AccountBlock{
+			BlockType:      ledger.BlockTypeReceive,
+			AccountAddress: sendBlock.ToAddress,
+			Hash:           types.DataHash(big.NewInt(seq).Bytes()),
+			FromBlockHash:  sendBlock.Hash,
+		}
+	}
+	insert := func(height uint64, balances map[types.Address]int64, blocks ...*ledger.AccountBlock) *ledger.SnapshotChunk {
+		for addr, balance := range balances {
+			chain.balances[addr] = big.NewInt(balance)
+		}
+		chunk := &ledger.SnapshotChunk{
+			SnapshotBlock: &ledger.SnapshotBlock{Height: height},
+			AccountBlocks: blocks,
+		}
+		batch := store.NewBatch()
+		if err := th.AfterInsertSnapshotBlock(batch, chunk.SnapshotBlock, chunk.AccountBlocks); err != nil {
+			t.Fatal(err)
+		}
+		store.WriteSnapshot(batch, nil)
+		return chunk
+	}
+	checkHolders := func(expected []types.Address, holderCount uint64, supply int64) {
+		holders, err := th.GetTokenHolders(tokenId, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(holders) != len(expected) {
+			t.Fatalf("unexpected holders %+v", holders)
+		}
+		for i, holder := range holders {
+			if holder.Address != expected[i] || holder.Balance.Cmp(chain.balances[holder.Address]) != 0 {
+				t.Fatalf("unexpected holder %d %+v", i, holder)
+			}
+		}
+		stats, err := th.GetTokenHolderStats(tokenId)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stats.HolderCount != holderCount || stats.Supply.Int64() != supply {
+			t.Fatalf("unexpected stats %+v", stats)
+		}
+	}
+
+	sendA1 := send(addrA, addrB)
+	insert(10, map[types.Address]int64{addrA: 900, addrB: 100}, sendA1, receive(sendA1))
+	checkHolders([]types.Address{addrA, addrB}, 2, 1000)
+
+	sendA2 := send(addrA, addrC)
+	sendB1 := send(addrB, addrC)
+	chunk := insert(11, map[types.Address]int64{addrA: 0, addrB: 50, addrC: 950}, sendA2, receive(sendA2), sendB1, receive(sendB1))
+	checkHolders([]types.Address{addrC, addrB}, 2, 1000)
+
+	if holders, err := th.GetTokenHolders(tokenId, 1, 10); err != nil || len(holders) != 1 || holders[0].Address != addrB {
+		t.Fatalf("unexpected holders %+v, err %v", holders, err)
+	}
+
+	batch := store.NewBatch()
+	if err := th.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{chunk}); err != nil {
+		t.Fatal(err)
+	}
+	store.RollbackSnapshot(batch)
+
+	chain.balances = map[types.Address]*big.Int{addrA: big.NewInt(900), addrB: big.NewInt(100)}
+	checkHolders([]types.Address{addrA, addrB}, 2, 1000)
+}
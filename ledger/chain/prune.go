@@ -0,0 +1,107 @@
+package chain
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_block "github.com/vitelabs/go-vite/v2/ledger/chain/block"
+)
+
+const (
+	// the blocks of recent 48 hours at least are kept, rollback and consensus read them
+	minPruneRetain = 48 * 3600
+
+	// try to prune every prunePeriod snapshot blocks
+	prunePeriod = 3600
+)
+
+func (c *chain) pruneRetain() uint64 {
+	if c.chainCfg.PruneRetain < minPruneRetain {
+		return minPruneRetain
+	}
+	return c.chainCfg.PruneRetain
+}
+
+// pruneAt prunes the old blocks in background when snapshotBlock is inserted
+func (c *chain) pruneAt(snapshotBlock *ledger.SnapshotBlock) {
+	if !c.chainCfg.Prune || snapshotBlock.Height%prunePeriod != 0 {
+		return
+	}
+
+	if !atomic.CompareAndSwapInt32(&c.pruning, 0, 1) {
+		return
+	}
+
+	c.pruneWg.Add(1)
+	go func() {
+		defer func() {
+			atomic.StoreInt32(&c.pruning, 0)
+			c.pruneWg.Done()
+		}()
+
+		if err := c.prune(snapshotBlock.Height); err != nil {
+			c.log.Error(fmt.Sprintf("prune failed, snapshot height is %d. Error: %s", snapshotBlock.Height, err), "method", "pruneAt")
+		}
+	}()
+}
+
+// prune deletes the block files which only contain the blocks before latestHeight - pruneRetain
+func (c *chain) prune(latestHeight uint64) error {
+	retain := c.pruneRetain()
+	if latestHeight <= retain+1 {
+		return nil
+	}
+	return c.pruneTo(latestHeight - retain)
+}
+
+// pruneTo deletes the block files which only contain the blocks before the snapshot block at height.
+// The headers of snapshot blocks, the latest blocks of accounts and the unreceived send blocks are retained.
+func (c *chain) pruneTo(height uint64) error {
+	location, err := c.indexDB.GetSnapshotBlockLocation(height)
+	if err != nil {
+		return fmt.Errorf("c.indexDB.GetSnapshotBlockLocation failed, height is %d. Error: %s", height, err)
+	}
+	if location == nil {
+		return nil
+	}
+
+	if err := c.blockDB.Prune(location.FileId, c.shouldRetainAccountBlock); err != nil {
+		if err == chain_block.ErrPruneStopped {
+			return nil
+		}
+		return fmt.Errorf("c.blockDB.Prune failed, fileId is %d. Error: %s", location.FileId, err)
+	}
+
+	if err := c.blockDB.CleanRetained(c.shouldRetainAccountBlock); err != nil {
+		return fmt.Errorf("c.blockDB.CleanRetained failed. Error: %s", err)
+	}
+
+	c.log.Info(fmt.Sprintf("prune blocks before %s", c.blockDB.PrunedLocation()), "method", "pruneTo")
+	return nil
+}
+
+// shouldRetainAccountBlock returns true if the block is the latest block of the account,
+// or the block contains a send block which is not received
+func (c *chain) shouldRetainAccountBlock(block *ledger.AccountBlock) bool {
+	height, _, err := c.indexDB.GetLatestAccountBlock(&block.AccountAddress)
+	if err != nil || height <= block.Height {
+		return true
+	}
+
+	if block.IsSendBlock() {
+		return !c.isReceived(block)
+	}
+
+	for _, sendBlock := range block.SendBlockList {
+		if !c.isReceived(sendBlock) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *chain) isReceived(sendBlock *ledger.AccountBlock) bool {
+	received, err := c.indexDB.IsReceived(&sendBlock.Hash)
+	return err == nil && received
+}
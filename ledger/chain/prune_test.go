@@ -0,0 +1,109 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_block "github.com/vitelabs/go-vite/v2/ledger/chain/block"
+)
+
+func TestPrune(t *testing.T) {
+	fileSize := chain_block.FixFileSize
+	chain_block.FixFileSize = 16 * 1024
+	defer func() {
+		chain_block.FixFileSize = fileSize
+	}()
+
+	chainInstance, accounts, snapshotBlockList := SetUp(t, 10, 1000, 1)
+
+	balances := make(map[*Account]string, len(accounts))
+	for _, account := range accounts {
+		balance, err := chainInstance.GetBalance(account.Addr, ledger.ViteTokenId)
+		assert.NoError(t, err)
+		balances[account] = balance.String()
+	}
+
+	chainInstance.flusher.Flush()
+
+	// the recent 600 snapshot blocks are read by the quota cache when starting
+	prunedFromBlock := snapshotBlockList[len(snapshotBlockList)-700]
+	pruneHeight := prunedFromBlock.Height
+	assert.NoError(t, chainInstance.pruneTo(pruneHeight))
+
+	prunedLocation := chainInstance.blockDB.PrunedLocation()
+	assert.NotNil(t, prunedLocation)
+	assert.True(t, prunedLocation.FileId > 1)
+
+	// reopen the chain, so the blocks are not cached
+	TearDown(chainInstance)
+	chainInstance.metaDB.Close()
+	chainInstance, err := NewChainInstance(t, chainInstance.dataDir, false)
+	assert.NoError(t, err)
+	defer func() {
+		TearDown(chainInstance)
+		Clear(chainInstance)
+	}()
+	assert.Equal(t, *prunedLocation, *chainInstance.blockDB.PrunedLocation())
+
+	// full snapshot blocks are pruned, but the headers are retained
+	_, err = chainInstance.QuerySnapshotBlockByHeight(2)
+	assert.Equal(t, chain_block.ErrPruned, err)
+
+	header, err := chainInstance.GetSnapshotHeaderByHeight(2)
+	assert.NoError(t, err)
+	assert.NotNil(t, header)
+
+	// the blocks after pruned location are kept
+	sb, err := chainInstance.QuerySnapshotBlockByHeight(pruneHeight)
+	assert.NoError(t, err)
+	assert.Equal(t, pruneHeight, sb.Height)
+
+	// the full snapshot blocks keep their content in prune mode
+	for _, original := range snapshotBlockList[len(snapshotBlockList)-700:] {
+		sb, err := chainInstance.GetSnapshotBlockByHeight(original.Height)
+		assert.NoError(t, err)
+		assert.Equal(t, original.Hash, sb.Hash)
+		assert.Equal(t, len(original.SnapshotContent), len(sb.SnapshotContent))
+		for addr, hashHeight := range original.SnapshotContent {
+			assert.Equal(t, hashHeight, sb.SnapshotContent[addr])
+		}
+	}
+
+	var prunedBlocks int
+	for _, account := range accounts {
+		balance, err := chainInstance.GetBalance(account.Addr, ledger.ViteTokenId)
+		assert.NoError(t, err)
+		assert.Equal(t, balances[account], balance.String())
+
+		// the latest block is always readable
+		latestBlock, err := chainInstance.GetLatestAccountBlock(account.Addr)
+		assert.NoError(t, err)
+		if latestBlock == nil {
+			continue
+		}
+
+		// the unreceived send blocks are retained
+		onRoadBlocks, err := chainInstance.GetOnRoadBlocksByAddr(account.Addr, 0, 1000)
+		assert.NoError(t, err)
+		for _, onRoadBlock := range onRoadBlocks {
+			block, err := chainInstance.GetAccountBlockByHash(onRoadBlock.Hash)
+			assert.NoError(t, err)
+			assert.NotNil(t, block)
+		}
+
+		for h := uint64(1); h < latestBlock.Height; h++ {
+			if _, err := chainInstance.GetAccountBlockByHeight(account.Addr, h); err == chain_block.ErrPruned {
+				prunedBlocks++
+			} else {
+				assert.NoError(t, err)
+			}
+		}
+	}
+	assert.True(t, prunedBlocks > 0)
+
+	// prune again is fine
+	assert.NoError(t, chainInstance.pruneTo(pruneHeight))
+	assert.Equal(t, *prunedLocation, *chainInstance.blockDB.PrunedLocation())
+}
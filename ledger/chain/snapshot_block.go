@@ -11,6 +11,7 @@ import (
 	"github.com/vitelabs/go-vite/v2/common/helper"
 	"github.com/vitelabs/go-vite/v2/common/types"
 	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	chain_block "github.com/vitelabs/go-vite/v2/ledger/chain/block"
 	chain_file_manager "github.com/vitelabs/go-vite/v2/ledger/chain/file_manager"
 )
 
@@ -98,6 +99,9 @@ func (c *chain) GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock,
 	// query block
 	snapshotBlock, err := c.blockDB.GetSnapshotHeader(location)
 	if err != nil {
+		if err == chain_block.ErrPruned {
+			return nil, err
+		}
 		cErr := fmt.Errorf("c.blockDB.GetSnapshotHeader failed, error is %s, height is %d, location is %+v",
 			err.Error(), height, location)
 		c.log.Error(cErr.Error(), "method", "GetSnapshotHeaderByHeight")
@@ -175,6 +179,9 @@ func (c *chain) GetSnapshotBlockByHash(hash types.Hash) (*ledger.SnapshotBlock,
 	// query block
 	snapshotBlock, err := c.blockDB.GetSnapshotBlock(location)
 	if err != nil {
+		if err == chain_block.ErrPruned {
+			return nil, err
+		}
 		cErr := fmt.Errorf("c.blockDB.GetSnapshotBlock failed, error is %s, hash is %s, location is %+v",
 			err.Error(), hash, location)
 		c.log.Error(cErr.Error(), "method", "GetSnapshotBlockByHash")
@@ -432,6 +439,9 @@ func (c *chain) QuerySnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock
 	// query block
 	snapshotBlock, err := c.blockDB.GetSnapshotBlock(location)
 	if err != nil {
+		if err == chain_block.ErrPruned {
+			return nil, err
+		}
 		cErr := fmt.Errorf("c.blockDB.GetSnapshotBlock failed, height is %d, location is %+v. Error: %s",
 			height, location, err.Error())
 		c.log.Error(cErr.Error(), "method", "QuerySnapshotBlockByHeight")
@@ -712,6 +722,9 @@ func (c *chain) GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotC
 
 	segList, err := c.blockDB.ReadRange(startLocation, endLocation)
 	if err != nil {
+		if err == chain_block.ErrPruned {
+			return nil, err
+		}
 		cErr := fmt.Errorf("c.blockDB.ReadRange failed, startLocation is %+v, endLocation is %+v, . Error: %s,",
 			startLocation, endLocation, err.Error())
 		c.log.Error(cErr.Error(), "method", "GetSubLedger")
@@ -736,6 +749,9 @@ func (c *chain) GetSubLedgerAfterHeight(height uint64) ([]*ledger.SnapshotChunk,
 
 	segList, err := c.blockDB.ReadRange(startLocation, nil)
 	if err != nil {
+		if err == chain_block.ErrPruned {
+			return nil, err
+		}
 		cErr := fmt.Errorf("c.blockDB.ReadRange failed,  startLocation is %+v, endLocation is nil. Error: %s,",
 			startLocation, err.Error())
 		c.log.Error(cErr.Error(), "method", "GetSubLedgerAfterHeight")
@@ -767,6 +783,10 @@ func (c *chain) getSnapshotBlockList(getList getSnapshotListFunc, higher bool, o
 
 		if block == nil {
 			block, err = c.blockDB.GetSnapshotBlock(location)
+			if err == chain_block.ErrPruned && onlyHeader {
+				// the headers of pruned snapshot blocks are retained
+				block, err = c.blockDB.GetSnapshotHeader(location)
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -53,7 +53,7 @@ func (c *chain) GetContractCode(contractAddress types.Address) ([]byte, error) {
 }
 
 func (c *chain) GetContractMeta(contractAddress types.Address) (*ledger.ContractMeta, error) {
-	if meta := ledger.GetBuiltinContractMeta(contractAddress); meta != nil {
+	if meta := ledger.GetBuiltinContractMeta(contractAddress, c.GetLatestSnapshotBlock().Height); meta != nil {
 		return meta, nil
 	}
 	meta, err := c.stateDB.GetContractMeta(contractAddress)
@@ -66,7 +66,7 @@ func (c *chain) GetContractMeta(contractAddress types.Address) (*ledger.Contract
 }
 
 func (c *chain) GetContractMetaInSnapshot(contractAddress types.Address, snapshotHeight uint64) (*ledger.ContractMeta, error) {
-	if meta := ledger.GetBuiltinContractMeta(contractAddress); meta != nil {
+	if meta := ledger.GetBuiltinContractMeta(contractAddress, snapshotHeight); meta != nil {
 		return meta, nil
 	}
 
@@ -102,7 +102,7 @@ func (c *chain) GetContractList(gid types.Gid) ([]types.Address, error) {
 		return nil, cErr
 	}
 	if util.IsDelegateGid(gid) {
-		addrList = append(addrList, types.BuiltinContracts...)
+		addrList = append(addrList, ledger.GetBuiltinContractList(c.GetLatestSnapshotBlock().Height)...)
 	}
 	return addrList, nil
 }
@@ -38,6 +38,19 @@ func (sDB *StateDB) initCache() error {
 	return nil
 }
 
+// ResetCache rebuilds the cache and the round cache after the state is replaced
+func (sDB *StateDB) ResetCache() error {
+	sDB.disableCache()
+	defer sDB.enableCache()
+
+	sDB.cache.Flush()
+	if err := sDB.initCache(); err != nil {
+		return err
+	}
+
+	return sDB.roundCache.Reset()
+}
+
 func (sDB *StateDB) disableCache() {
 	sDB.useCache = false
 }
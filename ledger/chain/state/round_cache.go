@@ -262,6 +262,22 @@ func (cache *RoundCache) Init(timeIndex interfaces.TimeIndex) (returnErr error)
 }
 
 // panic when return error
+// Reset drops all rounds and initializes the round cache again, the round cache keeps
+// disabled if it has not been initialized.
+func (cache *RoundCache) Reset() error {
+	if cache.status < INITED {
+		return nil
+	}
+
+	cache.status = STOP
+
+	cache.mu.Lock()
+	cache.data = nil
+	cache.mu.Unlock()
+
+	return cache.Init(cache.timeIndex)
+}
+
 func (cache *RoundCache) InsertSnapshotBlock(snapshotBlock *ledger.SnapshotBlock, snapshotLog SnapshotLog) (returnErr error) {
 	if cache.status < INITED {
 		return nil
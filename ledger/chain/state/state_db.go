@@ -48,13 +48,13 @@ type StateDB struct {
 
 func NewStateDB(chain Chain, chainCfg *config.Chain, chainDir string) (*StateDB, error) {
 
-	store, err := chain_db.NewStore(path.Join(chainDir, "state"), "stateDb")
+	store, err := chain_db.NewStoreWithEngine(path.Join(chainDir, "state"), "stateDb", chainCfg.DBEngine)
 
 	if err != nil {
 		return nil, err
 	}
 
-	redoStore, err := chain_db.NewStore(path.Join(chainDir, "state_redo"), "stateDbRedo")
+	redoStore, err := chain_db.NewStoreWithEngine(path.Join(chainDir, "state_redo"), "stateDbRedo", chainCfg.DBEngine)
 	if err != nil {
 		return nil, err
 	}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/patrickmn/go-cache"
 
+	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
 	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/errors"
 	"github.com/vitelabs/go-vite/v2/common/types"
 	"github.com/vitelabs/go-vite/v2/interfaces"
@@ -288,3 +289,10 @@ func (sDB *StateDB) canWriteVmLog(addr types.Address) bool {
 	_, ok := sDB.vmLogWhiteListSet[addr]
 	return ok
 }
+
+// WriteRaw writes raw key-values into the snapshot batch directly, used by importing a state snapshot.
+// The cache is flushed since the written keys may be cached, call ResetCache after all data is written.
+func (sDB *StateDB) WriteRaw(batch *leveldb.Batch) {
+	sDB.store.WriteDirectly(batch)
+	sDB.cache.Flush()
+}
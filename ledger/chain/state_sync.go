@@ -0,0 +1,751 @@
+package chain
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync/atomic"
+
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
+	leveldb "github.com/vitelabs/go-vite/v2/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/ledger/chain/state_sync"
+	chain_utils "github.com/vitelabs/go-vite/v2/ledger/chain/utils"
+)
+
+const (
+	// the snapshot blocks before the state snapshot height which are included in the state snapshot,
+	// one consensus cycle and one more hour, so the consensus can verify the subsequent snapshot blocks.
+	stateSnapshotWindow = 25 * 3600
+
+	// flush every stateImportBatchSize items when importing
+	stateImportBatchSize = 10000
+
+	// the count of the state snapshots exported every StateSnapshotInterval snapshot blocks to retain
+	stateSnapshotRetain = 2
+)
+
+type abHeight struct {
+	addr   types.Address
+	height uint64
+}
+
+// the state of the latest snapshot block on disk
+type stateSnapshot struct {
+	sb        *ledger.SnapshotBlock
+	stateSnap engine.Snapshot
+	indexSnap engine.Snapshot
+}
+
+func (snap *stateSnapshot) release() {
+	snap.stateSnap.Release()
+	snap.indexSnap.Release()
+}
+
+// StateSnapshotDir is the directory of the state snapshots exported every StateSnapshotInterval snapshot blocks
+func (c *chain) StateSnapshotDir() string {
+	return path.Join(c.chainDir, "state_snapshot")
+}
+
+// ExportStateSnapshot writes the state at the latest snapshot block to dir, the state snapshot contains:
+// 1. the snapshot blocks of the latest window
+// 2. the latest account block of each account and the account blocks of the onroad send blocks
+// 3. the latest state and the state history in the window
+// 4. the accounts, the onroad set and the indexes of the included account blocks
+func (c *chain) ExportStateSnapshot(dir string) (*interfaces.StateSnapshotMeta, error) {
+	snap, err := c.captureStateSnapshot(nil)
+	if err != nil {
+		return nil, err
+	}
+	defer snap.release()
+
+	return c.writeStateSnapshotFile(dir, snap)
+}
+
+// exportStateSnapshotAt exports the state snapshot every StateSnapshotInterval snapshot blocks, so the
+// nodes export the state snapshots at the same heights. The snapshot blocks are inserted one by one,
+// sb is still the latest snapshot block here.
+func (c *chain) exportStateSnapshotAt(sb *ledger.SnapshotBlock) {
+	interval := c.chainCfg.StateSnapshotInterval
+	if interval == 0 || sb.Height%interval != 0 {
+		return
+	}
+
+	if !atomic.CompareAndSwapInt32(&c.stateSnapshotExporting, 0, 1) {
+		c.log.Warn(fmt.Sprintf("skip exporting state snapshot %d, the previous exporting is not finished", sb.Height), "method", "exportStateSnapshotAt")
+		return
+	}
+
+	snap, err := c.captureStateSnapshot(sb)
+	if err != nil {
+		atomic.StoreInt32(&c.stateSnapshotExporting, 0)
+		c.log.Error(fmt.Sprintf("capture state snapshot %d failed. Error: %s", sb.Height, err), "method", "exportStateSnapshotAt")
+		return
+	}
+
+	c.stateSnapshotWg.Add(1)
+	go func() {
+		defer c.stateSnapshotWg.Done()
+		defer atomic.StoreInt32(&c.stateSnapshotExporting, 0)
+		defer snap.release()
+
+		dir := c.StateSnapshotDir()
+		if _, err := c.writeStateSnapshotFile(dir, snap); err != nil {
+			c.log.Error(fmt.Sprintf("export state snapshot %d failed. Error: %s", sb.Height, err), "method", "exportStateSnapshotAt")
+			return
+		}
+
+		// retain the latest 2 state snapshots
+		metas, err := state_sync.List(dir)
+		if err != nil {
+			c.log.Error(fmt.Sprintf("state_sync.List failed. Error: %s", err), "method", "exportStateSnapshotAt")
+			return
+		}
+		for i := 0; i+stateSnapshotRetain < len(metas); i++ {
+			if err := state_sync.Remove(dir, metas[i].Height); err != nil {
+				c.log.Error(fmt.Sprintf("state_sync.Remove failed. Error: %s", err), "method", "exportStateSnapshotAt")
+			}
+		}
+	}()
+}
+
+// captureStateSnapshot flushes and takes the snapshots of stateDB and indexDB on disk, the data on disk is exactly
+// the data of the latest snapshot block after flushing. Returns error if the latest snapshot block is not expected.
+func (c *chain) captureStateSnapshot(expected *ledger.SnapshotBlock) (*stateSnapshot, error) {
+	snap := &stateSnapshot{}
+
+	if err := c.flusher.FlushAndRun(func() error {
+		snap.sb = c.GetLatestSnapshotBlock()
+		if expected != nil && snap.sb.Hash != expected.Hash {
+			return fmt.Errorf("the latest snapshot block is %d %s, not %d %s", snap.sb.Height, snap.sb.Hash, expected.Height, expected.Hash)
+		}
+		if snap.sb.Height <= types.GenesisHeight {
+			return errors.New("no snapshot block after the genesis snapshot block")
+		}
+
+		var err error
+		if snap.stateSnap, err = c.stateDB.Store().GetSnapshot(); err != nil {
+			return err
+		}
+		if snap.indexSnap, err = c.indexDB.Store().GetSnapshot(); err != nil {
+			snap.stateSnap.Release()
+			return err
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+func (c *chain) writeStateSnapshotFile(dir string, snap *stateSnapshot) (*interfaces.StateSnapshotMeta, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	latestSb := snap.sb
+	filename := state_sync.FileName(dir, latestSb.Height)
+	tmpFilename := filename + ".tmp"
+
+	fd, err := os.Create(tmpFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := state_sync.NewWriter(fd)
+	err = c.writeStateSnapshot(writer, latestSb, snap.stateSnap, snap.indexSnap)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = fd.Sync()
+	}
+	if closeErr := fd.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpFilename)
+		return nil, err
+	}
+
+	// the snapshot blocks may be rolled back while exporting
+	sb, err := c.GetSnapshotBlockByHeight(latestSb.Height)
+	if err != nil || sb == nil || sb.Hash != latestSb.Hash {
+		os.Remove(tmpFilename)
+		return nil, fmt.Errorf("snapshot block %d %s is rolled back while exporting", latestSb.Height, latestSb.Hash)
+	}
+
+	if err := os.Rename(tmpFilename, filename); err != nil {
+		return nil, err
+	}
+
+	meta := &interfaces.StateSnapshotMeta{
+		Height: latestSb.Height,
+		Hash:   latestSb.Hash,
+		Root:   writer.Root(),
+		Size:   writer.Size(),
+		Items:  writer.Items(),
+	}
+	if err := state_sync.WriteMeta(dir, meta); err != nil {
+		return nil, err
+	}
+
+	c.log.Info(fmt.Sprintf("export state snapshot %s, items: %d, size: %d", meta, meta.Items, meta.Size), "method", "ExportStateSnapshot")
+	return meta, nil
+}
+
+func (c *chain) writeStateSnapshot(writer *state_sync.Writer, latestSb *ledger.SnapshotBlock, stateSnap, indexSnap engine.Snapshot) error {
+	windowStart := types.GenesisHeight + 1
+	if latestSb.Height > stateSnapshotWindow+types.GenesisHeight {
+		windowStart = latestSb.Height - stateSnapshotWindow + 1
+	}
+
+	// 1. snapshot blocks
+	for height := windowStart; height <= latestSb.Height; height++ {
+		sb, err := c.GetSnapshotBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+		if sb == nil {
+			return fmt.Errorf("snapshot block %d is not existed", height)
+		}
+		buf, err := sb.Serialize()
+		if err != nil {
+			return err
+		}
+		if err := writer.WriteSnapshotBlock(height, buf); err != nil {
+			return err
+		}
+	}
+
+	// 2. account blocks
+	blockSet := make(map[abHeight]struct{})
+	onRoadSet := make(map[types.Hash]struct{})
+
+	iter := indexSnap.NewIterator(util.BytesPrefix([]byte{chain_utils.AccountAddressKeyPrefix}))
+	for iter.Next() {
+		addr, err := types.BytesToAddress(iter.Key()[1:])
+		if err != nil {
+			iter.Release()
+			return err
+		}
+
+		lastIter := indexSnap.NewIterator(util.BytesPrefix(append([]byte{chain_utils.AccountBlockHeightKeyPrefix}, addr.Bytes()...)))
+		if lastIter.Last() {
+			blockSet[abHeight{addr: addr, height: chain_utils.BytesToUint64(lastIter.Key()[1+types.AddressSize:])}] = struct{}{}
+		}
+		lastIter.Release()
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	iter = indexSnap.NewIterator(util.BytesPrefix([]byte{chain_utils.OnRoadKeyPrefix}))
+	for iter.Next() {
+		sendHash, err := types.BytesToHash(iter.Key()[1+types.AddressSize:])
+		if err != nil {
+			iter.Release()
+			return err
+		}
+		onRoadSet[sendHash] = struct{}{}
+
+		value, err := indexSnap.Get(chain_utils.CreateAccountBlockHashKey(&sendHash).Bytes())
+		if err != nil {
+			iter.Release()
+			return fmt.Errorf("query the account block of onroad %s failed. Error: %s", sendHash, err)
+		}
+		addr, err := types.BytesToAddress(value[:types.AddressSize])
+		if err != nil {
+			iter.Release()
+			return err
+		}
+		blockSet[abHeight{addr: addr, height: chain_utils.BytesToUint64(value[types.AddressSize:])}] = struct{}{}
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+
+	blockList := make([]abHeight, 0, len(blockSet))
+	for item := range blockSet {
+		blockList = append(blockList, item)
+	}
+	sort.Slice(blockList, func(i, j int) bool {
+		if cmp := bytes.Compare(blockList[i].addr.Bytes(), blockList[j].addr.Bytes()); cmp != 0 {
+			return cmp < 0
+		}
+		return blockList[i].height < blockList[j].height
+	})
+
+	var sendHashList []types.Hash
+	for _, item := range blockList {
+		block, err := c.GetAccountBlockByHeight(item.addr, item.height)
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return fmt.Errorf("account block %s %d is not existed", item.addr, item.height)
+		}
+		if !block.IsReceiveBlock() {
+			sendHashList = append(sendHashList, block.Hash)
+		}
+		for _, sendBlock := range block.SendBlockList {
+			sendHashList = append(sendHashList, sendBlock.Hash)
+		}
+
+		// the genesis account blocks are created by the importer itself
+		if _, ok := c.genesisAccountBlockHash[block.Hash]; ok {
+			continue
+		}
+
+		buf, err := block.Serialize()
+		if err != nil {
+			return err
+		}
+		if err := writer.WriteAccountBlock(block.Hash, buf); err != nil {
+			return err
+		}
+	}
+
+	// 3. state
+	createBlockList, err := writeStateRecords(writer, stateSnap, windowStart, onRoadSet)
+	if err != nil {
+		return err
+	}
+
+	// 4. index
+	for _, prefix := range []byte{chain_utils.OnRoadKeyPrefix, chain_utils.AccountAddressKeyPrefix, chain_utils.AccountIdKeyPrefix} {
+		iter := indexSnap.NewIterator(util.BytesPrefix([]byte{prefix}))
+		for iter.Next() {
+			if err := writer.WriteIndex(iter.Key(), iter.Value()); err != nil {
+				iter.Release()
+				return err
+			}
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+
+	// received flag of the send blocks
+	for _, sendHash := range sendHashList {
+		key := chain_utils.CreateReceiveKey(&sendHash).Bytes()
+		value, err := indexSnap.Get(key)
+		if err != nil {
+			if err == leveldb.ErrNotFound {
+				continue
+			}
+			return err
+		}
+		if err := writer.WriteIndex(key, value); err != nil {
+			return err
+		}
+	}
+
+	// the confirmed heights of the included account blocks and the contract creation blocks
+	for _, createBlockHash := range createBlockList {
+		key := chain_utils.CreateAccountBlockHashKey(&createBlockHash).Bytes()
+		value, err := indexSnap.Get(key)
+		if err != nil {
+			if err == leveldb.ErrNotFound {
+				continue
+			}
+			return err
+		}
+		if err := writer.WriteIndex(key, value); err != nil {
+			return err
+		}
+
+		addr, err := types.BytesToAddress(value[:types.AddressSize])
+		if err != nil {
+			return err
+		}
+		blockList = append(blockList, abHeight{addr: addr, height: chain_utils.BytesToUint64(value[types.AddressSize:])})
+	}
+
+	for _, item := range blockList {
+		iter := indexSnap.NewIterator(&util.Range{
+			Start: chain_utils.CreateConfirmHeightKey(&item.addr, item.height).Bytes(),
+			Limit: chain_utils.CreateConfirmHeightKey(&item.addr, ^uint64(0)).Bytes(),
+		})
+		if iter.Next() {
+			if err := writer.WriteIndex(iter.Key(), iter.Value()); err != nil {
+				iter.Release()
+				return err
+			}
+		}
+		iter.Release()
+		if err := iter.Error(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeStateRecords writes the latest state and the state history, the history older than windowStart is
+// merged into the latest item of each key. Returns the creation block hashes of all contracts.
+func writeStateRecords(writer *state_sync.Writer, stateSnap engine.Snapshot, windowStart uint64, onRoadSet map[types.Hash]struct{}) ([]types.Hash, error) {
+	var createBlockList []types.Hash
+
+	var pendingKey, pendingValue []byte
+	flushPending := func() error {
+		if pendingKey == nil {
+			return nil
+		}
+		err := writer.WriteState(pendingKey, pendingValue)
+		pendingKey, pendingValue = nil, nil
+		return err
+	}
+
+	iter := stateSnap.NewIterator(nil)
+	defer iter.Release()
+
+	for iter.Next() {
+		key := iter.Key()
+		value := iter.Value()
+
+		switch key[0] {
+		case chain_utils.StorageHistoryKeyPrefix, chain_utils.BalanceHistoryKeyPrefix:
+			group := key[:len(key)-types.HeightSize]
+			if pendingKey != nil && !bytes.Equal(pendingKey[:len(pendingKey)-types.HeightSize], group) {
+				if err := flushPending(); err != nil {
+					return nil, err
+				}
+			}
+
+			if chain_utils.BytesToUint64(key[len(key)-types.HeightSize:]) < windowStart {
+				pendingKey = append(pendingKey[:0], key...)
+				pendingValue = append(pendingValue[:0], value...)
+				continue
+			}
+
+			if err := flushPending(); err != nil {
+				return nil, err
+			}
+		case chain_utils.StorageKeyPrefix, chain_utils.BalanceKeyPrefix, chain_utils.CodeKeyPrefix, chain_utils.GidContractKeyPrefix:
+		case chain_utils.ContractMetaKeyPrefix:
+			meta := &ledger.ContractMeta{}
+			if err := meta.Deserialize(value); err != nil {
+				return nil, err
+			}
+			if !meta.CreateBlockHash.IsZero() {
+				createBlockList = append(createBlockList, meta.CreateBlockHash)
+			}
+		case chain_utils.CallDepthKeyPrefix:
+			// only the call depth of the onroad send blocks is used
+			sendHash, err := types.BytesToHash(key[1:])
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := onRoadSet[sendHash]; !ok {
+				continue
+			}
+		default:
+			continue
+		}
+
+		if err := flushPending(); err != nil {
+			return nil, err
+		}
+		if err := writer.WriteState(key, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	if err := flushPending(); err != nil {
+		return nil, err
+	}
+	return createBlockList, nil
+}
+
+// ImportStateSnapshot replaces the ledger which only has the genesis snapshot block with the state snapshot,
+// the file is verified by meta.Root before written.
+func (c *chain) ImportStateSnapshot(filename string, meta *interfaces.StateSnapshotMeta) error {
+	if latestSb := c.GetLatestSnapshotBlock(); latestSb.Height != types.GenesisHeight {
+		return fmt.Errorf("can't import state snapshot, the latest snapshot block is %d", latestSb.Height)
+	}
+
+	if err := c.verifyStateSnapshot(filename, meta); err != nil {
+		return err
+	}
+
+	c.log.Info(fmt.Sprintf("import state snapshot %s", meta), "method", "ImportStateSnapshot")
+
+	// the ledger is unusable if the import is interrupted
+	if err := c.metaDB.Put([]byte{StateImportingKey}, meta.Hash.Bytes(), nil); err != nil {
+		return err
+	}
+
+	if err := c.importStateSnapshot(filename, meta); err != nil {
+		return err
+	}
+
+	if err := c.metaDB.Delete([]byte{StateImportingKey}, nil); err != nil {
+		return err
+	}
+
+	c.log.Info(fmt.Sprintf("import state snapshot %s finished", meta), "method", "ImportStateSnapshot")
+	return nil
+}
+
+// verifyStateSnapshot checks the root of the state snapshot and the snapshot blocks of it
+func (c *chain) verifyStateSnapshot(filename string, meta *interfaces.StateSnapshotMeta) error {
+	fd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	reader := state_sync.NewReader(fd)
+
+	var prevSb *ledger.SnapshotBlock
+	for {
+		record, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		if record.Type != state_sync.RecordSnapshotBlock {
+			continue
+		}
+
+		sb := &ledger.SnapshotBlock{}
+		if err := sb.Deserialize(record.Value); err != nil {
+			return err
+		}
+		if sb.ComputeHash() != sb.Hash {
+			return fmt.Errorf("snapshot block %d %s has an invalid hash", sb.Height, sb.Hash)
+		}
+		if prevSb != nil && (sb.Height != prevSb.Height+1 || sb.PrevHash != prevSb.Hash) {
+			return fmt.Errorf("snapshot block %d %s is not the next block of %d %s", sb.Height, sb.Hash, prevSb.Height, prevSb.Hash)
+		}
+		prevSb = sb
+	}
+
+	if root := reader.Root(); root != meta.Root {
+		return fmt.Errorf("state snapshot root is %s, expected %s", root, meta.Root)
+	}
+	if prevSb == nil || prevSb.Height != meta.Height || prevSb.Hash != meta.Hash {
+		return fmt.Errorf("the last snapshot block of state snapshot is not %d %s", meta.Height, meta.Hash)
+	}
+	return nil
+}
+
+func (c *chain) importStateSnapshot(filename string, meta *interfaces.StateSnapshotMeta) error {
+	// clean the genesis state
+	if err := c.cleanStateForImport(); err != nil {
+		return err
+	}
+
+	fd, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	reader := state_sync.NewReader(fd)
+
+	var latestSb *ledger.SnapshotBlock
+	var accountBlocks []*ledger.AccountBlock
+
+	stateBatch := new(leveldb.Batch)
+	indexBatch := new(leveldb.Batch)
+	for {
+		record, err := reader.Next()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+
+		switch record.Type {
+		case state_sync.RecordSnapshotBlock:
+			sb := &ledger.SnapshotBlock{}
+			if err := sb.Deserialize(record.Value); err != nil {
+				return err
+			}
+			if sb.Height == meta.Height {
+				latestSb = sb
+				continue
+			}
+			if err := c.importSnapshotBlock(sb, nil); err != nil {
+				return err
+			}
+			if sb.Height%stateImportBatchSize == 0 {
+				c.flusher.Flush()
+			}
+
+		case state_sync.RecordAccountBlock:
+			ab := &ledger.AccountBlock{}
+			if err := ab.Deserialize(record.Value); err != nil {
+				return err
+			}
+			accountBlocks = append(accountBlocks, ab)
+
+		case state_sync.RecordState:
+			stateBatch.Put(record.Key, record.Value)
+			if stateBatch.Len() >= stateImportBatchSize {
+				c.writeRawForImport(stateBatch, nil)
+				stateBatch = new(leveldb.Batch)
+			}
+
+		case state_sync.RecordIndex:
+			indexBatch.Put(record.Key, record.Value)
+			if indexBatch.Len() >= stateImportBatchSize {
+				c.writeRawForImport(nil, indexBatch)
+				indexBatch = new(leveldb.Batch)
+			}
+
+		default:
+			return fmt.Errorf("unknown record type %d", record.Type)
+		}
+	}
+	c.writeRawForImport(stateBatch, indexBatch)
+
+	// the accounts are imported
+	c.indexDB.InitAccountId()
+
+	if err := c.importLatestSnapshotBlock(latestSb, accountBlocks); err != nil {
+		return err
+	}
+
+	c.flusher.Flush()
+
+	if err := c.indexDB.Init(c); err != nil {
+		return err
+	}
+	if err := c.stateDB.ResetCache(); err != nil {
+		c.log.Warn(fmt.Sprintf("c.stateDB.ResetCache failed. Error: %s", err), "method", "ImportStateSnapshot")
+	}
+	return nil
+}
+
+func (c *chain) cleanStateForImport() error {
+	c.flushMu.RLock()
+	defer c.flushMu.RUnlock()
+
+	stateBatch := new(leveldb.Batch)
+	iter := c.stateDB.Store().NewIterator(nil)
+	for iter.Next() {
+		stateBatch.Delete(iter.Key())
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+
+	indexBatch := new(leveldb.Batch)
+	iter = c.indexDB.Store().NewIterator(util.BytesPrefix([]byte{chain_utils.OnRoadKeyPrefix}))
+	for iter.Next() {
+		indexBatch.Delete(iter.Key())
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return err
+	}
+
+	c.stateDB.WriteRaw(stateBatch)
+	c.indexDB.WriteRaw(indexBatch)
+	return nil
+}
+
+func (c *chain) writeRawForImport(stateBatch, indexBatch *leveldb.Batch) {
+	c.flushMu.RLock()
+	if stateBatch != nil {
+		c.stateDB.WriteRaw(stateBatch)
+	}
+	if indexBatch != nil {
+		c.indexDB.WriteRaw(indexBatch)
+	}
+	c.flushMu.RUnlock()
+
+	c.flusher.Flush()
+}
+
+// importSnapshotBlock writes the snapshot block and the account blocks confirmed by it,
+// the account blocks must be inserted into indexDB and cache before.
+func (c *chain) importSnapshotBlock(sb *ledger.SnapshotBlock, accountBlocks []*ledger.AccountBlock) error {
+	c.flushMu.RLock()
+	defer c.flushMu.RUnlock()
+
+	abLocationMap, sbLocation, err := c.blockDB.Write(&ledger.SnapshotChunk{
+		SnapshotBlock: sb,
+		AccountBlocks: accountBlocks,
+	})
+	if err != nil {
+		return fmt.Errorf("c.blockDB.Write failed, snapshot block is %d %s. Error: %s", sb.Height, sb.Hash, err)
+	}
+
+	c.indexDB.InsertSnapshotBlock(sb, accountBlocks, sbLocation, abLocationMap)
+
+	// the state is imported directly, no redo logs
+	if err := c.stateDB.InsertSnapshotBlock(sb, nil); err != nil {
+		return err
+	}
+
+	c.cache.InsertSnapshotBlock(sb, accountBlocks)
+	return nil
+}
+
+func (c *chain) importLatestSnapshotBlock(sb *ledger.SnapshotBlock, accountBlocks []*ledger.AccountBlock) error {
+	store := c.indexDB.Store()
+
+	// the onroad set and the received flags of the snapshot are the truth,
+	// restore them after the account blocks are inserted
+	restoreBatch := new(leveldb.Batch)
+	for _, ab := range accountBlocks {
+		sendBlocks := ab.SendBlockList
+		if !ab.IsReceiveBlock() {
+			sendBlocks = append([]*ledger.AccountBlock{ab}, sendBlocks...)
+		}
+
+		for _, sendBlock := range sendBlocks {
+			onRoadKey := chain_utils.CreateOnRoadKey(sendBlock.ToAddress, sendBlock.Hash).Bytes()
+			if ok, err := store.Has(onRoadKey); err != nil {
+				return err
+			} else if !ok {
+				restoreBatch.Delete(onRoadKey)
+			}
+
+			receiveKey := chain_utils.CreateReceiveKey(&sendBlock.Hash).Bytes()
+			if value, err := store.Get(receiveKey); err != nil {
+				return err
+			} else if value != nil {
+				restoreBatch.Put(receiveKey, value)
+			}
+		}
+	}
+
+	c.flushMu.RLock()
+	for _, ab := range accountBlocks {
+		c.cache.InsertAccountBlock(ab)
+		if err := c.indexDB.InsertAccountBlock(ab); err != nil {
+			c.flushMu.RUnlock()
+			return err
+		}
+	}
+	c.flushMu.RUnlock()
+
+	if err := c.importSnapshotBlock(sb, accountBlocks); err != nil {
+		return err
+	}
+
+	c.writeRawForImport(nil, restoreBatch)
+	return nil
+}
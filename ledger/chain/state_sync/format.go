@@ -0,0 +1,212 @@
+package state_sync
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+)
+
+// record types of a state snapshot file
+const (
+	RecordSnapshotBlock = byte(1)
+	RecordAccountBlock  = byte(2)
+	RecordState         = byte(3)
+	RecordIndex         = byte(4)
+)
+
+const maxRecordFieldSize = 64 * 1024 * 1024
+
+var errRecordTooLarge = errors.New("state snapshot record is too large")
+
+// Record is a key-value pair of the state snapshot, the meaning of Key and Value depends on Type:
+// RecordSnapshotBlock: height -> serialized snapshot block
+// RecordAccountBlock: hash -> serialized account block
+// RecordState: raw key -> raw value of stateDB
+// RecordIndex: raw key -> raw value of indexDB
+type Record struct {
+	Type  byte
+	Key   []byte
+	Value []byte
+}
+
+// Writer writes records to a state snapshot file and computes the root of all records.
+// The root is the blake2b-256 hash of the record stream, so the peers that agree on
+// the same root must serve the same bytes.
+type Writer struct {
+	w      *bufio.Writer
+	hasher hash.Hash
+
+	size  int64
+	items uint64
+
+	head [1 + 2*binary.MaxVarintLen64]byte
+}
+
+func NewWriter(w io.Writer) *Writer {
+	hasher, _ := blake2b.New256(nil)
+	return &Writer{
+		w:      bufio.NewWriterSize(w, 1024*1024),
+		hasher: hasher,
+	}
+}
+
+func (writer *Writer) WriteSnapshotBlock(height uint64, data []byte) error {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, height)
+	return writer.Write(RecordSnapshotBlock, key, data)
+}
+
+func (writer *Writer) WriteAccountBlock(hash types.Hash, data []byte) error {
+	return writer.Write(RecordAccountBlock, hash.Bytes(), data)
+}
+
+func (writer *Writer) WriteState(key, value []byte) error {
+	return writer.Write(RecordState, key, value)
+}
+
+func (writer *Writer) WriteIndex(key, value []byte) error {
+	return writer.Write(RecordIndex, key, value)
+}
+
+func (writer *Writer) Write(recordType byte, key, value []byte) error {
+	if len(key) > maxRecordFieldSize || len(value) > maxRecordFieldSize {
+		return errRecordTooLarge
+	}
+
+	head := writer.head[:0]
+	head = append(head, recordType)
+	head = appendUvarint(head, uint64(len(key)))
+	head = appendUvarint(head, uint64(len(value)))
+
+	for _, buf := range [][]byte{head, key, value} {
+		if _, err := writer.w.Write(buf); err != nil {
+			return err
+		}
+		writer.hasher.Write(buf)
+		writer.size += int64(len(buf))
+	}
+
+	writer.items++
+	return nil
+}
+
+func (writer *Writer) Flush() error {
+	return writer.w.Flush()
+}
+
+func (writer *Writer) Root() types.Hash {
+	root, _ := types.BytesToHash(writer.hasher.Sum(nil))
+	return root
+}
+
+func (writer *Writer) Size() int64 {
+	return writer.size
+}
+
+func (writer *Writer) Items() uint64 {
+	return writer.items
+}
+
+// Reader reads records from a state snapshot file, Root is available after Next returns io.EOF.
+type Reader struct {
+	r      *bufio.Reader
+	hasher hash.Hash
+
+	items uint64
+}
+
+func NewReader(r io.Reader) *Reader {
+	hasher, _ := blake2b.New256(nil)
+	return &Reader{
+		r:      bufio.NewReaderSize(r, 1024*1024),
+		hasher: hasher,
+	}
+}
+
+// Next returns the next record, return io.EOF if reach end
+func (reader *Reader) Next() (*Record, error) {
+	recordType, err := reader.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	keyLen, err := binary.ReadUvarint(reader.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	valueLen, err := binary.ReadUvarint(reader.r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if keyLen > maxRecordFieldSize || valueLen > maxRecordFieldSize {
+		return nil, errRecordTooLarge
+	}
+
+	record := &Record{
+		Type:  recordType,
+		Key:   make([]byte, keyLen),
+		Value: make([]byte, valueLen),
+	}
+	if _, err := io.ReadFull(reader.r, record.Key); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if _, err := io.ReadFull(reader.r, record.Value); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+
+	head := []byte{recordType}
+	head = appendUvarint(head, keyLen)
+	head = appendUvarint(head, valueLen)
+	reader.hasher.Write(head)
+	reader.hasher.Write(record.Key)
+	reader.hasher.Write(record.Value)
+
+	reader.items++
+	return record, nil
+}
+
+func (reader *Reader) Root() types.Hash {
+	root, _ := types.BytesToHash(reader.hasher.Sum(nil))
+	return root
+}
+
+func (reader *Reader) Items() uint64 {
+	return reader.items
+}
+
+// Verify reads all records of r and checks the root of them
+func Verify(r io.Reader, root types.Hash) error {
+	reader := NewReader(r)
+	for {
+		if _, err := reader.Next(); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+	}
+	if computed := reader.Root(); computed != root {
+		return fmt.Errorf("state snapshot root is %s, expected %s", computed, root)
+	}
+	return nil
+}
+
+func appendUvarint(buf []byte, x uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], x)
+	return append(buf, tmp[:n]...)
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+package state_sync
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vitelabs/go-vite/v2/interfaces"
+)
+
+const (
+	snapshotSuffix = ".snap"
+	metaSuffix     = ".json"
+)
+
+// FileName returns the filename of the state snapshot at height
+func FileName(dir string, height uint64) string {
+	return path.Join(dir, strconv.FormatUint(height, 10)+snapshotSuffix)
+}
+
+// MetaFileName returns the filename of the meta of the state snapshot at height
+func MetaFileName(dir string, height uint64) string {
+	return path.Join(dir, strconv.FormatUint(height, 10)+metaSuffix)
+}
+
+func WriteMeta(dir string, meta *interfaces.StateSnapshotMeta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+
+	filename := MetaFileName(dir, meta.Height)
+	tmpFilename := filename + ".tmp"
+	if err := ioutil.WriteFile(tmpFilename, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpFilename, filename)
+}
+
+func ReadMeta(dir string, height uint64) (*interfaces.StateSnapshotMeta, error) {
+	data, err := ioutil.ReadFile(MetaFileName(dir, height))
+	if err != nil {
+		return nil, err
+	}
+
+	meta := &interfaces.StateSnapshotMeta{}
+	if err := json.Unmarshal(data, meta); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// List returns the metas of the state snapshots in dir, sorted by height
+func List(dir string) ([]*interfaces.StateSnapshotMeta, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var metas []*interfaces.StateSnapshotMeta
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, metaSuffix) {
+			continue
+		}
+		height, err := strconv.ParseUint(strings.TrimSuffix(name, metaSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(FileName(dir, height)); err != nil {
+			continue
+		}
+
+		meta, err := ReadMeta(dir, height)
+		if err != nil {
+			return nil, err
+		}
+		metas = append(metas, meta)
+	}
+
+	sort.Slice(metas, func(i, j int) bool {
+		return metas[i].Height < metas[j].Height
+	})
+	return metas, nil
+}
+
+// Remove removes the state snapshot at height and its meta
+func Remove(dir string, height uint64) error {
+	if err := os.Remove(MetaFileName(dir, height)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(FileName(dir, height)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
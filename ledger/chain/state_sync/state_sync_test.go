@@ -0,0 +1,90 @@
+package state_sync
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+)
+
+func writeTestRecords(t *testing.T, buf *bytes.Buffer) *Writer {
+	writer := NewWriter(buf)
+	assert.NoError(t, writer.WriteSnapshotBlock(100, []byte("snapshot block")))
+	assert.NoError(t, writer.WriteAccountBlock(types.Hash{1}, []byte("account block")))
+	assert.NoError(t, writer.WriteState([]byte{3, 1, 2}, []byte("balance")))
+	assert.NoError(t, writer.WriteIndex([]byte{5, 1, 2}, nil))
+	assert.NoError(t, writer.Flush())
+	return writer
+}
+
+func TestReadWrite(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writer := writeTestRecords(t, buf)
+	assert.Equal(t, uint64(4), writer.Items())
+	assert.Equal(t, int64(buf.Len()), writer.Size())
+
+	reader := NewReader(bytes.NewReader(buf.Bytes()))
+	var records []*Record
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		records = append(records, record)
+	}
+
+	assert.Equal(t, 4, len(records))
+	assert.Equal(t, RecordSnapshotBlock, records[0].Type)
+	assert.Equal(t, []byte("snapshot block"), records[0].Value)
+	assert.Equal(t, RecordAccountBlock, records[1].Type)
+	assert.Equal(t, types.Hash{1}.Bytes(), records[1].Key)
+	assert.Equal(t, RecordState, records[2].Type)
+	assert.Equal(t, RecordIndex, records[3].Type)
+	assert.Equal(t, 0, len(records[3].Value))
+
+	assert.Equal(t, writer.Root(), reader.Root())
+	assert.NoError(t, Verify(bytes.NewReader(buf.Bytes()), writer.Root()))
+}
+
+func TestVerifyTampered(t *testing.T) {
+	buf := &bytes.Buffer{}
+	writer := writeTestRecords(t, buf)
+
+	data := buf.Bytes()
+	data[len(data)-5] ^= 0xff
+	assert.Error(t, Verify(bytes.NewReader(data), writer.Root()))
+
+	assert.Equal(t, io.ErrUnexpectedEOF, Verify(bytes.NewReader(data[:len(data)-1]), writer.Root()))
+}
+
+func TestMeta(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state_sync")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, height := range []uint64{300, 100, 200} {
+		assert.NoError(t, ioutil.WriteFile(FileName(dir, height), nil, 0600))
+		assert.NoError(t, WriteMeta(dir, &interfaces.StateSnapshotMeta{Height: height, Hash: types.Hash{byte(height)}}))
+	}
+	// meta without snapshot file is ignored
+	assert.NoError(t, WriteMeta(dir, &interfaces.StateSnapshotMeta{Height: 400}))
+
+	metas, err := List(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(metas))
+	assert.Equal(t, uint64(100), metas[0].Height)
+	assert.Equal(t, uint64(300), metas[2].Height)
+	assert.Equal(t, types.Hash{byte(200)}, metas[1].Hash)
+
+	assert.NoError(t, Remove(dir, 100))
+	metas, err = List(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(metas))
+}
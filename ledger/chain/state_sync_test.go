@@ -0,0 +1,112 @@
+package chain
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/ledger/chain/state_sync"
+)
+
+func TestExportAndImportStateSnapshot(t *testing.T) {
+	chainInstance, accounts, _ := SetUp(t, 10, 100, 5)
+	defer func() {
+		TearDown(chainInstance)
+		Clear(chainInstance)
+	}()
+
+	// confirm all account blocks
+	_, _, err := InsertSnapshotBlock(chainInstance, true)
+	assert.NoError(t, err)
+
+	dir, err := ioutil.TempDir("", "state_snapshot")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	meta, err := chainInstance.ExportStateSnapshot(dir)
+	assert.NoError(t, err)
+
+	latestSb := chainInstance.GetLatestSnapshotBlock()
+	assert.Equal(t, latestSb.Height, meta.Height)
+	assert.Equal(t, latestSb.Hash, meta.Hash)
+
+	metas, err := state_sync.List(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(metas))
+	assert.Equal(t, *meta, *metas[0])
+
+	importChain, err := NewChainInstance(t, t.Name()+"_import", true)
+	assert.NoError(t, err)
+	defer func() {
+		TearDown(importChain)
+		Clear(importChain)
+	}()
+
+	// the root must be matched
+	wrongMeta := *meta
+	wrongMeta.Root[0] ^= 0xff
+	assert.Error(t, importChain.ImportStateSnapshot(state_sync.FileName(dir, meta.Height), &wrongMeta))
+
+	assert.NoError(t, importChain.ImportStateSnapshot(state_sync.FileName(dir, meta.Height), meta))
+
+	importing, err := importChain.QueryStateImporting()
+	assert.NoError(t, err)
+	assert.False(t, importing)
+
+	assert.Equal(t, latestSb.Hash, importChain.GetLatestSnapshotBlock().Hash)
+
+	sb, err := importChain.GetSnapshotBlockByHeight(meta.Height - 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, sb)
+
+	for addr := range accounts {
+		balance, err := chainInstance.GetBalance(addr, ledger.ViteTokenId)
+		assert.NoError(t, err)
+		importBalance, err := importChain.GetBalance(addr, ledger.ViteTokenId)
+		assert.NoError(t, err)
+		assert.Equal(t, balance.String(), importBalance.String())
+
+		block, err := chainInstance.GetLatestAccountBlock(addr)
+		assert.NoError(t, err)
+		importBlock, err := importChain.GetLatestAccountBlock(addr)
+		assert.NoError(t, err)
+		if block == nil {
+			assert.Nil(t, importBlock)
+			continue
+		}
+		assert.NotNil(t, importBlock)
+		assert.Equal(t, block.Hash, importBlock.Hash)
+
+		onRoadBlocks, err := chainInstance.GetOnRoadBlocksByAddr(addr, 0, 1000)
+		assert.NoError(t, err)
+		importOnRoadBlocks, err := importChain.GetOnRoadBlocksByAddr(addr, 0, 1000)
+		assert.NoError(t, err)
+		assert.Equal(t, len(onRoadBlocks), len(importOnRoadBlocks))
+	}
+
+	// only the ledger at genesis can import
+	assert.Error(t, importChain.ImportStateSnapshot(state_sync.FileName(dir, meta.Height), meta))
+}
+
+func TestExportStateSnapshotAt(t *testing.T) {
+	chainInstance, _, _ := SetUp(t, 3, 20, 5)
+	defer func() {
+		TearDown(chainInstance)
+		Clear(chainInstance)
+	}()
+
+	chainInstance.chainCfg.StateSnapshotInterval = chainInstance.GetLatestSnapshotBlock().Height + 1
+
+	sb, _, err := InsertSnapshotBlock(chainInstance, true)
+	assert.NoError(t, err)
+	chainInstance.stateSnapshotWg.Wait()
+
+	metas, err := state_sync.List(chainInstance.StateSnapshotDir())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(metas))
+	assert.Equal(t, sb.Height, metas[0].Height)
+	assert.Equal(t, sb.Hash, metas[0].Hash)
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultAlgo is the election algorithm of the mainnet, see algo
+	DefaultAlgo = "default"
+	// TopAlgo elects the top NodeCount candidates by votes, without the success rate filter and the random promotion
+	TopAlgo = "top"
+)
+
+// AlgoCreator creates an election algorithm for a consensus group. The registered algorithms are only used
+// to simulate the elections, the consensus always elects by DefaultAlgo, since every node must elect the same.
+type AlgoCreator func(info *GroupInfo) Algo
+
+var (
+	algoCreators = map[string]AlgoCreator{
+		DefaultAlgo: func(info *GroupInfo) Algo { return NewAlgo(info) },
+		TopAlgo:     func(info *GroupInfo) Algo { return NewTopAlgo(info) },
+	}
+	algoCreatorsMu sync.RWMutex
+)
+
+// RegisterAlgo registers an election algorithm by name, the name can't be registered twice.
+func RegisterAlgo(name string, creator AlgoCreator) error {
+	algoCreatorsMu.Lock()
+	defer algoCreatorsMu.Unlock()
+	if name == "" || creator == nil {
+		return errors.New("the name and the creator of algo can't be empty")
+	}
+	if _, ok := algoCreators[name]; ok {
+		return errors.Errorf("algo[%s] is registered", name)
+	}
+	algoCreators[name] = creator
+	return nil
+}
+
+// NewAlgoByName creates the registered election algorithm for the consensus group, an empty name means DefaultAlgo.
+func NewAlgoByName(name string, info *GroupInfo) (Algo, error) {
+	if name == "" {
+		name = DefaultAlgo
+	}
+	algoCreatorsMu.RLock()
+	creator, ok := algoCreators[name]
+	algoCreatorsMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("algo[%s] is not registered, registered: %v", name, AlgoNames())
+	}
+	return creator(info), nil
+}
+
+// AlgoNames returns the names of the registered election algorithms in order
+func AlgoNames() []string {
+	algoCreatorsMu.RLock()
+	defer algoCreatorsMu.RUnlock()
+	names := make([]string, 0, len(algoCreators))
+	for name := range algoCreators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type topAlgo struct {
+	*algo
+}
+
+// NewTopAlgo creates the algorithm which elects the top NodeCount candidates by votes,
+// the members are shuffled the same as the default algorithm.
+func NewTopAlgo(info *GroupInfo) Algo {
+	return &topAlgo{algo: NewAlgo(info)}
+}
+
+func (self *topAlgo) FilterVotes(context *VoteAlgoContext) []*Vote {
+	groupA, groupB := self.FilterSimple(context.votes)
+	context.sbps = mergeGroup(groupA, groupB)
+
+	sort.Sort(ByBalance(groupA))
+	return groupA
+}
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+)
+
+func TestRegisterAlgo(t *testing.T) {
+	info := NewGroupInfo(time.Unix(1541640427, 0), types.ConsensusGroupInfo{
+		Gid:             types.SNAPSHOT_GID,
+		NodeCount:       25,
+		Interval:        1,
+		PerCount:        3,
+		RandCount:       2,
+		RandRank:        100,
+		CountingTokenId: ledger.ViteTokenId,
+	})
+
+	defaultAlgo, err := NewAlgoByName("", info)
+	assert.NoError(t, err)
+	assert.IsType(t, &algo{}, defaultAlgo)
+
+	_, err = NewAlgoByName("test_registry", info)
+	assert.Error(t, err)
+	assert.NoError(t, RegisterAlgo("test_registry", func(info *GroupInfo) Algo { return NewTopAlgo(info) }))
+	assert.Error(t, RegisterAlgo("test_registry", func(info *GroupInfo) Algo { return NewAlgo(info) }))
+	assert.Error(t, RegisterAlgo(DefaultAlgo, func(info *GroupInfo) Algo { return NewAlgo(info) }))
+	assert.Contains(t, AlgoNames(), "test_registry")
+
+	top, err := NewAlgoByName(TopAlgo, info)
+	assert.NoError(t, err)
+	var votes []*Vote
+	for i := 0; i < 100; i++ {
+		votes = append(votes, &Vote{Name: "wj_" + strconv.Itoa(i), Balance: big.NewInt(int64(i))})
+	}
+	hashH := &ledger.HashHeight{Height: 1}
+	// the producers whose success rate is low are elected by the top algo
+	successRate := map[types.Address]int32{}
+	context := NewVoteAlgoContext(votes, hashH, successRate, NewSeedInfo(0))
+	actual := top.FilterVotes(context)
+	assert.Len(t, actual, 25)
+	for i, v := range actual {
+		assert.Equal(t, "wj_"+strconv.Itoa(99-i), v.Name)
+	}
+}
@@ -0,0 +1,225 @@
+package consensus
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/ledger/consensus/core"
+)
+
+// SimulationReport is the result of replaying the historical votes of the snapshot consensus group through
+// the election algorithms. The success rates are the historical ones, which were produced by the producers
+// elected by the algorithm in use at that time.
+type SimulationReport struct {
+	StartIndex uint64
+	EndIndex   uint64
+	Algos      []*AlgoSimulation
+}
+
+// AlgoSimulation is the simulated elections of an algorithm, the first algorithm of the report is the baseline.
+type AlgoSimulation struct {
+	Algo    string
+	Periods []*PeriodSimulation
+
+	// ElectedPeriods is the count of the periods each producer is elected in
+	ElectedPeriods map[string]uint64
+	// DiffPeriods is the count of the periods whose producers are different from the baseline
+	DiffPeriods uint64
+
+	// AvgSuccessRate is the average success rate in the last hour of the elected producers which have the rate
+	AvgSuccessRate float64
+	// LowSuccessRateCount is the count of the elected producers whose success rate is below core.Line
+	LowSuccessRateCount uint64
+
+	// AvgStakeRatio is the average ratio of the votes of the elected producers to the votes of all candidates
+	AvgStakeRatio float64
+	// AvgHHI is the average Herfindahl-Hirschman index of the votes of the elected producers, from 1/n to 1
+	AvgHHI float64
+	// AvgNakamoto is the average of the minimum count of the elected producers holding over 1/3 of their votes
+	AvgNakamoto float64
+}
+
+// PeriodSimulation is the elected producers of a period in the order of the plan
+type PeriodSimulation struct {
+	Index     uint64
+	Height    uint64
+	Hash      types.Hash
+	Producers []string
+}
+
+type algoSimulationStat struct {
+	rateSum, rateCount  float64
+	stakeRatioSum       float64
+	hhiSum, nakamotoSum float64
+}
+
+// SimulateElection replays the votes at the proof snapshot blocks of the periods from startIndex to endIndex
+// through the registered election algorithms of algoNames.
+func (api *APISnapshot) SimulateElection(algoNames []string, startIndex, endIndex uint64) (*SimulationReport, error) {
+	snapshot := api.snapshot
+	if len(algoNames) == 0 {
+		return nil, errors.New("algos can't be empty")
+	}
+	if startIndex > endIndex {
+		return nil, errors.Errorf("start index[%d] must <= end index[%d]", startIndex, endIndex)
+	}
+
+	algos := make([]core.Algo, len(algoNames))
+	report := &SimulationReport{StartIndex: startIndex, EndIndex: endIndex}
+	for i, name := range algoNames {
+		algo, err := core.NewAlgoByName(name, &snapshot.GroupInfo)
+		if err != nil {
+			return nil, err
+		}
+		algos[i] = algo
+		report.Algos = append(report.Algos, &AlgoSimulation{Algo: name, ElectedPeriods: make(map[string]uint64)})
+	}
+	stats := make([]*algoSimulationStat, len(algos))
+	for i := range stats {
+		stats[i] = &algoSimulationStat{}
+	}
+
+	for index := startIndex; index <= endIndex; index++ {
+		proofTime, _ := snapshot.genSnapshotProofTimeIndx(index)
+		proofBlock, err := snapshot.rw.GetSnapshotBeforeTime(proofTime)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get proof block of index[%d]", index)
+		}
+		hashH := ledger.HashHeight{Hash: proofBlock.Hash, Height: proofBlock.Height}
+
+		votes, err := snapshot.rw.CalVotes(&snapshot.GroupInfo, hashH)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cal votes of index[%d] at %d", index, hashH.Height)
+		}
+		var successRate map[types.Address]int32
+		_, proofIndex := snapshot.genSnapshotProofTimeIndx(snapshot.Time2Index(*proofBlock.Timestamp))
+		if proofIndex > 0 {
+			successRate, err = snapshot.rw.GetSuccessRateByHour(proofIndex)
+			if err != nil {
+				return nil, errors.Wrapf(err, "get success rate of index[%d]", index)
+			}
+		}
+		seed := core.NewSeedInfo(snapshot.rw.GetSeedsBeforeHashH(hashH.Hash))
+
+		totalStake := big.NewInt(0)
+		for _, v := range votes {
+			totalStake.Add(totalStake, v.Balance)
+		}
+
+		var baseline []string
+		for i, algo := range algos {
+			context := core.NewVoteAlgoContext(copyVotes(votes), &hashH, copySuccessRate(successRate), seed)
+			finalVotes := algo.FilterVotes(context)
+			finalVotes = algo.ShuffleVotes(finalVotes, &hashH, seed)
+
+			period := &PeriodSimulation{Index: index, Height: hashH.Height, Hash: hashH.Hash}
+			for _, v := range finalVotes {
+				period.Producers = append(period.Producers, v.Name)
+			}
+			sim := report.Algos[i]
+			sim.Periods = append(sim.Periods, period)
+
+			if i == 0 {
+				baseline = period.Producers
+			} else if !sameProducers(baseline, period.Producers) {
+				sim.DiffPeriods++
+			}
+			stats[i].add(sim, finalVotes, successRate, totalStake)
+		}
+	}
+
+	periods := float64(endIndex - startIndex + 1)
+	for i, sim := range report.Algos {
+		stat := stats[i]
+		if stat.rateCount > 0 {
+			sim.AvgSuccessRate = stat.rateSum / stat.rateCount
+		}
+		sim.AvgStakeRatio = stat.stakeRatioSum / periods
+		sim.AvgHHI = stat.hhiSum / periods
+		sim.AvgNakamoto = stat.nakamotoSum / periods
+	}
+	return report, nil
+}
+
+func (stat *algoSimulationStat) add(sim *AlgoSimulation, elected []*core.Vote, successRate map[types.Address]int32, totalStake *big.Int) {
+	electedStake := big.NewInt(0)
+	for _, v := range elected {
+		sim.ElectedPeriods[v.Name]++
+		electedStake.Add(electedStake, v.Balance)
+
+		if rate, ok := successRate[v.Addr]; ok {
+			stat.rateSum += float64(rate) / 1000000
+			stat.rateCount++
+			if rate < core.Line {
+				sim.LowSuccessRateCount++
+			}
+		}
+	}
+	if totalStake.Sign() > 0 {
+		stat.stakeRatioSum += bigRatio(electedStake, totalStake)
+	}
+	if electedStake.Sign() <= 0 {
+		return
+	}
+
+	shares := make([]float64, 0, len(elected))
+	for _, v := range elected {
+		shares = append(shares, bigRatio(v.Balance, electedStake))
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(shares)))
+	sum := float64(0)
+	nakamoto := 0
+	for _, share := range shares {
+		stat.hhiSum += share * share
+		if sum <= float64(1)/3 {
+			sum += share
+			nakamoto++
+		}
+	}
+	stat.nakamotoSum += float64(nakamoto)
+}
+
+func bigRatio(x, y *big.Int) float64 {
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(x), new(big.Float).SetInt(y)).Float64()
+	return ratio
+}
+
+// copyVotes copies the votes, the algorithms sort the votes and mark their types in place
+func copyVotes(votes []*core.Vote) []*core.Vote {
+	result := make([]*core.Vote, len(votes))
+	for i, v := range votes {
+		result[i] = &core.Vote{Name: v.Name, Addr: v.Addr, Balance: new(big.Int).Set(v.Balance)}
+	}
+	return result
+}
+
+func copySuccessRate(successRate map[types.Address]int32) map[types.Address]int32 {
+	if successRate == nil {
+		return nil
+	}
+	result := make(map[types.Address]int32, len(successRate))
+	for k, v := range successRate {
+		result[k] = v
+	}
+	return result
+}
+
+func sameProducers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]bool, len(a))
+	for _, name := range a {
+		set[name] = true
+	}
+	for _, name := range b {
+		if !set[name] {
+			return false
+		}
+	}
+	return true
+}
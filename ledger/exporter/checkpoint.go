@@ -0,0 +1,105 @@
+package exporter
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+)
+
+// recentHashCount is the count of the delivered snapshot hashes kept in the checkpoint, to find
+// the fork point of a rollback happened while the node was down
+const recentHashCount = 256
+
+// checkpoint is the persisted position of a sink, all the events up to the snapshot height have been
+// delivered to the sink. Rollback is set if the chain rolled back below the delivered height and the
+// rollback event is not delivered yet
+type checkpoint struct {
+	file string
+
+	Height   uint64       `json:"height"`
+	Hash     types.Hash   `json:"hash"`
+	Rollback bool         `json:"rollback"`
+	Recent   []types.Hash `json:"recent"` // the hashes of the latest delivered snapshot blocks up to height, the oldest first
+}
+
+func newCheckpoint(file string, height uint64, hash types.Hash) *checkpoint {
+	cp := &checkpoint{file: file, Height: height, Hash: hash}
+	if height > 0 {
+		cp.Recent = []types.Hash{hash}
+	}
+	return cp
+}
+
+func (cp *checkpoint) push(height uint64, hash types.Hash) {
+	cp.Height = height
+	cp.Hash = hash
+	cp.Recent = append(cp.Recent, hash)
+	if len(cp.Recent) > recentHashCount {
+		cp.Recent = append([]types.Hash{}, cp.Recent[len(cp.Recent)-recentHashCount:]...)
+	}
+}
+
+func (cp *checkpoint) truncate(height uint64, hash types.Hash) {
+	if n := cp.Height - height; n >= uint64(len(cp.Recent)) {
+		cp.Recent = []types.Hash{hash}
+	} else {
+		cp.Recent = cp.Recent[:uint64(len(cp.Recent))-n]
+	}
+	cp.Height = height
+	cp.Hash = hash
+}
+
+// hashAt returns the hash of the delivered snapshot block at the height, false if it is out of the recent hashes
+func (cp *checkpoint) hashAt(height uint64) (types.Hash, bool) {
+	if height > cp.Height || cp.Height-height >= uint64(len(cp.Recent)) {
+		return types.Hash{}, false
+	}
+	return cp.Recent[uint64(len(cp.Recent))-1-(cp.Height-height)], true
+}
+
+// loadCheckpoint reads the checkpoint of a sink, it returns nil if the sink has never run
+func loadCheckpoint(file string) (*checkpoint, error) {
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, os.MkdirAll(filepath.Dir(file), 0700)
+		}
+		return nil, err
+	}
+	cp := &checkpoint{file: file}
+	if err := json.Unmarshal(buf, cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+func (cp *checkpoint) save() error {
+	buf, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(cp.file, buf)
+}
+
+func writeFileAtomic(file string, data []byte) error {
+	tmp := file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
@@ -0,0 +1,143 @@
+package exporter
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+)
+
+type EventType string
+
+const (
+	SnapshotBlockEvent EventType = "snapshotBlock"
+	AccountBlockEvent  EventType = "accountBlock"
+	LogEvent           EventType = "log"
+	// RollbackEvent reverts all the events above the snapshot height of the event
+	RollbackEvent EventType = "rollback"
+)
+
+type SnapshotBlockInfo struct {
+	Hash      types.Hash    `json:"hash"`
+	PrevHash  types.Hash    `json:"prevHash"`
+	Height    uint64        `json:"height"`
+	Producer  types.Address `json:"producer"`
+	Timestamp int64         `json:"timestamp"`
+}
+
+type AccountBlockInfo struct {
+	BlockType      byte                `json:"blockType"`
+	Hash           types.Hash          `json:"hash"`
+	PrevHash       types.Hash          `json:"prevHash"`
+	Height         uint64              `json:"height"`
+	AccountAddress types.Address       `json:"address"`
+	ToAddress      types.Address       `json:"toAddress"`
+	FromBlockHash  types.Hash          `json:"fromBlockHash"`
+	TokenId        types.TokenTypeId   `json:"tokenId"`
+	Amount         string              `json:"amount"`
+	Fee            string              `json:"fee"`
+	Data           []byte              `json:"data"`
+	SendBlockList  []*AccountBlockInfo `json:"sendBlockList,omitempty"`
+}
+
+type LogInfo struct {
+	AccountAddress types.Address `json:"address"`
+	BlockHash      types.Hash    `json:"blockHash"`
+	Index          int           `json:"index"`
+	Topics         []types.Hash  `json:"topics"`
+	Data           []byte        `json:"data"`
+}
+
+// Event is emitted in the order of the chain, the events of a snapshot block start with the snapshot block,
+// followed by the confirmed account blocks, each account block is followed by its VM logs
+type Event struct {
+	Type           EventType          `json:"type"`
+	SnapshotHeight uint64             `json:"snapshotHeight"`
+	SnapshotHash   types.Hash         `json:"snapshotHash"`
+	SnapshotBlock  *SnapshotBlockInfo `json:"snapshotBlock,omitempty"`
+	AccountBlock   *AccountBlockInfo  `json:"accountBlock,omitempty"`
+	Log            *LogInfo           `json:"log,omitempty"`
+
+	// the original ledger objects, used by the protobuf encoding
+	snapshotBlock *ledger.SnapshotBlock
+	accountBlock  *ledger.AccountBlock
+	vmLog         *ledger.VmLog
+}
+
+func amountToString(amount *big.Int) string {
+	if amount == nil {
+		return "0"
+	}
+	return amount.String()
+}
+
+func newAccountBlockInfo(block *ledger.AccountBlock) *AccountBlockInfo {
+	info := &AccountBlockInfo{
+		BlockType:      block.BlockType,
+		Hash:           block.Hash,
+		PrevHash:       block.PrevHash,
+		Height:         block.Height,
+		AccountAddress: block.AccountAddress,
+		ToAddress:      block.ToAddress,
+		FromBlockHash:  block.FromBlockHash,
+		TokenId:        block.TokenId,
+		Amount:         amountToString(block.Amount),
+		Fee:            amountToString(block.Fee),
+		Data:           block.Data,
+	}
+	for _, sendBlock := range block.SendBlockList {
+		info.SendBlockList = append(info.SendBlockList, newAccountBlockInfo(sendBlock))
+	}
+	return info
+}
+
+func newSnapshotBlockEvent(sb *ledger.SnapshotBlock) *Event {
+	return &Event{
+		Type:           SnapshotBlockEvent,
+		SnapshotHeight: sb.Height,
+		SnapshotHash:   sb.Hash,
+		SnapshotBlock: &SnapshotBlockInfo{
+			Hash:      sb.Hash,
+			PrevHash:  sb.PrevHash,
+			Height:    sb.Height,
+			Producer:  sb.Producer(),
+			Timestamp: sb.Timestamp.Unix(),
+		},
+		snapshotBlock: sb,
+	}
+}
+
+func newAccountBlockEvent(sb *ledger.SnapshotBlock, block *ledger.AccountBlock) *Event {
+	return &Event{
+		Type:           AccountBlockEvent,
+		SnapshotHeight: sb.Height,
+		SnapshotHash:   sb.Hash,
+		AccountBlock:   newAccountBlockInfo(block),
+		accountBlock:   block,
+	}
+}
+
+func newLogEvent(sb *ledger.SnapshotBlock, block *ledger.AccountBlock, index int, vmLog *ledger.VmLog) *Event {
+	return &Event{
+		Type:           LogEvent,
+		SnapshotHeight: sb.Height,
+		SnapshotHash:   sb.Hash,
+		Log: &LogInfo{
+			AccountAddress: block.AccountAddress,
+			BlockHash:      block.Hash,
+			Index:          index,
+			Topics:         vmLog.Topics,
+			Data:           vmLog.Data,
+		},
+		vmLog: vmLog,
+	}
+}
+
+// newRollbackEvent reverts the events above the height, the hash is the snapshot block at the height after the rollback
+func newRollbackEvent(height uint64, hash types.Hash) *Event {
+	return &Event{
+		Type:           RollbackEvent,
+		SnapshotHeight: height,
+		SnapshotHash:   hash,
+	}
+}
@@ -0,0 +1,442 @@
+package exporter
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/log15"
+)
+
+const (
+	idleInterval     = time.Second
+	minRetryInterval = time.Second
+	maxRetryInterval = time.Minute
+)
+
+var errExporterStarted = errors.New("the exporter is started")
+
+// Sink receives the events of the exporter. Send is called with the events of one snapshot block, or with
+// a single rollback event, the events must be durable when Send returns nil. A batch which failed is sent
+// again, so a sink may receive a batch more than once after an error or a crash.
+type Sink interface {
+	// Name is unique among the sinks of an exporter, the checkpoint of the sink is named after it
+	Name() string
+	Send(events []*Event) error
+	Close() error
+}
+
+type Chain interface {
+	Register(listener interfaces.EventListener)
+	UnRegister(listener interfaces.EventListener)
+
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+	GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error)
+	GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error)
+	GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error)
+}
+
+// Exporter emits the confirmed blocks, their VM logs and the rollbacks of the chain to the sinks in order.
+// Every sink is driven by its own worker and checkpoint, a slow or broken sink doesn't hold up the others,
+// and it resumes from its checkpoint after a restart.
+type Exporter struct {
+	cfg   *config.Exporter
+	chain Chain
+	log   log15.Logger
+
+	workers []*sinkWorker
+	started bool
+	term    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func New(cfg *config.Exporter, chain Chain) (*Exporter, error) {
+	e := &Exporter{
+		cfg:   cfg,
+		chain: chain,
+		log:   log15.New("module", "exporter"),
+	}
+
+	if cfg.FileEnabled {
+		format := cfg.FileFormat
+		if format == "" {
+			format = config.DefaultExporterFileFormat
+		}
+		maxSize := cfg.FileMaxSize
+		if maxSize <= 0 {
+			maxSize = config.DefaultExporterFileMaxSize
+		}
+		sink, err := NewFileSink(filepath.Join(cfg.Dir, "events"), format, maxSize)
+		if err != nil {
+			return nil, err
+		}
+		if err := e.AddSink(sink); err != nil {
+			return nil, err
+		}
+	}
+
+	timeout := time.Duration(cfg.WebhookTimeout) * time.Second
+	for i, url := range cfg.WebhookURLs {
+		sink := NewWebhookSink(fmt.Sprintf("webhook%d", i), url, cfg.WebhookSecret, timeout, cfg.WebhookMaxRetry)
+		if err := e.AddSink(sink); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// AddSink adds a sink before the exporter starts, e.g. a BrokerSink for a message broker
+func (e *Exporter) AddSink(sink Sink) error {
+	if e.started {
+		return errExporterStarted
+	}
+	for _, w := range e.workers {
+		if w.sink.Name() == sink.Name() {
+			return fmt.Errorf("sink %s is existed", sink.Name())
+		}
+	}
+	e.workers = append(e.workers, &sinkWorker{
+		sink:   sink,
+		chain:  e.chain,
+		file:   filepath.Join(e.cfg.Dir, sink.Name()+".checkpoint"),
+		notify: make(chan struct{}, 1),
+		log:    e.log.New("sink", sink.Name()),
+	})
+	return nil
+}
+
+func (e *Exporter) Start() error {
+	if e.started {
+		return errExporterStarted
+	}
+	for _, w := range e.workers {
+		if err := w.init(e.cfg.StartHeight); err != nil {
+			return err
+		}
+	}
+	e.chain.Register(e)
+
+	e.started = true
+	e.term = make(chan struct{})
+	for _, w := range e.workers {
+		e.wg.Add(1)
+		go func(w *sinkWorker) {
+			defer e.wg.Done()
+			w.run(e.term)
+		}(w)
+	}
+	e.log.Info(fmt.Sprintf("exporter started with %d sinks", len(e.workers)))
+	return nil
+}
+
+func (e *Exporter) Stop() {
+	if !e.started {
+		return
+	}
+	e.chain.UnRegister(e)
+	close(e.term)
+	e.wg.Wait()
+	for _, w := range e.workers {
+		if err := w.sink.Close(); err != nil {
+			w.log.Error(fmt.Sprintf("close sink failed, %s", err.Error()))
+		}
+	}
+	e.started = false
+}
+
+func (e *Exporter) PrepareInsertAccountBlocks(blocks []*interfaces.VmAccountBlock) error {
+	return nil
+}
+
+func (e *Exporter) InsertAccountBlocks(blocks []*interfaces.VmAccountBlock) error {
+	return nil
+}
+
+func (e *Exporter) PrepareInsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (e *Exporter) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	for _, w := range e.workers {
+		select {
+		case w.notify <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (e *Exporter) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+// PrepareDeleteSnapshotBlocks persists the rollback before the blocks are deleted, so the rollback is
+// not lost if the node crashes in the middle of the deletion
+func (e *Exporter) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	e.rollback(chunks)
+	return nil
+}
+
+// DeleteSnapshotBlocks checks the rollback again, a worker may have read a deleted block after the preparation
+func (e *Exporter) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	e.rollback(chunks)
+	return nil
+}
+
+func (e *Exporter) rollback(chunks []*ledger.SnapshotChunk) {
+	found := false
+	height := uint64(0)
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock != nil && (!found || chunk.SnapshotBlock.Height-1 < height) {
+			found = true
+			height = chunk.SnapshotBlock.Height - 1
+		}
+	}
+	if !found {
+		return
+	}
+	hash, err := snapshotHashAt(e.chain, height)
+	if err != nil {
+		e.log.Error(fmt.Sprintf("query snapshot block %d failed, %s", height, err.Error()), "method", "rollback")
+		return
+	}
+	for _, w := range e.workers {
+		if err := w.rollbackTo(height, hash); err != nil {
+			w.log.Error(fmt.Sprintf("save rollback to %d failed, %s", height, err.Error()), "method", "rollback")
+		}
+	}
+}
+
+func snapshotHashAt(chain Chain, height uint64) (types.Hash, error) {
+	if height == 0 {
+		return types.Hash{}, nil
+	}
+	sb, err := chain.GetSnapshotHeaderByHeight(height)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	if sb == nil {
+		return types.Hash{}, fmt.Errorf("snapshot block %d is not existed", height)
+	}
+	return sb.Hash, nil
+}
+
+// buildEvents collects the events of the snapshot block at the height
+func buildEvents(chain Chain, height uint64) (*ledger.SnapshotBlock, []*Event, error) {
+	chunks, err := chain.GetSubLedger(height-1, height)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, chunk := range chunks {
+		sb := chunk.SnapshotBlock
+		if sb == nil || sb.Height != height {
+			continue
+		}
+		events := []*Event{newSnapshotBlockEvent(sb)}
+		for _, block := range chunk.AccountBlocks {
+			events = append(events, newAccountBlockEvent(sb, block))
+			if block.LogHash == nil {
+				continue
+			}
+			logList, err := chain.GetVmLogList(block.LogHash)
+			if err != nil {
+				return nil, nil, err
+			}
+			for i, vmLog := range logList {
+				events = append(events, newLogEvent(sb, block, i, vmLog))
+			}
+		}
+		return sb, events, nil
+	}
+	return nil, nil, fmt.Errorf("snapshot block %d is not existed", height)
+}
+
+type sinkWorker struct {
+	sink  Sink
+	chain Chain
+	file  string
+	log   log15.Logger
+
+	cp         *checkpoint
+	delivering uint64 // the snapshot height being delivered, 0 if none
+	mu         sync.Mutex
+
+	notify chan struct{}
+}
+
+// init loads the checkpoint of the sink, a rollback is scheduled if the chain changed while the node was down
+func (w *sinkWorker) init(startHeight uint64) error {
+	cp, err := loadCheckpoint(w.file)
+	if err != nil {
+		return err
+	}
+	latest := w.chain.GetLatestSnapshotBlock()
+	if latest == nil {
+		return errors.New("GetLatestSnapshotBlock fail")
+	}
+
+	if cp == nil {
+		height, hash := latest.Height, latest.Hash
+		if startHeight > 0 {
+			if startHeight > latest.Height+1 {
+				return fmt.Errorf("start height %d is higher than the latest snapshot block %d", startHeight, latest.Height)
+			}
+			height = startHeight - 1
+			if hash, err = snapshotHashAt(w.chain, height); err != nil {
+				return err
+			}
+		}
+		w.cp = newCheckpoint(w.file, height, hash)
+		return w.cp.save()
+	}
+
+	cp.file = w.file
+	w.cp = cp
+	return w.rollbackToForkPoint(*cp)
+}
+
+// rollbackTo schedules a rollback event if the events above the height have been delivered, or are being delivered
+func (w *sinkWorker) rollbackTo(height uint64, hash types.Hash) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cp.Height <= height && w.delivering <= height {
+		return nil
+	}
+	w.cp.truncate(height, hash)
+	w.cp.Rollback = true
+	return w.cp.save()
+}
+
+// rollbackToForkPoint schedules a rollback to the highest delivered snapshot block which is still in the chain,
+// if the chain changed without the notification of the deletion, e.g. while the node was down
+func (w *sinkWorker) rollbackToForkPoint(cp checkpoint) error {
+	height := cp.Height
+	if latest := w.chain.GetLatestSnapshotBlock(); latest.Height < height {
+		height = latest.Height
+	}
+	for ; height > 0; height-- {
+		delivered, ok := cp.hashAt(height)
+		if !ok {
+			w.log.Warn(fmt.Sprintf("the fork point is lower than the recent delivered snapshot blocks, rollback to %d", height), "method", "rollbackToForkPoint")
+			break
+		}
+		hash, err := snapshotHashAt(w.chain, height)
+		if err != nil {
+			return err
+		}
+		if hash == delivered {
+			break
+		}
+	}
+	if height == cp.Height {
+		return nil
+	}
+	hash, err := snapshotHashAt(w.chain, height)
+	if err != nil {
+		return err
+	}
+	return w.rollbackTo(height, hash)
+}
+
+func (w *sinkWorker) run(term <-chan struct{}) {
+	retryInterval := minRetryInterval
+	for {
+		delivered, err := w.deliverNext()
+		if err != nil {
+			w.log.Error(fmt.Sprintf("deliver events failed, retry after %s, %s", retryInterval, err.Error()), "method", "run")
+			select {
+			case <-term:
+				return
+			case <-time.After(retryInterval):
+			}
+			if retryInterval *= 2; retryInterval > maxRetryInterval {
+				retryInterval = maxRetryInterval
+			}
+			continue
+		}
+		retryInterval = minRetryInterval
+
+		if delivered {
+			select {
+			case <-term:
+				return
+			default:
+			}
+			continue
+		}
+		select {
+		case <-term:
+			return
+		case <-w.notify:
+		case <-time.After(idleInterval):
+		}
+	}
+}
+
+// deliverNext sends the pending rollback or the events of the next snapshot block, it returns false if
+// there is nothing to deliver
+func (w *sinkWorker) deliverNext() (bool, error) {
+	w.mu.Lock()
+	cp := *w.cp
+	if !cp.Rollback {
+		w.delivering = cp.Height + 1
+	}
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		w.delivering = 0
+		w.mu.Unlock()
+	}()
+
+	if cp.Rollback {
+		if err := w.sink.Send([]*Event{newRollbackEvent(cp.Height, cp.Hash)}); err != nil {
+			return false, err
+		}
+		return true, w.advance(cp, cp.Height, cp.Hash)
+	}
+
+	latest := w.chain.GetLatestSnapshotBlock()
+	if latest == nil || latest.Height <= cp.Height {
+		return false, nil
+	}
+	sb, events, err := buildEvents(w.chain, cp.Height+1)
+	if err != nil {
+		return false, err
+	}
+	if sb.PrevHash != cp.Hash {
+		w.log.Warn(fmt.Sprintf("snapshot block %d is not the previous one of %d %s", cp.Height, sb.Height, sb.Hash), "method", "deliverNext")
+		return true, w.rollbackToForkPoint(cp)
+	}
+	if err := w.sink.Send(events); err != nil {
+		return false, err
+	}
+	return true, w.advance(cp, sb.Height, sb.Hash)
+}
+
+// advance moves the checkpoint after the delivery, unless a rollback happened during the delivery
+func (w *sinkWorker) advance(delivered checkpoint, height uint64, hash types.Hash) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cp.Height != delivered.Height || w.cp.Hash != delivered.Hash || w.cp.Rollback != delivered.Rollback {
+		return nil
+	}
+	if w.cp.Rollback {
+		w.cp.Rollback = false
+	} else {
+		w.cp.push(height, hash)
+	}
+	return w.cp.save()
+}
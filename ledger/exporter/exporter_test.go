@@ -0,0 +1,233 @@
+package exporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+)
+
+type testChain struct {
+	snapshotBlocks []*ledger.SnapshotBlock
+	accountBlocks  map[types.Hash][]*ledger.AccountBlock
+	logs           map[types.Hash]ledger.VmLogList
+	listeners      []interfaces.EventListener
+	mu             sync.RWMutex
+}
+
+func newTestChain() *testChain {
+	return &testChain{
+		accountBlocks: make(map[types.Hash][]*ledger.AccountBlock),
+		logs:          make(map[types.Hash]ledger.VmLogList),
+	}
+}
+
+func (c *testChain) Register(listener interfaces.EventListener) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listeners = append(c.listeners, listener)
+}
+
+func (c *testChain) UnRegister(listener interfaces.EventListener) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, l := range c.listeners {
+		if l == listener {
+			c.listeners = append(c.listeners[:i], c.listeners[i+1:]...)
+			return
+		}
+	}
+}
+
+func (c *testChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.snapshotBlocks[len(c.snapshotBlocks)-1]
+}
+
+func (c *testChain) GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if height == 0 || height > uint64(len(c.snapshotBlocks)) {
+		return nil, nil
+	}
+	return c.snapshotBlocks[height-1], nil
+}
+
+func (c *testChain) GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var chunks []*ledger.SnapshotChunk
+	for h := startHeight; h <= endHeight && h <= uint64(len(c.snapshotBlocks)); h++ {
+		if h == 0 {
+			continue
+		}
+		sb := c.snapshotBlocks[h-1]
+		chunks = append(chunks, &ledger.SnapshotChunk{SnapshotBlock: sb, AccountBlocks: c.accountBlocks[sb.Hash]})
+	}
+	return chunks, nil
+}
+
+func (c *testChain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.logs[*logListHash], nil
+}
+
+// insert appends a snapshot block which confirms an account block with a log, fork distinguishes the hashes of forks
+func (c *testChain) insert(fork string) {
+	c.mu.Lock()
+	height := uint64(len(c.snapshotBlocks)) + 1
+	now := time.Unix(int64(height), 0)
+	sb := &ledger.SnapshotBlock{
+		Height:    height,
+		Hash:      types.DataHash([]byte(fmt.Sprintf("%s-%d", fork, height))),
+		Timestamp: &now,
+	}
+	if height > 1 {
+		sb.PrevHash = c.snapshotBlocks[height-2].Hash
+	}
+	logHash := types.DataHash([]byte(fmt.Sprintf("log-%s-%d", fork, height)))
+	c.logs[logHash] = ledger.VmLogList{{Topics: []types.Hash{logHash}, Data: []byte{byte(height)}}}
+	c.accountBlocks[sb.Hash] = []*ledger.AccountBlock{{
+		BlockType:      ledger.BlockTypeReceive,
+		Hash:           types.DataHash([]byte(fmt.Sprintf("ab-%s-%d", fork, height))),
+		Height:         height,
+		AccountAddress: types.AddressDexTrade,
+		Amount:         big.NewInt(0),
+		Fee:            big.NewInt(0),
+		LogHash:        &logHash,
+	}}
+	c.snapshotBlocks = append(c.snapshotBlocks, sb)
+	listeners := c.listeners
+	c.mu.Unlock()
+
+	for _, l := range listeners {
+		l.InsertSnapshotBlocks([]*ledger.SnapshotChunk{{SnapshotBlock: sb}})
+	}
+}
+
+func (c *testChain) rollback(toHeight uint64) {
+	chunks, _ := c.GetSubLedger(toHeight+1, uint64(len(c.snapshotBlocks)))
+	c.mu.RLock()
+	listeners := c.listeners
+	c.mu.RUnlock()
+
+	for _, l := range listeners {
+		l.PrepareDeleteSnapshotBlocks(chunks)
+	}
+	c.mu.Lock()
+	c.snapshotBlocks = c.snapshotBlocks[:toHeight]
+	c.mu.Unlock()
+	for _, l := range listeners {
+		l.DeleteSnapshotBlocks(chunks)
+	}
+}
+
+func waitMessages(t *testing.T, broker *MemoryBroker, topic string, count int) []*Event {
+	deadline := time.Now().Add(10 * time.Second)
+	for broker.Len(topic) < count {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect %d messages, got %d", count, broker.Len(topic))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	var events []*Event
+	for _, msg := range broker.Read(topic, 0, broker.Len(topic)) {
+		event := &Event{}
+		if err := json.Unmarshal(msg, event); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+func startTestExporter(t *testing.T, cfg *config.Exporter, chain *testChain, broker *MemoryBroker) *Exporter {
+	e, err := New(cfg, chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddSink(NewBrokerSink("broker", "chain", broker)); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestExporter(t *testing.T) {
+	chain := newTestChain()
+	for i := 0; i < 3; i++ {
+		chain.insert("a")
+	}
+	cfg := &config.Exporter{Enabled: true, Dir: t.TempDir(), StartHeight: 2}
+	broker := NewMemoryBroker()
+	e := startTestExporter(t, cfg, chain, broker)
+
+	// snapshot block, account block and log of heights 2 and 3
+	events := waitMessages(t, broker, "chain", 6)
+	expected := []EventType{SnapshotBlockEvent, AccountBlockEvent, LogEvent}
+	for i, event := range events {
+		if event.Type != expected[i%3] || event.SnapshotHeight != uint64(2+i/3) {
+			t.Fatalf("unexpected event %d, %+v", i, event)
+		}
+	}
+	if events[2].Log.Data[0] != 2 || events[2].Log.BlockHash != events[1].AccountBlock.Hash {
+		t.Fatalf("unexpected log event %+v", events[2].Log)
+	}
+
+	// fork at height 3
+	chain.rollback(2)
+	chain.insert("b")
+	events = waitMessages(t, broker, "chain", 10)
+	if events[6].Type != RollbackEvent || events[6].SnapshotHeight != 2 || events[6].SnapshotHash != chain.snapshotBlocks[1].Hash {
+		t.Fatalf("unexpected rollback event %+v", events[6])
+	}
+	if events[7].Type != SnapshotBlockEvent || events[7].SnapshotHash != chain.snapshotBlocks[2].Hash {
+		t.Fatalf("unexpected event after rollback %+v", events[7])
+	}
+	e.Stop()
+
+	// the blocks inserted while the exporter is stopped are delivered after the restart
+	chain.insert("b")
+	e = startTestExporter(t, cfg, chain, broker)
+	defer e.Stop()
+	events = waitMessages(t, broker, "chain", 13)
+	if len(events) != 13 || events[10].Type != SnapshotBlockEvent || events[10].SnapshotHeight != 4 {
+		t.Fatalf("unexpected events after restart %+v", events[10:])
+	}
+}
+
+func TestExporter_RollbackWhileStopped(t *testing.T) {
+	chain := newTestChain()
+	for i := 0; i < 3; i++ {
+		chain.insert("a")
+	}
+	cfg := &config.Exporter{Enabled: true, Dir: t.TempDir(), StartHeight: 1}
+	broker := NewMemoryBroker()
+	e := startTestExporter(t, cfg, chain, broker)
+	waitMessages(t, broker, "chain", 9)
+	e.Stop()
+
+	chain.rollback(1)
+	chain.insert("b")
+	e = startTestExporter(t, cfg, chain, broker)
+	defer e.Stop()
+	// the fork point is at height 1, the block at height 2 is replaced
+	events := waitMessages(t, broker, "chain", 13)
+	if events[9].Type != RollbackEvent || events[9].SnapshotHeight != 1 || events[9].SnapshotHash != chain.snapshotBlocks[0].Hash {
+		t.Fatalf("unexpected rollback event %+v", events[9])
+	}
+	if events[10].Type != SnapshotBlockEvent || events[10].SnapshotHash != chain.snapshotBlocks[1].Hash {
+		t.Fatalf("unexpected event after rollback %+v", events[10])
+	}
+}
@@ -0,0 +1,96 @@
+package exporter
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Broker is a message broker with ordered topics, like a kafka topic with a single partition.
+// Publish returns nil after the broker has acknowledged all the messages
+type Broker interface {
+	Publish(topic string, messages [][]byte) error
+	Close() error
+}
+
+// BrokerSink publishes every event as a json message to the topic of the broker
+type BrokerSink struct {
+	name   string
+	topic  string
+	broker Broker
+}
+
+func NewBrokerSink(name string, topic string, broker Broker) *BrokerSink {
+	return &BrokerSink{
+		name:   name,
+		topic:  topic,
+		broker: broker,
+	}
+}
+
+func (s *BrokerSink) Name() string {
+	return s.name
+}
+
+func (s *BrokerSink) Send(events []*Event) error {
+	messages := make([][]byte, len(events))
+	for i, event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		messages[i] = data
+	}
+	return s.broker.Publish(s.topic, messages)
+}
+
+func (s *BrokerSink) Close() error {
+	return s.broker.Close()
+}
+
+// MemoryBroker is an in-process broker, the messages of a topic are kept in memory and read by offset
+type MemoryBroker struct {
+	topics map[string][][]byte
+	mu     sync.RWMutex
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		topics: make(map[string][][]byte),
+	}
+}
+
+func (b *MemoryBroker) Publish(topic string, messages [][]byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.topics[topic] = append(b.topics[topic], messages...)
+	return nil
+}
+
+// Read returns at most limit messages of the topic from the offset
+func (b *MemoryBroker) Read(topic string, offset int, limit int) [][]byte {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	messages := b.topics[topic]
+	if offset >= len(messages) {
+		return nil
+	}
+	end := offset + limit
+	if end > len(messages) {
+		end = len(messages)
+	}
+	return messages[offset:end]
+}
+
+// Len returns the count of the messages of the topic
+func (b *MemoryBroker) Len(topic string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return len(b.topics[topic])
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
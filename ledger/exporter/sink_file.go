@@ -0,0 +1,190 @@
+package exporter
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/vitelabs/go-vite/v2/common/helper"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+)
+
+const (
+	FileFormatJson     = "json"
+	FileFormatProtobuf = "protobuf"
+
+	filePrefix = "events."
+)
+
+// the record types of the protobuf format
+var recordTypes = map[EventType]byte{
+	SnapshotBlockEvent: 1,
+	AccountBlockEvent:  2,
+	LogEvent:           3,
+	RollbackEvent:      4,
+}
+
+// FileSink appends the events to local files. In the json format every line is an event, in the protobuf format
+// every record is [type 1 byte][length 4 bytes][payload], the payload of a block is the serialized ledger block,
+// the payload of a log is the block hash followed by the serialized log list with the log, the payload of a
+// rollback is the snapshot height followed by the snapshot hash. A new file is started once the current one
+// exceeds the max size.
+type FileSink struct {
+	dir     string
+	format  string
+	maxSize int64
+
+	file  *os.File
+	index int
+	size  int64
+}
+
+func NewFileSink(dir string, format string, maxSize int64) (*FileSink, error) {
+	if format != FileFormatJson && format != FileFormatProtobuf {
+		return nil, fmt.Errorf("unknown exporter file format %s", format)
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &FileSink{dir: dir, format: format, maxSize: maxSize}, nil
+}
+
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+func (s *FileSink) fileExt() string {
+	if s.format == FileFormatProtobuf {
+		return ".pb"
+	}
+	return ".ndjson"
+}
+
+func (s *FileSink) fileName(index int) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s%08d%s", filePrefix, index, s.fileExt()))
+}
+
+// open appends to the latest file in the directory
+func (s *FileSink) open() error {
+	infos, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, s.fileExt()) {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), s.fileExt()))
+		if err == nil && index > s.index {
+			s.index = index
+		}
+	}
+	return s.openIndex(s.index)
+}
+
+func (s *FileSink) openIndex(index int) error {
+	file, err := os.OpenFile(s.fileName(index), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file = file
+	s.index = index
+	s.size = info.Size()
+	return nil
+}
+
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+	return s.openIndex(s.index + 1)
+}
+
+func (s *FileSink) Send(events []*Event) error {
+	if s.file == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	if s.size >= s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	var data []byte
+	for _, event := range events {
+		record, err := s.encode(event)
+		if err != nil {
+			return err
+		}
+		data = append(data, record...)
+	}
+	if _, err := s.file.Write(data); err != nil {
+		// drop the partial batch, it is sent again
+		s.file.Truncate(s.size)
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		s.file.Truncate(s.size)
+		return err
+	}
+	s.size += int64(len(data))
+	return nil
+}
+
+func (s *FileSink) encode(event *Event) ([]byte, error) {
+	if s.format == FileFormatJson {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		return append(data, '\n'), nil
+	}
+
+	var payload []byte
+	var err error
+	switch event.Type {
+	case SnapshotBlockEvent:
+		payload, err = event.snapshotBlock.Serialize()
+	case AccountBlockEvent:
+		payload, err = event.accountBlock.Serialize()
+	case LogEvent:
+		var logList []byte
+		if logList, err = (ledger.VmLogList{event.vmLog}).Serialize(); err == nil {
+			payload = helper.JoinBytes(event.Log.BlockHash.Bytes(), logList)
+		}
+	case RollbackEvent:
+		height := make([]byte, 8)
+		binary.BigEndian.PutUint64(height, event.SnapshotHeight)
+		payload = helper.JoinBytes(height, event.SnapshotHash.Bytes())
+	}
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 5)
+	header[0] = recordTypes[event.Type]
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+	return helper.JoinBytes(header, payload), nil
+}
+
+func (s *FileSink) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
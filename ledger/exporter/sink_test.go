@@ -0,0 +1,147 @@
+package exporter
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+)
+
+func testEvents(t *testing.T) []*Event {
+	chain := newTestChain()
+	chain.insert("a")
+	_, events, err := buildEvents(chain, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(events, newRollbackEvent(0, events[0].SnapshotHash))
+}
+
+func TestFileSink_Json(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, FileFormatJson, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := testEvents(t)
+	for i := 0; i < 2; i++ {
+		if err := sink.Send(events); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sink.Close()
+
+	// the file exceeds the max size after the first batch, the second batch goes to a new file
+	for _, name := range []string{"events.00000000.ndjson", "events.00000001.ndjson"} {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner := bufio.NewScanner(file)
+		count := 0
+		for ; scanner.Scan(); count++ {
+			event := &Event{}
+			if err := json.Unmarshal(scanner.Bytes(), event); err != nil {
+				t.Fatal(err)
+			}
+			if event.Type != events[count].Type {
+				t.Fatalf("unexpected event %d in %s, %+v", count, name, event)
+			}
+		}
+		file.Close()
+		if count != len(events) {
+			t.Fatalf("expect %d events in %s, got %d", len(events), name, count)
+		}
+	}
+}
+
+func TestFileSink_Protobuf(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, FileFormatProtobuf, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := testEvents(t)
+	if err := sink.Send(events); err != nil {
+		t.Fatal(err)
+	}
+	sink.Close()
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "events.00000000.pb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, event := range events {
+		if len(data) < 5 || data[0] != recordTypes[event.Type] {
+			t.Fatalf("unexpected record %d", i)
+		}
+		length := binary.BigEndian.Uint32(data[1:5])
+		payload := data[5 : 5+length]
+		data = data[5+length:]
+
+		switch event.Type {
+		case SnapshotBlockEvent:
+			sb := &ledger.SnapshotBlock{}
+			if err := sb.Deserialize(payload); err != nil || sb.Hash != event.SnapshotHash {
+				t.Fatalf("unexpected snapshot block %+v, %v", sb, err)
+			}
+		case AccountBlockEvent:
+			ab := &ledger.AccountBlock{}
+			if err := ab.Deserialize(payload); err != nil || ab.Hash != event.AccountBlock.Hash {
+				t.Fatalf("unexpected account block %+v, %v", ab, err)
+			}
+		case RollbackEvent:
+			if binary.BigEndian.Uint64(payload[:8]) != event.SnapshotHeight {
+				t.Fatalf("unexpected rollback %x", payload)
+			}
+		}
+	}
+	if len(data) != 0 {
+		t.Fatalf("unexpected %d bytes after the records", len(data))
+	}
+}
+
+func TestWebhookSink(t *testing.T) {
+	secret := "secret"
+	requests := 0
+	var received []*Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get(SignatureHeader) != Sign([]byte(secret), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		res := &webhookBody{}
+		json.Unmarshal(body, res)
+		received = res.Events
+	}))
+	defer server.Close()
+
+	sink := NewWebhookSink("webhook0", server.URL, secret, time.Second, 1)
+	sink.retryInterval = time.Millisecond
+	events := testEvents(t)
+	if err := sink.Send(events); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 || len(received) != len(events) {
+		t.Fatalf("unexpected requests %d, received %d events", requests, len(received))
+	}
+
+	sink = NewWebhookSink("webhook1", server.URL, "wrong", time.Second, 0)
+	if err := sink.Send(events); err == nil {
+		t.Fatal("expect an error for a wrong signature")
+	}
+}
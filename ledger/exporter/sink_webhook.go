@@ -0,0 +1,108 @@
+package exporter
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultWebhookTimeout = 10 * time.Second
+
+	// SignatureHeader is the hex encoded hmac-sha256 of the request body keyed by the webhook secret
+	SignatureHeader = "X-Vite-Signature"
+	HeightHeader    = "X-Vite-Snapshot-Height"
+)
+
+type webhookBody struct {
+	Events []*Event `json:"events"`
+}
+
+// WebhookSink posts the events of a batch as a json object {"events":[...]} to the url,
+// the batch is delivered once the url responds with a 2xx status
+type WebhookSink struct {
+	name     string
+	url      string
+	secret   []byte
+	maxRetry int
+	client   *http.Client
+
+	retryInterval time.Duration
+}
+
+func NewWebhookSink(name string, url string, secret string, timeout time.Duration, maxRetry int) *WebhookSink {
+	if timeout <= 0 {
+		timeout = defaultWebhookTimeout
+	}
+	return &WebhookSink{
+		name:          name,
+		url:           url,
+		secret:        []byte(secret),
+		maxRetry:      maxRetry,
+		client:        &http.Client{Timeout: timeout},
+		retryInterval: minRetryInterval,
+	}
+}
+
+func (s *WebhookSink) Name() string {
+	return s.name
+}
+
+// Sign computes the signature of the body, receivers verify the SignatureHeader with it
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *WebhookSink) Send(events []*Event) error {
+	body, err := json.Marshal(&webhookBody{Events: events})
+	if err != nil {
+		return err
+	}
+	interval := s.retryInterval
+	for i := 0; ; i++ {
+		if err = s.post(body, events[0].SnapshotHeight); err == nil || i >= s.maxRetry {
+			return err
+		}
+		time.Sleep(interval)
+		interval *= 2
+	}
+}
+
+func (s *WebhookSink) post(body []byte, height uint64) error {
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeightHeader, strconv.FormatUint(height, 10))
+	if len(s.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(s.secret, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responds %s", s.url, resp.Status)
+	}
+	return nil
+}
+
+func (s *WebhookSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -21,6 +21,27 @@ func (manager *Manager) GetOnRoadTotalNumByAddr(gid types.Gid, addr types.Addres
 	return num, nil
 }
 
+// GetOnRoadCallerNumByAddr method returns the num of the contract' OnRoad blocks of each caller.
+func (manager *Manager) GetOnRoadCallerNumByAddr(gid types.Gid, addr types.Address) (map[types.Address]uint64, error) {
+	onRoadPool, ok := manager.onRoadPools.Load(gid)
+	if !ok || onRoadPool == nil {
+		manager.log.Error(onroad_pool.ErrOnRoadPoolNotAvailable.Error(), "gid", gid, "addr", addr)
+		return nil, onroad_pool.ErrOnRoadPoolNotAvailable
+	}
+	return onRoadPool.(onroad_pool.OnRoadPool).GetOnRoadCallerNumByAddr(addr)
+}
+
+// GetOnRoadContracts method returns the contracts which have OnRoad blocks in the contract OnRoad pool of the gid,
+// a contract may have no OnRoad block at present.
+func (manager *Manager) GetOnRoadContracts(gid types.Gid) ([]types.Address, error) {
+	onRoadPool, ok := manager.onRoadPools.Load(gid)
+	if !ok || onRoadPool == nil {
+		manager.log.Error(onroad_pool.ErrOnRoadPoolNotAvailable.Error(), "gid", gid)
+		return nil, onroad_pool.ErrOnRoadPoolNotAvailable
+	}
+	return onRoadPool.(onroad_pool.OnRoadPool).GetOnRoadContracts(), nil
+}
+
 // GetAllCallersFrontOnRoad method returns all callers's front OnRoad blocks, those with the lowest height,
 // in a contract OnRoad pool.
 func (manager *Manager) GetAllCallersFrontOnRoad(gid types.Gid, addr types.Address) ([]*ledger.AccountBlock, error) {
@@ -112,14 +112,10 @@ func (w *ContractWorker) Start(accEvent producerevent.AccountStartEvent) {
 		// 3. register listening events, including addContractLis and addSnapshotEventLis
 		log.Info("addContractLis", "gid", w.gid, "event", "accountEvent")
 		w.manager.addContractLis(w.gid, func(address types.Address) {
-			if w.isContractInBlackList(address) {
+			if w.isContractInBlackList(address) || w.isContractPaused(address) {
 				return
 			}
-			q := w.GetStakeQuota(address)
-			c := &contractTask{
-				Addr:  address,
-				Quota: q,
-			}
+			c := w.newContractTask(address)
 
 			if !w.isCancel.Load() {
 				w.pushContractTask(c)
@@ -135,18 +131,17 @@ func (w *ContractWorker) Start(accEvent producerevent.AccountStartEvent) {
 			for _, addr := range w.contractAddressList {
 				if pushContractTask, callerCount := w.releaseContract(addr); pushContractTask {
 					signalLog.Info(fmt.Sprintf("release contract %v RETRY callers len %v", addr, callerCount), "snapshot", latestHeight, "event", "snapshotEvent")
+					if w.isContractPaused(addr) {
+						continue
+					}
 
-					q := w.GetStakeQuota(addr)
-					pendingTask[count] = &contractTask{Addr: addr, Quota: q}
+					pendingTask[count] = w.newContractTask(addr)
 					count++
 				}
 			}
 			sortedTask := pendingTask[0:count]
 			sort.Slice(sortedTask, func(i, j int) bool {
-				if sortedTask[i].Quota > sortedTask[j].Quota {
-					return true
-				}
-				return false
+				return sortedTask[i].isPriorTo(sortedTask[j])
 			})
 			for _, task := range sortedTask {
 				if w.isCancel.Load() {
@@ -222,9 +217,10 @@ func (w *ContractWorker) getAndSortAllAddrQuota() {
 	i := 0
 	for addr, quota := range quotas {
 		task := &contractTask{
-			Addr:  addr,
-			Index: i,
-			Quota: quota,
+			Addr:     addr,
+			Index:    i,
+			Quota:    quota,
+			Priority: w.manager.receiveControl.getPriority(addr),
 		}
 		w.contractTaskPQueue[i] = task
 		i++
@@ -248,6 +244,7 @@ func (w *ContractWorker) pushContractTask(t *contractTask) {
 	for _, v := range w.contractTaskPQueue {
 		if v.Addr == t.Addr {
 			v.Quota = t.Quota
+			v.Priority = t.Priority
 			heap.Fix(&w.contractTaskPQueue, v.Index)
 			return
 		}
@@ -255,6 +252,15 @@ func (w *ContractWorker) pushContractTask(t *contractTask) {
 	heap.Push(&w.contractTaskPQueue, t)
 }
 
+// newContractTask creates a task of the contract with its current stake quota and pinned priority.
+func (w *ContractWorker) newContractTask(addr types.Address) *contractTask {
+	return &contractTask{
+		Addr:     addr,
+		Quota:    w.GetStakeQuota(addr),
+		Priority: w.manager.receiveControl.getPriority(addr),
+	}
+}
+
 func (w *ContractWorker) popContractTask() *contractTask {
 	w.ctpMutex.Lock()
 	defer w.ctpMutex.Unlock()
@@ -318,6 +324,10 @@ func (w *ContractWorker) isContractInBlackList(addr types.Address) bool {
 	return ok
 }
 
+func (w *ContractWorker) isContractPaused(addr types.Address) bool {
+	return w.manager.receiveControl.isPaused(addr)
+}
+
 func (w *ContractWorker) releaseContract(addr types.Address) (pushContractTask bool, releaseCallerCount int) {
 	w.blackListMutex.Lock()
 	defer w.blackListMutex.Unlock()
@@ -337,6 +347,67 @@ func (w *ContractWorker) releaseContract(addr types.Address) (pushContractTask b
 	return true, count
 }
 
+// updateContractPriority reorders the contract in the queue with the new priority weight.
+func (w *ContractWorker) updateContractPriority(addr types.Address, weight uint64) {
+	w.ctpMutex.Lock()
+	defer w.ctpMutex.Unlock()
+	for _, v := range w.contractTaskPQueue {
+		if v.Addr == addr {
+			v.Priority = weight
+			heap.Fix(&w.contractTaskPQueue, v.Index)
+			return
+		}
+	}
+}
+
+// resumeContract pushes the resumed contract into the queue if the worker is working for it.
+func (w *ContractWorker) resumeContract(addr types.Address) {
+	w.statusMutex.Lock()
+	defer w.statusMutex.Unlock()
+	if w.status != start || w.isCancel.Load() || w.isContractInBlackList(addr) {
+		return
+	}
+	for _, v := range w.contractAddressList {
+		if v == addr {
+			w.pushContractTask(w.newContractTask(addr))
+			w.wakeupOneTp()
+			return
+		}
+	}
+}
+
+func (w *ContractWorker) fillReceiveStatus(status *ContractReceiveStatus) {
+	w.statusMutex.Lock()
+	working := w.status == start
+	w.statusMutex.Unlock()
+	if !working {
+		return
+	}
+	status.Working = true
+	status.Quota = w.GetStakeQuota(status.Address)
+
+	w.ctpMutex.RLock()
+	for _, v := range w.contractTaskPQueue {
+		if v.Addr == status.Address {
+			status.InQueue = true
+			break
+		}
+	}
+	w.ctpMutex.RUnlock()
+
+	w.blackListMutex.RLock()
+	if s, ok := w.blackList[status.Address]; ok {
+		status.State = s.String()
+	}
+	w.blackListMutex.RUnlock()
+
+	if value, ok := w.selectivePendingCache.Load(status.Address); ok && value != nil {
+		for caller, s := range value.(*callerPendingMap).getInferiorList() {
+			status.Callers[caller] = s.String()
+		}
+	}
+}
+
 func (w *ContractWorker) acquireOnRoadBlocks(contractAddr types.Address) *ledger.AccountBlock {
 	addNewCount := 0
 	revertHappened := false
@@ -403,7 +474,7 @@ func (w *ContractWorker) releaseContractCallers(contract types.Address, state in
 
 // GetStakeQuota returns the available quota the contract can use at current.
 func (w *ContractWorker) GetStakeQuota(addr types.Address) uint64 {
-	if types.IsBuiltinContractAddrInUseWithoutQuota(addr) {
+	if ledger.IsBuiltinContractAddrInUseWithoutQuota(addr, w.manager.Chain().GetLatestSnapshotBlock().Height) {
 		return math.MaxUint64
 	}
 	_, quota, err := w.manager.Chain().GetStakeQuota(addr)
@@ -421,8 +492,9 @@ func (w *ContractWorker) GetStakeQuotas(beneficialList []types.Address) map[type
 	quotas := make(map[types.Address]uint64)
 	if w.gid == types.DELEGATE_GID {
 		commonContractAddressList := make([]types.Address, 0, len(beneficialList))
+		sbHeight := w.manager.Chain().GetLatestSnapshotBlock().Height
 		for _, addr := range beneficialList {
-			if types.IsBuiltinContractAddrInUseWithoutQuota(addr) {
+			if ledger.IsBuiltinContractAddrInUseWithoutQuota(addr, sbHeight) {
 				quotas[addr] = math.MaxUint64
 			} else {
 				commonContractAddressList = append(commonContractAddressList, addr)
@@ -6,8 +6,8 @@ import (
 	"sync"
 	"time"
 
-	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/vitelabs/go-vite/v2/common"
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
 	"github.com/vitelabs/go-vite/v2/common/types"
 	"github.com/vitelabs/go-vite/v2/interfaces"
 	"github.com/vitelabs/go-vite/v2/ledger/chain"
@@ -46,7 +46,9 @@ type Manager struct {
 
 	lastProducerAccEvent *producerevent.AccountStartEvent
 
-	db *leveldb.DB
+	receiveControl *receiveControl
+
+	db engine.Engine
 
 	log log15.Logger
 }
@@ -60,6 +62,7 @@ func NewManager(net netReader, pool pool, producer producer, sbpStatReader core.
 		pool:            pool,
 		sbpStatReader:   sbpStatReader,
 		contractWorkers: make(map[types.Gid]*ContractWorker),
+		receiveControl:  newReceiveControl(),
 		log:             slog.New("w", "manager"),
 	}
 	return m
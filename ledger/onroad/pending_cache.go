@@ -134,6 +134,16 @@ func (p *callerPendingMap) lenOfCallersByState(state inferiorState) int {
 
 }
 
+func (p *callerPendingMap) getInferiorList() map[types.Address]inferiorState {
+	p.addrMutex.RLock()
+	defer p.addrMutex.RUnlock()
+	result := make(map[types.Address]inferiorState, len(p.inferiorList))
+	for caller, s := range p.inferiorList {
+		result[caller] = s
+	}
+	return result
+}
+
 func (p *callerPendingMap) existInInferiorList(caller types.Address) bool {
 	p.addrMutex.RLock()
 	defer p.addrMutex.RUnlock()
@@ -6,7 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
 	"github.com/vitelabs/go-vite/v2/common/types"
 )
 
@@ -17,7 +17,7 @@ func Test_callerCache_1(t *testing.T) {
 	dir := path.Join(homeDir, ".gvite", "tmp", "onroad")
 	for _, item := range cases {
 		os.RemoveAll(dir)
-		d, err := leveldb.OpenFile(dir, nil)
+		d, err := engine.Open("", dir)
 		if err != nil {
 			panic(err)
 		}
@@ -211,7 +211,7 @@ func Test_callerCache_2(t *testing.T) {
 
 	for _, item := range cases {
 		os.RemoveAll(dir)
-		d, err := leveldb.OpenFile(dir, nil)
+		d, err := engine.Open("", dir)
 		if err != nil {
 			panic(err)
 		}
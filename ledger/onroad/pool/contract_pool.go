@@ -6,7 +6,7 @@ import (
 	"sort"
 	"sync"
 
-	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
 	"github.com/vitelabs/go-vite/v2/common/types"
 	"github.com/vitelabs/go-vite/v2/interfaces/core"
 	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
@@ -24,7 +24,7 @@ type contractOnRoadPool struct {
 	log   log15.Logger
 }
 
-func NewContractOnRoadPool(gid types.Gid, chain chainReader, db *leveldb.DB) OnRoadPool {
+func NewContractOnRoadPool(gid types.Gid, chain chainReader, db engine.Engine) OnRoadPool {
 	or := &contractOnRoadPool{
 		gid:   gid,
 		chain: chain,
@@ -123,11 +123,34 @@ func (p *contractOnRoadPool) GetFrontOnRoadBlocksByAddr(contract types.Address)
 }
 
 func (p *contractOnRoadPool) GetOnRoadTotalNumByAddr(contract types.Address) (uint64, error) {
+	numMap, err := p.GetOnRoadCallerNumByAddr(contract)
+	if err != nil {
+		return 0, err
+	}
+	sum := uint64(0)
+	for _, num := range numMap {
+		sum += num
+	}
+	return sum, nil
+}
+
+// GetOnRoadCallerNumByAddr returns the count of the OnRoad blocks of each caller to the contract.
+func (p *contractOnRoadPool) GetOnRoadCallerNumByAddr(contract types.Address) (map[types.Address]uint64, error) {
 	cc, ok := p.cache.Load(contract)
 	if !ok || cc == nil {
-		return 0, nil
+		return nil, nil
 	}
-	return uint64(cc.(*callerCache).len()), nil
+	return cc.(*callerCache).numOfCallers()
+}
+
+// GetOnRoadContracts returns the contracts which have ever had OnRoad blocks since the pool is loaded.
+func (p *contractOnRoadPool) GetOnRoadContracts() []types.Address {
+	contracts := make([]types.Address, 0)
+	p.cache.Range(func(key, value interface{}) bool {
+		contracts = append(contracts, key.(types.Address))
+		return true
+	})
+	return contracts
 }
 
 func (p *contractOnRoadPool) InsertAccountBlocks(orAddr types.Address, blocks []*ledger.AccountBlock) error {
@@ -394,16 +417,24 @@ func (cc *callerCache) lazyUpdateFrontTx(reader chainReader, hv *orHeightValue)
 	return newOrHashHeightFromOnroadTx(tx), nil
 }
 
-// @todo fix
 func (cc *callerCache) len() int {
+	numMap, err := cc.numOfCallers()
+	if err != nil {
+		onroadPoolLog.Warn("get num of callers failed", "contract", cc.address, "err", err)
+		return 0
+	}
+	count := 0
+	for _, num := range numMap {
+		count += int(num)
+	}
+	return count
+}
+
+func (cc *callerCache) numOfCallers() (map[types.Address]uint64, error) {
 	cc.mu.RLock()
 	defer cc.mu.RUnlock()
 
-	count := 0
-	// for _, l := range cc.cache {
-	// 	count += l.Len()
-	// }
-	return count
+	return cc.storage.GetOnroadTxNumOfCallers(cc.address)
 }
 
 func (cc *callerCache) addTx(caller *types.Address, or orHashHeight, isWrite bool) error {
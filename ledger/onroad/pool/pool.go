@@ -11,6 +11,8 @@ type OnRoadPool interface {
 	DeleteAccountBlocks(orAddr types.Address, blocks []*ledger.AccountBlock) error
 
 	GetOnRoadTotalNumByAddr(addr types.Address) (uint64, error)
+	GetOnRoadCallerNumByAddr(addr types.Address) (map[types.Address]uint64, error)
+	GetOnRoadContracts() []types.Address
 	GetFrontOnRoadBlocksByAddr(addr types.Address) ([]*ledger.AccountBlock, error)
 
 	IsFrontOnRoadOfCaller(orAddr, caller types.Address, hash types.Hash) (bool, error)
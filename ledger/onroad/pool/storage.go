@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"sync"
 
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/util"
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
 	"github.com/vitelabs/go-vite/v2/common/types"
 	chain_utils "github.com/vitelabs/go-vite/v2/ledger/chain/utils"
 )
@@ -37,13 +37,13 @@ type OnRoadDB interface {
 }
 
 type onroadStorage struct {
-	db *leveldb.DB
+	db engine.Engine
 
 	mu      sync.RWMutex
 	callers sync.Map
 }
 
-func newOnroadStorage(db *leveldb.DB) *onroadStorage {
+func newOnroadStorage(db engine.Engine) *onroadStorage {
 	return &onroadStorage{
 		db: db,
 		mu: sync.RWMutex{},
@@ -66,7 +66,7 @@ func (storage *onroadStorage) insertOnRoadTx(tx OnroadTx) error {
 
 	storage.addCaller(tx.FromAddr)
 	// batch.Put(tx.toOnroadHeightKey().Bytes(), tx.toOnroadHeightValue())
-	return storage.db.Put(tx.toOnroadHeightKey().Bytes(), tx.toOnroadHeightValue(), nil)
+	return storage.db.Put(tx.toOnroadHeightKey().Bytes(), tx.toOnroadHeightValue())
 }
 
 // @todo opt removeCaller
@@ -76,7 +76,7 @@ func (storage *onroadStorage) deleteOnRoadTx(tx OnroadTx) error {
 
 	// fmt.Println("remove on road tx", tx.String())
 
-	err := storage.db.Delete(tx.toOnroadHeightKey().Bytes(), nil)
+	err := storage.db.Delete(tx.toOnroadHeightKey().Bytes())
 	if err != nil {
 		return fmt.Errorf("delete onroad tx err:%s", err.Error())
 	}
@@ -94,14 +94,14 @@ func (storage *onroadStorage) updateFromIndex(tx OnroadTx) (bool, error) {
 	storage.mu.Lock()
 	defer storage.mu.Unlock()
 	key := tx.toOnroadHeightKey().Bytes()
-	exist, err := storage.db.Has(key, nil)
+	exist, err := storage.db.Has(key)
 	if err != nil {
 		return false, err
 	}
 	if !exist {
 		return false, nil
 	}
-	return true, storage.db.Put(tx.toOnroadHeightKey().Bytes(), tx.toOnroadHeightValue(), nil)
+	return true, storage.db.Put(tx.toOnroadHeightKey().Bytes(), tx.toOnroadHeightValue())
 }
 
 func (storage *onroadStorage) GetAllFirstOnroadTx(addr types.Address) (map[types.Address][]OnroadTx, error) {
@@ -132,7 +132,7 @@ func (storage *onroadStorage) GetAllFirstOnroadTx(addr types.Address) (map[types
 
 func (storage *onroadStorage) getFirstOnroadTx(addr types.Address, caller types.Address) ([]OnroadTx, error) {
 	key := chain_utils.NewOnRoadHeightKey()
-	iter := storage.db.NewIterator(util.BytesPrefix(key.IteratorPrefix(addr, caller)), nil)
+	iter := storage.db.NewIterator(util.BytesPrefix(key.IteratorPrefix(addr, caller)))
 	defer iter.Release()
 
 	result := make([]OnroadTx, 0)
@@ -168,6 +168,30 @@ func (storage *onroadStorage) GetFirstOnroadTx(addr types.Address, caller types.
 	return storage.getFirstOnroadTx(addr, caller)
 }
 
+// GetOnroadTxNumOfCallers returns the count of the onroad txs from each caller to the addr.
+func (storage *onroadStorage) GetOnroadTxNumOfCallers(addr types.Address) (map[types.Address]uint64, error) {
+	storage.mu.RLock()
+	defer storage.mu.RUnlock()
+
+	key := chain_utils.NewOnRoadHeightKey()
+	key.ToAddressRefill(addr)
+	iter := storage.db.NewIterator(util.BytesPrefix(key[:1+types.AddressSize]))
+	defer iter.Release()
+
+	result := make(map[types.Address]uint64)
+	for iter.Next() {
+		tx, err := newOnroadTxFromBytes(iter.Key(), iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		result[tx.FromAddr]++
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 func (tx OnroadTx) toOnroadHeightKey() chain_utils.OnRoadHeightKey {
 	return chain_utils.CreateOnRoadAddressHeightKey(tx.ToAddr, tx.FromAddr, tx.FromHeight, tx.FromHash)
 }
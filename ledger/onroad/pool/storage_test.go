@@ -6,7 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
 	"github.com/vitelabs/go-vite/v2/common/types"
 )
 
@@ -14,7 +14,7 @@ func newTestStorage(t *testing.T) *onroadStorage {
 	homeDir, err := os.UserHomeDir()
 	assert.NoError(t, err)
 	dir := path.Join(homeDir, ".gvite", "tmp", "onroad")
-	d, err := leveldb.OpenFile(dir, nil)
+	d, err := engine.Open("", dir)
 	if err != nil {
 		t.Error(err)
 		t.FailNow()
@@ -162,3 +162,35 @@ func TestOnroadStorages(t *testing.T) {
 	}
 
 }
+
+func TestOnroadStorage_GetOnroadTxNumOfCallers(t *testing.T) {
+	clearTestStorage(t)
+	storage := newTestStorage(t)
+	defer func() {
+		storage.db.Close()
+		clearTestStorage(t)
+	}()
+
+	toAddr := types.AddressDexTrade
+	callerA := types.AddressDexFund
+	callerB := types.AddressAsset
+
+	txs := []OnroadTx{
+		{FromAddr: callerA, ToAddr: toAddr, FromHeight: 1, FromHash: types.DataHash([]byte{1})},
+		{FromAddr: callerA, ToAddr: toAddr, FromHeight: 2, FromHash: types.DataHash([]byte{2})},
+		{FromAddr: callerB, ToAddr: toAddr, FromHeight: 1, FromHash: types.DataHash([]byte{3})},
+		{FromAddr: callerA, ToAddr: callerB, FromHeight: 3, FromHash: types.DataHash([]byte{4})},
+	}
+	for _, tx := range txs {
+		assert.NoError(t, storage.insertOnRoadTx(tx))
+	}
+
+	numMap, err := storage.GetOnroadTxNumOfCallers(toAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[types.Address]uint64{callerA: 2, callerB: 1}, numMap)
+
+	assert.NoError(t, storage.deleteOnRoadTx(txs[0]))
+	numMap, err = storage.GetOnroadTxNumOfCallers(toAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, map[types.Address]uint64{callerA: 1, callerB: 1}, numMap)
+}
@@ -0,0 +1,142 @@
+package onroad
+
+import (
+	"sync"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+)
+
+// receiveControl keeps the receive settings of contracts set by the operator of the node, the settings
+// are kept in memory and apply to the contract workers of all gids.
+type receiveControl struct {
+	priorities map[types.Address]uint64
+	paused     map[types.Address]bool
+	mu         sync.RWMutex
+}
+
+func newReceiveControl() *receiveControl {
+	return &receiveControl{
+		priorities: make(map[types.Address]uint64),
+		paused:     make(map[types.Address]bool),
+	}
+}
+
+func (rc *receiveControl) getPriority(addr types.Address) uint64 {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.priorities[addr]
+}
+
+func (rc *receiveControl) setPriority(addr types.Address, weight uint64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if weight == 0 {
+		delete(rc.priorities, addr)
+		return
+	}
+	rc.priorities[addr] = weight
+}
+
+func (rc *receiveControl) getPriorities() map[types.Address]uint64 {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	result := make(map[types.Address]uint64, len(rc.priorities))
+	for addr, weight := range rc.priorities {
+		result[addr] = weight
+	}
+	return result
+}
+
+func (rc *receiveControl) isPaused(addr types.Address) bool {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.paused[addr]
+}
+
+func (rc *receiveControl) setPaused(addr types.Address, paused bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if paused {
+		rc.paused[addr] = true
+		return
+	}
+	delete(rc.paused, addr)
+}
+
+func (rc *receiveControl) getPausedList() []types.Address {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	result := make([]types.Address, 0, len(rc.paused))
+	for addr := range rc.paused {
+		result = append(result, addr)
+	}
+	return result
+}
+
+// ContractReceiveStatus describes how the contract worker of this node handles the OnRoad blocks of a contract.
+type ContractReceiveStatus struct {
+	Address types.Address
+
+	// Working is whether the contract worker of the gid is working on this node, the other fields
+	// except Priority and Paused are only available when it is working.
+	Working bool
+	InQueue bool
+	Quota   uint64
+
+	Priority uint64
+	Paused   bool
+
+	// State is the state of the contract in the blacklist, empty if the contract is not restricted
+	State string
+	// Callers are the restricted callers of the contract and their states
+	Callers map[types.Address]string
+}
+
+// SetContractReceivePriority pins the priority weight of the contract, the contracts with a higher weight are
+// received before the others regardless of their stake quota. A weight of 0 removes the pin.
+func (manager *Manager) SetContractReceivePriority(addr types.Address, weight uint64) {
+	manager.receiveControl.setPriority(addr, weight)
+	for _, w := range manager.contractWorkers {
+		w.updateContractPriority(addr, weight)
+	}
+	manager.log.Info("set contract receive priority", "contract", addr, "weight", weight)
+}
+
+// GetContractReceivePriorities returns the pinned priority weights of the contracts.
+func (manager *Manager) GetContractReceivePriorities() map[types.Address]uint64 {
+	return manager.receiveControl.getPriorities()
+}
+
+// PauseContractReceive stops receiving the OnRoad blocks of the contract on this node until it is resumed.
+func (manager *Manager) PauseContractReceive(addr types.Address) {
+	manager.receiveControl.setPaused(addr, true)
+	manager.log.Info("pause contract receive", "contract", addr)
+}
+
+// ResumeContractReceive resumes receiving the OnRoad blocks of the contract paused by PauseContractReceive.
+func (manager *Manager) ResumeContractReceive(addr types.Address) {
+	manager.receiveControl.setPaused(addr, false)
+	for _, w := range manager.contractWorkers {
+		w.resumeContract(addr)
+	}
+	manager.log.Info("resume contract receive", "contract", addr)
+}
+
+// GetPausedContracts returns the contracts paused by PauseContractReceive.
+func (manager *Manager) GetPausedContracts() []types.Address {
+	return manager.receiveControl.getPausedList()
+}
+
+// GetContractReceiveStatus returns the status of receiving the OnRoad blocks of the contract in the gid.
+func (manager *Manager) GetContractReceiveStatus(gid types.Gid, addr types.Address) *ContractReceiveStatus {
+	status := &ContractReceiveStatus{
+		Address:  addr,
+		Priority: manager.receiveControl.getPriority(addr),
+		Paused:   manager.receiveControl.isPaused(addr),
+		Callers:  make(map[types.Address]string),
+	}
+	if w, ok := manager.contractWorkers[gid]; ok {
+		w.fillReceiveStatus(status)
+	}
+	return status
+}
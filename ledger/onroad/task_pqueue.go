@@ -5,9 +5,19 @@ import (
 )
 
 type contractTask struct {
-	Addr  types.Address
-	Index int
-	Quota uint64
+	Addr     types.Address
+	Index    int
+	Quota    uint64
+	Priority uint64 // the weight pinned by the operator, see Manager.SetContractReceivePriority
+}
+
+// isPriorTo returns whether the task should be handled before the other one, the pinned priority
+// goes first and then the stake quota.
+func (t *contractTask) isPriorTo(other *contractTask) bool {
+	if t.Priority != other.Priority {
+		return t.Priority > other.Priority
+	}
+	return t.Quota >= other.Quota
 }
 
 type contractTaskPQueue []*contractTask
@@ -30,10 +40,7 @@ func (q *contractTaskPQueue) Pop() interface{} {
 func (q *contractTaskPQueue) Len() int { return len(*q) }
 
 func (q *contractTaskPQueue) Less(i, j int) bool {
-	if (*q)[i].Quota < (*q)[j].Quota {
-		return false
-	}
-	return true
+	return (*q)[i].isPriorTo((*q)[j])
 }
 
 func (q *contractTaskPQueue) Swap(i, j int) {
@@ -145,6 +145,35 @@ func TestContractTaskPQueue_PushDuplicates(t *testing.T) {
 	}
 }
 
+func TestContractTaskPQueue_Priority(t *testing.T) {
+	addr := make([]types.Address, len(addrStr))
+	for i, value := range addrStr {
+		a, _ := types.HexToAddress(value)
+		addr[i] = a
+	}
+
+	ct := make(contractTaskPQueue, len(addr))
+	for key, value := range addr {
+		ct[key] = &contractTask{
+			Addr:  value,
+			Index: key,
+			Quota: q[key],
+		}
+	}
+	// pin the contracts with the lowest quota
+	ct[0].Priority = 1
+	ct[1].Priority = 2
+	heap.Init(&ct)
+
+	expected := []types.Address{addr[1], addr[0], addr[4], addr[3], addr[2]}
+	for i, e := range expected {
+		v := heap.Pop(&ct).(*contractTask)
+		if v.Addr != e {
+			t.Fatalf("unexpected task %v at %v, addr %v quota %v priority %v", i, e, v.Addr, v.Quota, v.Priority)
+		}
+	}
+}
+
 type testPQWorker struct {
 	contractTaskPQueue contractTaskPQueue
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
 	"github.com/vitelabs/go-vite/v2/ledger/generator"
 	"github.com/vitelabs/go-vite/v2/log15"
 	"github.com/vitelabs/go-vite/v2/vm/quota"
@@ -43,14 +44,13 @@ func (tp *ContractTaskProcessor) work() {
 		task := tp.worker.popContractTask()
 		if task != nil {
 			signalLog.Info(fmt.Sprintf("tp=%v wakeup, pop addr %v quota %v", tp.taskID, task.Addr, task.Quota))
-			if tp.worker.isContractInBlackList(task.Addr) || !tp.worker.addContractIntoWorkingList(task.Addr) {
+			if tp.worker.isContractInBlackList(task.Addr) || tp.worker.isContractPaused(task.Addr) || !tp.worker.addContractIntoWorkingList(task.Addr) {
 				continue
 			}
 			canContinue := tp.processOneAddress(task)
 			tp.worker.removeContractFromWorkingList(task.Addr)
 			if canContinue {
-				task.Quota = tp.worker.GetStakeQuota(task.Addr)
-				tp.worker.pushContractTask(task)
+				tp.worker.pushContractTask(tp.worker.newContractTask(task.Addr))
 			}
 			continue
 		}
@@ -140,7 +140,7 @@ func (tp *ContractTaskProcessor) processOneAddress(task *contractTask) (canConti
 	} else {
 		if genResult.IsRetry {
 			blog.Info("genResult.IsRetry true")
-			if !types.IsBuiltinContractAddrInUseWithoutQuota(task.Addr) {
+			if !ledger.IsBuiltinContractAddrInUseWithoutQuota(task.Addr, tp.worker.manager.Chain().GetLatestSnapshotBlock().Height) {
 				_, q, err := tp.worker.manager.Chain().GetStakeQuota(task.Addr)
 				if err != nil || q == nil {
 					blog.Error(fmt.Sprintf("failed to get stake quota, err:%v", err))
@@ -31,3 +31,13 @@ const (
 	// to a particular contract during a block period any more.
 	OUT
 )
+
+func (s inferiorState) String() string {
+	switch s {
+	case RETRY:
+		return "RETRY"
+	case OUT:
+		return "OUT"
+	}
+	return "UNKNOWN"
+}
@@ -48,6 +48,10 @@ func (bs blocks) location(height uint64) (*chain_file_manager.Location, error) {
 		if chunk.SnapshotBlock.Height == height {
 			return &cur, nil
 		} else if chunk.SnapshotBlock.Height > height {
+			// the blocks start after height, such as the backup of gvite recover
+			if cur == start.Location {
+				return &cur, nil
+			}
 			return nil, errors.New("strange error")
 		}
 		cur = *location
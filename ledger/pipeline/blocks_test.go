@@ -58,3 +58,47 @@ func TestNewBlocks(t *testing.T) {
 		t.FailNow()
 	}
 }
+
+func TestBlocks_LocationAfterStart(t *testing.T) {
+	// the blocks start from 1000, like the backup of gvite recover
+	dir := fileutils.CreateTempDir()
+	defer os.RemoveAll(dir)
+
+	blockDb, err := chain_block.NewBlockDBFixedSize(dir, testFilesize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1000; i < 1100; i++ {
+		var block core.SnapshotBlock
+		block.Mock(uint64(i))
+		if _, _, err = blockDb.Write(&core.SnapshotChunk{SnapshotBlock: &block}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	blockDb.Prepare()
+	blockDb.Commit()
+	blockDb.Close()
+
+	bs, err := newBlocks(dir, testFilesize)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, height := range []uint64{900, 1000, 1050} {
+		location, err := bs.location(height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunk, _, err := bs.blockDb.ReadChunk(*location)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := height
+		if expected < 1000 {
+			expected = 1000
+		}
+		if chunk.SnapshotBlock.Height != expected {
+			t.Errorf("height %d: expected chunk %d, got %d", height, expected, chunk.SnapshotBlock.Height)
+		}
+	}
+}
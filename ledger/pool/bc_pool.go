@@ -219,6 +219,28 @@ func (bcp *BCPool) rollbackCurrent(blocks []commonBlock) error {
 	return nil
 }
 
+// dropCurrent rollbacks the blocks deleted from chain to the current chain, and then drops them from the current chain,
+// so the deleted blocks will not be inserted again.
+func (bcp *BCPool) dropCurrent(blocks []commonBlock) error {
+	err := bcp.rollbackCurrent(blocks)
+	if err != nil {
+		return err
+	}
+
+	cur := bcp.CurrentChain()
+	for {
+		headHeight, _ := cur.HeadHH()
+		tailHeight, _ := cur.TailHH()
+		if headHeight <= tailHeight {
+			return nil
+		}
+		err = bcp.chainpool.tree.RemoveHead(cur, cur.GetKnot(headHeight, false))
+		if err != nil {
+			return err
+		}
+	}
+}
+
 // check blocks is a chain
 func (bcp *BCPool) checkChain(blocks []commonBlock) error {
 	var prev commonBlock
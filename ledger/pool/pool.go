@@ -63,6 +63,11 @@ type Debug interface {
 	AccountChainDetail(addr types.Address, chainID string, height uint64) map[string]interface{}
 }
 
+// Rollback deletes blocks from both the chain and the pool, it's used by the virtual node
+type Rollback interface {
+	RollbackSnapshotTo(height uint64) error
+}
+
 // BlockPool is responsible for organizing blocks and inserting it into the chain
 type BlockPool interface {
 	Writer
@@ -70,6 +75,7 @@ type BlockPool interface {
 	SnapshotProducerWriter
 	Debug
 	Pipeline
+	Rollback
 
 	Start()
 	Stop()
@@ -471,6 +477,75 @@ func (pl *pool) ForkAccountTo(addr types.Address, h *ledger.HashHeight) error {
 	return nil
 }
 
+// RollbackSnapshotTo deletes the snapshot blocks from height to the latest, and the account blocks confirmed by them
+// or unconfirmed. The deleted blocks are dropped from the pool, so they will not be inserted again.
+func (pl *pool) RollbackSnapshotTo(height uint64) error {
+	pl.LockInsert()
+	defer pl.UnLockInsert()
+	pl.LockRollback()
+	defer pl.UnLockRollback()
+	defer pl.rollbackVersion.Inc()
+	defer pl.version.Inc()
+
+	pl.log.Warn("rollback snapshot chain", "height", height)
+	snapshots, accounts, err := pl.pendingSc.rw.delToHeight(height)
+	if err != nil {
+		return err
+	}
+
+	if len(snapshots) > 0 {
+		err = pl.pendingSc.dropCurrent(snapshots)
+		if err != nil {
+			return err
+		}
+		pl.pendingSc.checkCurrent()
+	}
+
+	// the chain may keep the account blocks of the first deleted snapshot block as unconfirmed, delete them too
+	lowest := make(map[types.Address]uint64)
+	for _, b := range pl.bc.GetAllUnconfirmedBlocks() {
+		if h, ok := lowest[b.AccountAddress]; !ok || b.Height < h {
+			lowest[b.AccountAddress] = b.Height
+		}
+	}
+	deleted := make(map[types.Hash]bool)
+	for _, blocks := range accounts {
+		for _, b := range blocks {
+			deleted[b.Hash()] = true
+		}
+	}
+	for addr, h := range lowest {
+		if latest, err := pl.bc.GetLatestAccountBlock(addr); err != nil {
+			return err
+		} else if latest == nil || latest.Height < h {
+			// already deleted with the unconfirmed blocks of another account
+			continue
+		}
+		_, unconfirmed, err := pl.selfPendingAc(addr).rw.delToHeight(h)
+		if err != nil {
+			return err
+		}
+		for a, blocks := range unconfirmed {
+			for _, b := range blocks {
+				if !deleted[b.Hash()] {
+					deleted[b.Hash()] = true
+					accounts[a] = append(accounts[a], b)
+				}
+			}
+		}
+	}
+
+	for addr, blocks := range accounts {
+		ac := pl.selfPendingAc(addr)
+		err = ac.dropCurrent(blocks)
+		if err != nil {
+			return err
+		}
+		ac.checkCurrent()
+	}
+	return nil
+}
+
 func (pl *pool) RollbackAccountTo(addr types.Address, hash types.Hash, height uint64) error {
 	p := pl.selfPendingAc(addr)
 
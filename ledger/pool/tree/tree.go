@@ -55,6 +55,7 @@ type Tree interface {
 	RootHeadAdd(k Knot) error
 
 	AddHead(b Branch, k Knot) error
+	RemoveHead(b Branch, k Knot) error
 	RemoveTail(b Branch, k Knot) error
 	AddTail(b Branch, k Knot) error
 
@@ -145,6 +145,16 @@ func (self *tree) AddHead(b Branch, k Knot) error {
 	return nil
 }
 
+func (self *tree) RemoveHead(b Branch, k Knot) error {
+	if b.Type() == Disk {
+		return errors.New("can't remove head from chain.")
+	}
+	br := b.(*branch)
+	br.removeHead(k)
+	self.knotRemove(k)
+	return nil
+}
+
 func (self *tree) RemoveTail(b Branch, k Knot) error {
 	if b.Type() == Disk {
 		return errors.New("can't remove tail from chain.")
@@ -249,3 +249,35 @@ func TestTree_RootHeadAdd(t *testing.T) {
 	err := CheckTreeRing(tr)
 	assert.NoError(t, err)
 }
+
+func TestTree_RemoveHead(t *testing.T) {
+	root := NewMockBranchRoot()
+	tr := NewTree()
+	for i := 0; i < 5; i++ {
+		root.addHead(newMockKnot(root.Head(), "root"))
+	}
+	tr.Init("unittest", root)
+
+	main := tr.Main()
+	for i := 0; i < 3; i++ {
+		height, hash := main.HeadHH()
+		assert.NoError(t, tr.AddHead(main, newMockKnotByHH(height, hash, "main")))
+	}
+	headHeight, _ := main.HeadHH()
+	assert.Equal(t, uint64(8), headHeight)
+
+	for {
+		headHeight, _ := main.HeadHH()
+		tailHeight, _ := main.TailHH()
+		if headHeight <= tailHeight {
+			break
+		}
+		assert.NoError(t, tr.RemoveHead(main, main.GetKnot(headHeight, false)))
+	}
+	headHeight, _ = main.HeadHH()
+	assert.Equal(t, uint64(5), headHeight)
+	assert.Error(t, tr.RemoveHead(root, root.Head()))
+
+	err := CheckTreeRing(tr)
+	assert.NoError(t, err)
+}
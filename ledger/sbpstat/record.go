@@ -0,0 +1,119 @@
+package sbpstat
+
+import (
+	"expvar"
+	"sort"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+)
+
+const (
+	// CauseAbsent means no block of the slot is seen by the node
+	CauseAbsent = "absent"
+	// CauseLate means the block of the slot is seen after the slot ends, the next producer has built on another block
+	CauseLate = "late"
+	// CauseForked means the block of the slot is seen in time but it is not on the chain, the pool chose another fork
+	CauseForked = "forked"
+)
+
+// SlotRecord is the outcome of a slot of the snapshot consensus group
+type SlotRecord struct {
+	Timestamp   int64         `json:"timestamp"` // unix seconds of the slot start
+	PeriodIndex uint64        `json:"periodIndex"`
+	Producer    types.Address `json:"producer"` // the planned producer
+
+	Missed bool   `json:"missed"`
+	Cause  string `json:"cause,omitempty"` // empty if the block is on the chain
+
+	// Hash is the block on the chain, or the block seen but not on the chain if missed by late or forked
+	Hash   *types.Hash `json:"hash,omitempty"`
+	Height uint64      `json:"height,omitempty"`
+
+	// Delay is the milliseconds from the slot start to the first time the block is seen, -1 if unknown
+	Delay int64 `json:"delay"`
+	// Source is how the block is first seen: broadcast, fetch or chain(produced locally or synced)
+	Source string `json:"source,omitempty"`
+}
+
+// ProducerSummary sums up the slot records of a producer
+type ProducerSummary struct {
+	Producer types.Address `json:"producer"`
+	Planned  uint64        `json:"planned"`
+	Produced uint64        `json:"produced"`
+	Absent   uint64        `json:"absent"`
+	Late     uint64        `json:"late"`
+	Forked   uint64        `json:"forked"`
+
+	// AvgDelay and MaxDelay are the milliseconds of the produced blocks whose delay is known
+	AvgDelay int64 `json:"avgDelay"`
+	MaxDelay int64 `json:"maxDelay"`
+
+	delaySum, delayCount int64
+}
+
+func (s *ProducerSummary) add(r *SlotRecord) {
+	s.Planned++
+	if !r.Missed {
+		s.Produced++
+		if r.Delay >= 0 {
+			s.delaySum += r.Delay
+			s.delayCount++
+			s.AvgDelay = s.delaySum / s.delayCount
+			if r.Delay > s.MaxDelay {
+				s.MaxDelay = r.Delay
+			}
+		}
+		return
+	}
+	switch r.Cause {
+	case CauseLate:
+		s.Late++
+	case CauseForked:
+		s.Forked++
+	default:
+		s.Absent++
+	}
+}
+
+func summarize(records []*SlotRecord) []*ProducerSummary {
+	summaries := make(map[types.Address]*ProducerSummary)
+	for _, r := range records {
+		s, ok := summaries[r.Producer]
+		if !ok {
+			s = &ProducerSummary{Producer: r.Producer}
+			summaries[r.Producer] = s
+		}
+		s.add(r)
+	}
+	result := make([]*ProducerSummary, 0, len(summaries))
+	for _, s := range summaries {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Producer.String() < result[j].Producer.String()
+	})
+	return result
+}
+
+// slotMetrics are the counters of each producer since the node starts, they are published
+// at /debug/vars along with the pprof tool
+var slotMetrics = expvar.NewMap("sbp_slots")
+
+func addMetrics(r *SlotRecord) {
+	key := r.Producer.String()
+	m, ok := slotMetrics.Get(key).(*expvar.Map)
+	if !ok {
+		m = new(expvar.Map).Init()
+		slotMetrics.Set(key, m)
+	}
+	m.Add("planned", 1)
+	if !r.Missed {
+		m.Add("produced", 1)
+		if r.Delay >= 0 {
+			m.Add("delayed", 1)
+			m.Add("delaySum", r.Delay)
+		}
+		return
+	}
+	m.Add(r.Cause, 1)
+}
@@ -0,0 +1,80 @@
+package sbpstat
+
+import (
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+const slotRecordPrefix = byte(0)
+
+// slotDB is a ring buffer of the slot records, the record of a slot is stored at the position of its
+// start time modulo the retention, so a record is overwritten by the slot one retention later. A position
+// may keep a record older than the retention if the slot in between is not recorded, the timestamp of the
+// record tells them apart.
+type slotDB struct {
+	db        *leveldb.DB
+	retention uint64
+}
+
+func newSlotDB(db *leveldb.DB, retention uint64) *slotDB {
+	return &slotDB{db: db, retention: retention}
+}
+
+func (sdb *slotDB) put(records []*SlotRecord) error {
+	batch := new(leveldb.Batch)
+	for _, r := range records {
+		value, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		batch.Put(sdb.key(r.Timestamp), value)
+	}
+	return sdb.db.Write(batch, nil)
+}
+
+// get returns the record of the slot starting at timestamp, nil if it is not recorded or overwritten
+func (sdb *slotDB) get(timestamp int64) (*SlotRecord, error) {
+	value, err := sdb.db.Get(sdb.key(timestamp), nil)
+	if err != nil {
+		if err == leveldb.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	r := &SlotRecord{}
+	if err := json.Unmarshal(value, r); err != nil {
+		return nil, err
+	}
+	if r.Timestamp != timestamp {
+		return nil, nil
+	}
+	return r, nil
+}
+
+// rangeOf returns the records of the slots starting in [start, end) in order
+func (sdb *slotDB) rangeOf(start, end int64) ([]*SlotRecord, error) {
+	var records []*SlotRecord
+	for t := start; t < end; t++ {
+		r, err := sdb.get(t)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil {
+			records = append(records, r)
+		}
+	}
+	return records, nil
+}
+
+func (sdb *slotDB) key(timestamp int64) []byte {
+	key := make([]byte, 1+8)
+	key[0] = slotRecordPrefix
+	binary.BigEndian.PutUint64(key[1:], uint64(timestamp)%sdb.retention)
+	return key
+}
+
+func (sdb *slotDB) close() error {
+	return sdb.db.Close()
+}
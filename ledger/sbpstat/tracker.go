@@ -0,0 +1,359 @@
+package sbpstat
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/ledger/consensus"
+	"github.com/vitelabs/go-vite/v2/log15"
+)
+
+const (
+	dbDirName       = "sbp_stat"
+	processInterval = time.Second
+)
+
+var errTrackerStarted = errors.New("the sbp stat tracker is started")
+
+type Chain interface {
+	Register(listener interfaces.EventListener)
+	UnRegister(listener interfaces.EventListener)
+	NewDb(dirName string) (*leveldb.DB, error)
+
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+	GetSnapshotHeaderBeforeTime(timestamp *time.Time) (*ledger.SnapshotBlock, error)
+	GetSnapshotHeadersAfterOrEqualTime(endHashHeight *ledger.HashHeight, startTime *time.Time, producer *types.Address) ([]*ledger.SnapshotBlock, error)
+}
+
+// BlockFeed is the snapshot blocks received from the network, see net.BlockSubscriber
+type BlockFeed interface {
+	SubscribeSnapshotBlock(fn func(block *ledger.SnapshotBlock, source types.BlockSource)) (subId int)
+	UnsubscribeSnapshotBlock(subId int)
+}
+
+// sighting is the first time the node sees a snapshot block
+type sighting struct {
+	hash      types.Hash
+	height    uint64
+	producer  types.Address
+	firstSeen time.Time
+	source    string
+}
+
+// Tracker records the outcome of each slot of the snapshot consensus group. It watches the snapshot blocks
+// received from the network and inserted into the chain, and once the outcome of a period is settled, it
+// compares the planned producers with the chain and attributes each missed slot to a cause.
+type Tracker struct {
+	chain Chain
+	cs    consensus.Reader
+	feed  BlockFeed
+	db    *slotDB
+	log   log15.Logger
+
+	// sightings of the snapshot blocks by the unix seconds of their timestamp
+	sightings map[int64][]*sighting
+	mu        sync.Mutex
+
+	next    uint64 // the next period to process
+	subId   int
+	started bool
+	term    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func New(cfg *config.SBPStat, chain Chain, cs consensus.Reader, feed BlockFeed) (*Tracker, error) {
+	retention := cfg.Retention
+	if retention == 0 {
+		retention = config.DefaultSBPStatRetention
+	}
+	db, err := chain.NewDb(dbDirName)
+	if err != nil {
+		return nil, err
+	}
+	return &Tracker{
+		chain:     chain,
+		cs:        cs,
+		feed:      feed,
+		db:        newSlotDB(db, retention),
+		log:       log15.New("module", "sbpstat"),
+		sightings: make(map[int64][]*sighting),
+	}, nil
+}
+
+// Start tracks the slots from the next period, it must be called after the consensus is initialized
+func (t *Tracker) Start() error {
+	if t.started {
+		return errTrackerStarted
+	}
+	index, err := t.cs.VoteTimeToIndex(types.SNAPSHOT_GID, time.Now())
+	if err != nil {
+		return err
+	}
+	t.next = index + 1
+
+	t.subId = t.feed.SubscribeSnapshotBlock(t.onNetSnapshotBlock)
+	t.chain.Register(t)
+
+	t.started = true
+	t.term = make(chan struct{})
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.loop()
+	}()
+	t.log.Info(fmt.Sprintf("sbp stat tracker started from period %d", t.next))
+	return nil
+}
+
+func (t *Tracker) Stop() {
+	if !t.started {
+		return
+	}
+	t.chain.UnRegister(t)
+	t.feed.UnsubscribeSnapshotBlock(t.subId)
+	close(t.term)
+	t.wg.Wait()
+	if err := t.db.close(); err != nil {
+		t.log.Error(fmt.Sprintf("close sbp stat db failed, %s", err.Error()))
+	}
+	t.started = false
+}
+
+// GetSlotRecords returns the records of the slots starting in [start, end), only of the producer if it is not nil
+func (t *Tracker) GetSlotRecords(start, end time.Time, producer *types.Address) ([]*SlotRecord, error) {
+	records, err := t.db.rangeOf(start.Unix(), end.Unix())
+	if err != nil {
+		return nil, err
+	}
+	if producer == nil {
+		return records, nil
+	}
+	result := make([]*SlotRecord, 0)
+	for _, r := range records {
+		if r.Producer == *producer {
+			result = append(result, r)
+		}
+	}
+	return result, nil
+}
+
+// GetProducerSummaries sums up the records of the slots starting in [start, end) by producer
+func (t *Tracker) GetProducerSummaries(start, end time.Time) ([]*ProducerSummary, error) {
+	records, err := t.db.rangeOf(start.Unix(), end.Unix())
+	if err != nil {
+		return nil, err
+	}
+	return summarize(records), nil
+}
+
+func (t *Tracker) loop() {
+	ticker := time.NewTicker(processInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-t.term:
+			return
+		case now := <-ticker.C:
+			t.processSettled(now)
+		}
+	}
+}
+
+// processSettled processes the periods which ended more than a period ago, the blocks late for a period
+// or the rollback of the fork are settled by then
+func (t *Tracker) processSettled(now time.Time) {
+	for {
+		stime, etime, err := t.cs.VoteIndexToTime(types.SNAPSHOT_GID, t.next)
+		if err != nil {
+			t.log.Error(fmt.Sprintf("get time of period %d failed, %s", t.next, err.Error()))
+			return
+		}
+		if now.Before(etime.Add(etime.Sub(*stime))) {
+			return
+		}
+		if err := t.processPeriod(t.next, *stime, *etime); err != nil {
+			t.log.Error(fmt.Sprintf("process period %d failed, %s", t.next, err.Error()))
+		}
+		t.prune(*etime)
+		t.next++
+	}
+}
+
+func (t *Tracker) processPeriod(index uint64, stime, etime time.Time) error {
+	latest := t.chain.GetLatestSnapshotBlock()
+	if latest.Timestamp.Before(etime) {
+		// the blocks are inserted by sync, their sightings don't tell how the slots went
+		t.log.Info(fmt.Sprintf("skip period %d, the node is not synced", index))
+		return nil
+	}
+
+	events, _, err := t.cs.ReadByIndex(types.SNAPSHOT_GID, index)
+	if err != nil {
+		return err
+	}
+	blocks := make(map[int64]*ledger.SnapshotBlock)
+	endBlock, err := t.chain.GetSnapshotHeaderBeforeTime(&etime)
+	if err != nil {
+		return err
+	}
+	if endBlock != nil {
+		headers, err := t.chain.GetSnapshotHeadersAfterOrEqualTime(&ledger.HashHeight{Hash: endBlock.Hash, Height: endBlock.Height}, &stime, nil)
+		if err != nil {
+			return err
+		}
+		for _, header := range headers {
+			blocks[header.Timestamp.Unix()] = header
+		}
+	}
+
+	records := make([]*SlotRecord, 0, len(events))
+	t.mu.Lock()
+	for _, e := range events {
+		slot := e.Timestamp.Unix()
+		records = append(records, classify(index, e, blocks[slot], t.sightings[slot]))
+	}
+	t.mu.Unlock()
+
+	if err := t.db.put(records); err != nil {
+		return err
+	}
+	for _, r := range records {
+		addMetrics(r)
+	}
+	return nil
+}
+
+// classify attributes the slot to the block on the chain, or to the cause why it is missed
+func classify(index uint64, e *consensus.Event, block *ledger.SnapshotBlock, sightings []*sighting) *SlotRecord {
+	r := &SlotRecord{
+		Timestamp:   e.Timestamp.Unix(),
+		PeriodIndex: index,
+		Producer:    e.Address,
+		Delay:       -1,
+	}
+	if block != nil && block.Producer() == e.Address {
+		hash := block.Hash
+		r.Hash = &hash
+		r.Height = block.Height
+		for _, s := range sightings {
+			if s.hash == block.Hash {
+				r.Delay = s.firstSeen.Sub(e.Stime).Milliseconds()
+				r.Source = s.source
+			}
+		}
+		return r
+	}
+
+	r.Missed = true
+	r.Cause = CauseAbsent
+	var first *sighting
+	for _, s := range sightings {
+		if s.producer == e.Address && (first == nil || s.firstSeen.Before(first.firstSeen)) {
+			first = s
+		}
+	}
+	if first == nil {
+		return r
+	}
+	hash := first.hash
+	r.Hash = &hash
+	r.Height = first.height
+	r.Delay = first.firstSeen.Sub(e.Stime).Milliseconds()
+	r.Source = first.source
+	if first.firstSeen.Before(e.Etime) {
+		r.Cause = CauseForked
+	} else {
+		r.Cause = CauseLate
+	}
+	return r
+}
+
+func (t *Tracker) onNetSnapshotBlock(block *ledger.SnapshotBlock, source types.BlockSource) {
+	switch source {
+	case types.RemoteBroadcast:
+		t.observe(block, "broadcast", time.Now())
+	case types.RemoteFetch:
+		t.observe(block, "fetch", time.Now())
+	}
+}
+
+func (t *Tracker) observe(block *ledger.SnapshotBlock, source string, now time.Time) {
+	if block.Timestamp == nil {
+		return
+	}
+	slot := block.Timestamp.Unix()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, s := range t.sightings[slot] {
+		if s.hash == block.Hash {
+			return
+		}
+	}
+	t.sightings[slot] = append(t.sightings[slot], &sighting{
+		hash:      block.Hash,
+		height:    block.Height,
+		producer:  block.Producer(),
+		firstSeen: now,
+		source:    source,
+	})
+}
+
+// prune drops the sightings of the slots before the time
+func (t *Tracker) prune(before time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for slot := range t.sightings {
+		if slot < before.Unix() {
+			delete(t.sightings, slot)
+		}
+	}
+}
+
+func (t *Tracker) PrepareInsertAccountBlocks(blocks []*interfaces.VmAccountBlock) error {
+	return nil
+}
+
+func (t *Tracker) InsertAccountBlocks(blocks []*interfaces.VmAccountBlock) error {
+	return nil
+}
+
+func (t *Tracker) PrepareInsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+// InsertSnapshotBlocks sees the blocks produced by this node, and the blocks not received by the block feed
+func (t *Tracker) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	now := time.Now()
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock != nil {
+			t.observe(chunk.SnapshotBlock, "chain", now)
+		}
+	}
+	return nil
+}
+
+func (t *Tracker) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (t *Tracker) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (t *Tracker) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+// DeleteSnapshotBlocks does nothing, the sightings of the deleted blocks are kept to tell the forked slots
+func (t *Tracker) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
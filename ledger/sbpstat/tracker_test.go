@@ -0,0 +1,171 @@
+package sbpstat
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+
+	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/ledger/consensus"
+)
+
+type testChain struct {
+	dir    string
+	blocks []*ledger.SnapshotBlock
+}
+
+func (c *testChain) Register(listener interfaces.EventListener)   {}
+func (c *testChain) UnRegister(listener interfaces.EventListener) {}
+
+func (c *testChain) NewDb(dirName string) (*leveldb.DB, error) {
+	return leveldb.OpenFile(filepath.Join(c.dir, dirName), nil)
+}
+
+func (c *testChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.blocks[len(c.blocks)-1]
+}
+
+func (c *testChain) GetSnapshotHeaderBeforeTime(timestamp *time.Time) (*ledger.SnapshotBlock, error) {
+	var result *ledger.SnapshotBlock
+	for _, block := range c.blocks {
+		if block.Timestamp.Before(*timestamp) {
+			result = block
+		}
+	}
+	return result, nil
+}
+
+func (c *testChain) GetSnapshotHeadersAfterOrEqualTime(endHashHeight *ledger.HashHeight, startTime *time.Time, producer *types.Address) ([]*ledger.SnapshotBlock, error) {
+	var result []*ledger.SnapshotBlock
+	for _, block := range c.blocks {
+		if !block.Timestamp.Before(*startTime) && block.Height <= endHashHeight.Height {
+			result = append(result, block)
+		}
+	}
+	return result, nil
+}
+
+type testReader struct {
+	consensus.Reader
+	events []*consensus.Event
+}
+
+func (r *testReader) ReadByIndex(gid types.Gid, index uint64) ([]*consensus.Event, uint64, error) {
+	return r.events, index, nil
+}
+
+func TestTracker(t *testing.T) {
+	producers := make([]*ledger.SnapshotBlock, 4)
+	for i := range producers {
+		producers[i] = &ledger.SnapshotBlock{PublicKey: []byte{byte(i + 1)}}
+	}
+	addr := func(i int) types.Address {
+		return producers[i].Producer()
+	}
+	stime := time.Unix(1600000000, 0)
+	slot := func(i int) time.Time {
+		return stime.Add(time.Duration(i) * time.Second)
+	}
+	newBlock := func(i int, producer int, height uint64) *ledger.SnapshotBlock {
+		timestamp := slot(i)
+		return &ledger.SnapshotBlock{
+			Hash:      types.DataHash([]byte{byte(i), byte(producer), byte(height)}),
+			Height:    height,
+			Timestamp: &timestamp,
+			PublicKey: producers[producer].PublicKey,
+		}
+	}
+
+	reader := &testReader{}
+	for i := 0; i < 4; i++ {
+		reader.events = append(reader.events, &consensus.Event{Address: addr(i), Stime: slot(i), Etime: slot(i + 1), Timestamp: slot(i)})
+	}
+	produced := newBlock(0, 0, 10)
+	forked := newBlock(2, 2, 11)
+	late := newBlock(3, 3, 11)
+	chain := &testChain{dir: t.TempDir(), blocks: []*ledger.SnapshotBlock{produced, newBlock(4, 0, 11)}}
+
+	tracker, err := New(&config.SBPStat{Enabled: true, Retention: 100}, chain, reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tracker.db.close()
+
+	// slot 1 is absent, slot 2 is seen in time but not on the chain, slot 3 is seen after the slot ends
+	tracker.observe(produced, "broadcast", slot(0).Add(300*time.Millisecond))
+	tracker.observe(produced, "chain", slot(0).Add(400*time.Millisecond))
+	tracker.observe(forked, "broadcast", slot(2).Add(200*time.Millisecond))
+	tracker.observe(late, "fetch", slot(4).Add(100*time.Millisecond))
+
+	if err := tracker.processPeriod(1, slot(0), slot(4)); err != nil {
+		t.Fatal(err)
+	}
+	tracker.prune(slot(4))
+	if len(tracker.sightings) != 0 {
+		t.Fatalf("unexpected sightings %+v", tracker.sightings)
+	}
+
+	records, err := tracker.GetSlotRecords(slot(0), slot(4), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		missed bool
+		cause  string
+		hash   *types.Hash
+		delay  int64
+		source string
+	}{
+		{false, "", &produced.Hash, 300, "broadcast"},
+		{true, CauseAbsent, nil, -1, ""},
+		{true, CauseForked, &forked.Hash, 200, "broadcast"},
+		{true, CauseLate, &late.Hash, 1100, "fetch"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("unexpected records %+v", records)
+	}
+	for i, r := range records {
+		e := expected[i]
+		if r.Timestamp != slot(i).Unix() || r.Producer != addr(i) || r.PeriodIndex != 1 ||
+			r.Missed != e.missed || r.Cause != e.cause || r.Delay != e.delay || r.Source != e.source ||
+			(r.Hash == nil) != (e.hash == nil) || (r.Hash != nil && *r.Hash != *e.hash) {
+			t.Fatalf("unexpected record %d %+v", i, r)
+		}
+	}
+
+	if records, err := tracker.GetSlotRecords(slot(0), slot(4), &[]types.Address{addr(2)}[0]); err != nil || len(records) != 1 || records[0].Cause != CauseForked {
+		t.Fatalf("unexpected records %+v, err %v", records, err)
+	}
+
+	summaries, err := tracker.GetProducerSummaries(slot(0), slot(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summaries) != 4 {
+		t.Fatalf("unexpected summaries %+v", summaries)
+	}
+	for _, s := range summaries {
+		if s.Planned != 1 || s.Produced+s.Absent+s.Late+s.Forked != 1 {
+			t.Fatalf("unexpected summary %+v", s)
+		}
+		if s.Producer == addr(0) && (s.AvgDelay != 300 || s.MaxDelay != 300) {
+			t.Fatalf("unexpected summary %+v", s)
+		}
+	}
+
+	// the slots one retention later overwrite the records
+	if err := tracker.db.put([]*SlotRecord{{Timestamp: slot(100).Unix(), Producer: addr(1), Delay: -1}}); err != nil {
+		t.Fatal(err)
+	}
+	if records, err := tracker.GetSlotRecords(slot(0), slot(4), nil); err != nil || len(records) != 3 || records[0].Producer != addr(1) || records[0].Timestamp != slot(1).Unix() {
+		t.Fatalf("unexpected records %+v, err %v", records, err)
+	}
+	if records, err := tracker.GetSlotRecords(slot(100), slot(101), nil); err != nil || len(records) != 1 {
+		t.Fatalf("unexpected records %+v, err %v", records, err)
+	}
+}
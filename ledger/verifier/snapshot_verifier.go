@@ -18,13 +18,19 @@ import (
 type SnapshotVerifier struct {
 	reader   chain.Chain
 	verifier interfaces.ConsensusVerifier
+	now      func() time.Time
 }
 
 func NewSnapshotVerifier(ch chain.Chain, verifier interfaces.ConsensusVerifier) *SnapshotVerifier {
-	snapshotVerifier := &SnapshotVerifier{reader: ch, verifier: verifier}
+	snapshotVerifier := &SnapshotVerifier{reader: ch, verifier: verifier, now: time.Now}
 	return snapshotVerifier
 }
 
+// SetClock replaces the clock of the timestamp check, the virtual node verifies blocks by its virtual clock
+func (self *SnapshotVerifier) SetClock(now func() time.Time) {
+	self.now = now
+}
+
 func (self *SnapshotVerifier) VerifyNetSb(block *ledger.SnapshotBlock) error {
 	if err := self.verifyTimestamp(block); err != nil {
 		return err
@@ -40,7 +46,7 @@ func (self *SnapshotVerifier) verifyTimestamp(block *ledger.SnapshotBlock) error
 		return errors.New("timestamp is nil")
 	}
 
-	if block.Timestamp.After(time.Now().Add(time.Hour)) {
+	if block.Timestamp.After(self.now().Add(time.Hour)) {
 		return errors.New("snapshot Timestamp not arrive yet")
 	}
 	return nil
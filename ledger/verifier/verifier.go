@@ -2,6 +2,7 @@ package verifier
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/errors"
 	"github.com/vitelabs/go-vite/v2/crypto"
@@ -33,6 +34,7 @@ type Verifier interface {
 	VerifyReferred(block *ledger.SnapshotBlock) *SnapshotBlockVerifyStat
 
 	Init(v interfaces.ConsensusVerifier, sbpStatReader cs_interfaces.SBPStatReader, manager *onroad.Manager) Verifier
+	SetClock(now func() time.Time)
 }
 
 // VerifyResult explains the states of transaction validation.
@@ -61,6 +63,10 @@ func (v *verifier) Init(cs_v interfaces.ConsensusVerifier, sbpStatReader cs_inte
 	return v
 }
 
+func (v *verifier) SetClock(now func() time.Time) {
+	v.Sv.SetClock(now)
+}
+
 func (v *verifier) VerifyNetSnapshotBlock(block *ledger.SnapshotBlock) error {
 	return v.Sv.VerifyNetSb(block)
 }
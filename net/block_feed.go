@@ -60,7 +60,7 @@ func (bf *blockFeed) SubscribeSnapshotBlock(fn SnapshotBlockCallback) (subId int
 }
 
 func (bf *blockFeed) UnsubscribeSnapshotBlock(subId int) {
-	delete(bf.aSubs, subId)
+	delete(bf.bSubs, subId)
 }
 
 func (bf *blockFeed) notifySnapshotBlock(block *ledger.SnapshotBlock, source types.BlockSource) {
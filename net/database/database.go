@@ -26,16 +26,13 @@ import (
 	"sort"
 	"time"
 
-	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/errors"
-	"github.com/syndtr/goleveldb/leveldb/storage"
-	"github.com/syndtr/goleveldb/leveldb/util"
-
+	"github.com/vitelabs/go-vite/v2/common/db/engine"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
 	"github.com/vitelabs/go-vite/v2/net/vnode"
 )
 
 type DB struct {
-	*leveldb.DB
+	engine.Engine
 	id vnode.NodeID
 }
 
@@ -51,6 +48,7 @@ var (
 	nodeBlockIDPrefix = []byte("node:block:id:") // block expiration
 )
 
+// New opens the node database in path, an empty path means the database is in memory
 func New(path string, version int, id vnode.NodeID) (db *DB, err error) {
 	if path == "" {
 		db, err = newMemDB(id)
@@ -66,45 +64,38 @@ func New(path string, version int, id vnode.NodeID) (db *DB, err error) {
 }
 
 func newMemDB(id vnode.NodeID) (*DB, error) {
-	ldb, err := leveldb.Open(storage.NewMemStorage(), nil)
-	if err != nil {
-		return nil, err
-	}
 	return &DB{
-		DB: ldb,
-		id: id,
+		Engine: engine.OpenMemory(),
+		id:     id,
 	}, nil
 }
 
 func newFileDB(path string, version int, id vnode.NodeID) (*DB, error) {
-	ldb, err := leveldb.OpenFile(path, nil)
-	if _, ok := err.(*errors.ErrCorrupted); ok {
-		ldb, err = leveldb.RecoverFile(path, nil)
-	}
+	ldb, err := engine.Recover(engine.LevelDB, path)
 
 	if err != nil {
 		return nil, err
 	}
 
 	vBytes := encodeVarint(int64(version))
-	oldVBytes, err := ldb.Get(versionKey, nil)
+	oldVBytes, err := ldb.Get(versionKey)
 
-	if err == leveldb.ErrNotFound {
-		err = ldb.Put(versionKey, vBytes, nil)
+	if err == engine.ErrNotFound {
+		err = ldb.Put(versionKey, vBytes)
 
 		if err != nil {
 			_ = ldb.Close()
 			return nil, err
 		}
 		return &DB{
-			DB: ldb,
-			id: id,
+			Engine: ldb,
+			id:     id,
 		}, nil
 	} else if err == nil {
 		if bytes.Equal(oldVBytes, vBytes) {
 			return &DB{
-				DB: ldb,
-				id: id,
+				Engine: ldb,
+				id:     id,
 			}, err
 		}
 
@@ -166,7 +157,7 @@ func (db *DB) StoreMark(id vnode.NodeID, v int64) {
 	binary.BigEndian.PutUint64(value, uint64(v))
 	binary.BigEndian.PutUint64(value[8:], uint64(time.Now().Unix()))
 
-	_ = db.DB.Put(key, value, nil)
+	_ = db.Engine.Put(key, value)
 }
 
 func (db *DB) BlockIP(ip net.IP, expiration int64) {
@@ -183,7 +174,7 @@ func (db *DB) BlockId(id vnode.NodeID, expiration int64) {
 func (db *DB) RetrieveNode(id vnode.NodeID) (node *vnode.Node, err error) {
 	key := append(nodeDataPrefix, id.Bytes()...)
 	// retrieve node
-	data, err := db.Get(key, nil)
+	data, err := db.Get(key)
 	if err != nil {
 		return
 	}
@@ -205,7 +196,7 @@ func (db *DB) StoreNode(node *vnode.Node) (err error) {
 	id := node.ID.Bytes()
 	// store node
 	key := append(nodeDataPrefix, id...)
-	err = db.Put(key, data, nil)
+	err = db.Put(key, data)
 
 	return
 }
@@ -215,21 +206,21 @@ func (db *DB) RemoveNode(ID vnode.NodeID) {
 	id := ID.Bytes()
 
 	key := append(nodeDataPrefix, id...)
-	_ = db.Delete(key, nil)
+	_ = db.Delete(key)
 
 	key = append(nodeActivePrefix, id...)
-	_ = db.Delete(key, nil)
+	_ = db.Delete(key)
 
 	key = append(nodeCheckPrefix, id...)
-	_ = db.Delete(key, nil)
+	_ = db.Delete(key)
 
 	key = append(nodeMarkPrefix, id...)
-	_ = db.Delete(key, nil)
+	_ = db.Delete(key)
 }
 
 // ReadNodes from database, if time.Now().Unix() - node.activeAt < expiration
 func (db *DB) ReadNodes(expiration int64) (nodes []*vnode.Node) {
-	itr := db.NewIterator(util.BytesPrefix(nodeActivePrefix), nil)
+	itr := db.NewIterator(util.BytesPrefix(nodeActivePrefix))
 	defer itr.Release()
 
 	now := time.Now().Unix()
@@ -239,7 +230,7 @@ func (db *DB) ReadNodes(expiration int64) (nodes []*vnode.Node) {
 		key := itr.Key()
 		id, err := vnode.Bytes2NodeID(key[prefixLen:])
 		if err != nil {
-			_ = db.Delete(key, nil)
+			_ = db.Delete(key)
 			continue
 		}
 
@@ -263,7 +254,7 @@ func (db *DB) ReadNodes(expiration int64) (nodes []*vnode.Node) {
 }
 
 func (db *DB) RetrieveInt64(key []byte) int64 {
-	buf, err := db.Get(key, nil)
+	buf, err := db.Get(key)
 	if err != nil {
 		return 0
 	}
@@ -275,12 +266,12 @@ func (db *DB) StoreInt64(key []byte, n int64) {
 	buf := make([]byte, binary.MaxVarintLen64)
 	buf = buf[:binary.PutVarint(buf, n)]
 
-	_ = db.Put(key, buf, nil)
+	_ = db.Put(key, buf)
 }
 
 // Clean nodes if time.Now().Unix() - node.activeAt > expiration
 func (db *DB) Clean(expiration int64) {
-	itr := db.NewIterator(util.BytesPrefix(nodeActivePrefix), nil)
+	itr := db.NewIterator(util.BytesPrefix(nodeActivePrefix))
 	defer itr.Release()
 
 	now := time.Now().Unix()
@@ -290,7 +281,7 @@ func (db *DB) Clean(expiration int64) {
 		key := itr.Key()
 		id, err := vnode.Bytes2NodeID(key[prefixLen:])
 		if err != nil {
-			_ = db.Delete(key, nil)
+			_ = db.Delete(key)
 			continue
 		}
 
@@ -322,7 +313,7 @@ func (ms marks) Swap(i, j int) {
 }
 
 func (db *DB) ReadMarkNodes(n int) (nodes []*vnode.Node) {
-	itr := db.NewIterator(util.BytesPrefix(nodeMarkPrefix), nil)
+	itr := db.NewIterator(util.BytesPrefix(nodeMarkPrefix))
 	defer itr.Release()
 
 	var ms marks
@@ -334,13 +325,13 @@ func (db *DB) ReadMarkNodes(n int) (nodes []*vnode.Node) {
 		key := itr.Key()
 		id, err := vnode.Bytes2NodeID(key[prefixLen:])
 		if err != nil {
-			_ = db.Delete(key, nil)
+			_ = db.Delete(key)
 			continue
 		}
 
 		data := itr.Value()
 		if len(data) < 16 {
-			_ = db.Delete(key, nil)
+			_ = db.Delete(key)
 			continue
 		}
 
@@ -349,7 +340,7 @@ func (db *DB) ReadMarkNodes(n int) (nodes []*vnode.Node) {
 
 		// 7d
 		if now-markAt > 24*3600*7 {
-			_ = db.Delete(key, nil)
+			_ = db.Delete(key)
 			continue
 		}
 
@@ -378,7 +369,7 @@ func (db *DB) ReadMarkNodes(n int) (nodes []*vnode.Node) {
 }
 
 func (db *DB) Iterate(prefix []byte, fn func(key, value []byte) bool) {
-	itr := db.NewIterator(util.BytesPrefix(nodeMarkPrefix), nil)
+	itr := db.NewIterator(util.BytesPrefix(nodeMarkPrefix))
 	defer itr.Release()
 
 	for itr.Next() {
@@ -390,24 +381,24 @@ func (db *DB) Iterate(prefix []byte, fn func(key, value []byte) bool) {
 
 func (db *DB) Register(prefix []byte) *prefixDB {
 	return &prefixDB{
-		db:     db.DB,
+		db:     db.Engine,
 		prefix: prefix,
 	}
 }
 
 type prefixDB struct {
-	db     *leveldb.DB
+	db     engine.Engine
 	prefix []byte
 }
 
 func (pdb *prefixDB) Store(key, value []byte) error {
 	key = append(pdb.prefix, key...)
-	return pdb.db.Put(key, value, nil)
+	return pdb.db.Put(key, value)
 }
 
 func (pdb *prefixDB) Retrieve(key []byte) []byte {
 	key = append(pdb.prefix, key...)
-	value, err := pdb.db.Get(key, nil)
+	value, err := pdb.db.Get(key)
 	if err != nil {
 		return nil
 	}
@@ -416,5 +407,5 @@ func (pdb *prefixDB) Retrieve(key []byte) []byte {
 
 func (pdb *prefixDB) Remove(key []byte) {
 	key = append(pdb.prefix, key...)
-	_ = pdb.db.Delete(key, nil)
+	_ = pdb.db.Delete(key)
 }
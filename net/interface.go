@@ -46,12 +46,18 @@ type syncChain interface {
 	GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error)
 }
 
+type stateSyncChain interface {
+	StateSnapshotDir() string
+	ImportStateSnapshot(filename string, meta *interfaces.StateSnapshotMeta) error
+}
+
 type Chain interface {
 	snapshotBlockReader
 	accountBockReader
 	chainReader
 	ledgerReader
 	syncCacher
+	stateSyncChain
 }
 
 type IrreversibleReader interface {
@@ -45,6 +45,11 @@ const (
 	CodeNewSnapshotBlock  Code = 31
 	CodeNewAccountBlock   Code = 32
 
+	CodeGetStateSnapshotMeta Code = 33
+	CodeStateSnapshotMeta    Code = 34
+	CodeGetStateSnapshotData Code = 35
+	CodeStateSnapshotData    Code = 36
+
 	CodeSyncHandshake   Code = 60
 	CodeSyncHandshakeOK Code = 61
 	CodeSyncRequest     Code = 62
@@ -63,3 +63,11 @@ func (mc mockChain) GetLedgerReaderByHeight(startHeight uint64, endHeight uint64
 func (mc mockChain) GetSyncCache() interfaces.SyncCache {
 	panic("implement me")
 }
+
+func (mc mockChain) StateSnapshotDir() string {
+	panic("implement me")
+}
+
+func (mc mockChain) ImportStateSnapshot(filename string, meta *interfaces.StateSnapshotMeta) error {
+	panic("implement me")
+}
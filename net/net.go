@@ -61,8 +61,9 @@ type net struct {
 	*syncer  // use pointer but not interface, because syncer can be start/stop, but interface has no start/stop method
 	*fetcher // use pointer but not interface, because fetcher can be start/stop, but interface has no start/stop method
 	*broadcaster
-	reader     *cacheReader
-	downloader syncDownloader
+	stateSyncer *stateSyncer
+	reader      *cacheReader
+	downloader  syncDownloader
 	BlockSubscriber
 	handlers *msgHandlers
 	query    *queryHandler
@@ -431,6 +432,7 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		reader:          reader,
 		fetcher:         fetcher,
 		broadcaster:     broadcaster,
+		stateSyncer:     newStateSyncer(chain, peers, irreader, cfg.StateSync),
 		downloader:      downloader,
 		syncServer:      newSyncServer(cfg.ListenInterface+":"+strconv.Itoa(cfg.FilePort), chain, syncConnFac),
 		handlers:        newHandlers("vite"),
@@ -541,6 +543,11 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		panic(fmt.Errorf("cannot register handler: syncer: %v", err))
 	}
 
+	// CodeGetStateSnapshotMeta, CodeStateSnapshotMeta, CodeGetStateSnapshotData, CodeStateSnapshotData
+	if err = n.handlers.register(n.stateSyncer); err != nil {
+		panic(fmt.Errorf("cannot register handler: stateSyncer: %v", err))
+	}
+
 	return n, nil
 }
 
@@ -689,7 +696,15 @@ func (n *net) Start() (err error) {
 
 		n.fetcher.start()
 
-		go n.syncer.checkLoop(&n.running)
+		if n.stateSyncer.shouldSync() {
+			// sync blocks after the state snapshot imported
+			go func() {
+				n.stateSyncer.run(&n.running)
+				n.syncer.checkLoop(&n.running)
+			}()
+		} else {
+			go n.syncer.checkLoop(&n.running)
+		}
 
 		n.wg.Add(1)
 		go n.beatLoop()
@@ -712,6 +727,8 @@ func (n *net) Stop() error {
 
 		n.syncer.stop()
 
+		n.stateSyncer.stop()
+
 		n.downloader.stop()
 
 		_ = n.syncServer.stop()
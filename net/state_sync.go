@@ -0,0 +1,527 @@
+package net
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	"github.com/vitelabs/go-vite/v2/ledger/chain/state_sync"
+	"github.com/vitelabs/go-vite/v2/log15"
+)
+
+const stateSnapshotMetaLength = 88
+const stateSnapshotChunkSize = 1 << 20
+const stateSyncQuorum = 2
+const stateSyncRequestTimeout = 10 * time.Second
+const stateSyncRetryInterval = 30 * time.Second
+const stateSyncMaxRetry = 5
+const stateSyncChunkRetry = 3
+
+var errStateSyncStopped = errors.New("state sync stopped")
+var errNoStateSnapshot = errors.New("no state snapshot agreed by enough peers")
+
+type getStateSnapshotMeta struct{}
+
+func (g *getStateSnapshotMeta) Serialize() ([]byte, error) {
+	return nil, nil
+}
+
+// stateSnapshotMeta is the latest irreversible state snapshot of a peer, Meta is nil if the peer has no snapshot
+type stateSnapshotMeta struct {
+	Meta *interfaces.StateSnapshotMeta
+}
+
+func (s *stateSnapshotMeta) Serialize() ([]byte, error) {
+	if s.Meta == nil {
+		return nil, nil
+	}
+
+	buf := make([]byte, stateSnapshotMetaLength)
+	binary.BigEndian.PutUint64(buf[:8], s.Meta.Height)
+	copy(buf[8:40], s.Meta.Hash.Bytes())
+	copy(buf[40:72], s.Meta.Root.Bytes())
+	binary.BigEndian.PutUint64(buf[72:80], uint64(s.Meta.Size))
+	binary.BigEndian.PutUint64(buf[80:88], s.Meta.Items)
+
+	return buf, nil
+}
+
+func (s *stateSnapshotMeta) deserialize(data []byte) (err error) {
+	if len(data) == 0 {
+		s.Meta = nil
+		return nil
+	}
+
+	if len(data) != stateSnapshotMetaLength {
+		return errDeserialize
+	}
+
+	meta := &interfaces.StateSnapshotMeta{}
+	meta.Height = binary.BigEndian.Uint64(data[:8])
+	if meta.Hash, err = types.BytesToHash(data[8:40]); err != nil {
+		return
+	}
+	if meta.Root, err = types.BytesToHash(data[40:72]); err != nil {
+		return
+	}
+	meta.Size = int64(binary.BigEndian.Uint64(data[72:80]))
+	meta.Items = binary.BigEndian.Uint64(data[80:88])
+
+	s.Meta = meta
+	return nil
+}
+
+type getStateSnapshotData struct {
+	Height uint64
+	Offset uint64
+	Length uint32
+}
+
+func (g *getStateSnapshotData) Serialize() ([]byte, error) {
+	buf := make([]byte, 20)
+	binary.BigEndian.PutUint64(buf[:8], g.Height)
+	binary.BigEndian.PutUint64(buf[8:16], g.Offset)
+	binary.BigEndian.PutUint32(buf[16:20], g.Length)
+
+	return buf, nil
+}
+
+func (g *getStateSnapshotData) deserialize(data []byte) error {
+	if len(data) != 20 {
+		return errDeserialize
+	}
+
+	g.Height = binary.BigEndian.Uint64(data[:8])
+	g.Offset = binary.BigEndian.Uint64(data[8:16])
+	g.Length = binary.BigEndian.Uint32(data[16:20])
+
+	return nil
+}
+
+type stateSnapshotData struct {
+	Height uint64
+	Offset uint64
+	Data   []byte
+}
+
+func (s *stateSnapshotData) Serialize() ([]byte, error) {
+	buf := make([]byte, 16+len(s.Data))
+	binary.BigEndian.PutUint64(buf[:8], s.Height)
+	binary.BigEndian.PutUint64(buf[8:16], s.Offset)
+	copy(buf[16:], s.Data)
+
+	return buf, nil
+}
+
+func (s *stateSnapshotData) deserialize(data []byte) error {
+	if len(data) < 16 {
+		return errDeserialize
+	}
+
+	s.Height = binary.BigEndian.Uint64(data[:8])
+	s.Offset = binary.BigEndian.Uint64(data[8:16])
+	s.Data = data[16:]
+
+	return nil
+}
+
+// selectStateSnapshot choose the highest snapshot which is agreed by at least quorum peers,
+// and by the majority of peers reported snapshots at the same height.
+func selectStateSnapshot(metas map[peerId]*interfaces.StateSnapshotMeta, quorum int) (*interfaces.StateSnapshotMeta, []peerId) {
+	type candidate struct {
+		meta  *interfaces.StateSnapshotMeta
+		peers []peerId
+	}
+
+	candidates := make(map[interfaces.StateSnapshotMeta]*candidate)
+	heights := make(map[uint64]int)
+	for id, meta := range metas {
+		if meta == nil {
+			continue
+		}
+		heights[meta.Height]++
+
+		c, ok := candidates[*meta]
+		if !ok {
+			c = &candidate{meta: meta}
+			candidates[*meta] = c
+		}
+		c.peers = append(c.peers, id)
+	}
+
+	var best *candidate
+	for _, c := range candidates {
+		if len(c.peers) < quorum || len(c.peers)*2 <= heights[c.meta.Height] {
+			continue
+		}
+		if best == nil || c.meta.Height > best.meta.Height {
+			best = c
+		}
+	}
+
+	if best == nil {
+		return nil, nil
+	}
+
+	sort.Slice(best.peers, func(i, j int) bool {
+		return best.peers[i].String() < best.peers[j].String()
+	})
+
+	return best.meta, best.peers
+}
+
+// stateSyncer serve state snapshots to peers, and download a state snapshot agreed by several peers
+// when the ledger is empty, so the node need not execute all the historical blocks.
+type stateSyncer struct {
+	chain    Chain
+	peers    *peerSet
+	irreader IrreversibleReader
+	enabled  bool
+
+	gid
+
+	mu      sync.Mutex
+	pending map[MsgId]chan Msg
+
+	term chan struct{}
+	log  log15.Logger
+}
+
+func newStateSyncer(chain Chain, peers *peerSet, irreader IrreversibleReader, enabled bool) *stateSyncer {
+	return &stateSyncer{
+		chain:    chain,
+		peers:    peers,
+		irreader: irreader,
+		enabled:  enabled,
+		pending:  make(map[MsgId]chan Msg),
+		term:     make(chan struct{}),
+		log:      netLog.New("module", "stateSync"),
+	}
+}
+
+func (s *stateSyncer) name() string {
+	return "stateSync"
+}
+
+func (s *stateSyncer) codes() []Code {
+	return []Code{CodeGetStateSnapshotMeta, CodeStateSnapshotMeta, CodeGetStateSnapshotData, CodeStateSnapshotData}
+}
+
+func (s *stateSyncer) handle(msg Msg) (err error) {
+	switch msg.Code {
+	case CodeGetStateSnapshotMeta:
+		return msg.Sender.send(CodeStateSnapshotMeta, msg.Id, &stateSnapshotMeta{
+			Meta: s.latestMeta(),
+		})
+
+	case CodeGetStateSnapshotData:
+		var req = &getStateSnapshotData{}
+		if err = req.deserialize(msg.Payload); err != nil {
+			return
+		}
+
+		// respond empty data if the snapshot is missing
+		data, rerr := s.readChunk(req)
+		if rerr != nil {
+			s.log.Warn(fmt.Sprintf("failed to read state snapshot %d at %d for %s: %v", req.Height, req.Offset, msg.Sender, rerr))
+		}
+
+		return msg.Sender.send(CodeStateSnapshotData, msg.Id, &stateSnapshotData{
+			Height: req.Height,
+			Offset: req.Offset,
+			Data:   data,
+		})
+
+	default:
+		s.mu.Lock()
+		ch, ok := s.pending[msg.Id]
+		s.mu.Unlock()
+
+		if ok {
+			select {
+			case ch <- msg:
+			default:
+			}
+		}
+	}
+
+	return nil
+}
+
+// latestMeta return the latest state snapshot which is irreversible and on our chain
+func (s *stateSyncer) latestMeta() *interfaces.StateSnapshotMeta {
+	irreversible := s.irreader.GetIrreversibleBlock()
+	if irreversible == nil {
+		return nil
+	}
+
+	metas, err := state_sync.List(s.chain.StateSnapshotDir())
+	if err != nil {
+		s.log.Warn(fmt.Sprintf("failed to list state snapshots: %v", err))
+		return nil
+	}
+
+	for i := len(metas) - 1; i >= 0; i-- {
+		meta := metas[i]
+		if meta.Height > irreversible.Height {
+			continue
+		}
+
+		sb, err := s.chain.GetSnapshotBlockByHeight(meta.Height)
+		if err != nil || sb == nil || sb.Hash != meta.Hash {
+			continue
+		}
+
+		return meta
+	}
+
+	return nil
+}
+
+func (s *stateSyncer) readChunk(req *getStateSnapshotData) ([]byte, error) {
+	length := req.Length
+	if length > stateSnapshotChunkSize {
+		length = stateSnapshotChunkSize
+	}
+
+	file, err := os.Open(state_sync.FileName(s.chain.StateSnapshotDir(), req.Height))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data := make([]byte, length)
+	n, err := file.ReadAt(data, int64(req.Offset))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return data[:n], nil
+}
+
+func (s *stateSyncer) request(p *Peer, code Code, data Serializable) (msg Msg, err error) {
+	id := s.MsgID()
+	ch := make(chan Msg, 1)
+
+	s.mu.Lock()
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+	}()
+
+	if err = p.send(code, id, data); err != nil {
+		return
+	}
+
+	timer := time.NewTimer(stateSyncRequestTimeout)
+	defer timer.Stop()
+
+	select {
+	case msg = <-ch:
+		return msg, nil
+	case <-timer.C:
+		return msg, errFetchTimeout
+	case <-s.term:
+		return msg, errStateSyncStopped
+	}
+}
+
+// shouldSync return true if state sync is enabled and the ledger is empty
+func (s *stateSyncer) shouldSync() bool {
+	return s.enabled && s.chain.GetLatestSnapshotBlock().Height == types.GenesisHeight
+}
+
+// run download and import a state snapshot, it will give up after several failures,
+// then the node will sync all the blocks as usual.
+func (s *stateSyncer) run(run *int32) {
+	for i := 0; i < stateSyncMaxRetry; i++ {
+		if atomic.LoadInt32(run) == 0 || !s.shouldSync() {
+			return
+		}
+
+		err := s.sync()
+		if err == nil || err == errStateSyncStopped {
+			return
+		}
+
+		s.log.Warn(fmt.Sprintf("failed to sync state snapshot: %v", err))
+
+		select {
+		case <-time.After(stateSyncRetryInterval):
+		case <-s.term:
+			return
+		}
+	}
+
+	s.log.Warn("give up syncing state snapshot, sync all the blocks")
+}
+
+func (s *stateSyncer) stop() {
+	select {
+	case <-s.term:
+	default:
+		close(s.term)
+	}
+}
+
+func (s *stateSyncer) waitEnoughPeers() error {
+	ticker := time.NewTicker(waitEnoughPeers)
+	defer ticker.Stop()
+
+	for s.peers.count() < enoughPeers {
+		select {
+		case <-ticker.C:
+		case <-s.term:
+			return errStateSyncStopped
+		}
+	}
+
+	return nil
+}
+
+func (s *stateSyncer) queryMetas() map[peerId]*interfaces.StateSnapshotMeta {
+	ps := s.peers.peers()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	metas := make(map[peerId]*interfaces.StateSnapshotMeta, len(ps))
+
+	for _, p := range ps {
+		wg.Add(1)
+		go func(p *Peer) {
+			defer wg.Done()
+
+			msg, err := s.request(p, CodeGetStateSnapshotMeta, &getStateSnapshotMeta{})
+			if err != nil || msg.Code != CodeStateSnapshotMeta {
+				return
+			}
+
+			var res = &stateSnapshotMeta{}
+			if err = res.deserialize(msg.Payload); err != nil {
+				s.log.Warn(fmt.Sprintf("failed to deserialize state snapshot meta from %s: %v", p, err))
+				return
+			}
+
+			mu.Lock()
+			metas[p.Id] = res.Meta
+			mu.Unlock()
+		}(p)
+	}
+
+	wg.Wait()
+
+	return metas
+}
+
+func (s *stateSyncer) sync() (err error) {
+	if err = s.waitEnoughPeers(); err != nil {
+		return
+	}
+
+	meta, ids := selectStateSnapshot(s.queryMetas(), stateSyncQuorum)
+	if meta == nil {
+		return errNoStateSnapshot
+	}
+
+	s.log.Info(fmt.Sprintf("sync state snapshot %s from %d peers", meta, len(ids)))
+
+	dir := path.Join(s.chain.StateSnapshotDir(), "download")
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return
+	}
+	filename := state_sync.FileName(dir, meta.Height)
+	defer os.Remove(filename)
+
+	if err = s.download(filename, meta, ids); err != nil {
+		return
+	}
+
+	if err = s.chain.ImportStateSnapshot(filename, meta); err != nil {
+		return
+	}
+
+	s.log.Info(fmt.Sprintf("import state snapshot %s", meta))
+	return nil
+}
+
+// download fetch chunks from the peers agreed the snapshot in turn
+func (s *stateSyncer) download(filename string, meta *interfaces.StateSnapshotMeta, ids []peerId) (err error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	var index int
+	var offset uint64
+	for offset < uint64(meta.Size) {
+		var data []byte
+		for i := 0; i < stateSyncChunkRetry*len(ids); i++ {
+			p := s.peers.get(ids[index%len(ids)])
+			index++
+			if p == nil {
+				continue
+			}
+
+			data, err = s.downloadChunk(p, meta.Height, offset)
+			if err == nil {
+				break
+			}
+			if err == errStateSyncStopped {
+				return
+			}
+
+			s.log.Warn(fmt.Sprintf("failed to download state snapshot %d at %d from %s: %v", meta.Height, offset, p, err))
+		}
+
+		if len(data) == 0 {
+			return fmt.Errorf("failed to download state snapshot %d at %d", meta.Height, offset)
+		}
+
+		if _, err = file.Write(data); err != nil {
+			return
+		}
+		offset += uint64(len(data))
+	}
+
+	return file.Sync()
+}
+
+func (s *stateSyncer) downloadChunk(p *Peer, height, offset uint64) ([]byte, error) {
+	msg, err := s.request(p, CodeGetStateSnapshotData, &getStateSnapshotData{
+		Height: height,
+		Offset: offset,
+		Length: stateSnapshotChunkSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Code != CodeStateSnapshotData {
+		return nil, errNoResource
+	}
+
+	var res = &stateSnapshotData{}
+	if err = res.deserialize(msg.Payload); err != nil {
+		return nil, err
+	}
+
+	if res.Height != height || res.Offset != offset || len(res.Data) == 0 {
+		return nil, errNoResource
+	}
+
+	return res.Data, nil
+}
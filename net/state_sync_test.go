@@ -0,0 +1,135 @@
+package net
+
+import (
+	crand "crypto/rand"
+	mrand "math/rand"
+	"testing"
+
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	"github.com/vitelabs/go-vite/v2/net/vnode"
+)
+
+func mockStateSnapshotMeta(height uint64) *interfaces.StateSnapshotMeta {
+	meta := &interfaces.StateSnapshotMeta{
+		Height: height,
+		Size:   mrand.Int63(),
+		Items:  mrand.Uint64(),
+	}
+	_, _ = crand.Read(meta.Hash[:])
+	_, _ = crand.Read(meta.Root[:])
+
+	return meta
+}
+
+func TestStateSnapshotMeta_Serialize(t *testing.T) {
+	var s = &stateSnapshotMeta{
+		Meta: mockStateSnapshotMeta(mrand.Uint64()),
+	}
+
+	buf, err := s.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var s2 = &stateSnapshotMeta{}
+	if err = s2.deserialize(buf); err != nil {
+		t.Fatal(err)
+	}
+	if *s.Meta != *s2.Meta {
+		t.Errorf("different meta: %s %s", s.Meta, s2.Meta)
+	}
+
+	// no snapshot
+	buf, err = (&stateSnapshotMeta{}).Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s2.deserialize(buf); err != nil {
+		t.Fatal(err)
+	}
+	if s2.Meta != nil {
+		t.Errorf("meta should be nil")
+	}
+
+	if err = s2.deserialize([]byte{1, 2, 3}); err == nil {
+		t.Errorf("should be error")
+	}
+}
+
+func TestStateSnapshotData_Serialize(t *testing.T) {
+	var g = &getStateSnapshotData{
+		Height: mrand.Uint64(),
+		Offset: mrand.Uint64(),
+		Length: mrand.Uint32(),
+	}
+	buf, err := g.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var g2 = &getStateSnapshotData{}
+	if err = g2.deserialize(buf); err != nil {
+		t.Fatal(err)
+	}
+	if *g != *g2 {
+		t.Errorf("different request: %v %v", g, g2)
+	}
+
+	var d = &stateSnapshotData{
+		Height: g.Height,
+		Offset: g.Offset,
+		Data:   make([]byte, 100),
+	}
+	_, _ = crand.Read(d.Data)
+	buf, err = d.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d2 = &stateSnapshotData{}
+	if err = d2.deserialize(buf); err != nil {
+		t.Fatal(err)
+	}
+	if d.Height != d2.Height || d.Offset != d2.Offset || string(d.Data) != string(d2.Data) {
+		t.Errorf("different data")
+	}
+}
+
+func TestSelectStateSnapshot(t *testing.T) {
+	low := mockStateSnapshotMeta(100)
+	high := mockStateSnapshotMeta(200)
+	fake := mockStateSnapshotMeta(200)
+
+	metas := map[peerId]*interfaces.StateSnapshotMeta{
+		vnode.RandomNodeID(): low,
+		vnode.RandomNodeID(): low,
+		vnode.RandomNodeID(): high,
+		vnode.RandomNodeID(): nil,
+	}
+
+	// only one peer has the high snapshot
+	meta, ids := selectStateSnapshot(metas, stateSyncQuorum)
+	if meta != low || len(ids) != 2 {
+		t.Errorf("should select the low snapshot")
+	}
+
+	high2 := *high
+	metas[vnode.RandomNodeID()] = &high2
+	meta, ids = selectStateSnapshot(metas, stateSyncQuorum)
+	if meta == nil || *meta != *high || len(ids) != 2 {
+		t.Errorf("should select the high snapshot")
+	}
+
+	// conflict snapshots at the same height
+	metas[vnode.RandomNodeID()] = fake
+	metas[vnode.RandomNodeID()] = fake
+	meta, _ = selectStateSnapshot(metas, stateSyncQuorum)
+	if meta != low {
+		t.Errorf("should select the low snapshot")
+	}
+
+	meta, ids = selectStateSnapshot(map[peerId]*interfaces.StateSnapshotMeta{
+		vnode.RandomNodeID(): low,
+	}, stateSyncQuorum)
+	if meta != nil || ids != nil {
+		t.Errorf("should select nothing")
+	}
+}
@@ -31,6 +31,14 @@ type Config struct {
 	VmLogWhiteList []types.Address `json:"vmLogWhiteList"` // contract address white list which save VM logs
 	VmLogAll       *bool           `json:"vmLogAll"`       // save all VM logs, it will cost more disk space
 
+	StateSnapshotInterval uint64 `json:"StateSnapshotInterval"` // export a state snapshot for peers every interval snapshot blocks, 0 means never
+
+	Prune       bool   `json:"Prune"`       // delete the old block files, only headers and the state are kept for old snapshot blocks
+	PruneRetain uint64 `json:"PruneRetain"` // keep full blocks for the latest PruneRetain snapshot blocks
+
+	DBEngine       string `json:"DBEngine"`       // the db engine of a new state and index db, "leveldb" or "bolt", default is "leveldb"
+	PluginDBEngine string `json:"PluginDBEngine"` // the db engine of a new plugins db, "leveldb", "btree" or "bolt", default is "leveldb"
+
 	// genesis
 	GenesisFile string `json:"GenesisFile"`
 
@@ -59,12 +67,20 @@ type Config struct {
 	WhiteBlockList     []string // from high to low, like: "xxxxxx-10001"
 	ForwardStrategy    string
 
+	// state sync
+	StateSync bool `json:"StateSync"`
+
 	//producer
 	EntropyStorePath     string `json:"EntropyStorePath"`
 	EntropyStorePassword string `json:"EntropyStorePassword"`
 	CoinBase             string `json:"CoinBase"`
 	MinerEnabled         bool   `json:"Miner"`
 
+	// producer failover
+	ProducerLeaseFile  string `json:"ProducerLeaseFile"`
+	ProducerLeaseOwner string `json:"ProducerLeaseOwner"`
+	ProducerLeaseTTL   uint64 `json:"ProducerLeaseTTL"`
+
 	//rpc
 	RPCEnabled  bool  `json:"RPCEnabled"`
 	IPCEnabled  bool  `json:"IPCEnabled"`
@@ -102,6 +118,21 @@ type Config struct {
 	// subscribe
 	SubscribeEnabled bool `json:"SubscribeEnabled"`
 
+	// exporter
+	ExporterEnabled         bool     `json:"ExporterEnabled"`
+	ExporterStartHeight     uint64   `json:"ExporterStartHeight"`
+	ExporterFileEnabled     bool     `json:"ExporterFileEnabled"`
+	ExporterFileFormat      string   `json:"ExporterFileFormat"`
+	ExporterFileMaxSize     int64    `json:"ExporterFileMaxSize"`
+	ExporterWebhookURLs     []string `json:"ExporterWebhookURLs"`
+	ExporterWebhookSecret   string   `json:"ExporterWebhookSecret"`
+	ExporterWebhookTimeout  uint64   `json:"ExporterWebhookTimeout"`
+	ExporterWebhookMaxRetry int      `json:"ExporterWebhookMaxRetry"`
+
+	// sbp stat
+	SBPStatEnabled   bool   `json:"SBPStatEnabled"`
+	SBPStatRetention uint64 `json:"SBPStatRetention"`
+
 	// dashboard
 	DashboardTargetURL string
 
@@ -132,6 +163,8 @@ func (c *Config) MakeViteConfig() *config.Config {
 		Subscribe:  c.MakeSubscribeConfig(),
 		NodeReward: c.MakeRewardConfig(),
 		Genesis:    config.MakeGenesisConfig(c.GenesisFile),
+		Exporter:   c.MakeExporterConfig(),
+		SBPStat:    c.MakeSBPStatConfig(),
 		LogLevel:   c.LogLevel,
 	}
 }
@@ -165,6 +198,8 @@ func (c *Config) MakeNetConfig() *config.Net {
 		BlackBlockHashList: c.BlackBlockHashList,
 		WhiteBlockList:     c.WhiteBlockList,
 		MineKey:            nil,
+
+		StateSync: c.StateSync,
 	}
 }
 
@@ -189,12 +224,38 @@ func (c *Config) MakeSubscribeConfig() *config.Subscribe {
 		IsSubscribe: c.SubscribeEnabled,
 	}
 }
+
+func (c *Config) MakeExporterConfig() *config.Exporter {
+	return &config.Exporter{
+		Enabled:         c.ExporterEnabled,
+		Dir:             filepath.Join(c.DataDir, config.DefaultExporterDirName),
+		StartHeight:     c.ExporterStartHeight,
+		FileEnabled:     c.ExporterFileEnabled,
+		FileFormat:      c.ExporterFileFormat,
+		FileMaxSize:     c.ExporterFileMaxSize,
+		WebhookURLs:     c.ExporterWebhookURLs,
+		WebhookSecret:   c.ExporterWebhookSecret,
+		WebhookTimeout:  c.ExporterWebhookTimeout,
+		WebhookMaxRetry: c.ExporterWebhookMaxRetry,
+	}
+}
+
+func (c *Config) MakeSBPStatConfig() *config.SBPStat {
+	return &config.SBPStat{
+		Enabled:   c.SBPStatEnabled,
+		Retention: c.SBPStatRetention,
+	}
+}
+
 func (c *Config) MakeMinerConfig() *config.Producer {
 	cfg := &config.Producer{
 		Producer:                c.MinerEnabled,
 		Coinbase:                c.CoinBase,
 		EntropyStorePath:        c.EntropyStorePath,
 		VirtualSnapshotVerifier: false,
+		LeaseFile:               c.ProducerLeaseFile,
+		LeaseOwner:              c.ProducerLeaseOwner,
+		LeaseTTL:                c.ProducerLeaseTTL,
 	}
 	err := cfg.Parse()
 	if err != nil {
@@ -227,6 +288,14 @@ func (c *Config) MakeChainConfig() *config.Chain {
 		OpenPlugins:    openPlugins,
 		VmLogWhiteList: c.VmLogWhiteList,
 		VmLogAll:       vmLogAll,
+
+		StateSnapshotInterval: c.StateSnapshotInterval,
+
+		Prune:       c.Prune,
+		PruneRetain: c.PruneRetain,
+
+		DBEngine:       c.DBEngine,
+		PluginDBEngine: c.PluginDBEngine,
 	}
 }
 
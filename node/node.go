@@ -15,6 +15,7 @@ import (
 	"github.com/vitelabs/go-vite/v2"
 	"github.com/vitelabs/go-vite/v2/cmd/utils/flock"
 	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/ledger/chain"
 	"github.com/vitelabs/go-vite/v2/log15"
 	"github.com/vitelabs/go-vite/v2/monitor"
 	nodeconfig "github.com/vitelabs/go-vite/v2/node/config"
@@ -93,6 +94,13 @@ func (node *Node) Prepare() (err error) {
 	}
 	log.Info(fmt.Sprintf("DataDir is OK. "))
 
+	if node.viteConfig.Chain != nil {
+		if err = chain.CheckDBEngines(node.viteConfig.DataDir, node.viteConfig.Chain); err != nil {
+			log.Error(fmt.Sprintf("Check db engines error: %v", err))
+			return err
+		}
+	}
+
 	//prepare node
 	log.Info(fmt.Sprintf("Begin Prepare node... "))
 	//prepare wallet
@@ -1,6 +1,8 @@
 package producer
 
 import (
+	"time"
+
 	"github.com/vitelabs/go-vite/v2/common/types"
 	"github.com/vitelabs/go-vite/v2/producer/producerevent"
 )
@@ -12,4 +14,8 @@ type Producer interface {
 	Stop() error
 	GetCoinBase() types.Address
 	SnapshotOnce() error
+
+	// virtual clock of SnapshotOnce
+	Now() time.Time
+	SetTime(t time.Time)
 }
@@ -0,0 +1,265 @@
+package producer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLeaseTTL is the seconds of a producer lease, the standby takes over after it expired
+	DefaultLeaseTTL = 10
+
+	leaseLockRetry    = 50
+	leaseLockInterval = 10 * time.Millisecond
+)
+
+var (
+	errSlotSigned    = errors.New("the slot has been signed")
+	errLeaseNotHeld  = errors.New("the producer lease is not held")
+	errLeaseLockBusy = errors.New("the producer lease lock is busy")
+)
+
+// slotMark is the persisted high-water mark of the signed snapshot slots,
+// the producer never signs a snapshot block at or below it, even across restarts.
+type slotMark struct {
+	file string
+	last int64 // unix nano of the latest signed slot
+	mu   sync.Mutex
+}
+
+func newSlotMark(file string) (*slotMark, error) {
+	m := &slotMark{file: file}
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, os.MkdirAll(filepath.Dir(file), 0700)
+		}
+		return nil, err
+	}
+	last, err := strconv.ParseInt(strings.TrimSpace(string(buf)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse slot mark %s failed, %s", file, err.Error())
+	}
+	m.last = last
+	return m, nil
+}
+
+// advance persists the slot before the block is signed, a slot which is lost by a crash is never signed again.
+func (m *slotMark) advance(slot time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	nano := slot.UnixNano()
+	if nano <= m.last {
+		return errSlotSigned
+	}
+	if err := writeFileAtomic(m.file, []byte(strconv.FormatInt(nano, 10))); err != nil {
+		return err
+	}
+	m.last = nano
+	return nil
+}
+
+type leaseRecord struct {
+	Owner  string `json:"owner"`
+	Token  uint64 `json:"token"`  // fencing token, increased when the lease changes hands
+	Expire int64  `json:"expire"` // unix nano
+	Slot   int64  `json:"slot"`   // unix nano of the latest slot signed by any holder
+}
+
+// lease is shared by the active and the standby producers with the same coinbase,
+// only the holder of an unexpired lease signs blocks.
+type lease struct {
+	file  string
+	owner string
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	token uint64
+}
+
+func newLease(file string, owner string, ttl time.Duration) (*lease, error) {
+	if owner == "" {
+		return nil, errors.New("lease owner must not be empty")
+	}
+	if ttl <= 0 {
+		ttl = DefaultLeaseTTL * time.Second
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return nil, err
+	}
+	return &lease{file: file, owner: owner, ttl: ttl}, nil
+}
+
+// acquire renews the lease held by self, or takes over the lease if it's expired.
+// It returns false if the lease is held by another owner.
+func (l *lease) acquire(now time.Time) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	unlock, err := l.lock(now)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	rec, err := l.read()
+	if err != nil {
+		return false, err
+	}
+
+	next := &leaseRecord{Owner: l.owner, Token: 1, Expire: now.Add(l.ttl).UnixNano()}
+	if rec != nil {
+		held := rec.Owner == l.owner && rec.Token == l.token
+		if !held && now.UnixNano() < rec.Expire {
+			l.token = 0
+			return false, nil
+		}
+		next.Slot = rec.Slot
+		next.Token = rec.Token
+		if !held {
+			next.Token++
+		}
+	}
+	if err := l.write(next); err != nil {
+		return false, err
+	}
+	l.token = next.Token
+	return true, nil
+}
+
+// fence checks the lease is held with the same token and records the slot to sign,
+// so a slot signed by the old holder is never signed by the new one.
+func (l *lease) fence(now time.Time, slot time.Time) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	unlock, err := l.lock(now)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	rec, err := l.read()
+	if err != nil {
+		return err
+	}
+	if rec == nil || l.token == 0 || rec.Owner != l.owner || rec.Token != l.token || now.UnixNano() >= rec.Expire {
+		return errLeaseNotHeld
+	}
+	if slot.UnixNano() <= rec.Slot {
+		return errSlotSigned
+	}
+	rec.Slot = slot.UnixNano()
+	return l.write(rec)
+}
+
+// held reports whether the lease is held by self now.
+func (l *lease) held(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	rec, err := l.read()
+	if err != nil || rec == nil {
+		return false
+	}
+	return l.token != 0 && rec.Owner == l.owner && rec.Token == l.token && now.UnixNano() < rec.Expire
+}
+
+// release expires the lease held by self, so the standby takes over without waiting.
+func (l *lease) release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	unlock, err := l.lock(now)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	rec, err := l.read()
+	if err != nil {
+		return err
+	}
+	if rec == nil || rec.Owner != l.owner || rec.Token != l.token {
+		return nil
+	}
+	l.token = 0
+	rec.Expire = now.UnixNano()
+	return l.write(rec)
+}
+
+// lock creates the lock file exclusively, a lock left by a crashed producer is removed after ttl.
+func (l *lease) lock(now time.Time) (func(), error) {
+	lockFile := l.file + ".lock"
+	for i := 0; i < leaseLockRetry; i++ {
+		f, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() {
+				os.Remove(lockFile)
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(lockFile); err == nil && now.Sub(info.ModTime()) > l.ttl {
+			os.Remove(lockFile)
+			continue
+		}
+		time.Sleep(leaseLockInterval)
+	}
+	return nil, errLeaseLockBusy
+}
+
+func (l *lease) read() (*leaseRecord, error) {
+	buf, err := ioutil.ReadFile(l.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	rec := &leaseRecord{}
+	if err := json.Unmarshal(buf, rec); err != nil {
+		return nil, fmt.Errorf("parse lease %s failed, %s", l.file, err.Error())
+	}
+	return rec, nil
+}
+
+func (l *lease) write(rec *leaseRecord) error {
+	buf, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(l.file, buf)
+}
+
+func writeFileAtomic(file string, data []byte) error {
+	tmp := file + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
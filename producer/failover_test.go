@@ -0,0 +1,91 @@
+package producer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlotMark(t *testing.T) {
+	dir, err := ioutil.TempDir("", "slot_mark")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "producer", "coinbase.slot")
+	m, err := newSlotMark(file)
+	assert.NoError(t, err)
+
+	slot := time.Unix(1600000000, 0)
+	assert.NoError(t, m.advance(slot))
+	assert.Equal(t, errSlotSigned, m.advance(slot))
+	assert.Equal(t, errSlotSigned, m.advance(slot.Add(-time.Second)))
+
+	// restart
+	m, err = newSlotMark(file)
+	assert.NoError(t, err)
+	assert.Equal(t, errSlotSigned, m.advance(slot))
+	assert.NoError(t, m.advance(slot.Add(time.Second)))
+}
+
+func TestLeaseFailover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "producer_lease")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "lease")
+	ttl := 10 * time.Second
+	active, err := newLease(file, "active", ttl)
+	assert.NoError(t, err)
+	standby, err := newLease(file, "standby", ttl)
+	assert.NoError(t, err)
+
+	now := time.Unix(1600000000, 0)
+	held, err := active.acquire(now)
+	assert.NoError(t, err)
+	assert.True(t, held)
+	token := active.token
+
+	slot := now.Add(time.Second)
+	assert.NoError(t, active.fence(now, slot))
+	assert.Equal(t, errSlotSigned, active.fence(now, slot))
+
+	// the standby waits until the lease expired
+	held, err = standby.acquire(now.Add(ttl / 2))
+	assert.NoError(t, err)
+	assert.False(t, held)
+	assert.Equal(t, errLeaseNotHeld, standby.fence(now.Add(ttl/2), slot.Add(time.Second)))
+
+	// renew by the active
+	held, err = active.acquire(now.Add(ttl / 2))
+	assert.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, token, active.token)
+
+	// the active is down, the standby takes over with a new fencing token
+	takeover := now.Add(ttl/2 + ttl)
+	held, err = standby.acquire(takeover)
+	assert.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, token+1, standby.token)
+
+	// the old active is fenced
+	assert.Equal(t, errLeaseNotHeld, active.fence(takeover, slot.Add(time.Second)))
+	held, err = active.acquire(takeover)
+	assert.NoError(t, err)
+	assert.False(t, held)
+
+	// the slot signed by the old active is never signed again
+	assert.Equal(t, errSlotSigned, standby.fence(takeover, slot))
+	assert.NoError(t, standby.fence(takeover, slot.Add(time.Second)))
+
+	// release lets the other one take over without waiting
+	assert.NoError(t, standby.release())
+	held, err = active.acquire(time.Now())
+	assert.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, token+2, active.token)
+}
@@ -3,10 +3,14 @@ package producer
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/vitelabs/go-vite/v2/common"
+	"github.com/vitelabs/go-vite/v2/common/config"
 	"github.com/vitelabs/go-vite/v2/common/types"
 	"github.com/vitelabs/go-vite/v2/interfaces"
 	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
@@ -62,6 +66,13 @@ type producer struct {
 	accountFn  func(producerevent.AccountEvent)
 	syncState  net.SyncState
 	netSyncId  int
+
+	lease       *lease
+	leaseStopCh chan struct{}
+	leaseWg     sync.WaitGroup
+
+	// the offset of the virtual clock for SnapshotOnce, in nanoseconds
+	timeOffset int64
 }
 
 // todo syncDone
@@ -78,6 +89,36 @@ func NewProducer(rw chain.Chain,
 	miner.subscriber = subscriber
 	return miner
 }
+
+// SetFailover persists the signed snapshot slots in dataDir, and coordinates with the standby
+// producers through the lease file if it's configured.
+func (self *producer) SetFailover(dataDir string, cfg *config.Producer) error {
+	slots, err := newSlotMark(filepath.Join(dataDir, "producer", self.coinbase.Address().Hex()+".slot"))
+	if err != nil {
+		return err
+	}
+	self.worker.slots = slots
+
+	if cfg == nil || cfg.LeaseFile == "" {
+		return nil
+	}
+	owner := cfg.LeaseOwner
+	if owner == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		owner = hostname + ":" + dataDir
+	}
+	l, err := newLease(cfg.LeaseFile, owner, time.Duration(cfg.LeaseTTL)*time.Second)
+	if err != nil {
+		return err
+	}
+	self.lease = l
+	self.worker.lease = l
+	return nil
+}
+
 func (self *producer) Init() error {
 	if !self.PreInit() {
 		return errors.New("pre init fail")
@@ -127,12 +168,32 @@ func (self *producer) Start() error {
 		self.syncState = state
 	})
 	self.netSyncId = id
+
+	if self.lease != nil {
+		self.leaseStopCh = make(chan struct{})
+		self.leaseWg.Add(1)
+		common.Go(self.renewLease)
+	}
 	wLog.Info("started.")
 	return nil
 }
 
+// Now is the time of the virtual clock, SnapshotOnce produces the snapshot block at it
+func (self *producer) Now() time.Time {
+	return time.Now().Add(time.Duration(atomic.LoadInt64(&self.timeOffset)))
+}
+
+// SetTime moves the virtual clock to t, the clock keeps running from t
+func (self *producer) SetTime(t time.Time) {
+	atomic.StoreInt64(&self.timeOffset, int64(t.Sub(time.Now())))
+}
+
 func (self *producer) SnapshotOnce() error {
-	t := time.Now()
+	t := self.Now()
+	// the timestamp of snapshot block must be greater than the latest one
+	if head := self.tools.chain.GetLatestSnapshotBlock(); !t.After(*head.Timestamp) {
+		t = head.Timestamp.Add(time.Second)
+	}
 	e := &consensus.Event{
 		Gid:         types.SNAPSHOT_GID,
 		Address:     self.coinbase.Address(),
@@ -143,7 +204,7 @@ func (self *producer) SnapshotOnce() error {
 		PeriodStime: t,
 		PeriodEtime: t,
 	}
-	self.worker.genAndInsert(e)
+	self.worker.genAndInsert(e, false)
 	return nil
 }
 
@@ -162,6 +223,14 @@ func (self *producer) Stop() error {
 	self.subscriber.UnsubscribeSyncStatus(self.netSyncId)
 	self.netSyncId = 0
 
+	if self.lease != nil {
+		close(self.leaseStopCh)
+		self.leaseWg.Wait()
+		if err := self.lease.release(); err != nil {
+			mLog.Error("release producer lease fail.", "err", err)
+		}
+	}
+
 	err := self.worker.Stop()
 	if err != nil {
 		return err
@@ -169,9 +238,38 @@ func (self *producer) Stop() error {
 	return nil
 }
 
+// renewLease keeps the lease as the active producer, or takes over the lease as the standby after it expired.
+func (self *producer) renewLease() {
+	defer self.leaseWg.Done()
+	ticker := time.NewTicker(self.lease.ttl / 3)
+	defer ticker.Stop()
+
+	active := false
+	for {
+		held, err := self.lease.acquire(time.Now())
+		if err != nil {
+			mLog.Error("acquire producer lease fail.", "err", err)
+		} else if held != active {
+			active = held
+			mLog.Info("producer lease changed.", "owner", self.lease.owner, "active", active, "token", self.lease.token)
+		}
+
+		select {
+		case <-self.leaseStopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 func (self *producer) producerContract(e consensus.Event) {
 	fn := self.accountFn
 
+	if self.lease != nil && !self.lease.held(time.Now()) {
+		mLog.Info("contract producer skip, the producer is standby.", "addr", e.Address)
+		return
+	}
+
 	if fn != nil {
 		if e.Address != self.coinbase.Address() {
 			mLog.Error("coinbase can't match.", "addr", e.Address.String(), "coinbase", self.coinbase.Address())
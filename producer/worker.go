@@ -28,6 +28,10 @@ type worker struct {
 	wg        sync.WaitGroup
 	seedCache *lru.Cache
 	log       log15.Logger
+
+	// nil if failover is not set
+	slots *slotMark
+	lease *lease
 }
 
 func newWorker(chain *tools, coinbase interfaces.Account) *worker {
@@ -70,11 +74,13 @@ func (w *worker) produceSnapshot(e consensus.Event) {
 	}
 	tmpE := &e
 	common.Go(func() {
-		w.genAndInsert(tmpE)
+		w.genAndInsert(tmpE, true)
 	})
 }
 
-func (w *worker) genAndInsert(e *consensus.Event) {
+// genAndInsert produces the snapshot block of the event, the slot is checked when guard is true.
+// The virtual node produces blocks without guard, since its clock may be reverted.
+func (w *worker) genAndInsert(e *consensus.Event, guard bool) {
 	wLog.Info("genAndInsert start.", "event", e)
 	defer wLog.Info("genAndInsert end.", "event", e)
 	defer monitor.LogTime("producer", "snapshotGenInsert", time.Now())
@@ -87,6 +93,13 @@ func (w *worker) genAndInsert(e *consensus.Event) {
 	// unlock pool
 	defer w.tools.pool.UnLockInsert()
 
+	if guard {
+		if err := w.checkSlot(e.Timestamp); err != nil {
+			wLog.Error("produce snapshot block fail[slot].", "err", err, "timestamp", e.Timestamp)
+			return
+		}
+	}
+
 	seed := w.randomSeed()
 
 	// generate snapshot block
@@ -107,6 +120,19 @@ func (w *worker) genAndInsert(e *consensus.Event) {
 	w.storeSeedHash(seed, b.SeedHash)
 }
 
+// checkSlot records the slot before signing, a slot is never signed twice by the producers sharing the lease.
+func (w *worker) checkSlot(slot time.Time) error {
+	if w.lease != nil {
+		if err := w.lease.fence(time.Now(), slot); err != nil {
+			return err
+		}
+	}
+	if w.slots != nil {
+		return w.slots.advance(slot)
+	}
+	return nil
+}
+
 func (w *worker) randomSeed() uint64 {
 	r := rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
 	return r.Uint64()
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/vm/contracts/abi"
+)
+
+type AccountPolicyTxParams struct {
+	EntropyFile *string       `json:"entropyFile,omitempty"`
+	Address     types.Address `json:"address"`
+	Passphrase  string        `json:"passphrase"`
+	Difficulty  *string       `json:"difficulty,omitempty"`
+}
+
+type SetAccountPolicyParams struct {
+	AccountPolicyTxParams
+	DailyLimits     map[types.TokenTypeId]string `json:"dailyLimits"`
+	Allowlist       []types.Address              `json:"allowlist"`
+	RecoveryAddress types.Address                `json:"recoveryAddress"`
+}
+
+type InitiateRecoveryParams struct {
+	AccountPolicyTxParams
+	Account types.Address `json:"account"`
+}
+
+// SetAccountPolicy sends a transaction to register the daily spending limits, the allowlist of recipients and
+// the recovery address of the account. A looser policy takes effect after the delay of the account policy contract
+func (m WalletApi) SetAccountPolicy(params SetAccountPolicyParams) (*types.Hash, error) {
+	tokenIds := make([]types.TokenTypeId, 0, len(params.DailyLimits))
+	for tokenId := range params.DailyLimits {
+		tokenIds = append(tokenIds, tokenId)
+	}
+	sort.Slice(tokenIds, func(i, j int) bool {
+		return bytes.Compare(tokenIds[i].Bytes(), tokenIds[j].Bytes()) < 0
+	})
+	limits := make([]*big.Int, len(tokenIds))
+	for i, tokenId := range tokenIds {
+		limitStr := params.DailyLimits[tokenId]
+		limit, err := stringToBigInt(&limitStr)
+		if err != nil {
+			return nil, err
+		}
+		limits[i] = limit
+	}
+	allowlist := params.Allowlist
+	if allowlist == nil {
+		allowlist = []types.Address{}
+	}
+	data, err := abi.ABIAccountPolicy.PackMethod(abi.MethodNameSetAccountPolicy, tokenIds, limits, allowlist, params.RecoveryAddress)
+	if err != nil {
+		return nil, err
+	}
+	return m.sendAccountPolicyTx(params.AccountPolicyTxParams, data)
+}
+
+// InitiateRecovery sends a transaction from the recovery address to take over the account after the recovery delay
+func (m WalletApi) InitiateRecovery(params InitiateRecoveryParams) (*types.Hash, error) {
+	data, err := abi.ABIAccountPolicy.PackMethod(abi.MethodNameInitiateRecovery, params.Account)
+	if err != nil {
+		return nil, err
+	}
+	return m.sendAccountPolicyTx(params.AccountPolicyTxParams, data)
+}
+
+// CancelRecovery sends a transaction to cancel the recovery of the account before it takes effect
+func (m WalletApi) CancelRecovery(params AccountPolicyTxParams) (*types.Hash, error) {
+	data, err := abi.ABIAccountPolicy.PackMethod(abi.MethodNameCancelRecovery)
+	if err != nil {
+		return nil, err
+	}
+	return m.sendAccountPolicyTx(params, data)
+}
+
+func (m WalletApi) sendAccountPolicyTx(params AccountPolicyTxParams, data []byte) (*types.Hash, error) {
+	return m.CreateTransaction(CreateTransactionParms{
+		EntropyFile: params.EntropyFile,
+		Address:     params.Address,
+		ToAddress:   types.AddressAccountPolicy,
+		TokenId:     ledger.ViteTokenId,
+		Passphrase:  params.Passphrase,
+		Amount:      "0",
+		Data:        data,
+		Difficulty:  params.Difficulty,
+	})
+}
+
+type AccountPolicy struct {
+	DailyLimits     map[types.TokenTypeId]string `json:"dailyLimits"`
+	Allowlist       []types.Address              `json:"allowlist"`
+	RecoveryAddress *types.Address               `json:"recoveryAddress"`
+	EffectiveHeight string                       `json:"effectiveHeight"`
+}
+
+type AccountPolicyInfo struct {
+	Policy         *AccountPolicy `json:"policy"`
+	PendingPolicy  *AccountPolicy `json:"pendingPolicy"`
+	RecoveryHeight *string        `json:"recoveryHeight"`
+}
+
+func newAccountPolicy(policy *types.AccountPolicy) *AccountPolicy {
+	if policy == nil {
+		return nil
+	}
+	result := &AccountPolicy{
+		DailyLimits:     make(map[types.TokenTypeId]string, len(policy.TokenIds)),
+		Allowlist:       policy.Allowlist,
+		EffectiveHeight: Uint64ToString(policy.EffectiveHeight),
+	}
+	for i, tokenId := range policy.TokenIds {
+		result.DailyLimits[tokenId] = *bigIntToString(policy.DailyLimits[i])
+	}
+	if !policy.RecoveryAddress.IsZero() {
+		result.RecoveryAddress = &policy.RecoveryAddress
+	}
+	return result
+}
+
+// GetAccountPolicy returns the policy in effect, the policy waiting for the delay and the recovery of an account
+func (c *ContractApi) GetAccountPolicy(account types.Address) (*AccountPolicyInfo, error) {
+	db, err := getVmDb(c.chain, types.AddressAccountPolicy)
+	if err != nil {
+		return nil, err
+	}
+	sb, err := db.LatestSnapshotBlock()
+	if err != nil {
+		return nil, err
+	}
+	policy, pending, err := abi.GetSavedAccountPolicy(db, account)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil && pending.EffectiveHeight <= sb.Height {
+		policy, pending = pending, nil
+	}
+	if policy != nil && abi.IsEmptyAccountPolicy(policy) {
+		policy = nil
+	}
+	info := &AccountPolicyInfo{Policy: newAccountPolicy(policy), PendingPolicy: newAccountPolicy(pending)}
+	recoveryHeight, err := abi.GetAccountRecoveryHeight(db, account)
+	if err != nil {
+		return nil, err
+	}
+	if recoveryHeight > 0 {
+		info.RecoveryHeight = new(string)
+		*info.RecoveryHeight = Uint64ToString(recoveryHeight)
+	}
+	return info, nil
+}
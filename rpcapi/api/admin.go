@@ -0,0 +1,265 @@
+package api
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+
+	"github.com/vitelabs/go-vite/v2"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/ledger/chain"
+	"github.com/vitelabs/go-vite/v2/ledger/onroad"
+	"github.com/vitelabs/go-vite/v2/log15"
+)
+
+type AdminApi struct {
+	chain  chain.Chain
+	onroad *onroad.Manager
+	log    log15.Logger
+}
+
+func NewAdminApi(vite *vite.Vite) *AdminApi {
+	return &AdminApi{
+		chain:  vite.Chain(),
+		onroad: vite.OnRoad(),
+		log:    log15.New("module", "rpc_api/admin_api"),
+	}
+}
+
+func (a AdminApi) String() string {
+	return "AdminApi"
+}
+
+type CheckpointFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	Linked bool   `json:"linked"`
+}
+
+type CheckpointStore struct {
+	Name   string `json:"name"`
+	Engine string `json:"engine"`
+	Items  string `json:"items"`
+}
+
+type Checkpoint struct {
+	Dir       string             `json:"dir"`
+	Height    string             `json:"height"`
+	Hash      types.Hash         `json:"hash"`
+	Timestamp int64              `json:"timestamp"`
+	Files     []*CheckpointFile  `json:"files"`
+	Stores    []*CheckpointStore `json:"stores"`
+}
+
+// private: admin_createCheckpoint
+// CreateCheckpoint writes a consistent copy of the ledger to dir without stopping the node,
+// dir must be new or empty and can be used as the DataDir of a node.
+func (a AdminApi) CreateCheckpoint(dir string) (*Checkpoint, error) {
+	manifest, err := a.chain.CreateCheckpoint(dir)
+	if err != nil {
+		a.log.Error("CreateCheckpoint failed", "dir", dir, "err", err)
+		return nil, err
+	}
+	a.log.Info("CreateCheckpoint", "dir", dir, "height", manifest.Height, "hash", manifest.Hash)
+
+	checkpoint := &Checkpoint{
+		Dir:       dir,
+		Height:    strconv.FormatUint(manifest.Height, 10),
+		Hash:      manifest.Hash,
+		Timestamp: manifest.Timestamp,
+		Files:     make([]*CheckpointFile, 0, len(manifest.Files)),
+		Stores:    make([]*CheckpointStore, 0, len(manifest.Stores)),
+	}
+	for _, file := range manifest.Files {
+		checkpoint.Files = append(checkpoint.Files, &CheckpointFile{
+			Name:   file.Name,
+			Size:   file.Size,
+			Linked: file.Linked,
+		})
+	}
+	for _, store := range manifest.Stores {
+		checkpoint.Stores = append(checkpoint.Stores, &CheckpointStore{
+			Name:   store.Name,
+			Engine: store.Engine,
+			Items:  strconv.FormatUint(store.Items, 10),
+		})
+	}
+	return checkpoint, nil
+}
+
+type ContractBacklog struct {
+	Address     types.Address `json:"address"`
+	Count       string        `json:"count"`
+	CallerCount int           `json:"callerCount"`
+	Priority    string        `json:"priority"`
+	Paused      bool          `json:"paused"`
+}
+
+type CallerBacklog struct {
+	Address types.Address `json:"address"`
+	Count   string        `json:"count"`
+	State   string        `json:"state,omitempty"`
+}
+
+type ContractBacklogDetail struct {
+	Address types.Address    `json:"address"`
+	Count   string           `json:"count"`
+	Callers []*CallerBacklog `json:"callers"`
+}
+
+type ContractReceiveStatus struct {
+	Address  types.Address            `json:"address"`
+	Working  bool                     `json:"working"`
+	InQueue  bool                     `json:"inQueue"`
+	Quota    string                   `json:"quota"`
+	Priority string                   `json:"priority"`
+	Paused   bool                     `json:"paused"`
+	State    string                   `json:"state,omitempty"`
+	Callers  map[types.Address]string `json:"restrictedCallers"`
+}
+
+type ContractReceiveSettings struct {
+	Priorities map[types.Address]string `json:"priorities"`
+	Paused     []types.Address          `json:"paused"`
+}
+
+// private: admin_getContractBacklogList
+// GetContractBacklogList returns the contracts which have unreceived blocks in descending order of the count.
+func (a AdminApi) GetContractBacklogList(gid *types.Gid) ([]*ContractBacklog, error) {
+	g := defaultContractGid(gid)
+	contracts, err := a.onroad.GetOnRoadContracts(g)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[types.Address]uint64, len(contracts))
+	list := make([]*ContractBacklog, 0, len(contracts))
+	for _, addr := range contracts {
+		numMap, err := a.onroad.GetOnRoadCallerNumByAddr(g, addr)
+		if err != nil {
+			return nil, err
+		}
+		for _, num := range numMap {
+			counts[addr] += num
+		}
+		if counts[addr] == 0 {
+			continue
+		}
+		status := a.onroad.GetContractReceiveStatus(g, addr)
+		list = append(list, &ContractBacklog{
+			Address:     addr,
+			Count:       strconv.FormatUint(counts[addr], 10),
+			CallerCount: len(numMap),
+			Priority:    strconv.FormatUint(status.Priority, 10),
+			Paused:      status.Paused,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return counts[list[i].Address] > counts[list[j].Address]
+	})
+	return list, nil
+}
+
+// private: admin_getContractBacklog
+// GetContractBacklog returns the unreceived blocks of the contract from each caller in descending order of
+// the count, the state is the restriction of the caller by the contract worker of this node.
+func (a AdminApi) GetContractBacklog(addr types.Address, gid *types.Gid) (*ContractBacklogDetail, error) {
+	if !types.IsContractAddr(addr) {
+		return nil, errors.New("Address must be the type of Contract.")
+	}
+	g := defaultContractGid(gid)
+	numMap, err := a.onroad.GetOnRoadCallerNumByAddr(g, addr)
+	if err != nil {
+		return nil, err
+	}
+	status := a.onroad.GetContractReceiveStatus(g, addr)
+
+	detail := &ContractBacklogDetail{
+		Address: addr,
+		Callers: make([]*CallerBacklog, 0, len(numMap)),
+	}
+	sum := uint64(0)
+	for caller, num := range numMap {
+		sum += num
+		detail.Callers = append(detail.Callers, &CallerBacklog{
+			Address: caller,
+			Count:   strconv.FormatUint(num, 10),
+			State:   status.Callers[caller],
+		})
+	}
+	sort.Slice(detail.Callers, func(i, j int) bool {
+		return numMap[detail.Callers[i].Address] > numMap[detail.Callers[j].Address]
+	})
+	detail.Count = strconv.FormatUint(sum, 10)
+	return detail, nil
+}
+
+// private: admin_getContractReceiveStatus
+// GetContractReceiveStatus returns why the contract worker of this node does not receive the contract,
+// the state is RETRY if the contract is restricted until the next snapshot block, and OUT if it is
+// restricted until the end of the producing period of this node.
+func (a AdminApi) GetContractReceiveStatus(addr types.Address, gid *types.Gid) (*ContractReceiveStatus, error) {
+	if !types.IsContractAddr(addr) {
+		return nil, errors.New("Address must be the type of Contract.")
+	}
+	status := a.onroad.GetContractReceiveStatus(defaultContractGid(gid), addr)
+	return &ContractReceiveStatus{
+		Address:  status.Address,
+		Working:  status.Working,
+		InQueue:  status.InQueue,
+		Quota:    strconv.FormatUint(status.Quota, 10),
+		Priority: strconv.FormatUint(status.Priority, 10),
+		Paused:   status.Paused,
+		State:    status.State,
+		Callers:  status.Callers,
+	}, nil
+}
+
+// private: admin_setContractReceivePriority
+// SetContractReceivePriority pins the priority weight of the contract on this node, the contracts with a
+// higher weight are received before the others regardless of their stake quota, 0 removes the pin.
+// The settings are kept until the node restarts.
+func (a AdminApi) SetContractReceivePriority(addr types.Address, weight uint64) error {
+	if !types.IsContractAddr(addr) {
+		return errors.New("Address must be the type of Contract.")
+	}
+	a.onroad.SetContractReceivePriority(addr, weight)
+	return nil
+}
+
+// private: admin_pauseContractReceive
+// PauseContractReceive stops receiving the contract on this node until it is resumed or the node restarts.
+func (a AdminApi) PauseContractReceive(addr types.Address) error {
+	if !types.IsContractAddr(addr) {
+		return errors.New("Address must be the type of Contract.")
+	}
+	a.onroad.PauseContractReceive(addr)
+	return nil
+}
+
+// private: admin_resumeContractReceive
+func (a AdminApi) ResumeContractReceive(addr types.Address) error {
+	if !types.IsContractAddr(addr) {
+		return errors.New("Address must be the type of Contract.")
+	}
+	a.onroad.ResumeContractReceive(addr)
+	return nil
+}
+
+// private: admin_getContractReceiveSettings
+func (a AdminApi) GetContractReceiveSettings() *ContractReceiveSettings {
+	settings := &ContractReceiveSettings{
+		Priorities: make(map[types.Address]string),
+		Paused:     a.onroad.GetPausedContracts(),
+	}
+	for addr, weight := range a.onroad.GetContractReceivePriorities() {
+		settings.Priorities[addr] = strconv.FormatUint(weight, 10)
+	}
+	return settings
+}
+
+func defaultContractGid(gid *types.Gid) types.Gid {
+	if gid == nil {
+		return types.DELEGATE_GID
+	}
+	return *gid
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"sort"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/vitelabs/go-vite/v2/common/types"
 	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
 	"github.com/vitelabs/go-vite/v2/ledger/chain"
+	chain_plugins "github.com/vitelabs/go-vite/v2/ledger/chain/plugins"
 	"github.com/vitelabs/go-vite/v2/ledger/consensus"
 	"github.com/vitelabs/go-vite/v2/log15"
 	"github.com/vitelabs/go-vite/v2/vm"
@@ -569,6 +571,90 @@ func (v *ContractApi) GetVotedSBP(addr types.Address) (*VotedSBPInfo, error) {
 	return nil, nil
 }
 
+type GovernanceParamProposal struct {
+	Id              string `json:"proposalId"`
+	Proposer        string `json:"proposer"`
+	ParamId         uint8  `json:"paramId"`
+	ParamName       string `json:"paramName"`
+	Value           string `json:"value"`
+	EffectiveHeight string `json:"effectiveHeight"`
+	VoteIndex       string `json:"voteIndex"`
+	ApproveWeight   string `json:"approveWeight"`
+	RejectWeight    string `json:"rejectWeight"`
+	Status          uint8  `json:"status"`
+}
+
+func (r *ContractApi) GetGovernanceParamProposalList() ([]*GovernanceParamProposal, error) {
+	db, err := getVmDb(r.chain, types.AddressGovernance)
+	if err != nil {
+		return nil, err
+	}
+	sb, err := db.LatestSnapshotBlock()
+	if err != nil {
+		return nil, err
+	}
+	list, err := abi.GetParamProposalList(db)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*GovernanceParamProposal, len(list))
+	for i, proposal := range list {
+		result[i] = &GovernanceParamProposal{
+			Id:              Uint64ToString(proposal.Id),
+			Proposer:        proposal.Proposer,
+			ParamId:         proposal.ParamId,
+			Value:           *bigIntToString(proposal.Value),
+			EffectiveHeight: Uint64ToString(proposal.EffectiveHeight),
+			VoteIndex:       Uint64ToString(proposal.VoteIndex),
+			ApproveWeight:   *bigIntToString(proposal.ApproveWeight),
+			RejectWeight:    *bigIntToString(proposal.RejectWeight),
+			Status:          proposal.StatusAt(sb.Height),
+		}
+		if def := util.GetGovernanceParamDef(proposal.ParamId); def != nil {
+			result[i].ParamName = def.Name
+		}
+	}
+	return result, nil
+}
+
+type GovernanceParamInfo struct {
+	ParamId       uint8             `json:"paramId"`
+	ParamName     string            `json:"paramName"`
+	Value         string            `json:"value"`
+	PendingValues map[string]string `json:"pendingValues"`
+}
+
+func (r *ContractApi) GetGovernanceParams() ([]*GovernanceParamInfo, error) {
+	db, err := getVmDb(r.chain, types.AddressGovernance)
+	if err != nil {
+		return nil, err
+	}
+	sb, err := db.LatestSnapshotBlock()
+	if err != nil {
+		return nil, err
+	}
+	idList := util.GovernanceParamIdList()
+	result := make([]*GovernanceParamInfo, len(idList))
+	for i, id := range idList {
+		value, err := util.GetGovernanceParam(db, id, sb.Height)
+		if err != nil {
+			return nil, err
+		}
+		schedule, err := abi.GetGovernanceParamSchedule(db, id)
+		if err != nil {
+			return nil, err
+		}
+		pendingValues := make(map[string]string)
+		for j, height := range schedule.Heights {
+			if height > sb.Height {
+				pendingValues[Uint64ToString(height)] = *bigIntToString(schedule.Values[j])
+			}
+		}
+		result[i] = &GovernanceParamInfo{id, util.GetGovernanceParamDef(id).Name, *bigIntToString(value), pendingValues}
+	}
+	return result, nil
+}
+
 type VoteDetail struct {
 	Name            string                   `json:"blockProducerName"`
 	VoteNum         string                   `json:"totalVotes"`
@@ -661,6 +747,95 @@ func (m *ContractApi) GetTokenInfoById(tokenId types.TokenTypeId) (*RpcTokenInfo
 	return nil, nil
 }
 
+type TokenHolder struct {
+	Address types.Address `json:"address"`
+	Balance string        `json:"balance"`
+}
+
+type TokenHolderList struct {
+	Count uint64         `json:"totalCount"`
+	List  []*TokenHolder `json:"holderList"`
+}
+
+// GetTokenHolders returns the accounts holding the token in descending order of confirmed balance
+func (m *ContractApi) GetTokenHolders(tokenId types.TokenTypeId, pageIndex int, pageSize int) (*TokenHolderList, error) {
+	if pageSize > 1000 {
+		return nil, fmt.Errorf("count must be less than 1000")
+	}
+	plugin, err := m.getTokenHoldersPlugin()
+	if err != nil {
+		return nil, err
+	}
+	stats, err := plugin.GetTokenHolderStats(tokenId)
+	if err != nil {
+		m.log.Error("GetTokenHolderStats failed, error is "+err.Error(), "method", "GetTokenHolders")
+		return nil, err
+	}
+	holders, err := plugin.GetTokenHolders(tokenId, pageIndex*pageSize, pageSize)
+	if err != nil {
+		m.log.Error("GetTokenHolders failed, error is "+err.Error(), "method", "GetTokenHolders")
+		return nil, err
+	}
+	list := &TokenHolderList{
+		Count: stats.HolderCount,
+		List:  make([]*TokenHolder, 0, len(holders)),
+	}
+	for _, holder := range holders {
+		list.List = append(list.List, &TokenHolder{
+			Address: holder.Address,
+			Balance: holder.Balance.String(),
+		})
+	}
+	return list, nil
+}
+
+type TokenSupply struct {
+	TokenId           types.TokenTypeId `json:"tokenId"`
+	TotalSupply       string            `json:"totalSupply"`
+	CirculatingSupply string            `json:"circulatingSupply"`
+	HolderCount       string            `json:"holderCount"`
+}
+
+// GetTokenSupply returns the total supply of the token, which is reduced by the burned amounts, and the
+// circulating supply, which is the sum of the confirmed balances of all accounts and excludes the
+// amounts on road.
+func (m *ContractApi) GetTokenSupply(tokenId types.TokenTypeId) (*TokenSupply, error) {
+	plugin, err := m.getTokenHoldersPlugin()
+	if err != nil {
+		return nil, err
+	}
+	db, err := getVmDb(m.chain, types.AddressAsset)
+	if err != nil {
+		return nil, err
+	}
+	tokenInfo, err := abi.GetTokenByID(db, tokenId)
+	if err != nil {
+		return nil, err
+	}
+	if tokenInfo == nil {
+		return nil, errors.New("token not exist")
+	}
+	stats, err := plugin.GetTokenHolderStats(tokenId)
+	if err != nil {
+		m.log.Error("GetTokenHolderStats failed, error is "+err.Error(), "method", "GetTokenSupply")
+		return nil, err
+	}
+	return &TokenSupply{
+		TokenId:           tokenId,
+		TotalSupply:       tokenInfo.TotalSupply.String(),
+		CirculatingSupply: stats.Supply.String(),
+		HolderCount:       Uint64ToString(stats.HolderCount),
+	}, nil
+}
+
+func (m *ContractApi) getTokenHoldersPlugin() (*chain_plugins.TokenHolders, error) {
+	plugins := m.chain.Plugins()
+	if plugins == nil {
+		return nil, errors.New("config.OpenPlugins is false, api can't work")
+	}
+	return plugins.GetPlugin("tokenHolders").(*chain_plugins.TokenHolders), nil
+}
+
 func (m *ContractApi) GetTokenInfoListByOwner(owner types.Address) ([]*RpcTokenInfo, error) {
 	db, err := getVmDb(m.chain, types.AddressAsset)
 	if err != nil {
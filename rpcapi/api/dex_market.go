@@ -0,0 +1,181 @@
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	chain_plugins "github.com/vitelabs/go-vite/v2/ledger/chain/plugins"
+	apidex "github.com/vitelabs/go-vite/v2/rpcapi/api/dex"
+	"github.com/vitelabs/go-vite/v2/vm/contracts/dex"
+)
+
+const (
+	dexDepthLimitMax  = 500
+	dexKlineLimitMax  = 1500
+	dexTradesLimitMax = 1000
+)
+
+type DexDepthLevel struct {
+	Price      string `json:"price"`
+	Quantity   string `json:"quantity"`
+	OrderCount int    `json:"orderCount"`
+}
+
+type DexDepth struct {
+	Asks []*DexDepthLevel `json:"asks"`
+	Bids []*DexDepthLevel `json:"bids"`
+}
+
+type DexKline struct {
+	Time   int64  `json:"time"`
+	Open   string `json:"open"`
+	High   string `json:"high"`
+	Low    string `json:"low"`
+	Close  string `json:"close"`
+	Volume string `json:"volume"`
+	Amount string `json:"amount"`
+	Count  uint64 `json:"count"`
+}
+
+type DexTrade struct {
+	Id        string `json:"id"`
+	TakerSide bool   `json:"takerSide"`
+	TakerId   string `json:"takerId"`
+	MakerId   string `json:"makerId"`
+	Price     string `json:"price"`
+	Quantity  string `json:"quantity"`
+	Amount    string `json:"amount"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+func (f DexTradeApi) getDexMarketPlugin() (*chain_plugins.DexMarket, error) {
+	plugins := f.chain.Plugins()
+	if plugins == nil {
+		return nil, errors.New("config.OpenPlugins is false, api can't work")
+	}
+	return plugins.GetPlugin("dexMarket").(*chain_plugins.DexMarket), nil
+}
+
+func (f DexTradeApi) getMarketId(tradeToken, quoteToken types.TokenTypeId) (int32, error) {
+	fundDb, err := getVmDb(f.chain, types.AddressDexFund)
+	if err != nil {
+		return 0, err
+	}
+	marketInfo, ok := dex.GetMarketInfo(fundDb, tradeToken, quoteToken)
+	if !ok {
+		return 0, dex.TradeMarketNotExistsErr
+	}
+	return marketInfo.MarketId, nil
+}
+
+func depthLevelsToRpc(levels []*chain_plugins.DexDepthLevel) []*DexDepthLevel {
+	result := make([]*DexDepthLevel, len(levels))
+	for i, level := range levels {
+		result[i] = &DexDepthLevel{
+			Price:      dex.BytesToPrice(level.Price),
+			Quantity:   level.Quantity.String(),
+			OrderCount: level.OrderCount,
+		}
+	}
+	return result
+}
+
+// GetDepth returns the order book of a market aggregated by price, limit is the count of price levels of each side.
+// Only the orders confirmed by snapshot blocks are counted
+func (f DexTradeApi) GetDepth(tradeToken, quoteToken types.TokenTypeId, limit int) (*DexDepth, error) {
+	if limit <= 0 || limit > dexDepthLimitMax {
+		return nil, fmt.Errorf("limit must be between 1 and %d", dexDepthLimitMax)
+	}
+	plugin, err := f.getDexMarketPlugin()
+	if err != nil {
+		return nil, err
+	}
+	marketId, err := f.getMarketId(tradeToken, quoteToken)
+	if err != nil {
+		return nil, err
+	}
+	asks, bids, err := plugin.GetDepth(marketId, limit)
+	if err != nil {
+		return nil, err
+	}
+	return &DexDepth{Asks: depthLevelsToRpc(asks), Bids: depthLevelsToRpc(bids)}, nil
+}
+
+// GetKlines returns the candles of a market in the interval, which start between startTime and endTime in seconds.
+// Valid intervals are minute, minute5, minute15, minute30, hour, hour4, day and week
+func (f DexTradeApi) GetKlines(tradeToken, quoteToken types.TokenTypeId, interval string, startTime, endTime int64, limit int) ([]*DexKline, error) {
+	if limit <= 0 || limit > dexKlineLimitMax {
+		return nil, fmt.Errorf("limit must be between 1 and %d", dexKlineLimitMax)
+	}
+	intervalIndex := -1
+	for i, name := range chain_plugins.DexKlineIntervals {
+		if name == interval {
+			intervalIndex = i
+			break
+		}
+	}
+	if intervalIndex < 0 {
+		return nil, fmt.Errorf("unknown interval %s", interval)
+	}
+	plugin, err := f.getDexMarketPlugin()
+	if err != nil {
+		return nil, err
+	}
+	marketId, err := f.getMarketId(tradeToken, quoteToken)
+	if err != nil {
+		return nil, err
+	}
+	klines, err := plugin.GetKlines(marketId, intervalIndex, startTime, endTime, limit)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*DexKline, len(klines))
+	for i, kline := range klines {
+		result[i] = &DexKline{
+			Time:   kline.Time,
+			Open:   dex.BytesToPrice(kline.Open),
+			High:   dex.BytesToPrice(kline.High),
+			Low:    dex.BytesToPrice(kline.Low),
+			Close:  dex.BytesToPrice(kline.Close),
+			Volume: kline.Volume.String(),
+			Amount: kline.Amount.String(),
+			Count:  kline.Count,
+		}
+	}
+	return result, nil
+}
+
+// GetTrades returns the latest transactions of a market confirmed by snapshot blocks, the newest comes first
+func (f DexTradeApi) GetTrades(tradeToken, quoteToken types.TokenTypeId, limit int) ([]*DexTrade, error) {
+	if limit <= 0 || limit > dexTradesLimitMax {
+		return nil, fmt.Errorf("limit must be between 1 and %d", dexTradesLimitMax)
+	}
+	plugin, err := f.getDexMarketPlugin()
+	if err != nil {
+		return nil, err
+	}
+	marketId, err := f.getMarketId(tradeToken, quoteToken)
+	if err != nil {
+		return nil, err
+	}
+	trades, err := plugin.GetTrades(marketId, limit)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*DexTrade, len(trades))
+	for i, trade := range trades {
+		result[i] = &DexTrade{
+			Id:        hex.EncodeToString(trade.Id),
+			TakerSide: trade.TakerSide,
+			TakerId:   hex.EncodeToString(trade.TakerId),
+			MakerId:   hex.EncodeToString(trade.MakerId),
+			Price:     dex.BytesToPrice(trade.Price),
+			Quantity:  apidex.AmountBytesToString(trade.Quantity),
+			Amount:    apidex.AmountBytesToString(trade.Amount),
+			Timestamp: trade.Timestamp,
+		}
+	}
+	return result, nil
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/errors"
 	walleterrors "github.com/vitelabs/go-vite/v2/common/errors"
+	chain_block "github.com/vitelabs/go-vite/v2/ledger/chain/block"
 	"github.com/vitelabs/go-vite/v2/ledger/verifier"
 	"github.com/vitelabs/go-vite/v2/vm/contracts/dex"
 	"github.com/vitelabs/go-vite/v2/vm/util"
@@ -196,6 +197,12 @@ var (
 		Code:    -37013,
 	}
 
+	// -38001 ~ -38999 ledger
+	ErrLedgerPruned = JsonRpc2Error{
+		Message: chain_block.ErrPruned.Error(),
+		Code:    -38001,
+	}
+
 	concernedErrorMap map[string]JsonRpc2Error
 )
 
@@ -245,6 +252,8 @@ func init() {
 	concernedErrorMap[ErrDexTradeMarketInvalidTokenPair.Error()] = ErrDexTradeMarketInvalidTokenPair
 	concernedErrorMap[ErrDexFundUserNotExists.Error()] = ErrDexFundUserNotExists
 
+	concernedErrorMap[ErrLedgerPruned.Error()] = ErrLedgerPruned
+
 }
 
 func TryMakeConcernedError(err error) (newerr error, concerned bool) {
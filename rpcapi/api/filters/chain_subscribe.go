@@ -52,10 +52,11 @@ type ChainSubscribe struct {
 	es                     *EventSystem
 	listenIdList           []uint64
 	preDeleteAccountBlocks []*AccountChainEvent
+	removed                *removedSnapshotBlocks
 }
 
 func NewChainSubscribe(v *vite.Vite, e *EventSystem) *ChainSubscribe {
-	c := &ChainSubscribe{vite: v, es: e}
+	c := &ChainSubscribe{vite: v, es: e, removed: newRemovedSnapshotBlocks()}
 	v.Chain().Register(c)
 	return c
 }
@@ -111,6 +112,7 @@ func (c *ChainSubscribe) DeleteAccountBlocks(blocks []*ledger.AccountBlock) erro
 	return nil
 }
 func (c *ChainSubscribe) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	c.removed.add(chunks)
 	acEvents := make([]*AccountChainEvent, 0)
 	for _, chunk := range chunks {
 		for _, b := range chunk.AccountBlocks {
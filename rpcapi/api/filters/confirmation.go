@@ -0,0 +1,232 @@
+package filters
+
+import (
+	"errors"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/rpcapi/api"
+)
+
+const (
+	maxConfirmationDepth   = 10000
+	maxConfirmationTargets = 1000
+
+	// the confirmed blocks of the addresses are forgotten after the retention, a rollback deeper than
+	// it can't re-insert them
+	confirmedRetention     = 3600
+	confirmedPruneInterval = 100
+)
+
+const (
+	ConfirmationEventConfirmed = "confirmed"
+	ConfirmationEventRevoked   = "revoked"
+)
+
+// ConfirmationParam registers the account blocks to watch, the blocks of the addresses inserted after the
+// subscription and the blocks of the hashes, until they have been confirmed by Depth snapshot blocks
+type ConfirmationParam struct {
+	Addresses []types.Address `json:"addresses"`
+	Hashes    []types.Hash    `json:"hashes"`
+	Depth     uint64          `json:"depth"`
+}
+
+// ConfirmationEvent is sent once when the account block reaches the confirmation depth, or when it is
+// rolled back before that
+type ConfirmationEvent struct {
+	Type           string        `json:"type"`
+	Hash           types.Hash    `json:"hash"`
+	Address        types.Address `json:"address"`
+	Height         string        `json:"height"`
+	ConfirmedTimes string        `json:"confirmedTimes,omitempty"`
+	ConfirmedHash  *types.Hash   `json:"confirmedHash,omitempty"`
+}
+
+type confirmationFilter struct {
+	addrs  map[types.Address]struct{}
+	hashes map[types.Hash]struct{}
+}
+
+func newConfirmationFilter(param ConfirmationParam) (*confirmationFilter, error) {
+	if param.Depth == 0 || param.Depth > maxConfirmationDepth {
+		return nil, errors.New("invalid confirmation depth")
+	}
+	if len(param.Addresses) == 0 && len(param.Hashes) == 0 {
+		return nil, errors.New("addresses and hashes are empty")
+	}
+	if len(param.Addresses)+len(param.Hashes) > maxConfirmationTargets {
+		return nil, errors.New("too many addresses and hashes")
+	}
+	f := &confirmationFilter{
+		addrs:  make(map[types.Address]struct{}, len(param.Addresses)),
+		hashes: make(map[types.Hash]struct{}, len(param.Hashes)),
+	}
+	for _, addr := range param.Addresses {
+		f.addrs[addr] = struct{}{}
+	}
+	for _, hash := range param.Hashes {
+		f.hashes[hash] = struct{}{}
+	}
+	return f, nil
+}
+
+func (f *confirmationFilter) match(e *AccountChainEvent) bool {
+	if _, ok := f.addrs[e.Addr]; ok {
+		return true
+	}
+	_, ok := f.hashes[e.Hash]
+	return ok
+}
+
+// confirmationUpdate is sent by the event system to a confirmation subscription, either the matched account
+// blocks inserted or removed, or a snapshot block change
+type confirmationUpdate struct {
+	blocks   []*AccountChainEvent
+	removed  bool
+	snapshot bool
+}
+
+type confirmationChain interface {
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+	GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error)
+	GetConfirmSnapshotHeaderByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error)
+}
+
+type pendingConfirmation struct {
+	addr          types.Address
+	height        uint64
+	inserted      bool // the block is in the chain
+	confirmHeight uint64
+}
+
+// confirmationTracker keeps the watched account blocks of a subscription until they are confirmed,
+// every block is reported once
+type confirmationTracker struct {
+	chain  confirmationChain
+	filter *confirmationFilter
+	depth  uint64
+
+	pending   map[types.Hash]*pendingConfirmation
+	confirmed map[types.Hash]uint64 // the hashes reported as confirmed, to the snapshot height of the report
+	pruned    uint64
+}
+
+func newConfirmationTracker(chain confirmationChain, filter *confirmationFilter, depth uint64) *confirmationTracker {
+	return &confirmationTracker{
+		chain:     chain,
+		filter:    filter,
+		depth:     depth,
+		pending:   make(map[types.Hash]*pendingConfirmation),
+		confirmed: make(map[types.Hash]uint64),
+	}
+}
+
+// init starts watching the registered hashes, it's called after the subscription is installed so
+// that no insertion is missed in between
+func (t *confirmationTracker) init() ([]*ConfirmationEvent, error) {
+	for hash := range t.filter.hashes {
+		if _, ok := t.pending[hash]; ok {
+			continue
+		}
+		p := &pendingConfirmation{}
+		block, err := t.chain.GetAccountBlockByHash(hash)
+		if err != nil {
+			return nil, err
+		}
+		if block != nil {
+			p.addr, p.height, p.inserted = block.AccountAddress, block.Height, true
+		}
+		t.pending[hash] = p
+	}
+	return t.check()
+}
+
+func (t *confirmationTracker) update(u *confirmationUpdate) ([]*ConfirmationEvent, error) {
+	if u.snapshot {
+		return t.check()
+	}
+	var events []*ConfirmationEvent
+	for _, e := range u.blocks {
+		if _, ok := t.confirmed[e.Hash]; ok {
+			continue
+		}
+		p, ok := t.pending[e.Hash]
+		if !u.removed {
+			if !ok {
+				p = &pendingConfirmation{}
+				t.pending[e.Hash] = p
+			}
+			p.addr, p.height, p.inserted = e.Addr, e.Height, true
+			continue
+		}
+		if !ok || !p.inserted {
+			continue
+		}
+		events = append(events, &ConfirmationEvent{
+			Type:    ConfirmationEventRevoked,
+			Hash:    e.Hash,
+			Address: p.addr,
+			Height:  api.Uint64ToString(p.height),
+		})
+		// a registered hash is still watched in case the block is inserted again
+		if _, registered := t.filter.hashes[e.Hash]; registered {
+			p.inserted, p.confirmHeight = false, 0
+		} else {
+			delete(t.pending, e.Hash)
+		}
+	}
+	return events, nil
+}
+
+// check reports the pending blocks which have reached the confirmation depth, the events are
+// returned along with an error too since they are no longer pending
+func (t *confirmationTracker) check() ([]*ConfirmationEvent, error) {
+	var events []*ConfirmationEvent
+	latest := t.chain.GetLatestSnapshotBlock()
+	for hash, p := range t.pending {
+		if !p.inserted {
+			continue
+		}
+		if p.confirmHeight == 0 || latest.Height+1-p.confirmHeight >= t.depth {
+			// the confirming snapshot block is read again before reporting, the cached height may be
+			// stale if a rollback is not handled yet
+			sb, err := t.chain.GetConfirmSnapshotHeaderByAbHash(hash)
+			if err != nil {
+				return events, err
+			}
+			if sb == nil || sb.Height > latest.Height {
+				p.confirmHeight = 0
+				continue
+			}
+			p.confirmHeight = sb.Height
+			times := latest.Height + 1 - sb.Height
+			if times < t.depth {
+				continue
+			}
+			events = append(events, &ConfirmationEvent{
+				Type:           ConfirmationEventConfirmed,
+				Hash:           hash,
+				Address:        p.addr,
+				Height:         api.Uint64ToString(p.height),
+				ConfirmedTimes: api.Uint64ToString(times),
+				ConfirmedHash:  &sb.Hash,
+			})
+			delete(t.pending, hash)
+			t.confirmed[hash] = latest.Height
+		}
+	}
+	t.prune(latest.Height)
+	return events, nil
+}
+
+func (t *confirmationTracker) prune(height uint64) {
+	if height < t.pruned+confirmedPruneInterval {
+		return
+	}
+	t.pruned = height
+	for hash, h := range t.confirmed {
+		if _, registered := t.filter.hashes[hash]; !registered && h+confirmedRetention < height {
+			delete(t.confirmed, hash)
+		}
+	}
+}
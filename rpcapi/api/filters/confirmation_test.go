@@ -0,0 +1,89 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+)
+
+type testConfirmationChain struct {
+	height   uint64
+	blocks   map[types.Hash]*ledger.AccountBlock
+	confirms map[types.Hash]uint64
+}
+
+func (c *testConfirmationChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return &ledger.SnapshotBlock{Height: c.height}
+}
+
+func (c *testConfirmationChain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	return c.blocks[blockHash], nil
+}
+
+func (c *testConfirmationChain) GetConfirmSnapshotHeaderByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error) {
+	if h, ok := c.confirms[abHash]; ok {
+		return &ledger.SnapshotBlock{Height: h, Hash: types.DataHash([]byte{byte(h)})}, nil
+	}
+	return nil, nil
+}
+
+func TestConfirmationTracker(t *testing.T) {
+	addr := types.AddressDexTrade
+	registered := &ledger.AccountBlock{AccountAddress: types.AddressQuota, Height: 1, Hash: types.DataHash([]byte("registered"))}
+	chain := &testConfirmationChain{
+		height:   10,
+		blocks:   map[types.Hash]*ledger.AccountBlock{registered.Hash: registered},
+		confirms: map[types.Hash]uint64{registered.Hash: 8},
+	}
+	f, err := newConfirmationFilter(ConfirmationParam{Addresses: []types.Address{addr}, Hashes: []types.Hash{registered.Hash}, Depth: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracker := newConfirmationTracker(chain, f, 3)
+
+	// the registered block has been confirmed 3 times already
+	events, err := tracker.init()
+	if err != nil || len(events) != 1 || events[0].Type != ConfirmationEventConfirmed || events[0].ConfirmedTimes != "3" {
+		t.Fatalf("unexpected events on init %+v, %v", events, err)
+	}
+
+	// two blocks of the address, the first is confirmed 3 times, the second is rolled back before that
+	first := &AccountChainEvent{Hash: types.DataHash([]byte("first")), Addr: addr, Height: 1}
+	second := &AccountChainEvent{Hash: types.DataHash([]byte("second")), Addr: addr, Height: 2}
+	if !f.match(first) || f.match(&AccountChainEvent{Addr: types.AddressQuota}) {
+		t.Fatal("unexpected match")
+	}
+	tracker.update(&confirmationUpdate{blocks: []*AccountChainEvent{first, second}})
+	chain.height = 11
+	chain.confirms[first.Hash] = 11
+	chain.confirms[second.Hash] = 11
+	if events, _ := tracker.update(&confirmationUpdate{snapshot: true}); len(events) != 0 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+
+	delete(chain.confirms, second.Hash)
+	events, _ = tracker.update(&confirmationUpdate{blocks: []*AccountChainEvent{second}, removed: true})
+	if len(events) != 1 || events[0].Type != ConfirmationEventRevoked || events[0].Hash != second.Hash {
+		t.Fatalf("unexpected revoked events %+v", events)
+	}
+
+	chain.height = 13
+	for i := 0; i < 2; i++ {
+		events, _ = tracker.update(&confirmationUpdate{snapshot: true})
+		if i == 0 && (len(events) != 1 || events[0].Hash != first.Hash || events[0].Height != "1") {
+			t.Fatalf("unexpected confirmed events %+v", events)
+		}
+		if i == 1 && len(events) != 0 {
+			t.Fatalf("expect the block to be reported once, got %+v", events)
+		}
+	}
+
+	// a confirmed block is not revoked or confirmed again
+	if events, _ := tracker.update(&confirmationUpdate{blocks: []*AccountChainEvent{first}, removed: true}); len(events) != 0 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+	if _, err := newConfirmationFilter(ConfirmationParam{Addresses: []types.Address{addr}}); err == nil {
+		t.Fatal("expect an error for the depth 0")
+	}
+}
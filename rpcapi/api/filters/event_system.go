@@ -26,6 +26,7 @@ const (
 	OnroadBlocksSubscriptionV2
 	SnapshotBlocksSubscription
 	SnapshotBlocksSubscriptionV2
+	ConfirmationsSubscription
 )
 
 type subscription struct {
@@ -41,6 +42,8 @@ type subscription struct {
 	accountBlockWithHeightCh chan []*AccountBlockWithHeight
 	logsCh                   chan []*Logs
 	onroadMsgCh              chan []*OnroadMsg
+	confirmation             *confirmationFilter
+	confirmationCh           chan *confirmationUpdate
 }
 
 type EventSystem struct {
@@ -93,7 +96,7 @@ func (es *EventSystem) Stop() {
 func (es *EventSystem) eventLoop() {
 	es.log.Info("start event loop")
 	index := make(map[FilterType]map[rpc.ID]*subscription)
-	for i := LogsSubscription; i <= SnapshotBlocksSubscriptionV2; i++ {
+	for i := LogsSubscription; i <= ConfirmationsSubscription; i++ {
 		index[i] = make(map[rpc.ID]*subscription)
 	}
 
@@ -144,6 +147,9 @@ func (es *EventSystem) handleSbEvent(filters map[FilterType]map[rpc.ID]*subscrip
 	for _, f := range filters[SnapshotBlocksSubscriptionV2] {
 		f.snapshotBlockCh <- blocks
 	}
+	for _, f := range filters[ConfirmationsSubscription] {
+		f.confirmationCh <- &confirmationUpdate{snapshot: true}
+	}
 }
 
 func (es *EventSystem) handleAcEvent(filters map[FilterType]map[rpc.ID]*subscription, acEvent []*AccountChainEvent, removed bool) {
@@ -247,6 +253,18 @@ func (es *EventSystem) handleAcEvent(filters map[FilterType]map[rpc.ID]*subscrip
 			f.logsCh <- logs
 		}
 	}
+	// handle confirmations
+	for _, f := range filters[ConfirmationsSubscription] {
+		var blocks []*AccountChainEvent
+		for _, e := range acEvent {
+			if f.confirmation.match(e) {
+				blocks = append(blocks, e)
+			}
+		}
+		if len(blocks) > 0 {
+			f.confirmationCh <- &confirmationUpdate{blocks: blocks, removed: removed}
+		}
+	}
 }
 
 func appendOnroadMsg(onroadMsgs map[types.Address][]*OnroadMsg, toAddr types.Address, hash types.Hash, closed, removed bool) map[types.Address][]*OnroadMsg {
@@ -300,6 +318,7 @@ func (s *RpcSubscription) Unsubscribe() {
 			case <-s.sub.logsCh:
 			case <-s.sub.snapshotBlockCh:
 			case <-s.sub.onroadMsgCh:
+			case <-s.sub.confirmationCh:
 			}
 		}
 		<-s.Err()
@@ -318,6 +337,7 @@ func (es *EventSystem) SubscribeAccountBlocks(ch chan []*AccountBlock) *RpcSubsc
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		confirmationCh:           make(chan *confirmationUpdate),
 	}
 	return es.subscribe(sub)
 }
@@ -335,6 +355,7 @@ func (es *EventSystem) SubscribeAccountBlocksByAddr(addr types.Address, ch chan
 		accountBlockWithHeightCh: ch,
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		confirmationCh:           make(chan *confirmationUpdate),
 	}
 	return es.subscribe(sub)
 }
@@ -352,6 +373,7 @@ func (es *EventSystem) SubscribeOnroadBlocksByAddr(addr types.Address, ch chan [
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              ch,
+		confirmationCh:           make(chan *confirmationUpdate),
 	}
 	return es.subscribe(sub)
 }
@@ -368,6 +390,7 @@ func (es *EventSystem) SubscribeSnapshotBlocks(ch chan []*SnapshotBlock, ft Filt
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   make(chan []*Logs),
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		confirmationCh:           make(chan *confirmationUpdate),
 	}
 	return es.subscribe(sub)
 }
@@ -385,6 +408,25 @@ func (es *EventSystem) SubscribeLogs(p *api.FilterParam, ch chan []*Logs, ft Fil
 		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
 		logsCh:                   ch,
 		onroadMsgCh:              make(chan []*OnroadMsg),
+		confirmationCh:           make(chan *confirmationUpdate),
+	}
+	return es.subscribe(sub)
+}
+
+func (es *EventSystem) SubscribeConfirmations(f *confirmationFilter, ch chan *confirmationUpdate) *RpcSubscription {
+	sub := &subscription{
+		id:                       rpc.NewID(),
+		typ:                      ConfirmationsSubscription,
+		createTime:               time.Now(),
+		installed:                make(chan struct{}),
+		err:                      make(chan error),
+		snapshotBlockCh:          make(chan []*SnapshotBlock),
+		accountBlockCh:           make(chan []*AccountBlock),
+		accountBlockWithHeightCh: make(chan []*AccountBlockWithHeight),
+		logsCh:                   make(chan []*Logs),
+		onroadMsgCh:              make(chan []*OnroadMsg),
+		confirmation:             f,
+		confirmationCh:           ch,
 	}
 	return es.subscribe(sub)
 }
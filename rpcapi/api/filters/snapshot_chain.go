@@ -0,0 +1,258 @@
+package filters
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/rpcapi/api"
+)
+
+const (
+	removedSnapshotBlockCacheSize = 2048
+	replayBatchSize               = 100
+)
+
+const (
+	ChainEventSnapshotBlock = "snapshotBlock"
+	ChainEventRollback      = "rollback"
+	ChainEventError         = "error"
+)
+
+var errUnknownCursor = errors.New("the snapshot block of the cursor is unknown, resubscribe from an earlier cursor")
+
+// ChainCursor is the last snapshot block seen by the client of a snapshot chain subscription
+type ChainCursor struct {
+	Height string     `json:"height"`
+	Hash   types.Hash `json:"hash"`
+}
+
+type SnapshotChainParam struct {
+	Cursor   *ChainCursor `json:"cursor"` // resume after the cursor, nil to start from the latest snapshot block
+	WithLogs bool         `json:"withLogs"`
+}
+
+type ConfirmedAccountBlock struct {
+	Address types.Address   `json:"address"`
+	Hash    types.Hash      `json:"hash"`
+	Height  string          `json:"height"`
+	Logs    []*ledger.VmLog `json:"vmlogs,omitempty"`
+}
+
+type ChainSnapshotBlock struct {
+	Hash          types.Hash               `json:"hash"`
+	PrevHash      types.Hash               `json:"prevHash"`
+	Height        string                   `json:"height"`
+	Timestamp     int64                    `json:"timestamp"`
+	AccountBlocks []*ConfirmedAccountBlock `json:"accountBlocks"`
+}
+
+// ChainEvent moves the cursor of the client to Height and Hash. A snapshotBlock event appends the SnapshotBlock,
+// a rollback event removes the snapshot blocks in Removed, the highest first. Replayed is set for the events read
+// from the ledger before the subscription reaches the latest snapshot block
+type ChainEvent struct {
+	Type          string                `json:"type"`
+	Height        string                `json:"height"`
+	Hash          types.Hash            `json:"hash"`
+	Replayed      bool                  `json:"replayed"`
+	SnapshotBlock *ChainSnapshotBlock   `json:"snapshotBlock,omitempty"`
+	Removed       []*ChainSnapshotBlock `json:"removed,omitempty"`
+	Error         string                `json:"error,omitempty"`
+}
+
+type removedSnapshotBlock struct {
+	height uint64
+	block  *ChainSnapshotBlock
+}
+
+// removedSnapshotBlocks keeps the latest snapshot blocks deleted by rollbacks, the cursors walk back
+// from a removed snapshot block to the fork point with them
+type removedSnapshotBlocks struct {
+	blocks map[types.Hash]*removedSnapshotBlock
+	order  []types.Hash
+	mu     sync.RWMutex
+}
+
+func newRemovedSnapshotBlocks() *removedSnapshotBlocks {
+	return &removedSnapshotBlocks{blocks: make(map[types.Hash]*removedSnapshotBlock)}
+}
+
+func (r *removedSnapshotBlocks) add(chunks []*ledger.SnapshotChunk) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, chunk := range chunks {
+		sb := chunk.SnapshotBlock
+		if sb == nil {
+			continue
+		}
+		if _, ok := r.blocks[sb.Hash]; ok {
+			continue
+		}
+		r.blocks[sb.Hash] = &removedSnapshotBlock{height: sb.Height, block: newChainSnapshotBlock(sb, chunk.AccountBlocks)}
+		r.order = append(r.order, sb.Hash)
+	}
+	if n := len(r.order) - removedSnapshotBlockCacheSize; n > 0 {
+		for _, hash := range r.order[:n] {
+			delete(r.blocks, hash)
+		}
+		r.order = append([]types.Hash{}, r.order[n:]...)
+	}
+}
+
+func (r *removedSnapshotBlocks) get(hash types.Hash) (*removedSnapshotBlock, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	b, ok := r.blocks[hash]
+	return b, ok
+}
+
+func newChainSnapshotBlock(sb *ledger.SnapshotBlock, blocks []*ledger.AccountBlock) *ChainSnapshotBlock {
+	csb := &ChainSnapshotBlock{
+		Hash:          sb.Hash,
+		PrevHash:      sb.PrevHash,
+		Height:        api.Uint64ToString(sb.Height),
+		AccountBlocks: make([]*ConfirmedAccountBlock, len(blocks)),
+	}
+	if sb.Timestamp != nil {
+		csb.Timestamp = sb.Timestamp.Unix()
+	}
+	for i, b := range blocks {
+		csb.AccountBlocks[i] = &ConfirmedAccountBlock{Address: b.AccountAddress, Hash: b.Hash, Height: api.Uint64ToString(b.Height)}
+	}
+	return csb
+}
+
+type cursorChain interface {
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+	GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error)
+	GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error)
+	GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error)
+}
+
+// chainCursor follows the snapshot chain from the last snapshot block seen by a client. It reads the
+// ledger instead of relying on the chain events, so the same code replays the history and follows
+// the live chain
+type chainCursor struct {
+	chain    cursorChain
+	removed  *removedSnapshotBlocks
+	withLogs bool
+
+	height uint64
+	hash   types.Hash
+}
+
+func newChainCursor(chain cursorChain, removed *removedSnapshotBlocks, cursor *ChainCursor, withLogs bool) (*chainCursor, error) {
+	c := &chainCursor{chain: chain, removed: removed, withLogs: withLogs}
+	if cursor == nil {
+		latest := chain.GetLatestSnapshotBlock()
+		c.height, c.hash = latest.Height, latest.Hash
+		return c, nil
+	}
+	height, err := api.StringToUint64(cursor.Height)
+	if err != nil {
+		return nil, err
+	}
+	c.height, c.hash = height, cursor.Hash
+
+	// reject a cursor which is neither in the chain nor a known removed snapshot block
+	sb, err := chain.GetSnapshotHeaderByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if sb == nil || sb.Hash != cursor.Hash {
+		if b, ok := removed.get(cursor.Hash); !ok || b.height != height {
+			return nil, errUnknownCursor
+		}
+	}
+	return c, nil
+}
+
+// next returns the events moving the cursor by at most limit snapshot blocks towards the latest one,
+// a rollback event comes first if the snapshot block of the cursor was removed. It returns no
+// events if the cursor is at the latest snapshot block. The events are returned along with an
+// error too, the cursor has already moved past them
+func (c *chainCursor) next(limit uint64, replayed bool) ([]*ChainEvent, error) {
+	var events []*ChainEvent
+	sb, err := c.chain.GetSnapshotHeaderByHeight(c.height)
+	if err != nil {
+		return nil, err
+	}
+	if sb == nil || sb.Hash != c.hash {
+		rollback, err := c.rollback(replayed)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, rollback)
+	}
+
+	latest := c.chain.GetLatestSnapshotBlock()
+	if latest.Height <= c.height {
+		return events, nil
+	}
+	end := c.height + limit
+	if end > latest.Height {
+		end = latest.Height
+	}
+	chunks, err := c.chain.GetSubLedger(c.height, end)
+	if err != nil {
+		return events, err
+	}
+	for _, chunk := range chunks {
+		sb := chunk.SnapshotBlock
+		if sb == nil || sb.Height <= c.height {
+			continue
+		}
+		// a rollback happened after the check above, the next call rolls back
+		if sb.Height != c.height+1 || sb.PrevHash != c.hash {
+			break
+		}
+		csb := newChainSnapshotBlock(sb, chunk.AccountBlocks)
+		if c.withLogs {
+			for i, b := range chunk.AccountBlocks {
+				if b.LogHash == nil {
+					continue
+				}
+				logList, err := c.chain.GetVmLogList(b.LogHash)
+				if err != nil {
+					return events, err
+				}
+				csb.AccountBlocks[i].Logs = logList
+			}
+		}
+		events = append(events, &ChainEvent{
+			Type:          ChainEventSnapshotBlock,
+			Height:        csb.Height,
+			Hash:          sb.Hash,
+			Replayed:      replayed,
+			SnapshotBlock: csb,
+		})
+		c.height, c.hash = sb.Height, sb.Hash
+	}
+	return events, nil
+}
+
+// rollback walks back from the snapshot block of the cursor to the fork point through the removed snapshot blocks
+func (c *chainCursor) rollback(replayed bool) (*ChainEvent, error) {
+	event := &ChainEvent{Type: ChainEventRollback, Replayed: replayed}
+	height, hash := c.height, c.hash
+	for {
+		sb, err := c.chain.GetSnapshotHeaderByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		if sb != nil && sb.Hash == hash {
+			break
+		}
+		b, ok := c.removed.get(hash)
+		if !ok || b.height != height {
+			return nil, fmt.Errorf("%w, height %d hash %s", errUnknownCursor, height, hash)
+		}
+		event.Removed = append(event.Removed, b.block)
+		height, hash = height-1, b.block.PrevHash
+	}
+	c.height, c.hash = height, hash
+	event.Height, event.Hash = api.Uint64ToString(height), hash
+	return event, nil
+}
@@ -0,0 +1,114 @@
+package filters
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+)
+
+type testCursorChain struct {
+	chunks []*ledger.SnapshotChunk
+}
+
+func (c *testCursorChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.chunks[len(c.chunks)-1].SnapshotBlock
+}
+
+func (c *testCursorChain) GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	if height == 0 || height > uint64(len(c.chunks)) {
+		return nil, nil
+	}
+	return c.chunks[height-1].SnapshotBlock, nil
+}
+
+func (c *testCursorChain) GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error) {
+	var chunks []*ledger.SnapshotChunk
+	for h := startHeight; h <= endHeight && h <= uint64(len(c.chunks)); h++ {
+		if h > 0 {
+			chunks = append(chunks, c.chunks[h-1])
+		}
+	}
+	return chunks, nil
+}
+
+func (c *testCursorChain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
+	return ledger.VmLogList{{Topics: []types.Hash{*logListHash}}}, nil
+}
+
+func (c *testCursorChain) insert(fork string) {
+	height := uint64(len(c.chunks)) + 1
+	sb := &ledger.SnapshotBlock{Height: height, Hash: types.DataHash([]byte(fmt.Sprintf("%s-%d", fork, height)))}
+	if height > 1 {
+		sb.PrevHash = c.chunks[height-2].SnapshotBlock.Hash
+	}
+	logHash := types.DataHash([]byte(fmt.Sprintf("log-%s-%d", fork, height)))
+	ab := &ledger.AccountBlock{Hash: types.DataHash([]byte(fmt.Sprintf("ab-%s-%d", fork, height))), Height: height, LogHash: &logHash}
+	c.chunks = append(c.chunks, &ledger.SnapshotChunk{SnapshotBlock: sb, AccountBlocks: []*ledger.AccountBlock{ab}})
+}
+
+func (c *testCursorChain) rollback(toHeight uint64, removed *removedSnapshotBlocks) {
+	removed.add(c.chunks[toHeight:])
+	c.chunks = c.chunks[:toHeight]
+}
+
+func TestChainCursor(t *testing.T) {
+	chain := &testCursorChain{}
+	removed := newRemovedSnapshotBlocks()
+	for i := 0; i < 5; i++ {
+		chain.insert("a")
+	}
+
+	// replay from height 2 in batches of 2
+	cursor, err := newChainCursor(chain, removed, &ChainCursor{Height: "2", Hash: chain.chunks[1].SnapshotBlock.Hash}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []*ChainEvent
+	for {
+		batch, err := cursor.next(2, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		events = append(events, batch...)
+	}
+	if len(events) != 3 || events[2].Height != "5" || !events[0].Replayed {
+		t.Fatalf("unexpected replayed events %+v", events)
+	}
+	if ab := events[0].SnapshotBlock.AccountBlocks[0]; len(ab.Logs) != 1 || ab.Hash != chain.chunks[2].AccountBlocks[0].Hash {
+		t.Fatalf("unexpected account block %+v", ab)
+	}
+
+	// fork at height 3, the cursor at height 5 rolls back to 3 before the new blocks
+	chain.rollback(3, removed)
+	chain.insert("b")
+	events, err = cursor.next(10, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].Type != ChainEventRollback || events[0].Height != "3" || len(events[0].Removed) != 2 {
+		t.Fatalf("unexpected events after the fork %+v", events)
+	}
+	if events[0].Removed[0].Height != "5" || events[1].Hash != chain.chunks[3].SnapshotBlock.Hash || events[1].Replayed {
+		t.Fatalf("unexpected events after the fork %+v", events)
+	}
+
+	// a client which disconnected at the removed height 5 resumes with a rollback
+	cursor, err = newChainCursor(chain, removed, &ChainCursor{Height: "5", Hash: events[0].Removed[0].Hash}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, _ = cursor.next(10, true)
+	if len(events) != 2 || events[0].Type != ChainEventRollback || events[0].Height != "3" || events[1].Height != "4" {
+		t.Fatalf("unexpected resumed events %+v", events)
+	}
+
+	if _, err := newChainCursor(chain, removed, &ChainCursor{Height: "3", Hash: types.DataHash([]byte("unknown"))}, false); !errors.Is(err, errUnknownCursor) {
+		t.Fatalf("expect an unknown cursor error, got %v", err)
+	}
+}
@@ -721,6 +721,130 @@ func (s *SubscribeApi) createVmLogSubscription(ctx context.Context, rangeMap map
 	return rpcSub, nil
 }
 
+// NewSnapshotChain subscribes the confirmed snapshot chain from the cursor of the client. The history after
+// the cursor is replayed from the ledger first, a rollback event is sent if the cursor was reorged away,
+// then the subscription follows the live chain. Clients save the height and hash of the last event as the
+// cursor to resume after a disconnect.
+func (s *SubscribeApi) NewSnapshotChain(ctx context.Context, param SnapshotChainParam) (*rpc.Subscription, error) {
+	s.log.Info("NewSnapshotChain")
+	cursor, err := newChainCursor(s.vite.Chain(), s.eventSystem.chain.removed, param.Cursor, param.WithLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		// the snapshot block events only wake up the cursor, which reads the chain by itself
+		snapshotBlockCh := make(chan []*SnapshotBlock, 128)
+		sbSub := s.eventSystem.SubscribeSnapshotBlocks(snapshotBlockCh, SnapshotBlocksSubscriptionV2)
+		wake := make(chan struct{}, 1)
+		done := make(chan struct{})
+		defer close(done)
+		defer sbSub.Unsubscribe()
+		go func() {
+			for {
+				select {
+				case <-snapshotBlockCh:
+					select {
+					case wake <- struct{}{}:
+					default:
+					}
+				case <-done:
+					return
+				}
+			}
+		}()
+
+		live, failed := false, false
+		for {
+			for !failed {
+				events, err := cursor.next(replayBatchSize, !live)
+				if len(events) > 0 {
+					notifier.Notify(rpcSub.ID, events)
+				}
+				if err != nil {
+					s.log.Error("follow snapshot chain failed", "id", rpcSub.ID, "err", err)
+					if errors.Is(err, errUnknownCursor) {
+						notifier.Notify(rpcSub.ID, []*ChainEvent{{Type: ChainEventError, Error: err.Error()}})
+						failed = true
+					}
+					break
+				}
+				if len(events) == 0 {
+					live = true
+					break
+				}
+				select {
+				case <-rpcSub.Err():
+					return
+				case <-notifier.Closed():
+					return
+				default:
+				}
+			}
+			select {
+			case <-wake:
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
+// NewAccountBlockConfirmation notifies once when each watched account block has been confirmed by the depth
+// of snapshot blocks, or when it is rolled back before that. The account blocks of the addresses are
+// watched from the subscription on, the account blocks of the hashes may have been inserted earlier.
+func (s *SubscribeApi) NewAccountBlockConfirmation(ctx context.Context, param ConfirmationParam) (*rpc.Subscription, error) {
+	s.log.Info("NewAccountBlockConfirmation")
+	f, err := newConfirmationFilter(param)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		confirmationCh := make(chan *confirmationUpdate, 128)
+		sub := s.eventSystem.SubscribeConfirmations(f, confirmationCh)
+		tracker := newConfirmationTracker(s.vite.Chain(), f, param.Depth)
+
+		events, err := tracker.init()
+		for {
+			if err != nil {
+				s.log.Error("track confirmations failed", "id", rpcSub.ID, "err", err)
+			}
+			if len(events) > 0 {
+				notifier.Notify(rpcSub.ID, events)
+			}
+			select {
+			case u := <-confirmationCh:
+				events, err = tracker.update(u)
+			case <-rpcSub.Err():
+				sub.Unsubscribe()
+				return
+			case <-notifier.Closed():
+				sub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
+
 // Deprecated: use ledger_getVmLogsByFilter instead
 func (s *SubscribeApi) GetLogs(param RpcFilterParam) ([]*Logs, error) {
 	logs, err := api.GetLogs(s.vite.Chain(), param.AddrRange, param.Topics, param.PageIndex, param.PageSize)
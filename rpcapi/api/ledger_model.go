@@ -8,6 +8,7 @@ import (
 	"github.com/vitelabs/go-vite/v2/common/types"
 	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
 	"github.com/vitelabs/go-vite/v2/ledger/chain"
+	chain_plugins "github.com/vitelabs/go-vite/v2/ledger/chain/plugins"
 	"github.com/vitelabs/go-vite/v2/vm/quota"
 )
 
@@ -410,6 +411,49 @@ type BalanceInfo struct {
 	TransactionCount *string       `json:"transactionCount,omitempty"` // uint64
 }
 
+type AccountSummary struct {
+	Address               types.Address          `json:"address"`
+	FirstHeight           string                 `json:"firstHeight"` // uint64
+	FirstTime             int64                  `json:"firstTime"`
+	LastHeight            string                 `json:"lastHeight"` // uint64
+	LastTime              int64                  `json:"lastTime"`
+	CounterpartyCount     string                 `json:"counterpartyCount"`     // uint64
+	ContractCreationCount string                 `json:"contractCreationCount"` // uint64
+	Tokens                []*AccountTokenSummary `json:"tokens"`
+}
+
+type AccountTokenSummary struct {
+	TokenInfo    *RpcTokenInfo `json:"tokenInfo,omitempty"`
+	SendCount    string        `json:"sendCount"`    // uint64
+	ReceiveCount string        `json:"receiveCount"` // uint64
+	TotalIn      string        `json:"totalIn"`      // big int
+	TotalOut     string        `json:"totalOut"`     // big int
+}
+
+func ToAccountSummary(chain chain.Chain, addr types.Address, summary *chain_plugins.AccountSummary) *AccountSummary {
+	result := &AccountSummary{
+		Address:               addr,
+		FirstHeight:           Uint64ToString(summary.FirstHeight),
+		FirstTime:             summary.FirstTime,
+		LastHeight:            Uint64ToString(summary.LastHeight),
+		LastTime:              summary.LastTime,
+		CounterpartyCount:     Uint64ToString(summary.Counterparties),
+		ContractCreationCount: Uint64ToString(summary.ContractCreations),
+		Tokens:                make([]*AccountTokenSummary, 0, len(summary.Tokens)),
+	}
+	for _, token := range summary.Tokens {
+		tokenInfo, _ := chain.GetTokenInfoById(token.TokenId)
+		result.Tokens = append(result.Tokens, &AccountTokenSummary{
+			TokenInfo:    RawTokenInfoToRpc(tokenInfo, token.TokenId),
+			SendCount:    Uint64ToString(token.SendCount),
+			ReceiveCount: Uint64ToString(token.ReceiveCount),
+			TotalIn:      token.In.String(),
+			TotalOut:     token.Out.String(),
+		})
+	}
+	return result
+}
+
 type RpcTokenInfo struct {
 	TokenName     string            `json:"tokenName"`
 	TokenSymbol   string            `json:"tokenSymbol"`
@@ -728,3 +728,24 @@ func (l *LedgerApi) GetChunksV2(startHeight interface{}, endHeight interface{})
 func (l LedgerApi) GetUpgradeInfo() (interface{}, error) {
 	return upgrade.GetAllPoints(), nil
 }
+
+// GetAccountSummary returns the heights and times of the first and last account blocks, the transfers of each token,
+// the count of the distinct counterparties and the count of the created contracts of an account.
+// Only the account blocks confirmed by snapshot blocks are counted
+func (l *LedgerApi) GetAccountSummary(addr types.Address) (*AccountSummary, error) {
+	plugins := l.chain.Plugins()
+	if plugins == nil {
+		return nil, errors.New("config.OpenPlugins is false, api can't work")
+	}
+
+	plugin := plugins.GetPlugin("accountSummary").(*chain_plugins.AccountSummaries)
+	summary, err := plugin.GetAccountSummary(addr)
+	if err != nil {
+		l.log.Error("GetAccountSummary failed, error is "+err.Error(), "method", "GetAccountSummary")
+		return nil, err
+	}
+	if summary == nil {
+		return nil, nil
+	}
+	return ToAccountSummary(l.chain, addr, summary), nil
+}
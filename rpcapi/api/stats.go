@@ -8,18 +8,21 @@ import (
 	"github.com/vitelabs/go-vite/v2/common/types"
 	"github.com/vitelabs/go-vite/v2/ledger/consensus"
 	"github.com/vitelabs/go-vite/v2/ledger/consensus/core"
+	"github.com/vitelabs/go-vite/v2/ledger/sbpstat"
 	"github.com/vitelabs/go-vite/v2/log15"
 )
 
 type StatsApi struct {
-	cs  consensus.Consensus
-	log log15.Logger
+	cs      consensus.Consensus
+	sbpStat *sbpstat.Tracker
+	log     log15.Logger
 }
 
 func NewStatsApi(vite *vite.Vite) *StatsApi {
 	return &StatsApi{
-		cs:  vite.Consensus(),
-		log: log15.New("module", "rpc_api/stats_api"),
+		cs:      vite.Consensus(),
+		sbpStat: vite.SBPStat(),
+		log:     log15.New("module", "rpc_api/stats_api"),
 	}
 }
 
@@ -148,6 +151,40 @@ func (c StatsApi) GetDaySBPStats(startIdx uint64, endIdx uint64) ([]map[string]i
 	return result, nil
 }
 
+// GetSBPSlotRecords returns the outcome of each slot of the periods, only of the producer if it is not nil
+func (c StatsApi) GetSBPSlotRecords(startIdx uint64, endIdx uint64, producer *types.Address) ([]*sbpstat.SlotRecord, error) {
+	stime, etime, err := c.sbpStatRange(startIdx, endIdx)
+	if err != nil {
+		return nil, err
+	}
+	return c.sbpStat.GetSlotRecords(stime, etime, producer)
+}
+
+// GetSBPSlotSummary sums up the slots of the periods by producer, including the causes of the missed slots
+func (c StatsApi) GetSBPSlotSummary(startIdx uint64, endIdx uint64) ([]*sbpstat.ProducerSummary, error) {
+	stime, etime, err := c.sbpStatRange(startIdx, endIdx)
+	if err != nil {
+		return nil, err
+	}
+	return c.sbpStat.GetProducerSummaries(stime, etime)
+}
+
+func (c StatsApi) sbpStatRange(startIdx uint64, endIdx uint64) (time.Time, time.Time, error) {
+	if c.sbpStat == nil {
+		return time.Time{}, time.Time{}, errors.New("sbp stat is disabled, set SBPStatEnabled to enable it")
+	}
+	if endIdx > startIdx && endIdx-startIdx > 48 {
+		return time.Time{}, time.Time{}, errors.New("max step is 48")
+	}
+	timeIndex := c.cs.SBPReader().GetPeriodTimeIndex()
+	if startIdx > endIdx {
+		startIdx, endIdx = c.reIndex(timeIndex)
+	}
+	stime, _ := timeIndex.Index2Time(startIdx)
+	_, etime := timeIndex.Index2Time(endIdx)
+	return stime, etime, nil
+}
+
 func (c StatsApi) reIndex(timeIndex core.TimeIndex) (uint64, uint64) {
 	startIdx := uint64(0)
 	endIdx := timeIndex.Time2Index(time.Now())
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/vm/contracts/abi"
+)
+
+type CreateVestingParam struct {
+	Beneficiary types.Address `json:"beneficiary"`
+	StartHeight string        `json:"startHeight"`
+	CliffHeight string        `json:"cliffHeight"`
+	EndHeight   string        `json:"endHeight"`
+	Revocable   bool          `json:"revocable"`
+}
+
+// GetCreateVestingData packs the call data to lock the sent tokens for the beneficiary,
+// the tokens are vested linearly from start height to end height, and nothing is vested before cliff height
+func (c *ContractApi) GetCreateVestingData(param CreateVestingParam) ([]byte, error) {
+	startHeight, err := StringToUint64(param.StartHeight)
+	if err != nil {
+		return nil, err
+	}
+	cliffHeight, err := StringToUint64(param.CliffHeight)
+	if err != nil {
+		return nil, err
+	}
+	endHeight, err := StringToUint64(param.EndHeight)
+	if err != nil {
+		return nil, err
+	}
+	return abi.ABIVesting.PackMethod(abi.MethodNameCreateVesting, param.Beneficiary, startHeight, cliffHeight, endHeight, param.Revocable)
+}
+
+// GetClaimVestingData packs the call data to claim the vested tokens by the beneficiary
+func (c *ContractApi) GetClaimVestingData(id types.Hash) ([]byte, error) {
+	return abi.ABIVesting.PackMethod(abi.MethodNameClaimVesting, id)
+}
+
+// GetRevokeVestingData packs the call data to revoke the unvested tokens by the creator
+func (c *ContractApi) GetRevokeVestingData(id types.Hash) ([]byte, error) {
+	return abi.ABIVesting.PackMethod(abi.MethodNameRevokeVesting, id)
+}
+
+type VestingInfo struct {
+	Id              types.Hash        `json:"id"`
+	Creator         types.Address     `json:"creator"`
+	Beneficiary     types.Address     `json:"beneficiary"`
+	TokenId         types.TokenTypeId `json:"tokenId"`
+	Amount          string            `json:"amount"`
+	VestedAmount    string            `json:"vestedAmount"`
+	ClaimedAmount   string            `json:"claimedAmount"`
+	ClaimableAmount string            `json:"claimableAmount"`
+	StartHeight     string            `json:"startHeight"`
+	CliffHeight     string            `json:"cliffHeight"`
+	EndHeight       string            `json:"endHeight"`
+	Revocable       bool              `json:"revocable"`
+	RevokeHeight    string            `json:"revokeHeight"`
+}
+
+func newVestingInfo(info *abi.VestingInfo, sb *ledger.SnapshotBlock) *VestingInfo {
+	return &VestingInfo{
+		Id:              info.Id,
+		Creator:         info.Creator,
+		Beneficiary:     info.Beneficiary,
+		TokenId:         info.TokenId,
+		Amount:          *bigIntToString(info.Amount),
+		VestedAmount:    *bigIntToString(info.VestedAmount(sb.Height)),
+		ClaimedAmount:   *bigIntToString(info.ClaimedAmount),
+		ClaimableAmount: *bigIntToString(info.ClaimableAmount(sb.Height)),
+		StartHeight:     Uint64ToString(info.StartHeight),
+		CliffHeight:     Uint64ToString(info.CliffHeight),
+		EndHeight:       Uint64ToString(info.EndHeight),
+		Revocable:       info.Revocable,
+		RevokeHeight:    Uint64ToString(info.RevokeHeight),
+	}
+}
+
+// GetVestingInfo returns the schedule of a vesting by id, which is the hash of the create vesting send block
+func (c *ContractApi) GetVestingInfo(id types.Hash) (*VestingInfo, error) {
+	db, err := getVmDb(c.chain, types.AddressVesting)
+	if err != nil {
+		return nil, err
+	}
+	info, err := abi.GetVestingInfo(db, id)
+	if err != nil || info == nil {
+		return nil, err
+	}
+	sb, err := db.LatestSnapshotBlock()
+	if err != nil {
+		return nil, err
+	}
+	return newVestingInfo(info, sb), nil
+}
+
+// GetVestingList returns the schedules of all vestings of the beneficiary which are not fully claimed
+func (c *ContractApi) GetVestingList(beneficiary types.Address) ([]*VestingInfo, error) {
+	db, err := getVmDb(c.chain, types.AddressVesting)
+	if err != nil {
+		return nil, err
+	}
+	list, err := abi.GetVestingInfoList(db, beneficiary)
+	if err != nil {
+		return nil, err
+	}
+	sb, err := db.LatestSnapshotBlock()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*VestingInfo, len(list))
+	for i, info := range list {
+		result[i] = newVestingInfo(info, sb)
+	}
+	return result, nil
+}
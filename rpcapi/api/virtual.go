@@ -2,24 +2,30 @@ package api
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/vitelabs/go-vite/v2"
 	"github.com/vitelabs/go-vite/v2/common/upgrade"
 	"github.com/vitelabs/go-vite/v2/ledger/chain"
 	"github.com/vitelabs/go-vite/v2/ledger/consensus"
+	"github.com/vitelabs/go-vite/v2/ledger/pool"
+	"github.com/vitelabs/go-vite/v2/producer"
 )
 
 type VirtualApi struct {
-	vite  *vite.Vite
-	chain chain.Chain
-	cs    consensus.Consensus
+	chain    chain.Chain
+	cs       consensus.Consensus
+	pool     pool.BlockPool
+	producer producer.Producer
 }
 
 func NewVirtualApi(vite *vite.Vite) *VirtualApi {
 	return &VirtualApi{
-		vite:  vite,
-		chain: vite.Chain(),
-		cs:    vite.Consensus(),
+		chain:    vite.Chain(),
+		cs:       vite.Consensus(),
+		pool:     vite.Pool(),
+		producer: vite.Producer(),
 	}
 }
 
@@ -28,7 +34,7 @@ func (api VirtualApi) String() string {
 }
 
 func (api *VirtualApi) Mine() error {
-	return api.vite.Producer().SnapshotOnce()
+	return api.producer.SnapshotOnce()
 }
 
 func (api *VirtualApi) MineBatch(number uint64) error {
@@ -37,7 +43,7 @@ func (api *VirtualApi) MineBatch(number uint64) error {
 	}
 
 	for i := uint64(0); i < number; i++ {
-		err := api.vite.Producer().SnapshotOnce()
+		err := api.producer.SnapshotOnce()
 		if err != nil {
 			return err
 		}
@@ -48,3 +54,66 @@ func (api *VirtualApi) MineBatch(number uint64) error {
 func (api *VirtualApi) AddUpgrade(version uint32, height uint64) error {
 	return upgrade.AddUpgradePoint(version, height)
 }
+
+// SetTime sets the clock of the virtual node, the following blocks are produced from the timestamp(unix seconds)
+func (api *VirtualApi) SetTime(timestamp int64) error {
+	t := time.Unix(timestamp, 0)
+	head := api.chain.GetLatestSnapshotBlock()
+	if !t.After(*head.Timestamp) {
+		return fmt.Errorf("time must be after the latest snapshot block, %s", head.Timestamp)
+	}
+	api.producer.SetTime(t)
+	return nil
+}
+
+// IncreaseTime moves the clock of the virtual node forward by seconds, and returns the new time(unix seconds)
+func (api *VirtualApi) IncreaseTime(seconds uint64) (int64, error) {
+	t := api.producer.Now().Add(time.Duration(seconds) * time.Second)
+	api.producer.SetTime(t)
+	return t.Unix(), nil
+}
+
+// MineToCycle moves the clock to the start of the cycle and mines a snapshot block in it
+func (api *VirtualApi) MineToCycle(cycle uint64) error {
+	index := api.cs.SBPReader().GetDayTimeIndex()
+	current := index.Time2Index(api.producer.Now())
+	if head := api.chain.GetLatestSnapshotBlock(); index.Time2Index(*head.Timestamp) > current {
+		current = index.Time2Index(*head.Timestamp)
+	}
+	if cycle <= current {
+		return fmt.Errorf("cycle must be greater than the current cycle %d", current)
+	}
+	stime, _ := index.Index2Time(cycle)
+	api.producer.SetTime(stime)
+	return api.Mine()
+}
+
+// Snapshot returns the snapshot id, which is the height of the latest snapshot block. A snapshot block is mined
+// only if there are unconfirmed blocks, so taking snapshots repeatedly doesn't change the chain or the clock
+func (api *VirtualApi) Snapshot() (uint64, error) {
+	if len(api.chain.GetAllUnconfirmedBlocks()) > 0 {
+		if err := api.Mine(); err != nil {
+			return 0, err
+		}
+		if len(api.chain.GetAllUnconfirmedBlocks()) > 0 {
+			return 0, errors.New("there are unconfirmed blocks after mining")
+		}
+	}
+	return api.chain.GetLatestSnapshotBlock().Height, nil
+}
+
+// Revert restores the chain to the snapshot id, the blocks after it are deleted and the clock is set back
+func (api *VirtualApi) Revert(id uint64) error {
+	head := api.chain.GetLatestSnapshotBlock()
+	if id == 0 || id > head.Height {
+		return fmt.Errorf("invalid snapshot id %d, the latest height is %d", id, head.Height)
+	}
+	if id < head.Height {
+		if err := api.pool.RollbackSnapshotTo(id + 1); err != nil {
+			return err
+		}
+	}
+	head = api.chain.GetLatestSnapshotBlock()
+	api.producer.SetTime(head.Timestamp.Add(time.Second))
+	return nil
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/fileutils"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/common/upgrade"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/ledger/chain"
+	"github.com/vitelabs/go-vite/v2/ledger/consensus"
+	"github.com/vitelabs/go-vite/v2/ledger/generator"
+	"github.com/vitelabs/go-vite/v2/ledger/pool"
+	"github.com/vitelabs/go-vite/v2/ledger/verifier"
+	"github.com/vitelabs/go-vite/v2/net"
+	"github.com/vitelabs/go-vite/v2/producer"
+	"github.com/vitelabs/go-vite/v2/vm"
+	"github.com/vitelabs/go-vite/v2/vm/quota"
+	"github.com/vitelabs/go-vite/v2/wallet"
+)
+
+func newTestVirtualApi(t *testing.T, rich *wallet.Account) (*VirtualApi, func()) {
+	upgrade.CleanupUpgradeBox()
+	upgrade.InitUpgradeBox(upgrade.NewLatestUpgradeBox())
+	quota.InitQuotaConfig(false, true)
+
+	genesis := config.MockGenesis()
+	amount := new(big.Int).Mul(big.NewInt(100000), big.NewInt(1e18))
+	genesis.AccountBalanceMap[rich.Address().String()] = map[string]*big.Int{ledger.ViteTokenId.String(): amount}
+	genesis.QuotaInfo.StakeBeneficialMap[rich.Address().String()] = amount
+
+	tmpDir := fileutils.CreateTempDir()
+	vm.InitVMConfig(false, false, false, false, tmpDir)
+	c := chain.NewChain(tmpDir, &config.Chain{}, genesis)
+	assert.NoError(t, c.Init())
+	assert.NoError(t, c.Start())
+
+	p, err := pool.NewPool(c)
+	assert.NoError(t, err)
+	cs := consensus.NewConsensus(c, p)
+	assert.NoError(t, cs.Init(nil))
+	cs.Start()
+
+	coinbase, err := wallet.RandomAccount()
+	assert.NoError(t, err)
+	pr := producer.NewProducer(c, net.Mock(c), coinbase, cs, p)
+	v := verifier.NewVerifier(c).Init(consensus.NewVirtualVerifier(), cs.SBPReader(), nil)
+	v.SetClock(pr.Now)
+	p.Init(net.Mock(c), v, cs.SBPReader())
+	assert.NoError(t, pr.Init())
+	p.Start()
+
+	api := &VirtualApi{chain: c, cs: cs, pool: p, producer: pr}
+	return api, func() {
+		p.Stop()
+		cs.Stop()
+		c.Stop()
+	}
+}
+
+func sendTestTx(t *testing.T, api *VirtualApi, from *wallet.Account, to types.Address) *ledger.AccountBlock {
+	addr := from.Address()
+	msg := &interfaces.IncomingMessage{
+		BlockType:      ledger.BlockTypeSendCall,
+		AccountAddress: addr,
+		ToAddress:      &to,
+		TokenId:        &ledger.ViteTokenId,
+		Amount:         big.NewInt(1),
+	}
+	addrState, err := generator.GetAddressStateForGenerator(api.chain, &addr)
+	assert.NoError(t, err)
+	g, err := generator.NewGenerator(api.chain, api.cs.SBPReader(), addr, addrState.LatestSnapshotHash, addrState.LatestAccountHash)
+	assert.NoError(t, err)
+	result, err := g.GenerateWithMessage(msg, &addr, from.Sign)
+	assert.NoError(t, err)
+	assert.NoError(t, result.Err)
+	assert.NoError(t, api.pool.AddDirectAccountBlock(addr, result.VMBlock))
+	return result.VMBlock.AccountBlock
+}
+
+func TestVirtualApi_Revert(t *testing.T) {
+	rich, err := wallet.RandomAccount()
+	assert.NoError(t, err)
+	to, err := wallet.RandomAccount()
+	assert.NoError(t, err)
+	api, stop := newTestVirtualApi(t, rich)
+	defer stop()
+
+	id, err := api.Snapshot()
+	assert.NoError(t, err)
+	snapshotTime := *api.chain.GetLatestSnapshotBlock().Timestamp
+
+	_, err = api.IncreaseTime(3600)
+	assert.NoError(t, err)
+	block := sendTestTx(t, api, rich, to.Address())
+	assert.NoError(t, api.Mine())
+	assert.Equal(t, id+1, api.chain.GetLatestSnapshotBlock().Height)
+	confirmed, err := api.chain.GetConfirmSnapshotHeaderByAbHash(block.Hash)
+	assert.NoError(t, err)
+	assert.NotNil(t, confirmed)
+
+	assert.NoError(t, api.Revert(id))
+	assert.Equal(t, id, api.chain.GetLatestSnapshotBlock().Height)
+	// the clock is set back to the snapshot
+	assert.True(t, api.producer.Now().Before(snapshotTime.Add(time.Minute)))
+
+	// the reverted blocks are not inserted again by the pool
+	assert.NoError(t, api.Mine())
+	time.Sleep(time.Second)
+	assert.NoError(t, api.Mine())
+	reverted, err := api.chain.GetAccountBlockByHash(block.Hash)
+	assert.NoError(t, err)
+	assert.Nil(t, reverted)
+	assert.Empty(t, api.chain.GetAllUnconfirmedBlocks())
+	latest, err := api.chain.GetLatestAccountBlock(rich.Address())
+	assert.NoError(t, err)
+	assert.True(t, latest == nil || latest.Height < block.Height)
+}
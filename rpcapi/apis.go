@@ -36,6 +36,7 @@ const (
 	DATA
 	LEDGERDEBUG
 	VIRTUAL
+	ADMIN
 	apiTypeLimit // this will be the last ApiType + 1
 )
 
@@ -66,6 +67,7 @@ var apiTypeStrings = []string{
 	"data",
 	"ledgerdebug",
 	"virtual",
+	"admin",
 }
 
 func (at ApiType) name() string {
@@ -272,6 +274,13 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewVirtualApi(vite),
 			Public:    false,
 		}
+	case ApiType(ADMIN).name():
+		return rpc.API{
+			Namespace: "admin",
+			Version:   "1.0",
+			Service:   api.NewAdminApi(vite),
+			Public:    false,
+		}
 	default:
 		return rpc.API{Namespace: apiModule}
 	}
@@ -11,8 +11,10 @@ import (
 	"github.com/vitelabs/go-vite/v2/common/upgrade"
 	"github.com/vitelabs/go-vite/v2/ledger/chain"
 	"github.com/vitelabs/go-vite/v2/ledger/consensus"
+	"github.com/vitelabs/go-vite/v2/ledger/exporter"
 	"github.com/vitelabs/go-vite/v2/ledger/onroad"
 	"github.com/vitelabs/go-vite/v2/ledger/pool"
+	"github.com/vitelabs/go-vite/v2/ledger/sbpstat"
 	"github.com/vitelabs/go-vite/v2/ledger/verifier"
 	"github.com/vitelabs/go-vite/v2/log15"
 	"github.com/vitelabs/go-vite/v2/net"
@@ -36,6 +38,8 @@ type Vite struct {
 	pool          pool.BlockPool
 	consensus     consensus.Consensus
 	onRoad        *onroad.Manager
+	exporter      *exporter.Exporter
+	sbpStat       *sbpstat.Tracker
 }
 
 func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err error) {
@@ -91,10 +95,31 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 	}
 
 	if account != nil {
-		vite.producer = producer.NewProducer(chain, net, account, cs, pl)
+		p := producer.NewProducer(chain, net, account, cs, pl)
+		if err = p.SetFailover(cfg.DataDir, cfg.Producer); err != nil {
+			log.Error("set producer failover failed, error is "+err.Error(), "method", "vite.New")
+			return nil, err
+		}
+		vite.producer = p
 	}
 	// set onroad
 	vite.onRoad = onroad.NewManager(net, pl, vite.producer, vite.consensus.SBPReader(), account)
+
+	// exporter
+	if cfg.Exporter != nil && cfg.Exporter.Enabled {
+		if vite.exporter, err = exporter.New(cfg.Exporter, chain); err != nil {
+			log.Error("new exporter failed, error is "+err.Error(), "method", "vite.New")
+			return nil, err
+		}
+	}
+
+	// sbp stat
+	if cfg.SBPStat != nil && cfg.SBPStat.Enabled {
+		if vite.sbpStat, err = sbpstat.New(cfg.SBPStat, chain, cs, net); err != nil {
+			log.Error("new sbp stat failed, error is "+err.Error(), "method", "vite.New")
+			return nil, err
+		}
+	}
 	return
 }
 
@@ -113,6 +138,9 @@ func (v *Vite) Init() (err error) {
 	v.onRoad.Init(v.chain)
 	if v.config.Producer.VirtualSnapshotVerifier {
 		v.verifier.Init(consensus.NewVirtualVerifier(), v.Consensus().SBPReader(), v.onRoad)
+		if v.producer != nil {
+			v.verifier.SetClock(v.producer.Now)
+		}
 	} else {
 		v.verifier.Init(v.consensus, v.Consensus().SBPReader(), v.onRoad)
 	}
@@ -124,6 +152,13 @@ func (v *Vite) Start() (err error) {
 
 	v.chain.Start()
 
+	if v.exporter != nil {
+		if err := v.exporter.Start(); err != nil {
+			log.Error("exporter.Start failed, error is "+err.Error(), "method", "vite.Start")
+			return err
+		}
+	}
+
 	err = v.consensus.Init(consensus.Cfg())
 	if err != nil {
 		return err
@@ -135,6 +170,13 @@ func (v *Vite) Start() (err error) {
 
 	v.consensus.Start()
 
+	if v.sbpStat != nil {
+		if err := v.sbpStat.Start(); err != nil {
+			log.Error("sbpStat.Start failed, error is "+err.Error(), "method", "vite.Start")
+			return err
+		}
+	}
+
 	err = v.net.Start()
 	if err != nil {
 		return
@@ -154,6 +196,9 @@ func (v *Vite) Start() (err error) {
 func (v *Vite) Stop() (err error) {
 
 	v.net.Stop()
+	if v.sbpStat != nil {
+		v.sbpStat.Stop()
+	}
 	v.pool.Stop()
 
 	if v.producer != nil {
@@ -163,6 +208,9 @@ func (v *Vite) Stop() (err error) {
 		}
 	}
 	v.consensus.Stop()
+	if v.exporter != nil {
+		v.exporter.Stop()
+	}
 	v.chain.Stop()
 	v.onRoad.Stop()
 	return nil
@@ -172,6 +220,16 @@ func (v *Vite) Chain() chain.Chain {
 	return v.chain
 }
 
+// Exporter returns nil if the exporter is disabled, sinks can be added to it before vite starts
+func (v *Vite) Exporter() *exporter.Exporter {
+	return v.exporter
+}
+
+// SBPStat returns nil if the sbp stat is disabled
+func (v *Vite) SBPStat() *sbpstat.Tracker {
+	return v.sbpStat
+}
+
 func (v *Vite) Net() net.Net {
 	return v.net
 }
@@ -0,0 +1,90 @@
+//go:build go1.18
+// +build go1.18
+
+package abi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fuzzDefs are the argument lists decoded by FuzzUnpackValues, the defs of unpackTests
+// are appended in init
+var fuzzDefs = []string{
+	`[{"type":"string"}]`,
+	`[{"type":"tokenId"},{"type":"gid"},{"type":"address"}]`,
+	`[{"type":"string"},{"type":"bytes"},{"type":"uint256[]"}]`,
+	`[{"type":"bool"},{"type":"uint8"},{"type":"int256"},{"type":"bytes32"},{"type":"string"}]`,
+	`[{"type":"address[]"},{"type":"tokenId[]"},{"type":"uint64"}]`,
+	`[{"type":"bytes8[2]"},{"type":"string"},{"type":"int8"}]`,
+}
+
+func init() {
+	for _, test := range unpackTests {
+		if fuzzDefIndex(test.def) < 0 {
+			fuzzDefs = append(fuzzDefs, test.def)
+		}
+	}
+}
+
+func fuzzDefIndex(def string) int {
+	for i, d := range fuzzDefs {
+		if d == def {
+			return i
+		}
+	}
+	return -1
+}
+
+func fuzzArguments(def string) (Arguments, error) {
+	abi, err := JSONToABIContract(strings.NewReader(fmt.Sprintf(`[{"name":"method","type":"function","inputs":%s}]`, def)))
+	if err != nil {
+		return nil, err
+	}
+	return abi.Methods["method"].Inputs, nil
+}
+
+// FuzzUnpackValues decodes random data, the decoder must not panic, and the decoded values
+// must be packed into the canonical data which is decoded into the same values.
+func FuzzUnpackValues(f *testing.F) {
+	for i := range fuzzDefs {
+		f.Add(uint8(i), make([]byte, 32*4))
+	}
+	for _, test := range unpackTests {
+		enc, err := hex.DecodeString(test.enc)
+		if err != nil {
+			f.Fatalf("invalid hex: %s", test.enc)
+		}
+		f.Add(uint8(fuzzDefIndex(test.def)), enc)
+	}
+
+	f.Fuzz(func(t *testing.T, index uint8, data []byte) {
+		def := fuzzDefs[int(index)%len(fuzzDefs)]
+		arguments, err := fuzzArguments(def)
+		if err != nil {
+			t.Fatalf("invalid ABI definition %s: %v", def, err)
+		}
+		values, err := arguments.UnpackValues(data)
+		if err != nil {
+			return
+		}
+		packed, err := arguments.PackValues(values)
+		if err != nil {
+			t.Fatalf("%s: pack the unpacked values %v failed, %v\ndata: %x", def, values, err, data)
+		}
+		repacked, err := arguments.UnpackValues(packed)
+		if err != nil {
+			t.Fatalf("%s: unpack the packed values %v failed, %v\ndata: %x\npacked: %x", def, values, err, data, packed)
+		}
+		if !reflect.DeepEqual(values, repacked) {
+			t.Fatalf("%s: values changed after the round trip, expected %v, got %v\ndata: %x\npacked: %x", def, values, repacked, data, packed)
+		}
+		if packedAgain, err := arguments.PackValues(repacked); err != nil || !bytes.Equal(packed, packedAgain) {
+			t.Fatalf("%s: packed data changed after the round trip, expected %x, got %x, %v", def, packed, packedAgain, err)
+		}
+	})
+}
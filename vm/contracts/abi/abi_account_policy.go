@@ -0,0 +1,163 @@
+package abi
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/vitelabs/go-vite/v2/common/helper"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/vm/abi"
+	"github.com/vitelabs/go-vite/v2/vm/util"
+)
+
+const (
+	jsonAccountPolicy = `
+	[
+		{"type":"function","name":"SetAccountPolicy", "inputs":[{"name":"tokenIds","type":"tokenId[]"},{"name":"dailyLimits","type":"uint256[]"},{"name":"allowlist","type":"address[]"},{"name":"recoveryAddress","type":"address"}]},
+		{"type":"function","name":"InitiateRecovery", "inputs":[{"name":"account","type":"address"}]},
+		{"type":"function","name":"CancelRecovery", "inputs":[]},
+
+		{"type":"variable","name":"accountPolicy","inputs":[{"name":"tokenIds","type":"tokenId[]"},{"name":"dailyLimits","type":"uint256[]"},{"name":"allowlist","type":"address[]"},{"name":"recoveryAddress","type":"address"},{"name":"effectiveHeight","type":"uint64"}]},
+		{"type":"variable","name":"accountRecovery","inputs":[{"name":"recoveryHeight","type":"uint64"}]},
+		{"type":"variable","name":"accountSpending","inputs":[{"name":"period","type":"uint64"},{"name":"amount","type":"uint256"}]},
+
+		{"type":"event","name":"setAccountPolicy","inputs":[{"name":"account","type":"address","indexed":true},{"name":"effectiveHeight","type":"uint64"}]},
+		{"type":"event","name":"initiateRecovery","inputs":[{"name":"account","type":"address","indexed":true},{"name":"recoveryAddress","type":"address"},{"name":"recoveryHeight","type":"uint64"}]},
+		{"type":"event","name":"cancelRecovery","inputs":[{"name":"account","type":"address","indexed":true}]}
+	]`
+
+	MethodNameSetAccountPolicy  = "SetAccountPolicy"
+	MethodNameInitiateRecovery  = "InitiateRecovery"
+	MethodNameCancelRecovery    = "CancelRecovery"
+	VariableNameAccountPolicy   = "accountPolicy"
+	VariableNameAccountRecovery = "accountRecovery"
+	VariableNameAccountSpending = "accountSpending"
+)
+
+var (
+	// ABIAccountPolicy is abi definition of account policy contract
+	ABIAccountPolicy, _ = abi.JSONToABIContract(strings.NewReader(jsonAccountPolicy))
+
+	accountPolicyPendingKeySuffix  = []byte{'p'}
+	accountPolicyRecoveryKeySuffix = []byte{'r'}
+	accountSpendingKeyPrefix       = []byte{0xfd}
+)
+
+// ParamSetAccountPolicy defines parameters of set account policy method in account policy contract
+type ParamSetAccountPolicy struct {
+	TokenIds        []types.TokenTypeId
+	DailyLimits     []*big.Int
+	Allowlist       []types.Address
+	RecoveryAddress types.Address
+}
+
+// AccountSpending is the amount of a token sent by an account in a period, saved in the storage of the account
+type AccountSpending struct {
+	Period uint64
+	Amount *big.Int
+}
+
+// GetAccountPolicyKey generate db key for the policy in effect of an account
+func GetAccountPolicyKey(account types.Address) []byte {
+	return account.Bytes()
+}
+
+// GetAccountPolicyPendingKey generate db key for the policy of an account waiting for the delay
+func GetAccountPolicyPendingKey(account types.Address) []byte {
+	return helper.JoinBytes(account.Bytes(), accountPolicyPendingKeySuffix)
+}
+
+// GetAccountRecoveryKey generate db key for the recovery initiated by the recovery address of an account
+func GetAccountRecoveryKey(account types.Address) []byte {
+	return helper.JoinBytes(account.Bytes(), accountPolicyRecoveryKeySuffix)
+}
+
+// GetAccountSpendingKey generate db key for the spending of a token, the key is in the storage of the account
+func GetAccountSpendingKey(tokenId types.TokenTypeId) []byte {
+	return helper.JoinBytes(accountSpendingKeyPrefix, tokenId.Bytes())
+}
+
+func unpackAccountPolicy(data []byte) (*types.AccountPolicy, error) {
+	policy := new(types.AccountPolicy)
+	if err := ABIAccountPolicy.UnpackVariable(policy, VariableNameAccountPolicy, data); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// GetSavedAccountPolicy query the policy in effect saved by the contract and the pending policy of an account
+func GetSavedAccountPolicy(db StorageDatabase, account types.Address) (policy *types.AccountPolicy, pending *types.AccountPolicy, err error) {
+	if *db.Address() != types.AddressAccountPolicy {
+		return nil, nil, util.ErrAddressNotMatch
+	}
+	if data, err := db.GetValue(GetAccountPolicyKey(account)); err != nil {
+		return nil, nil, err
+	} else if len(data) > 0 {
+		if policy, err = unpackAccountPolicy(data); err != nil {
+			return nil, nil, err
+		}
+	}
+	if data, err := db.GetValue(GetAccountPolicyPendingKey(account)); err != nil {
+		return nil, nil, err
+	} else if len(data) > 0 {
+		if pending, err = unpackAccountPolicy(data); err != nil {
+			return nil, nil, err
+		}
+	}
+	return policy, pending, nil
+}
+
+// GetAccountRecoveryHeight query the snapshot height the recovery of an account takes effect, 0 if not initiated
+func GetAccountRecoveryHeight(db StorageDatabase, account types.Address) (uint64, error) {
+	if *db.Address() != types.AddressAccountPolicy {
+		return 0, util.ErrAddressNotMatch
+	}
+	data, err := db.GetValue(GetAccountRecoveryKey(account))
+	if err != nil || len(data) == 0 {
+		return 0, err
+	}
+	height := new(uint64)
+	if err := ABIAccountPolicy.UnpackVariable(height, VariableNameAccountRecovery, data); err != nil {
+		return 0, err
+	}
+	return *height, nil
+}
+
+// GetAccountPolicy query the policy of an account at snapshot height, the pending policy takes
+// effect once the delay passes. Return nil if the account has no policy
+func GetAccountPolicy(db StorageDatabase, account types.Address, sbHeight uint64) (*types.AccountPolicy, error) {
+	policy, pending, err := GetSavedAccountPolicy(db, account)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil && pending.EffectiveHeight <= sbHeight {
+		policy = pending
+	}
+	if policy == nil || IsEmptyAccountPolicy(policy) {
+		return nil, nil
+	}
+	if policy.RecoveryHeight, err = GetAccountRecoveryHeight(db, account); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// IsEmptyAccountPolicy checks whether the policy restricts nothing
+func IsEmptyAccountPolicy(policy *types.AccountPolicy) bool {
+	return len(policy.TokenIds) == 0 && len(policy.Allowlist) == 0 && policy.RecoveryAddress.IsZero()
+}
+
+// GetAccountSpending query the spending of a token in the storage of the account
+func GetAccountSpending(db StorageDatabase, tokenId types.TokenTypeId) (*AccountSpending, error) {
+	spending := &AccountSpending{Amount: big.NewInt(0)}
+	data, err := db.GetValue(GetAccountSpendingKey(tokenId))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := ABIAccountPolicy.UnpackVariable(spending, VariableNameAccountSpending, data); err != nil {
+			return nil, err
+		}
+	}
+	return spending, nil
+}
@@ -44,7 +44,20 @@ const (
 		{"type":"function","name":"CancelVote","inputs":[{"name":"gid","type":"gid"}]},
 		{"type":"function","name":"CancelSBPVoting","inputs":[]},
 
-		{"type":"variable","name":"voteInfo","inputs":[{"name":"sbpName","type":"string"}]}
+		{"type":"variable","name":"voteInfo","inputs":[{"name":"sbpName","type":"string"}]},
+
+		{"type":"function","name":"ProposeParamChange", "inputs":[{"name":"sbpName","type":"string"},{"name":"paramId","type":"uint8"},{"name":"value","type":"uint256"},{"name":"effectiveHeight","type":"uint64"}]},
+		{"type":"function","name":"VoteForParamChange", "inputs":[{"name":"sbpName","type":"string"},{"name":"proposalId","type":"uint64"},{"name":"approve","type":"bool"}]},
+
+		{"type":"variable","name":"paramProposalCount","inputs":[{"name":"count","type":"uint64"}]},
+		{"type":"variable","name":"paramProposal","inputs":[{"name":"proposer","type":"string"},{"name":"paramId","type":"uint8"},{"name":"value","type":"uint256"},{"name":"effectiveHeight","type":"uint64"},{"name":"voteIndex","type":"uint64"},{"name":"approveWeight","type":"uint256"},{"name":"rejectWeight","type":"uint256"},{"name":"status","type":"uint8"}]},
+		{"type":"variable","name":"paramProposalVote","inputs":[{"name":"approve","type":"bool"}]},
+		{"type":"variable","name":"governanceParam","inputs":[{"name":"heights","type":"uint64[]"},{"name":"values","type":"uint256[]"}]},
+
+		{"type":"event","name":"proposeParamChange","inputs":[{"name":"proposalId","type":"uint64","indexed":true},{"name":"sbpName","type":"string"},{"name":"paramId","type":"uint8"},{"name":"value","type":"uint256"},{"name":"effectiveHeight","type":"uint64"}]},
+		{"type":"event","name":"voteForParamChange","inputs":[{"name":"proposalId","type":"uint64","indexed":true},{"name":"sbpName","type":"string"},{"name":"approve","type":"bool"},{"name":"weight","type":"uint256"}]},
+		{"type":"event","name":"paramChangeApproved","inputs":[{"name":"proposalId","type":"uint64","indexed":true},{"name":"paramId","type":"uint8"},{"name":"value","type":"uint256"},{"name":"effectiveHeight","type":"uint64"}]},
+		{"type":"event","name":"paramChangeRejected","inputs":[{"name":"proposalId","type":"uint64","indexed":true}]}
 	]`
 
 	VariableNameConsensusGroupInfo = "consensusGroupInfo"
@@ -72,6 +85,15 @@ const (
 	MethodNameCancelVoteV3 = "CancelSBPVoting"
 	VariableNameVoteInfo   = "voteInfo"
 
+	MethodNameProposeParamChange   = "ProposeParamChange"
+	MethodNameVoteForParamChange   = "VoteForParamChange"
+	VariableNameParamProposalCount = "paramProposalCount"
+	VariableNameParamProposal      = "paramProposal"
+	VariableNameParamProposalVote  = "paramProposalVote"
+	VariableNameGovernanceParam    = "governanceParam"
+	EventNameParamChangeApproved   = "paramChangeApproved"
+	EventNameParamChangeRejected   = "paramChangeRejected"
+
 	groupInfoKeyPrefixSize    = 1
 	voteInfoKeyPrefixSize     = 1
 	consensusGroupInfoKeySize = groupInfoKeyPrefixSize + types.GidSize                    // 11byte, 1 + 10byte gid
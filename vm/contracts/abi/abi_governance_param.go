@@ -0,0 +1,205 @@
+package abi
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/v2/common/helper"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/vm/util"
+)
+
+const (
+	// ParamProposalStatusVoting means the proposal is waiting for votes
+	ParamProposalStatusVoting uint8 = 0
+	// ParamProposalStatusApproved means the proposal is approved and the value takes effect at effective height
+	ParamProposalStatusApproved uint8 = 1
+	// ParamProposalStatusRejected means the proposal is rejected before approved
+	ParamProposalStatusRejected uint8 = 2
+	// ParamProposalStatusExpired means the proposal reaches the effective height while voting,
+	// it's derived from the snapshot height and never saved
+	ParamProposalStatusExpired uint8 = 3
+
+	paramProposalKeySize     = 10 // 10byte, 0xfe + 'p' + 8byte proposal id
+	paramProposalVoteKeySize = 29 // 29byte, 0xfe + 'v' + 8byte proposal id + 19byte name hash
+)
+
+var (
+	paramProposalCountKey      = []byte{0xfe, 'c'}
+	paramProposalKeyPrefix     = []byte{0xfe, 'p'}
+	paramProposalVoteKeyPrefix = []byte{0xfe, 'v'}
+	governanceParamKeyPrefix   = []byte{0xfe, 'e'}
+)
+
+type ParamProposeParamChange struct {
+	SbpName         string
+	ParamId         uint8
+	Value           *big.Int
+	EffectiveHeight uint64
+}
+
+type ParamVoteForParamChange struct {
+	SbpName    string
+	ProposalId uint64
+	Approve    bool
+}
+
+// ParamProposal is a governance param change proposed by a SBP
+type ParamProposal struct {
+	Id              uint64
+	Proposer        string
+	ParamId         uint8
+	Value           *big.Int
+	EffectiveHeight uint64
+	VoteIndex       uint64 // the consensus cycle whose votes received by the SBPs weight the votes on the proposal
+	ApproveWeight   *big.Int
+	RejectWeight    *big.Int
+	Status          uint8
+}
+
+// StatusAt returns the status of the proposal at snapshot height, a proposal still voting at the effective height is expired
+func (p *ParamProposal) StatusAt(sbHeight uint64) uint8 {
+	if p.Status == ParamProposalStatusVoting && sbHeight >= p.EffectiveHeight {
+		return ParamProposalStatusExpired
+	}
+	return p.Status
+}
+
+// GovernanceParamSchedule is the approved values of a governance param ordered by effective height
+type GovernanceParamSchedule struct {
+	Heights []uint64
+	Values  []*big.Int
+}
+
+// GetParamProposalCountKey generate db key for param proposal count
+func GetParamProposalCountKey() []byte {
+	return paramProposalCountKey
+}
+
+// GetParamProposalKey generate db key for param proposal
+func GetParamProposalKey(id uint64) []byte {
+	return helper.JoinBytes(paramProposalKeyPrefix, helper.LeftPadBytes(new(big.Int).SetUint64(id).Bytes(), 8))
+}
+
+func isParamProposalKey(key []byte) bool {
+	return len(key) == paramProposalKeySize
+}
+
+func getIdFromParamProposalKey(key []byte) uint64 {
+	return new(big.Int).SetBytes(key[len(paramProposalKeyPrefix):]).Uint64()
+}
+
+// GetParamProposalVoteKey generate db key for the vote of a SBP on a param proposal
+func GetParamProposalVoteKey(id uint64, name string) []byte {
+	return helper.JoinBytes(paramProposalVoteKeyPrefix, helper.LeftPadBytes(new(big.Int).SetUint64(id).Bytes(), 8), types.DataHash([]byte(name)).Bytes()[:paramProposalVoteKeySize-10])
+}
+
+// GetGovernanceParamKey generate db key for the approved values of a governance param
+func GetGovernanceParamKey(id uint8) []byte {
+	return helper.JoinBytes(governanceParamKeyPrefix, []byte{id})
+}
+
+// GetParamProposalCount query the count of param proposals, which is also the id of the latest proposal
+func GetParamProposalCount(db StorageDatabase) (uint64, error) {
+	if *db.Address() != types.AddressGovernance {
+		return 0, util.ErrAddressNotMatch
+	}
+	data, err := db.GetValue(GetParamProposalCountKey())
+	if err != nil || len(data) == 0 {
+		return 0, err
+	}
+	count := new(uint64)
+	if err := ABIGovernance.UnpackVariable(count, VariableNameParamProposalCount, data); err != nil {
+		return 0, err
+	}
+	return *count, nil
+}
+
+// GetParamProposal query param proposal by id
+func GetParamProposal(db StorageDatabase, id uint64) (*ParamProposal, error) {
+	if *db.Address() != types.AddressGovernance {
+		return nil, util.ErrAddressNotMatch
+	}
+	data, err := db.GetValue(GetParamProposalKey(id))
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	return parseParamProposal(data, id)
+}
+
+func parseParamProposal(data []byte, id uint64) (*ParamProposal, error) {
+	proposal := new(ParamProposal)
+	if err := ABIGovernance.UnpackVariable(proposal, VariableNameParamProposal, data); err != nil {
+		return nil, err
+	}
+	proposal.Id = id
+	return proposal, nil
+}
+
+// GetParamProposalList query all param proposals ordered by id
+func GetParamProposalList(db StorageDatabase) ([]*ParamProposal, error) {
+	if *db.Address() != types.AddressGovernance {
+		return nil, util.ErrAddressNotMatch
+	}
+	iterator, err := db.NewStorageIterator(paramProposalKeyPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Release()
+	proposalList := make([]*ParamProposal, 0)
+	for {
+		if !iterator.Next() {
+			if iterator.Error() != nil {
+				return nil, iterator.Error()
+			}
+			break
+		}
+		if !filterKeyValue(iterator.Key(), iterator.Value(), isParamProposalKey) {
+			continue
+		}
+		if proposal, err := parseParamProposal(iterator.Value(), getIdFromParamProposalKey(iterator.Key())); err == nil {
+			proposalList = append(proposalList, proposal)
+		}
+	}
+	return proposalList, nil
+}
+
+// GetGovernanceParamSchedule query the approved values of a governance param
+func GetGovernanceParamSchedule(db StorageDatabase, id uint8) (*GovernanceParamSchedule, error) {
+	if *db.Address() != types.AddressGovernance {
+		return nil, util.ErrAddressNotMatch
+	}
+	data, err := db.GetValue(GetGovernanceParamKey(id))
+	if err != nil {
+		return nil, err
+	}
+	schedule := &GovernanceParamSchedule{}
+	if len(data) == 0 {
+		return schedule, nil
+	}
+	if err := ABIGovernance.UnpackVariable(schedule, VariableNameGovernanceParam, data); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// GetGovernanceParam query the approved value of a governance param taking effect at snapshot height,
+// return nil if no approved value takes effect
+func GetGovernanceParam(db StorageDatabase, id uint8, sbHeight uint64) (*big.Int, error) {
+	schedule, err := GetGovernanceParamSchedule(db, id)
+	if err != nil {
+		return nil, err
+	}
+	return schedule.ValueAt(sbHeight), nil
+}
+
+// ValueAt returns the value with the largest effective height not greater than snapshot height
+func (s *GovernanceParamSchedule) ValueAt(sbHeight uint64) *big.Int {
+	var value *big.Int
+	for i, height := range s.Heights {
+		if height > sbHeight {
+			break
+		}
+		value = s.Values[i]
+	}
+	return value
+}
@@ -13,7 +13,7 @@ import (
 )
 
 func TestContractsABIInit(t *testing.T) {
-	tests := []string{jsonQuota, jsonGovernance, jsonAsset}
+	tests := []string{jsonQuota, jsonGovernance, jsonAsset, jsonVesting, jsonAccountPolicy}
 	for _, data := range tests {
 		if _, err := abi.JSONToABIContract(strings.NewReader(data)); err != nil {
 			t.Fatalf("json to abi failed, %v, %v", data, err)
@@ -92,3 +92,23 @@ func TestRegistrationInfoVariable(t *testing.T) {
 		t.Fatalf("check registration info v2 prefix failed, expected not equal to %v, got %v", hex.EncodeToString(registerInfoValuePrefix), hex.EncodeToString(v2[:32]))
 	}
 }
+
+func TestParamProposalStatusAt(t *testing.T) {
+	tests := []struct {
+		status   uint8
+		sbHeight uint64
+		expected uint8
+	}{
+		{ParamProposalStatusVoting, 99, ParamProposalStatusVoting},
+		{ParamProposalStatusVoting, 100, ParamProposalStatusExpired},
+		{ParamProposalStatusVoting, 101, ParamProposalStatusExpired},
+		{ParamProposalStatusApproved, 100, ParamProposalStatusApproved},
+		{ParamProposalStatusRejected, 100, ParamProposalStatusRejected},
+	}
+	for _, test := range tests {
+		proposal := &ParamProposal{EffectiveHeight: 100, Status: test.status}
+		if status := proposal.StatusAt(test.sbHeight); status != test.expected {
+			t.Fatalf("status of proposal %v at height %v, expected %v, got %v", test.status, test.sbHeight, test.expected, status)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package abi
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/vitelabs/go-vite/v2/common/helper"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/vm/abi"
+	"github.com/vitelabs/go-vite/v2/vm/util"
+)
+
+const (
+	jsonVesting = `
+	[
+		{"type":"function","name":"CreateVesting", "inputs":[{"name":"beneficiary","type":"address"},{"name":"startHeight","type":"uint64"},{"name":"cliffHeight","type":"uint64"},{"name":"endHeight","type":"uint64"},{"name":"revocable","type":"bool"}]},
+		{"type":"function","name":"ClaimVesting", "inputs":[{"name":"id","type":"bytes32"}]},
+		{"type":"function","name":"RevokeVesting", "inputs":[{"name":"id","type":"bytes32"}]},
+
+		{"type":"variable","name":"vestingInfo","inputs":[{"name":"creator","type":"address"},{"name":"beneficiary","type":"address"},{"name":"tokenId","type":"tokenId"},{"name":"amount","type":"uint256"},{"name":"claimedAmount","type":"uint256"},{"name":"startHeight","type":"uint64"},{"name":"cliffHeight","type":"uint64"},{"name":"endHeight","type":"uint64"},{"name":"revocable","type":"bool"},{"name":"revokeHeight","type":"uint64"},{"name":"id","type":"bytes32"}]},
+
+		{"type":"event","name":"createVesting","inputs":[{"name":"id","type":"bytes32","indexed":true},{"name":"creator","type":"address"},{"name":"beneficiary","type":"address"},{"name":"tokenId","type":"tokenId"},{"name":"amount","type":"uint256"}]},
+		{"type":"event","name":"claimVesting","inputs":[{"name":"id","type":"bytes32","indexed":true},{"name":"beneficiary","type":"address"},{"name":"tokenId","type":"tokenId"},{"name":"amount","type":"uint256"}]},
+		{"type":"event","name":"revokeVesting","inputs":[{"name":"id","type":"bytes32","indexed":true},{"name":"creator","type":"address"},{"name":"tokenId","type":"tokenId"},{"name":"amount","type":"uint256"}]}
+	]`
+
+	MethodNameCreateVesting = "CreateVesting"
+	MethodNameClaimVesting  = "ClaimVesting"
+	MethodNameRevokeVesting = "RevokeVesting"
+	VariableNameVestingInfo = "vestingInfo"
+)
+
+var (
+	// ABIVesting is abi definition of vesting contract
+	ABIVesting, _      = abi.JSONToABIContract(strings.NewReader(jsonVesting))
+	vestingInfoKeySize = types.AddressSize + 8
+)
+
+// ParamCreateVesting defines parameters of create vesting method in vesting contract
+type ParamCreateVesting struct {
+	Beneficiary types.Address
+	StartHeight uint64
+	CliffHeight uint64
+	EndHeight   uint64
+	Revocable   bool
+}
+
+// VestingInfo defines a grant locked in vesting contract, nothing is vested before cliff height,
+// and the amount is vested linearly from start height to end height
+type VestingInfo struct {
+	Creator       types.Address
+	Beneficiary   types.Address
+	TokenId       types.TokenTypeId
+	Amount        *big.Int
+	ClaimedAmount *big.Int
+	StartHeight   uint64
+	CliffHeight   uint64
+	EndHeight     uint64
+	Revocable     bool
+	RevokeHeight  uint64
+	Id            types.Hash
+}
+
+// VestedAmount returns the amount vested at snapshot height, the vesting stops at revoke height
+func (v *VestingInfo) VestedAmount(sbHeight uint64) *big.Int {
+	if v.RevokeHeight > 0 && sbHeight > v.RevokeHeight {
+		sbHeight = v.RevokeHeight
+	}
+	if sbHeight < v.CliffHeight {
+		return big.NewInt(0)
+	}
+	if sbHeight >= v.EndHeight {
+		return new(big.Int).Set(v.Amount)
+	}
+	vested := new(big.Int).Mul(v.Amount, new(big.Int).SetUint64(sbHeight-v.StartHeight))
+	return vested.Quo(vested, new(big.Int).SetUint64(v.EndHeight-v.StartHeight))
+}
+
+// ClaimableAmount returns the vested amount not claimed yet at snapshot height
+func (v *VestingInfo) ClaimableAmount(sbHeight uint64) *big.Int {
+	return new(big.Int).Sub(v.VestedAmount(sbHeight), v.ClaimedAmount)
+}
+
+// GetVestingInfoKey generate db key for vesting info
+func GetVestingInfoKey(beneficiary types.Address, index uint64) []byte {
+	return append(beneficiary.Bytes(), helper.LeftPadBytes(new(big.Int).SetUint64(index).Bytes(), 8)...)
+}
+
+// GetVestingInfoKeyPrefix is used for db iterator
+func GetVestingInfoKeyPrefix(beneficiary types.Address) []byte {
+	return beneficiary.Bytes()
+}
+
+// IsVestingInfoKey check whether a db key is vesting info key
+func IsVestingInfoKey(key []byte) bool {
+	return len(key) == vestingInfoKeySize
+}
+
+// UnpackVestingInfo decode vesting info
+func UnpackVestingInfo(data []byte) (*VestingInfo, error) {
+	info := new(VestingInfo)
+	if err := ABIVesting.UnpackVariable(info, VariableNameVestingInfo, data); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// GetVestingInfoKeyById query db key of vesting info by id, return nil if not exists
+func GetVestingInfoKeyById(db StorageDatabase, id types.Hash) ([]byte, error) {
+	if *db.Address() != types.AddressVesting {
+		return nil, util.ErrAddressNotMatch
+	}
+	key, err := db.GetValue(id.Bytes())
+	if err != nil || len(key) == 0 {
+		return nil, err
+	}
+	return key, nil
+}
+
+// GetVestingInfo query vesting info by id
+func GetVestingInfo(db StorageDatabase, id types.Hash) (*VestingInfo, error) {
+	key, err := GetVestingInfoKeyById(db, id)
+	if err != nil || key == nil {
+		return nil, err
+	}
+	data, err := db.GetValue(key)
+	if err != nil || len(data) == 0 {
+		return nil, err
+	}
+	return UnpackVestingInfo(data)
+}
+
+// GetVestingInfoList query vesting info list by beneficiary
+func GetVestingInfoList(db StorageDatabase, beneficiary types.Address) ([]*VestingInfo, error) {
+	if *db.Address() != types.AddressVesting {
+		return nil, util.ErrAddressNotMatch
+	}
+	iterator, err := db.NewStorageIterator(GetVestingInfoKeyPrefix(beneficiary))
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Release()
+	vestingInfoList := make([]*VestingInfo, 0)
+	for {
+		if !iterator.Next() {
+			if iterator.Error() != nil {
+				return nil, iterator.Error()
+			}
+			break
+		}
+		if !filterKeyValue(iterator.Key(), iterator.Value(), IsVestingInfoKey) {
+			continue
+		}
+		if info, err := UnpackVestingInfo(iterator.Value()); err == nil {
+			vestingInfoList = append(vestingInfoList, info)
+		}
+	}
+	return vestingInfoList, nil
+}
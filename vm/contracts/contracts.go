@@ -62,7 +62,7 @@ var (
 	dexRobotContracts        = newDexRobotContracts()
 	dexStableMarketContracts = newDexStableMarketContracts()
 	dexEnrichOrderContracts  = newDexEnrichOrderContracts()
-	dexCrossTransferContracts  = newDexCrossTransferContracts()
+	accountPolicyContracts   = newAccountPolicyContracts()
 )
 
 func newSimpleContracts() map[types.Address]*builtinContract {
@@ -249,11 +249,44 @@ func newDexCrossTransferContracts() map[types.Address]*builtinContract {
 	return contracts
 }
 
+func newGovernanceParamContracts() map[types.Address]*builtinContract {
+	contracts := newDexCrossTransferContracts()
+	contracts[types.AddressGovernance].m[cabi.MethodNameProposeParamChange] = &MethodProposeParamChange{cabi.MethodNameProposeParamChange}
+	contracts[types.AddressGovernance].m[cabi.MethodNameVoteForParamChange] = &MethodVoteForParamChange{cabi.MethodNameVoteForParamChange}
+	return contracts
+}
+
+func newVestingContracts() map[types.Address]*builtinContract {
+	contracts := newGovernanceParamContracts()
+	contracts[types.AddressVesting] = &builtinContract{
+		map[string]BuiltinContractMethod{
+			cabi.MethodNameCreateVesting: &MethodCreateVesting{cabi.MethodNameCreateVesting},
+			cabi.MethodNameClaimVesting:  &MethodClaimVesting{cabi.MethodNameClaimVesting},
+			cabi.MethodNameRevokeVesting: &MethodRevokeVesting{cabi.MethodNameRevokeVesting},
+		},
+		cabi.ABIVesting,
+	}
+	return contracts
+}
+
+func newAccountPolicyContracts() map[types.Address]*builtinContract {
+	contracts := newVestingContracts()
+	contracts[types.AddressAccountPolicy] = &builtinContract{
+		map[string]BuiltinContractMethod{
+			cabi.MethodNameSetAccountPolicy: &MethodSetAccountPolicy{cabi.MethodNameSetAccountPolicy},
+			cabi.MethodNameInitiateRecovery: &MethodInitiateRecovery{cabi.MethodNameInitiateRecovery},
+			cabi.MethodNameCancelRecovery:   &MethodCancelRecovery{cabi.MethodNameCancelRecovery},
+		},
+		cabi.ABIAccountPolicy,
+	}
+	return contracts
+}
+
 // GetBuiltinContractMethod finds method instance of built-in contract method by address and method id
 func GetBuiltinContractMethod(addr types.Address, methodSelector []byte, sbHeight uint64) (BuiltinContractMethod, bool, error) {
 	var contractsMap map[types.Address]*builtinContract
 	if upgrade.IsVersionXUpgrade(sbHeight) {
-		contractsMap = dexCrossTransferContracts
+		contractsMap = accountPolicyContracts
 	} else if upgrade.IsVersion11Upgrade(sbHeight) {
 		contractsMap = dexEnrichOrderContracts
 	} else if upgrade.IsDexStableMarketUpgrade(sbHeight) {
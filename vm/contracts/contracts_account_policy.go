@@ -0,0 +1,274 @@
+package contracts
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/common/upgrade"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/v2/vm/util"
+)
+
+type MethodSetAccountPolicy struct {
+	MethodName string
+}
+
+func (p *MethodSetAccountPolicy) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodSetAccountPolicy) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodSetAccountPolicy) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.SetAccountPolicyQuota, nil
+}
+func (p *MethodSetAccountPolicy) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodSetAccountPolicy) DoSend(db interfaces.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() > 0 {
+		return util.ErrInvalidMethodParam
+	}
+	param := new(abi.ParamSetAccountPolicy)
+	if err := abi.ABIAccountPolicy.UnpackMethod(param, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	if len(param.TokenIds) != len(param.DailyLimits) ||
+		len(param.TokenIds) > accountPolicyListLengthMax ||
+		len(param.Allowlist) > accountPolicyListLengthMax ||
+		param.RecoveryAddress == block.AccountAddress {
+		return util.ErrInvalidMethodParam
+	}
+	tokenIds := make(map[types.TokenTypeId]bool, len(param.TokenIds))
+	for i, tokenId := range param.TokenIds {
+		if tokenIds[tokenId] || param.DailyLimits[i].Sign() < 0 {
+			return util.ErrInvalidMethodParam
+		}
+		tokenIds[tokenId] = true
+	}
+	block.Data, _ = abi.ABIAccountPolicy.PackMethod(p.MethodName, param.TokenIds, param.DailyLimits, param.Allowlist, param.RecoveryAddress)
+	return nil
+}
+
+func (p *MethodSetAccountPolicy) DoReceive(db interfaces.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamSetAccountPolicy)
+	abi.ABIAccountPolicy.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	sbHeight := vm.GlobalStatus().SnapshotBlock().Height
+	old, err := abi.GetAccountPolicy(db, sendBlock.AccountAddress, sbHeight)
+	util.DealWithErr(err)
+	if old != nil && old.IsRecovered(sbHeight) {
+		return nil, util.ErrInvalidMethodParam
+	}
+	policy := &types.AccountPolicy{
+		TokenIds:        param.TokenIds,
+		DailyLimits:     param.DailyLimits,
+		Allowlist:       param.Allowlist,
+		RecoveryAddress: param.RecoveryAddress,
+		EffectiveHeight: sbHeight,
+	}
+	// a stricter policy takes effect at once, otherwise the account has to wait for the delay,
+	// so that a stolen key can't lift the restrictions before the owner notices
+	policyKey := abi.GetAccountPolicyKey(sendBlock.AccountAddress)
+	pendingKey := abi.GetAccountPolicyPendingKey(sendBlock.AccountAddress)
+	if isStricterAccountPolicy(policy, old) {
+		if abi.IsEmptyAccountPolicy(policy) {
+			util.SetValue(db, policyKey, nil)
+		} else {
+			saveAccountPolicy(db, policyKey, policy)
+		}
+		util.SetValue(db, pendingKey, nil)
+	} else {
+		saveAccountPolicy(db, policyKey, old)
+		policy.EffectiveHeight = sbHeight + nodeConfig.params.AccountPolicyDelay
+		saveAccountPolicy(db, pendingKey, policy)
+	}
+	db.AddLog(NewLog(abi.ABIAccountPolicy, util.FirstToLower(p.MethodName), sendBlock.AccountAddress, policy.EffectiveHeight))
+	return nil, nil
+}
+
+type MethodInitiateRecovery struct {
+	MethodName string
+}
+
+func (p *MethodInitiateRecovery) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodInitiateRecovery) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodInitiateRecovery) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.InitiateRecoveryQuota, nil
+}
+func (p *MethodInitiateRecovery) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodInitiateRecovery) DoSend(db interfaces.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() > 0 {
+		return util.ErrInvalidMethodParam
+	}
+	account := new(types.Address)
+	if err := abi.ABIAccountPolicy.UnpackMethod(account, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIAccountPolicy.PackMethod(p.MethodName, *account)
+	return nil
+}
+
+func (p *MethodInitiateRecovery) DoReceive(db interfaces.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	account := new(types.Address)
+	abi.ABIAccountPolicy.UnpackMethod(account, p.MethodName, sendBlock.Data)
+	sbHeight := vm.GlobalStatus().SnapshotBlock().Height
+	policy, err := abi.GetAccountPolicy(db, *account, sbHeight)
+	util.DealWithErr(err)
+	if policy == nil || policy.RecoveryAddress.IsZero() ||
+		policy.RecoveryAddress != sendBlock.AccountAddress || policy.RecoveryHeight > 0 {
+		return nil, util.ErrInvalidMethodParam
+	}
+	recoveryHeight := sbHeight + nodeConfig.params.AccountRecoveryDelay
+	recoveryData, _ := abi.ABIAccountPolicy.PackVariable(abi.VariableNameAccountRecovery, recoveryHeight)
+	util.SetValue(db, abi.GetAccountRecoveryKey(*account), recoveryData)
+	db.AddLog(NewLog(abi.ABIAccountPolicy, util.FirstToLower(p.MethodName), *account, sendBlock.AccountAddress, recoveryHeight))
+	return nil, nil
+}
+
+type MethodCancelRecovery struct {
+	MethodName string
+}
+
+func (p *MethodCancelRecovery) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodCancelRecovery) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodCancelRecovery) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.CancelRecoveryQuota, nil
+}
+func (p *MethodCancelRecovery) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodCancelRecovery) DoSend(db interfaces.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() > 0 {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIAccountPolicy.PackMethod(p.MethodName)
+	return nil
+}
+
+func (p *MethodCancelRecovery) DoReceive(db interfaces.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	recoveryHeight, err := abi.GetAccountRecoveryHeight(db, sendBlock.AccountAddress)
+	util.DealWithErr(err)
+	if recoveryHeight == 0 || vm.GlobalStatus().SnapshotBlock().Height >= recoveryHeight {
+		return nil, util.ErrInvalidMethodParam
+	}
+	util.SetValue(db, abi.GetAccountRecoveryKey(sendBlock.AccountAddress), nil)
+	db.AddLog(NewLog(abi.ABIAccountPolicy, util.FirstToLower(p.MethodName), sendBlock.AccountAddress))
+	return nil, nil
+}
+
+func saveAccountPolicy(db interfaces.VmDb, key []byte, policy *types.AccountPolicy) {
+	data, _ := abi.ABIAccountPolicy.PackVariable(
+		abi.VariableNameAccountPolicy,
+		policy.TokenIds,
+		policy.DailyLimits,
+		policy.Allowlist,
+		policy.RecoveryAddress,
+		policy.EffectiveHeight)
+	util.SetValue(db, key, data)
+}
+
+// isStricterAccountPolicy checks whether the new policy restricts everything the old policy restricts
+func isStricterAccountPolicy(policy *types.AccountPolicy, old *types.AccountPolicy) bool {
+	if old == nil {
+		return true
+	}
+	if policy.RecoveryAddress != old.RecoveryAddress {
+		return false
+	}
+	for i, tokenId := range old.TokenIds {
+		limit := policy.DailyLimit(tokenId)
+		if limit == nil || limit.Cmp(old.DailyLimits[i]) > 0 {
+			return false
+		}
+	}
+	if len(old.Allowlist) == 0 {
+		return true
+	}
+	if len(policy.Allowlist) == 0 {
+		return false
+	}
+	for _, addr := range policy.Allowlist {
+		if !old.IsAllowed(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckAccountPolicy checks a send block of a user account against the policy registered in
+// the account policy contract, and records the spending of the account in its own storage.
+// The fee is paid in vite and counts toward the daily limit of vite
+func CheckAccountPolicy(db interfaces.VmDb, block *ledger.AccountBlock, sbHeight uint64) error {
+	if !upgrade.IsVersionXUpgrade(sbHeight) {
+		return nil
+	}
+	policy, err := db.GetAccountPolicy(&block.AccountAddress, sbHeight)
+	util.DealWithErr(err)
+	if policy == nil {
+		return nil
+	}
+	if policy.IsRecovered(sbHeight) {
+		if block.ToAddress != policy.RecoveryAddress {
+			return util.ErrAccountPolicyViolated
+		}
+		return nil
+	}
+	if block.ToAddress == types.AddressAccountPolicy && block.Amount.Sign() == 0 {
+		return nil
+	}
+	if !policy.IsAllowed(block.ToAddress) {
+		return util.ErrAccountPolicyViolated
+	}
+	fee := block.Fee
+	if fee == nil {
+		fee = big.NewInt(0)
+	}
+	if block.TokenId == ledger.ViteTokenId {
+		return recordAccountSpending(db, policy, block.TokenId, new(big.Int).Add(block.Amount, fee), sbHeight)
+	}
+	if err := recordAccountSpending(db, policy, block.TokenId, block.Amount, sbHeight); err != nil {
+		return err
+	}
+	return recordAccountSpending(db, policy, ledger.ViteTokenId, fee, sbHeight)
+}
+
+func recordAccountSpending(db interfaces.VmDb, policy *types.AccountPolicy, tokenId types.TokenTypeId, spent *big.Int, sbHeight uint64) error {
+	limit := policy.DailyLimit(tokenId)
+	if limit == nil || spent.Sign() == 0 {
+		return nil
+	}
+	spending, err := abi.GetAccountSpending(db, tokenId)
+	util.DealWithErr(err)
+	period := sbHeight / nodeConfig.params.AccountSpendingPeriod
+	amount := new(big.Int).Set(spent)
+	if spending.Period == period {
+		amount.Add(amount, spending.Amount)
+	}
+	if amount.Cmp(limit) > 0 {
+		return util.ErrAccountPolicyViolated
+	}
+	spendingData, _ := abi.ABIAccountPolicy.PackVariable(abi.VariableNameAccountSpending, period, amount)
+	util.SetValue(db, abi.GetAccountSpendingKey(tokenId), spendingData)
+	return nil
+}
@@ -76,9 +76,9 @@ func (p *MethodRegister) DoReceive(db interfaces.VmDb, block *ledger.AccountBloc
 
 	snapshotBlock := vm.GlobalStatus().SnapshotBlock()
 	sb, err := db.LatestSnapshotBlock()
-	if isLeafFork := upgrade.IsLeafUpgrade(sb.Height); (!isLeafFork && sendBlock.Amount.Cmp(SbpStakeAmountPreMainnet) != 0) ||
-		(isLeafFork && sendBlock.Amount.Cmp(SbpStakeAmountMainnet) != 0) ||
-		sendBlock.TokenId != ledger.ViteTokenId {
+	stakeAmount, err := getSbpStakeAmount(db, sb.Height)
+	util.DealWithErr(err)
+	if sendBlock.Amount.Cmp(stakeAmount) != 0 || sendBlock.TokenId != ledger.ViteTokenId {
 		return nil, util.ErrInvalidMethodParam
 	}
 
@@ -160,6 +160,18 @@ func (p *MethodRegister) DoReceive(db interfaces.VmDb, block *ledger.AccountBloc
 	return nil, nil
 }
 
+// getSbpStakeAmount returns the stake amount to register a SBP, which is changed by governance
+// param proposals after version x fork
+func getSbpStakeAmount(db interfaces.VmDb, sbHeight uint64) (*big.Int, error) {
+	if !upgrade.IsLeafUpgrade(sbHeight) {
+		return SbpStakeAmountPreMainnet, nil
+	}
+	if !upgrade.IsVersionXUpgrade(sbHeight) {
+		return SbpStakeAmountMainnet, nil
+	}
+	return util.GetGovernanceParam(db, util.GovernanceParamSBPStakeAmount, sbHeight)
+}
+
 func saveWithdrawRewardAddress(db interfaces.VmDb, oldAddr *types.Address, newAddr, owner types.Address, sbpName string) {
 	if oldAddr == nil {
 		if newAddr == owner {
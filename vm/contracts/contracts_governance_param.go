@@ -0,0 +1,250 @@
+package contracts
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/ledger/consensus/core"
+	"github.com/vitelabs/go-vite/v2/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/v2/vm/util"
+)
+
+type MethodProposeParamChange struct {
+	MethodName string
+}
+
+func (p *MethodProposeParamChange) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+func (p *MethodProposeParamChange) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+func (p *MethodProposeParamChange) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.ProposeParamChangeQuota, nil
+}
+func (p *MethodProposeParamChange) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodProposeParamChange) DoSend(db interfaces.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() != 0 {
+		return util.ErrInvalidMethodParam
+	}
+	param := new(abi.ParamProposeParamChange)
+	if err := abi.ABIGovernance.UnpackMethod(param, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	if !checkRegisterAndVoteParam(types.SNAPSHOT_GID, param.SbpName) ||
+		!util.CheckGovernanceParam(param.ParamId, param.Value) {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIGovernance.PackMethod(p.MethodName, param.SbpName, param.ParamId, param.Value, param.EffectiveHeight)
+	return nil
+}
+
+func (p *MethodProposeParamChange) DoReceive(db interfaces.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamProposeParamChange)
+	abi.ABIGovernance.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	sb, err := db.LatestSnapshotBlock()
+	util.DealWithErr(err)
+	if param.EffectiveHeight < sb.Height+nodeConfig.params.GovernanceParamDelayMin {
+		return nil, util.ErrInvalidMethodParam
+	}
+	if _, err := getParamChangeVoter(db, param.SbpName, sendBlock.AccountAddress); err != nil {
+		return nil, err
+	}
+	stats, err := getParamChangeVoteStats(db, vm.ConsensusReader(), sb)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := abi.GetParamProposalCount(db)
+	util.DealWithErr(err)
+	id := count + 1
+	countData, _ := abi.ABIGovernance.PackVariable(abi.VariableNameParamProposalCount, id)
+	util.SetValue(db, abi.GetParamProposalCountKey(), countData)
+	db.AddLog(NewLog(abi.ABIGovernance, util.FirstToLower(p.MethodName), id, param.SbpName, param.ParamId, param.Value, param.EffectiveHeight))
+
+	// proposer approves the proposal
+	proposal := &abi.ParamProposal{
+		Id:              id,
+		Proposer:        param.SbpName,
+		ParamId:         param.ParamId,
+		Value:           param.Value,
+		EffectiveHeight: param.EffectiveHeight,
+		VoteIndex:       stats.Index,
+		ApproveWeight:   big.NewInt(0),
+		RejectWeight:    big.NewInt(0),
+		Status:          abi.ParamProposalStatusVoting,
+	}
+	voteForParamProposal(db, proposal, param.SbpName, stats, true, sb.Height)
+	return nil, nil
+}
+
+type MethodVoteForParamChange struct {
+	MethodName string
+}
+
+func (p *MethodVoteForParamChange) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+func (p *MethodVoteForParamChange) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+func (p *MethodVoteForParamChange) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.VoteForParamChangeQuota, nil
+}
+func (p *MethodVoteForParamChange) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodVoteForParamChange) DoSend(db interfaces.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() != 0 {
+		return util.ErrInvalidMethodParam
+	}
+	param := new(abi.ParamVoteForParamChange)
+	if err := abi.ABIGovernance.UnpackMethod(param, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	if !checkRegisterAndVoteParam(types.SNAPSHOT_GID, param.SbpName) || param.ProposalId == 0 {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIGovernance.PackMethod(p.MethodName, param.SbpName, param.ProposalId, param.Approve)
+	return nil
+}
+
+func (p *MethodVoteForParamChange) DoReceive(db interfaces.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamVoteForParamChange)
+	abi.ABIGovernance.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	sb, err := db.LatestSnapshotBlock()
+	util.DealWithErr(err)
+	proposal, err := abi.GetParamProposal(db, param.ProposalId)
+	util.DealWithErr(err)
+	if proposal == nil || proposal.StatusAt(sb.Height) != abi.ParamProposalStatusVoting {
+		return nil, util.ErrInvalidMethodParam
+	}
+	if _, err := getParamChangeVoter(db, param.SbpName, sendBlock.AccountAddress); err != nil {
+		return nil, err
+	}
+	if len(util.GetValue(db, abi.GetParamProposalVoteKey(proposal.Id, param.SbpName))) > 0 {
+		return nil, util.ErrInvalidMethodParam
+	}
+	stats, err := getParamChangeVoteStatsByIndex(vm.ConsensusReader(), proposal.VoteIndex)
+	if err != nil {
+		return nil, err
+	}
+	voteForParamProposal(db, proposal, param.SbpName, stats, param.Approve, sb.Height)
+	return nil, nil
+}
+
+// getParamChangeVoter returns the active snapshot registration owned by the sender
+func getParamChangeVoter(db interfaces.VmDb, name string, sender types.Address) (*types.Registration, error) {
+	registration, err := abi.GetRegistration(db, types.SNAPSHOT_GID, name)
+	util.DealWithErr(err)
+	if registration == nil || !registration.IsActive() || registration.StakeAddress != sender {
+		return nil, util.ErrInvalidMethodParam
+	}
+	return registration, nil
+}
+
+// getParamChangeVoteStats returns the consensus stats of the latest cycle settled at the snapshot block, a new
+// proposal is weighted by the votes received by the SBPs in the cycle
+func getParamChangeVoteStats(db interfaces.VmDb, reader util.ConsensusReader, sb *ledger.SnapshotBlock) (*core.DayStats, error) {
+	genesisTime := db.GetGenesisSnapshotBlock().Timestamp.Unix()
+	index, _, withinOneDay := reader.GetIndexByEndTime(getRewardTimeLimit(sb), genesisTime)
+	if withinOneDay {
+		return nil, util.ErrInvalidMethodParam
+	}
+	return getParamChangeVoteStatsByIndex(reader, index)
+}
+
+func getParamChangeVoteStatsByIndex(reader util.ConsensusReader, index uint64) (*core.DayStats, error) {
+	detailList, err := reader.GetConsensusDetailByDay(index, index)
+	util.DealWithErr(err)
+	if len(detailList) == 0 {
+		return nil, util.ErrInvalidMethodParam
+	}
+	return detailList[0], nil
+}
+
+// getSbpVoteCount returns the votes received by the SBP in the cycle, which consensus counts by the
+// balances of the voters to elect the SBPs, see consensus core.CalVotes
+func getSbpVoteCount(stats *core.DayStats, name string) *big.Int {
+	if sbpStats, ok := stats.Stats[name]; ok && sbpStats.VoteCnt != nil {
+		return sbpStats.VoteCnt.Int
+	}
+	return big.NewInt(0)
+}
+
+// voteForParamProposal counts the votes received by the SBP in the cycle of the proposal as vote weight, a proposal
+// is approved if more than 2/3 of total votes received by active SBPs approves, and rejected if approval becomes impossible
+func voteForParamProposal(db interfaces.VmDb, proposal *abi.ParamProposal, name string, stats *core.DayStats, approve bool, sbHeight uint64) {
+	voteData, _ := abi.ABIGovernance.PackVariable(abi.VariableNameParamProposalVote, approve)
+	util.SetValue(db, abi.GetParamProposalVoteKey(proposal.Id, name), voteData)
+	weight := getSbpVoteCount(stats, name)
+	if approve {
+		proposal.ApproveWeight = new(big.Int).Add(proposal.ApproveWeight, weight)
+	} else {
+		proposal.RejectWeight = new(big.Int).Add(proposal.RejectWeight, weight)
+	}
+	db.AddLog(NewLog(abi.ABIGovernance, util.FirstToLower(abi.MethodNameVoteForParamChange), proposal.Id, name, approve, weight))
+
+	candidateList, err := abi.GetCandidateList(db, types.SNAPSHOT_GID)
+	util.DealWithErr(err)
+	totalWeight := big.NewInt(0)
+	for _, candidate := range candidateList {
+		totalWeight.Add(totalWeight, getSbpVoteCount(stats, candidate.Name))
+	}
+	if new(big.Int).Mul(proposal.ApproveWeight, big.NewInt(3)).Cmp(new(big.Int).Mul(totalWeight, big.NewInt(2))) > 0 {
+		proposal.Status = abi.ParamProposalStatusApproved
+		scheduleGovernanceParam(db, proposal.ParamId, proposal.Value, proposal.EffectiveHeight, sbHeight)
+		db.AddLog(NewLog(abi.ABIGovernance, abi.EventNameParamChangeApproved, proposal.Id, proposal.ParamId, proposal.Value, proposal.EffectiveHeight))
+	} else if new(big.Int).Mul(proposal.RejectWeight, big.NewInt(3)).Cmp(totalWeight) >= 0 {
+		proposal.Status = abi.ParamProposalStatusRejected
+		db.AddLog(NewLog(abi.ABIGovernance, abi.EventNameParamChangeRejected, proposal.Id))
+	}
+	proposalData, _ := abi.ABIGovernance.PackVariable(
+		abi.VariableNameParamProposal,
+		proposal.Proposer,
+		proposal.ParamId,
+		proposal.Value,
+		proposal.EffectiveHeight,
+		proposal.VoteIndex,
+		proposal.ApproveWeight,
+		proposal.RejectWeight,
+		proposal.Status)
+	util.SetValue(db, abi.GetParamProposalKey(proposal.Id), proposalData)
+}
+
+// scheduleGovernanceParam inserts an approved value into the schedule of the param, values which
+// will never be read at or after current snapshot height are removed
+func scheduleGovernanceParam(db interfaces.VmDb, id uint8, value *big.Int, effectiveHeight uint64, sbHeight uint64) {
+	schedule, err := abi.GetGovernanceParamSchedule(db, id)
+	util.DealWithErr(err)
+	heights := make([]uint64, 0, len(schedule.Heights)+1)
+	values := make([]*big.Int, 0, len(schedule.Values)+1)
+	inserted := false
+	for i, height := range schedule.Heights {
+		if i+1 < len(schedule.Heights) && schedule.Heights[i+1] <= sbHeight {
+			continue
+		}
+		if !inserted && effectiveHeight <= height {
+			heights = append(heights, effectiveHeight)
+			values = append(values, value)
+			inserted = true
+			if effectiveHeight == height {
+				continue
+			}
+		}
+		heights = append(heights, height)
+		values = append(values, schedule.Values[i])
+	}
+	if !inserted {
+		heights = append(heights, effectiveHeight)
+		values = append(values, value)
+	}
+	data, _ := abi.ABIGovernance.PackVariable(abi.VariableNameGovernanceParam, heights, values)
+	util.SetValue(db, abi.GetGovernanceParamKey(id), data)
+}
@@ -0,0 +1,219 @@
+package contracts
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/v2/vm/util"
+)
+
+type MethodCreateVesting struct {
+	MethodName string
+}
+
+func (p *MethodCreateVesting) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodCreateVesting) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodCreateVesting) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.CreateVestingQuota, nil
+}
+func (p *MethodCreateVesting) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodCreateVesting) DoSend(db interfaces.VmDb, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() <= 0 {
+		return util.ErrInvalidMethodParam
+	}
+	param := new(abi.ParamCreateVesting)
+	if err := abi.ABIVesting.UnpackMethod(param, p.MethodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	if param.Beneficiary == types.ZERO_ADDRESS ||
+		param.StartHeight > param.CliffHeight ||
+		param.CliffHeight > param.EndHeight ||
+		param.EndHeight-param.StartHeight > vestingHeightMax {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIVesting.PackMethod(p.MethodName, param.Beneficiary, param.StartHeight, param.CliffHeight, param.EndHeight, param.Revocable)
+	return nil
+}
+
+func (p *MethodCreateVesting) DoReceive(db interfaces.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	param := new(abi.ParamCreateVesting)
+	abi.ABIVesting.UnpackMethod(param, p.MethodName, sendBlock.Data)
+	if param.EndHeight > vm.GlobalStatus().SnapshotBlock().Height+vestingHeightMax {
+		return nil, util.ErrInvalidMethodParam
+	}
+	vestingInfoKey := abi.GetVestingInfoKey(param.Beneficiary, block.Height)
+	vestingInfo, _ := abi.ABIVesting.PackVariable(
+		abi.VariableNameVestingInfo,
+		sendBlock.AccountAddress,
+		param.Beneficiary,
+		sendBlock.TokenId,
+		sendBlock.Amount,
+		big.NewInt(0),
+		param.StartHeight,
+		param.CliffHeight,
+		param.EndHeight,
+		param.Revocable,
+		uint64(0),
+		sendBlock.Hash)
+	util.SetValue(db, vestingInfoKey, vestingInfo)
+	util.SetValue(db, sendBlock.Hash.Bytes(), vestingInfoKey)
+	db.AddLog(NewLog(abi.ABIVesting, util.FirstToLower(p.MethodName), sendBlock.Hash, sendBlock.AccountAddress, param.Beneficiary, sendBlock.TokenId, sendBlock.Amount))
+	return nil, nil
+}
+
+type MethodClaimVesting struct {
+	MethodName string
+}
+
+func (p *MethodClaimVesting) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodClaimVesting) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodClaimVesting) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.ClaimVestingQuota, nil
+}
+func (p *MethodClaimVesting) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodClaimVesting) DoSend(db interfaces.VmDb, block *ledger.AccountBlock) error {
+	return doSendVestingById(p.MethodName, block)
+}
+
+func (p *MethodClaimVesting) DoReceive(db interfaces.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	id := new(types.Hash)
+	abi.ABIVesting.UnpackMethod(id, p.MethodName, sendBlock.Data)
+	vestingInfoKey, vestingInfo := getVestingInfo(db, *id)
+	if vestingInfo == nil || vestingInfo.Beneficiary != sendBlock.AccountAddress {
+		return nil, util.ErrInvalidMethodParam
+	}
+	sbHeight := vm.GlobalStatus().SnapshotBlock().Height
+	amount := vestingInfo.ClaimableAmount(sbHeight)
+	if amount.Sign() <= 0 {
+		return nil, util.ErrInvalidMethodParam
+	}
+	vestingInfo.ClaimedAmount.Add(vestingInfo.ClaimedAmount, amount)
+	saveVestingInfo(db, vestingInfoKey, vestingInfo)
+	db.AddLog(NewLog(abi.ABIVesting, util.FirstToLower(p.MethodName), vestingInfo.Id, vestingInfo.Beneficiary, vestingInfo.TokenId, amount))
+	return []*ledger.AccountBlock{
+		{
+			AccountAddress: block.AccountAddress,
+			ToAddress:      vestingInfo.Beneficiary,
+			BlockType:      ledger.BlockTypeSendCall,
+			Amount:         amount,
+			TokenId:        vestingInfo.TokenId,
+			Data:           []byte{},
+		},
+	}, nil
+}
+
+type MethodRevokeVesting struct {
+	MethodName string
+}
+
+func (p *MethodRevokeVesting) GetFee(block *ledger.AccountBlock) (*big.Int, error) {
+	return big.NewInt(0), nil
+}
+
+func (p *MethodRevokeVesting) GetRefundData(sendBlock *ledger.AccountBlock, sbHeight uint64) ([]byte, bool) {
+	return []byte{}, false
+}
+
+func (p *MethodRevokeVesting) GetSendQuota(data []byte, gasTable *util.QuotaTable) (uint64, error) {
+	return gasTable.RevokeVestingQuota, nil
+}
+func (p *MethodRevokeVesting) GetReceiveQuota(gasTable *util.QuotaTable) uint64 {
+	return 0
+}
+
+func (p *MethodRevokeVesting) DoSend(db interfaces.VmDb, block *ledger.AccountBlock) error {
+	return doSendVestingById(p.MethodName, block)
+}
+
+func (p *MethodRevokeVesting) DoReceive(db interfaces.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, vm vmEnvironment) ([]*ledger.AccountBlock, error) {
+	id := new(types.Hash)
+	abi.ABIVesting.UnpackMethod(id, p.MethodName, sendBlock.Data)
+	vestingInfoKey, vestingInfo := getVestingInfo(db, *id)
+	sbHeight := vm.GlobalStatus().SnapshotBlock().Height
+	if vestingInfo == nil || vestingInfo.Creator != sendBlock.AccountAddress ||
+		!vestingInfo.Revocable || vestingInfo.RevokeHeight > 0 || sbHeight >= vestingInfo.EndHeight {
+		return nil, util.ErrInvalidMethodParam
+	}
+	vestingInfo.RevokeHeight = sbHeight
+	amount := new(big.Int).Sub(vestingInfo.Amount, vestingInfo.VestedAmount(sbHeight))
+	saveVestingInfo(db, vestingInfoKey, vestingInfo)
+	db.AddLog(NewLog(abi.ABIVesting, util.FirstToLower(p.MethodName), vestingInfo.Id, vestingInfo.Creator, vestingInfo.TokenId, amount))
+	return []*ledger.AccountBlock{
+		{
+			AccountAddress: block.AccountAddress,
+			ToAddress:      vestingInfo.Creator,
+			BlockType:      ledger.BlockTypeSendCall,
+			Amount:         amount,
+			TokenId:        vestingInfo.TokenId,
+			Data:           []byte{},
+		},
+	}, nil
+}
+
+func doSendVestingById(methodName string, block *ledger.AccountBlock) error {
+	if block.Amount.Sign() > 0 {
+		return util.ErrInvalidMethodParam
+	}
+	id := new(types.Hash)
+	if err := abi.ABIVesting.UnpackMethod(id, methodName, block.Data); err != nil {
+		return util.ErrInvalidMethodParam
+	}
+	block.Data, _ = abi.ABIVesting.PackMethod(methodName, id)
+	return nil
+}
+
+func getVestingInfo(db interfaces.VmDb, id types.Hash) ([]byte, *abi.VestingInfo) {
+	vestingInfoKey := util.GetValue(db, id.Bytes())
+	if len(vestingInfoKey) == 0 {
+		return nil, nil
+	}
+	vestingInfo, err := abi.UnpackVestingInfo(util.GetValue(db, vestingInfoKey))
+	if err != nil {
+		return nil, nil
+	}
+	return vestingInfoKey, vestingInfo
+}
+
+// saveVestingInfo updates the vesting info, the vesting info is deleted once all vested amount is claimed
+func saveVestingInfo(db interfaces.VmDb, vestingInfoKey []byte, vestingInfo *abi.VestingInfo) {
+	if vestingInfo.ClaimedAmount.Cmp(vestingInfo.VestedAmount(vestingInfo.EndHeight)) >= 0 {
+		util.SetValue(db, vestingInfoKey, nil)
+		util.SetValue(db, vestingInfo.Id.Bytes(), nil)
+		return
+	}
+	data, _ := abi.ABIVesting.PackVariable(
+		abi.VariableNameVestingInfo,
+		vestingInfo.Creator,
+		vestingInfo.Beneficiary,
+		vestingInfo.TokenId,
+		vestingInfo.Amount,
+		vestingInfo.ClaimedAmount,
+		vestingInfo.StartHeight,
+		vestingInfo.CliffHeight,
+		vestingInfo.EndHeight,
+		vestingInfo.Revocable,
+		vestingInfo.RevokeHeight,
+		vestingInfo.Id)
+	util.SetValue(db, vestingInfoKey, data)
+}
@@ -25,6 +25,10 @@ const (
 	rewardTimeLimit   int64  = 3600 // Cannot get snapshot block reward of current few blocks, for latest snapshot block could be reverted
 
 	stakeHeightMax uint64 = 3600 * 24 * 365
+
+	vestingHeightMax uint64 = 3600 * 24 * 365 * 10 // Maximum snapshot height of a vesting schedule
+
+	accountPolicyListLengthMax int = 32 // Maximum count of limited tokens and allowed addresses of an account policy
 )
 
 var (
@@ -39,20 +43,32 @@ var (
 )
 
 type contractsParams struct {
-	StakeHeight            uint64 // locking height for stake
-	DexVipStakeHeight      uint64 // locking height for dex_fund contract, in order to upgrade to dex vip
-	DexSuperVipStakeHeight uint64 // locking height for dex_fund contract, in order to upgrade to dex super vip
+	StakeHeight             uint64 // locking height for stake
+	DexVipStakeHeight       uint64 // locking height for dex_fund contract, in order to upgrade to dex vip
+	DexSuperVipStakeHeight  uint64 // locking height for dex_fund contract, in order to upgrade to dex super vip
+	GovernanceParamDelayMin uint64 // minimum snapshot height between a governance param proposal and its effective height
+	AccountPolicyDelay      uint64 // snapshot height before a looser account policy takes effect
+	AccountRecoveryDelay    uint64 // snapshot height before a recovery takes effect, the account can cancel it in the meantime
+	AccountSpendingPeriod   uint64 // snapshot height of a period of the daily spending limits
 }
 
 var (
 	contractsParamsTest = contractsParams{
-		StakeHeight:            600,
-		DexVipStakeHeight:      600,
-		DexSuperVipStakeHeight: 600,
+		StakeHeight:             600,
+		DexVipStakeHeight:       600,
+		DexSuperVipStakeHeight:  600,
+		GovernanceParamDelayMin: 75,
+		AccountPolicyDelay:      20,
+		AccountRecoveryDelay:    40,
+		AccountSpendingPeriod:   100,
 	}
 	contractsParamsMainNet = contractsParams{
-		StakeHeight:            3600 * 24 * 3,
-		DexVipStakeHeight:      3600 * 24 * 30,
-		DexSuperVipStakeHeight: 3600 * 24 * 30,
+		StakeHeight:             3600 * 24 * 3,
+		DexVipStakeHeight:       3600 * 24 * 30,
+		DexSuperVipStakeHeight:  3600 * 24 * 30,
+		GovernanceParamDelayMin: 3600 * 24 * 7,
+		AccountPolicyDelay:      3600 * 24 * 2,
+		AccountRecoveryDelay:    3600 * 24 * 7,
+		AccountSpendingPeriod:   3600 * 24,
 	}
 )
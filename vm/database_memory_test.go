@@ -227,6 +227,12 @@ func (db *memoryDatabase) GetContractMetaInSnapshot(contractAddress types.Addres
 func (db *memoryDatabase) GetStakeBeneficialAmount(addr *types.Address) (*big.Int, error) {
 	return big.NewInt(0), nil
 }
+func (db *memoryDatabase) GetGovernanceParam(id uint8, sbHeight uint64) (*big.Int, error) {
+	return nil, nil
+}
+func (db *memoryDatabase) GetAccountPolicy(addr *types.Address, sbHeight uint64) (*types.AccountPolicy, error) {
+	return nil, nil
+}
 func (db *memoryDatabase) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
 	return 0, nil
 }
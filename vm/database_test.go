@@ -98,7 +98,7 @@ func (db *testDatabase) SetContractMeta(toAddr types.Address, meta *ledger.Contr
 	db.contractMetaMap[toAddr] = meta
 }
 func (db *testDatabase) GetContractMeta() (*ledger.ContractMeta, error) {
-	if types.IsBuiltinContractAddrInUse(db.addr) {
+	if ledger.IsBuiltinContractAddrInUse(db.addr, db.snapshotBlockList[len(db.snapshotBlockList)-1].Height) {
 		return &ledger.ContractMeta{QuotaRatio: 10}, nil
 	}
 	return db.contractMetaMap[db.addr], nil
@@ -320,6 +320,19 @@ func (db *testDatabase) GetStakeBeneficialAmount(addr *types.Address) (*big.Int,
 func (db *testDatabase) DebugGetStorage() (map[string][]byte, error) {
 	return db.storageMap[db.addr], nil
 }
+func (db *testDatabase) GetGovernanceParam(id uint8, sbHeight uint64) (*big.Int, error) {
+	data, _ := db.storageMap[types.AddressGovernance][ToKey(abi.GetGovernanceParamKey(id))]
+	if len(data) > 0 {
+		schedule := new(abi.GovernanceParamSchedule)
+		abi.ABIGovernance.UnpackVariable(schedule, abi.VariableNameGovernanceParam, data)
+		return schedule.ValueAt(sbHeight), nil
+	}
+	return nil, nil
+}
+func (db *testDatabase) GetAccountPolicy(addr *types.Address, sbHeight uint64) (*types.AccountPolicy, error) {
+	return nil, nil
+}
+
 func (db *testDatabase) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
 	return 1, nil
 }
@@ -0,0 +1,324 @@
+//go:build go1.18
+// +build go1.18
+
+package vm
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/log15"
+	"github.com/vitelabs/go-vite/v2/vm/abi"
+	"github.com/vitelabs/go-vite/v2/vm/contracts"
+	cabi "github.com/vitelabs/go-vite/v2/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/v2/vm/util"
+)
+
+// fuzzTrace prints the inputs and the results of a fuzz case, and the interpreter steps,
+// used to reproduce a single case, e.g.
+// go test ./vm -run 'FuzzInterpreter/<case>' -v -fuzztrace
+var fuzzTrace = flag.Bool("fuzztrace", false, "print the inputs, results and interpreter steps of the fuzz cases")
+
+const (
+	// fuzzMaxHeight covers all the upgrade points of initCustomFork
+	fuzzMaxHeight  = 700
+	fuzzQuotaTotal = 1000000
+)
+
+var (
+	fuzzFromAddr    = types.HexToAddressPanic("vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a")
+	fuzzToAddr      = types.HexToAddressPanic("vite_470328ad08903a431953bfdcaf7760c084233c475e5726a35c")
+	fuzzBuiltins    = []types.Address{types.AddressQuota, types.AddressGovernance, types.AddressAsset, types.AddressDexFund, types.AddressDexTrade}
+	fuzzBuiltinAbis = map[types.Address]abi.ABIContract{
+		types.AddressQuota:      cabi.ABIQuota,
+		types.AddressGovernance: cabi.ABIGovernance,
+		types.AddressAsset:      cabi.ABIAsset,
+		types.AddressDexFund:    cabi.ABIDexFund,
+		types.AddressDexTrade:   cabi.ABIDexTrade,
+	}
+)
+
+func fuzzHeight(height uint64) uint64 {
+	if height = height % fuzzMaxHeight; height == 0 {
+		return 1
+	}
+	return height
+}
+
+func initFuzzTrace() {
+	if *fuzzTrace {
+		nodeConfig.IsDebug = true
+		nodeConfig.interpreterLog = log15.New("module", "vm")
+		nodeConfig.interpreterLog.SetHandler(log15.StreamHandler(os.Stdout, log15.TerminalFormat()))
+	}
+}
+
+type interpreterResult struct {
+	ret       []byte
+	err       error
+	quotaLeft uint64
+	storage   map[string][]byte
+	logHash   *types.Hash
+	sendList  []*ledger.AccountBlock
+}
+
+func (r *interpreterResult) String() string {
+	keys := make([]string, 0, len(r.storage))
+	for k := range r.storage {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var storage bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&storage, "\n  %x: %x", k, r.storage[k])
+	}
+	var sendList bytes.Buffer
+	for _, b := range r.sendList {
+		fmt.Fprintf(&sendList, "\n  %v %v %v %v %x", b.BlockType, b.ToAddress, b.TokenId, b.Amount, b.Data)
+	}
+	return fmt.Sprintf("ret: %x\nerr: %v\nquotaLeft: %v\nlogHash: %v\nstorage:%s\nsendList:%s",
+		r.ret, r.err, r.quotaLeft, r.logHash, storage.String(), sendList.String())
+}
+
+func runInterpreter(height uint64, code []byte, input []byte, amount *big.Int) *interpreterResult {
+	sbTime := time.Unix(genesisTimestamp+int64(height), 0)
+	sb := ledger.SnapshotBlock{
+		Height:    height,
+		Timestamp: &sbTime,
+		Hash:      types.DataHash([]byte{1, 1}),
+	}
+	vm := NewVM(nil)
+	vm.i = newInterpreter(height, false)
+	vm.gasTable = util.QuotaTableByHeight(height)
+	vm.globalStatus = NewTestGlobalStatus(height, &sb)
+	vm.latestSnapshotHeight = height
+	sendCallBlock := &ledger.AccountBlock{
+		AccountAddress: fuzzFromAddr,
+		ToAddress:      fuzzToAddr,
+		BlockType:      ledger.BlockTypeSendCall,
+		Data:           input,
+		Amount:         amount,
+		Fee:            big.NewInt(0),
+		TokenId:        ledger.ViteTokenId,
+	}
+	receiveCallBlock := &ledger.AccountBlock{
+		AccountAddress: fuzzToAddr,
+		BlockType:      ledger.BlockTypeReceive,
+	}
+	db := newMemoryDatabase(fuzzToAddr, &sb)
+	c := newContract(receiveCallBlock, db, sendCallBlock, sendCallBlock.Data, fuzzQuotaTotal)
+	c.setCallCode(fuzzToAddr, code)
+	util.AddBalance(db, &sendCallBlock.TokenId, sendCallBlock.Amount)
+	ret, err := c.run(vm)
+	return &interpreterResult{
+		ret:       ret,
+		err:       err,
+		quotaLeft: c.quotaLeft,
+		storage:   db.storage,
+		logHash:   db.GetLogListHash(),
+		sendList:  vm.sendBlockList,
+	}
+}
+
+func checkInterpreterResult(r1, r2 *interpreterResult) string {
+	if r1.quotaLeft > fuzzQuotaTotal {
+		return fmt.Sprintf("quota left %v is more than quota total %v", r1.quotaLeft, fuzzQuotaTotal)
+	}
+	if r1.String() != r2.String() {
+		return "results of the two runs are different"
+	}
+	return ""
+}
+
+// FuzzInterpreter runs random code twice, the interpreter must not panic, the quota used must
+// not be more than the quota total and the results must be the same.
+func FuzzInterpreter(f *testing.F) {
+	testDir := "./test/interpreter_test/"
+	testFiles, err := ioutil.ReadDir(testDir)
+	if err != nil {
+		f.Fatalf("read dir failed, %v", err)
+	}
+	for _, testFile := range testFiles {
+		if testFile.IsDir() {
+			continue
+		}
+		buf, err := ioutil.ReadFile(filepath.Join(testDir, testFile.Name()))
+		if err != nil {
+			f.Fatalf("read test file failed, %v", err)
+		}
+		testCaseMap := make(map[string]*struct {
+			SBHeight  uint64
+			InputData string
+			Amount    string
+			Code      string
+		})
+		if err := json.Unmarshal(buf, &testCaseMap); err != nil {
+			f.Fatalf("decode test file %v failed, %v", testFile.Name(), err)
+		}
+		for _, testCase := range testCaseMap {
+			code, _ := hex.DecodeString(testCase.Code)
+			input, _ := hex.DecodeString(testCase.InputData)
+			amount, _ := hex.DecodeString(testCase.Amount)
+			f.Add(testCase.SBHeight, code, input, amount)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, height uint64, code []byte, input []byte, amount []byte) {
+		initCustomFork(t)
+		initFuzzTrace()
+		height = fuzzHeight(height)
+		r1 := runInterpreter(height, code, input, new(big.Int).SetBytes(amount))
+		r2 := runInterpreter(height, code, input, new(big.Int).SetBytes(amount))
+		if *fuzzTrace {
+			t.Logf("height: %v\ncode: %x\ninput: %x\namount: %x\n%v", height, code, input, amount, r1)
+		}
+		if problem := checkInterpreterResult(r1, r2); problem != "" {
+			t.Fatalf("%v\nheight: %v\ncode: %x\ninput: %x\namount: %x\nfirst run:\n%v\nsecond run:\n%v",
+				problem, height, code, input, amount, r1, r2)
+		}
+	})
+}
+
+type doSendResult struct {
+	fee      *big.Int
+	feeErr   error
+	quota    uint64
+	quotaErr error
+	err      error
+	data     []byte
+	amount   *big.Int
+	tokenId  types.TokenTypeId
+	blockFee *big.Int
+}
+
+func (r *doSendResult) String() string {
+	return fmt.Sprintf("fee: %v, %v\nsend quota: %v, %v\ndoSend: %v\ndata: %x\namount: %v\ntokenId: %v\nblock fee: %v",
+		r.fee, r.feeErr, r.quota, r.quotaErr, r.err, r.data, r.amount, r.tokenId, r.blockFee)
+}
+
+func runDoSend(height uint64, to types.Address, data []byte, amount *big.Int) (*doSendResult, error) {
+	method, ok, err := contracts.GetBuiltinContractMethod(to, data, height)
+	if !ok || err != nil {
+		return nil, err
+	}
+	sbTime := time.Unix(genesisTimestamp+int64(height), 0)
+	sb := &ledger.SnapshotBlock{
+		Height:    height,
+		Timestamp: &sbTime,
+		Hash:      types.DataHash([]byte{1, 1}),
+	}
+	prevBlock := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		Height:         1,
+		Hash:           prevHash,
+		PrevHash:       types.ZERO_HASH,
+		AccountAddress: fuzzFromAddr,
+	}
+	balance := map[types.TokenTypeId]string{ledger.ViteTokenId: amount.Text(16)}
+	db, err := NewMockDB(&fuzzFromAddr, sb, prevBlock, quotaInfoList, big.NewInt(0), balance, nil, nil, nil, genesisTimestamp, forkSnapshotBlockMap)
+	if err != nil {
+		return nil, err
+	}
+	block := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeSendCall,
+		AccountAddress: fuzzFromAddr,
+		ToAddress:      to,
+		Height:         prevBlock.Height + 1,
+		PrevHash:       prevBlock.Hash,
+		Data:           append([]byte{}, data...),
+		Amount:         amount,
+		TokenId:        ledger.ViteTokenId,
+		Fee:            big.NewInt(0),
+	}
+	r := &doSendResult{}
+	r.fee, r.feeErr = method.GetFee(block)
+	r.quota, r.quotaErr = method.GetSendQuota(block.Data, util.QuotaTableByHeight(height))
+	r.err = method.DoSend(db, block)
+	r.data, r.amount, r.tokenId, r.blockFee = block.Data, block.Amount, block.TokenId, block.Fee
+	return r, nil
+}
+
+// FuzzBuiltinDoSend calls DoSend of the built-in contract methods twice with random params,
+// the methods must not panic and the results must be the same.
+func FuzzBuiltinDoSend(f *testing.F) {
+	builtinIndex := make(map[types.Address]uint8)
+	for i, addr := range fuzzBuiltins {
+		builtinIndex[addr] = uint8(i)
+		for _, method := range fuzzBuiltinAbis[addr].Methods {
+			f.Add(uint64(fuzzMaxHeight-1), uint8(i), method.Id(), []byte{})
+		}
+	}
+	testDir := "./test/run_test/"
+	testFiles, err := ioutil.ReadDir(testDir)
+	if err != nil {
+		f.Fatalf("read dir failed, %v", err)
+	}
+	for _, testFile := range testFiles {
+		if testFile.IsDir() {
+			continue
+		}
+		buf, err := ioutil.ReadFile(filepath.Join(testDir, testFile.Name()))
+		if err != nil {
+			f.Fatalf("read test file failed, %v", err)
+		}
+		testCaseMap := make(map[string]*struct {
+			SbHeight  uint64
+			BlockType byte
+			ToAddress string
+			Data      string
+			Amount    string
+		})
+		if err := json.Unmarshal(buf, &testCaseMap); err != nil {
+			f.Fatalf("decode test file %v failed, %v", testFile.Name(), err)
+		}
+		for _, testCase := range testCaseMap {
+			if !ledger.IsSendBlock(testCase.BlockType) {
+				continue
+			}
+			to, err := types.HexToAddress(testCase.ToAddress)
+			if err != nil {
+				continue
+			}
+			i, ok := builtinIndex[to]
+			if !ok {
+				continue
+			}
+			data, _ := hex.DecodeString(testCase.Data)
+			amount, _ := hex.DecodeString(testCase.Amount)
+			f.Add(testCase.SbHeight, i, data, amount)
+		}
+	}
+
+	f.Fuzz(func(t *testing.T, height uint64, to uint8, data []byte, amount []byte) {
+		initCustomFork(t)
+		height = fuzzHeight(height)
+		addr := fuzzBuiltins[int(to)%len(fuzzBuiltins)]
+		r1, err := runDoSend(height, addr, data, new(big.Int).SetBytes(amount))
+		if r1 == nil {
+			if *fuzzTrace {
+				t.Logf("height: %v\nto: %v\ndata: %x\nmethod not found, %v", height, addr, data, err)
+			}
+			return
+		}
+		r2, _ := runDoSend(height, addr, data, new(big.Int).SetBytes(amount))
+		if *fuzzTrace {
+			t.Logf("height: %v\nto: %v\ndata: %x\namount: %x\n%v", height, addr, data, amount, r1)
+		}
+		if r1.String() != r2.String() {
+			t.Fatalf("results of the two runs are different\nheight: %v\nto: %v\ndata: %x\namount: %x\nfirst run:\n%v\nsecond run:\n%v",
+				height, addr, data, amount, r1, r2)
+		}
+	})
+}
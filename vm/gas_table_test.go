@@ -4,6 +4,9 @@ import (
 	"testing"
 
 	"github.com/vitelabs/go-vite/v2/common/helper"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/common/upgrade"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
 	"github.com/vitelabs/go-vite/v2/vm/util"
 )
 
@@ -30,3 +33,33 @@ func TestMemoryGasCost(t *testing.T) {
 		t.Errorf("Expected error")
 	}
 }
+
+func TestGasUserSendCallBeforeVersionX(t *testing.T) {
+	upgrade.CleanupUpgradeBox()
+	upgrade.InitUpgradeBox(upgrade.NewLatestUpgradeBox().AddPoint(13, 100))
+	defer initEmptyFork(t)
+
+	gasTable := util.QuotaTableByHeight(1)
+	for _, addr := range []types.Address{types.AddressVesting, types.AddressAccountPolicy} {
+		block := &ledger.AccountBlock{BlockType: ledger.BlockTypeSendCall, ToAddress: addr, Data: []byte{1, 2, 3, 4}}
+
+		// before the upgrade the send is a user transfer, the data is not required to be a contract method
+		if ledger.GetBuiltinContractMeta(addr, 99) != nil {
+			t.Fatalf("%v is in use before VersionX", addr)
+		}
+		expected, err := gasSendCall(block, gasTable)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cost, err := gasUserSendCall(block, gasTable, 99); err != nil || cost != expected {
+			t.Fatalf("unexpected send quota to %v before VersionX, cost %v, expected %v, err %v", addr, cost, expected, err)
+		}
+
+		if ledger.GetBuiltinContractMeta(addr, 100) == nil {
+			t.Fatalf("%v is not in use after VersionX", addr)
+		}
+		if _, err := gasUserSendCall(block, gasTable, 100); err != util.ErrAbiMethodNotFound {
+			t.Fatalf("expected abi method not found to %v after VersionX, got %v", addr, err)
+		}
+	}
+}
@@ -342,7 +342,7 @@ func (db *mockDB) SetContractMeta(toAddr types.Address, meta *ledger.ContractMet
 	db.contractMetaMap[toAddr] = meta
 }
 func (db *mockDB) GetContractMeta() (*ledger.ContractMeta, error) {
-	if meta := ledger.GetBuiltinContractMeta(*db.currentAddr); meta != nil {
+	if meta := ledger.GetBuiltinContractMeta(*db.currentAddr, db.latestSnapshotBlock.Height); meta != nil {
 		return meta, nil
 	}
 	if meta, ok := db.contractMetaMap[*db.currentAddr]; ok {
@@ -354,7 +354,7 @@ func (db *mockDB) GetContractMeta() (*ledger.ContractMeta, error) {
 	return nil, nil
 }
 func (db *mockDB) GetContractMetaInSnapshot(contractAddress types.Address, snapshotBlock *ledger.SnapshotBlock) (meta *ledger.ContractMeta, err error) {
-	if meta := ledger.GetBuiltinContractMeta(contractAddress); meta != nil {
+	if meta := ledger.GetBuiltinContractMeta(contractAddress, snapshotBlock.Height); meta != nil {
 		return meta, nil
 	}
 	if meta, ok := db.contractMetaMap[contractAddress]; ok {
@@ -411,6 +411,12 @@ func (db *mockDB) GetStakeBeneficialAmount(addr *types.Address) (*big.Int, error
 		return db.pledgeBeneficialAmount, nil
 	}
 }
+func (db *mockDB) GetGovernanceParam(id uint8, sbHeight uint64) (*big.Int, error) {
+	return nil, nil
+}
+func (db *mockDB) GetAccountPolicy(addr *types.Address, sbHeight uint64) (*types.AccountPolicy, error) {
+	return nil, nil
+}
 func (db *mockDB) DebugGetStorage() (map[string][]byte, error) {
 	return nil, nil
 }
@@ -63,6 +63,7 @@ type quotaDb interface {
 	GetUnconfirmedBlocks(address types.Address) []*ledger.AccountBlock
 	GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock, error)
 	GetConfirmedTimes(blockHash types.Hash) (uint64, error)
+	GetGovernanceParam(id uint8, sbHeight uint64) (*big.Int, error)
 }
 
 // CalcBlockQuotaUsed recalculate quotaUsed field of an account block
@@ -157,6 +158,10 @@ func calcQuotaTotal(db quotaDb, addr types.Address, stakeAmount *big.Int, diffic
 	if err != nil {
 		return 0, 0, 0, 0, 0, false, 0, err
 	}
+	stakeAmount, err = calcGovernedStakeAmount(db, stakeAmount, sbHeight)
+	if err != nil {
+		return 0, 0, 0, 0, 0, false, 0, err
+	}
 	qc, _, isCongestion := CalcQc(db, sbHeight)
 	quotaStake = calcStakeQuota(qc, isCongestion, stakeAmount)
 	quotaAddition = calcPoWQuota(qc, isCongestion, difficulty)
@@ -204,6 +209,21 @@ func calcQuotaTotal(db quotaDb, addr types.Address, stakeAmount *big.Int, diffic
 	return quotaTotal + quotaAddition, quotaStake, quotaAddition, snapshotCurrentQuota, quotaAvg, blocked, blockReleaseHeight, nil
 }
 
+// calcGovernedStakeAmount scales stake amount by the quota stake ratio approved in governance contract
+func calcGovernedStakeAmount(db quotaDb, stakeAmount *big.Int, sbHeight uint64) (*big.Int, error) {
+	if stakeAmount == nil || stakeAmount.Sign() <= 0 || !upgrade.IsVersionXUpgrade(sbHeight) {
+		return stakeAmount, nil
+	}
+	ratio, err := util.GetGovernanceParam(db, util.GovernanceParamQuotaStakeRatio, sbHeight)
+	if err != nil {
+		return nil, err
+	}
+	if ratio.Cmp(helper.Big100) == 0 {
+		return stakeAmount, nil
+	}
+	return new(big.Int).Div(new(big.Int).Mul(stakeAmount, ratio), helper.Big100), nil
+}
+
 func calcStakeQuota(qc *big.Int, isCongestion bool, stakeAmount *big.Int) uint64 {
 	if stakeAmount == nil || stakeAmount.Sign() <= 0 {
 		return 0
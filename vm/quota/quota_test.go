@@ -48,6 +48,12 @@ func (db *testQuotaDb) GetQuotaUsedList(address types.Address) []types.QuotaInfo
 func (db *testQuotaDb) GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock {
 	return db.unconfirmedBlockList
 }
+func (db *testQuotaDb) GetGovernanceParam(id uint8, sbHeight uint64) (*big.Int, error) {
+	return nil, nil
+}
+func (db *testQuotaDb) GetAccountPolicy(addr *types.Address, sbHeight uint64) (*types.AccountPolicy, error) {
+	return nil, nil
+}
 func (db *testQuotaDb) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
 	return 0, nil
 }
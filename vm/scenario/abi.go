@@ -0,0 +1,115 @@
+package scenario
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/vm/abi"
+	cabi "github.com/vitelabs/go-vite/v2/vm/contracts/abi"
+)
+
+var builtinAbis = map[types.Address]abi.ABIContract{
+	types.AddressQuota:         cabi.ABIQuota,
+	types.AddressGovernance:    cabi.ABIGovernance,
+	types.AddressAsset:         cabi.ABIAsset,
+	types.AddressDexFund:       cabi.ABIDexFund,
+	types.AddressDexTrade:      cabi.ABIDexTrade,
+	types.AddressVesting:       cabi.ABIVesting,
+	types.AddressAccountPolicy: cabi.ABIAccountPolicy,
+}
+
+func parseAbi(raw []byte) (*abi.ABIContract, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	// the abi is either a json array or a string of the json array
+	if raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		raw = []byte(s)
+	}
+	contract, err := abi.JSONToABIContract(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("invalid abi, %v", err)
+	}
+	return &contract, nil
+}
+
+// packArgs converts the params in the scenario into the arguments of the abi
+func (r *runner) packArgs(params []string, arguments abi.Arguments) ([]interface{}, error) {
+	if len(params) != len(arguments) {
+		return nil, fmt.Errorf("%d params required, got %d", len(arguments), len(params))
+	}
+	args := make([]interface{}, len(params))
+	for i, argument := range arguments {
+		arg, err := r.convert(params[i], argument.Type)
+		if err != nil {
+			return nil, fmt.Errorf("invalid param %d, %v", i, err)
+		}
+		args[i] = arg
+	}
+	return args, nil
+}
+
+func (r *runner) convert(param string, t abi.Type) (interface{}, error) {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		n, ok := new(big.Int).SetString(param, 10)
+		if !ok {
+			return nil, fmt.Errorf("%s is not an integer", param)
+		}
+		if t.Type == reflect.TypeOf(n) {
+			return n, nil
+		}
+		if t.T == abi.IntTy {
+			return reflect.ValueOf(n.Int64()).Convert(t.Type).Interface(), nil
+		}
+		return reflect.ValueOf(n.Uint64()).Convert(t.Type).Interface(), nil
+	case abi.BoolTy:
+		return param == "true", nil
+	case abi.StringTy:
+		return param, nil
+	case abi.AddressTy:
+		return r.address(param)
+	case abi.TokenIdTy:
+		return parseTokenId(param)
+	case abi.GidTy:
+		return types.HexToGid(param)
+	case abi.BytesTy:
+		return hex.DecodeString(strings.TrimPrefix(param, "0x"))
+	case abi.FixedBytesTy:
+		b, err := hex.DecodeString(strings.TrimPrefix(param, "0x"))
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != t.Size {
+			return nil, fmt.Errorf("%d bytes required, got %d", t.Size, len(b))
+		}
+		arr := reflect.New(t.Type).Elem()
+		reflect.Copy(arr, reflect.ValueOf(b))
+		return arr.Interface(), nil
+	case abi.SliceTy:
+		// the elements are separated by commas, an empty param is an empty slice
+		slice := reflect.MakeSlice(t.Type, 0, 0)
+		if param == "" {
+			return slice.Interface(), nil
+		}
+		for _, elem := range strings.Split(param, ",") {
+			v, err := r.convert(strings.TrimSpace(elem), *t.Elem)
+			if err != nil {
+				return nil, err
+			}
+			slice = reflect.Append(slice, reflect.ValueOf(v))
+		}
+		return slice.Interface(), nil
+	}
+	return nil, fmt.Errorf("type %s is not supported", t.String())
+}
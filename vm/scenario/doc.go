@@ -0,0 +1,43 @@
+/*
+Package scenario runs declarative vm scenarios against an in-memory ledger, without a chain or a node.
+
+A scenario is a json file:
+
+	{
+	  "Description": "...",
+	  "Env": {"Upgrade": {"Level": "latest"}, "SnapshotHeight": 1},
+	  "Accounts": {
+	    "alice": {"Address": "vite_...", "Balance": {"VITE": "1000"}, "Stake": "1000"}
+	  },
+	  "Steps": [
+	    {"Send": {"From": "alice", "To": "quota", "Method": "StakeForQuota", "Params": ["alice"], "Amount": "1000"}},
+	    {"Snapshot": {}},
+	    {"Settle": true, "Expect": {"Accounts": {"quota": {"Balance": {"VITE": "1000"}, "Onroad": 0}}}}
+	  ]
+	}
+
+Accounts are referred by the key in Accounts, by the address, or by the names of the built-in
+contracts: quota, governance, asset, dexfund, dextrade, vesting and accountpolicy. Contracts created
+by a Create step are bound to the name in Bind.
+
+Params of a slice type are separated by commas.
+
+Every step runs one action:
+  - Send creates a send call block, the call data is packed by the abi of To if Method is set
+  - Create creates a send create block of the solidity++ code
+  - Receive receives the earliest onroad block of an account
+  - Settle receives the onroad blocks of all the contracts in order, including the triggered
+    send blocks, until no more onroad block can be received
+  - Snapshot advances the latest snapshot block, which confirms all the account blocks
+
+A contract only receives send blocks confirmed by enough snapshot blocks, and can't be called
+until its create block is confirmed, same as the chain. Use a Snapshot step before receiving.
+
+Expect is checked after the step. Error, BlockType, Quota, QuotaUsed, SendBlocks and Logs
+are checked against the block of a Send, Create or Receive step, Error is matched by substring
+and an empty list means none is expected. Accounts are checked after any step, only the
+listed tokens and storage keys are checked.
+
+The upgrade box and the vm config are global, scenarios can't be run concurrently.
+*/
+package scenario
@@ -0,0 +1,817 @@
+package scenario
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/vitelabs/go-vite/v2/common/config"
+	"github.com/vitelabs/go-vite/v2/common/helper"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/common/upgrade"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/ledger/consensus/core"
+	"github.com/vitelabs/go-vite/v2/vm"
+	"github.com/vitelabs/go-vite/v2/vm/abi"
+	"github.com/vitelabs/go-vite/v2/vm/util"
+	"github.com/vitelabs/go-vite/v2/vm_db"
+)
+
+const (
+	defaultGenesisTime       int64 = 1546272000
+	defaultConsensusInterval int64 = 24 * 3600
+	defaultQuotaMultiplier   uint8 = 10
+
+	// settleLimit is the max count of blocks received in a settle step, in case of endless calls
+	settleLimit = 1000
+)
+
+// builtinNames are the names of the built-in contracts which can be used as addresses
+var builtinNames = map[string]types.Address{
+	"quota":         types.AddressQuota,
+	"governance":    types.AddressGovernance,
+	"asset":         types.AddressAsset,
+	"dexfund":       types.AddressDexFund,
+	"dextrade":      types.AddressDexTrade,
+	"vesting":       types.AddressVesting,
+	"accountpolicy": types.AddressAccountPolicy,
+}
+
+var blockTypeNames = map[byte]string{
+	ledger.BlockTypeSendCreate:   "create",
+	ledger.BlockTypeSendCall:     "call",
+	ledger.BlockTypeSendReward:   "reward",
+	ledger.BlockTypeReceive:      "receive",
+	ledger.BlockTypeReceiveError: "receiveError",
+	ledger.BlockTypeSendRefund:   "refund",
+}
+
+type runner struct {
+	s     *Scenario
+	w     *world
+	cs    util.ConsensusReader
+	names map[string]types.Address
+	abis  map[types.Address]*abi.ABIContract
+}
+
+// Run runs the steps of the scenario in order and returns the error of the first failed step.
+// The upgrade box and the vm config are global, so scenarios can't be run concurrently,
+// and the upgrade box is cleaned up after the run.
+func Run(s *Scenario) (err error) {
+	upgradeCfg := s.Env.Upgrade
+	if upgradeCfg == nil {
+		upgradeCfg = &config.Upgrade{Level: "latest"}
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			err = fmt.Errorf("panic: %v", e)
+		}
+		upgrade.CleanupUpgradeBox()
+	}()
+	upgrade.CleanupUpgradeBox()
+	upgrade.InitUpgradeBox(upgradeCfg.MakeUpgradeBox())
+	vm.InitVMConfig(false, s.Env.TestParam, s.Env.TestParam, false, "")
+
+	r, err := newRunner(s)
+	if err != nil {
+		return err
+	}
+	for i, step := range s.Steps {
+		if err := r.runStep(step); err != nil {
+			if step.Name != "" {
+				return fmt.Errorf("step %d (%s): %v", i+1, step.Name, err)
+			}
+			return fmt.Errorf("step %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+func newRunner(s *Scenario) (*runner, error) {
+	genesisTime := s.Env.GenesisTime
+	if genesisTime == 0 {
+		genesisTime = defaultGenesisTime
+	}
+	interval := s.Env.SnapshotInterval
+	if interval <= 0 {
+		interval = 1
+	}
+	height := s.Env.SnapshotHeight
+	if height == 0 {
+		height = 1
+	}
+	r := &runner{
+		s:     s,
+		w:     newWorld(genesisTime, interval, height),
+		names: make(map[string]types.Address),
+		abis:  make(map[types.Address]*abi.ABIContract),
+	}
+	for name, addr := range builtinNames {
+		r.names[name] = addr
+	}
+	for addr, contract := range builtinAbis {
+		contract := contract
+		r.abis[addr] = &contract
+	}
+
+	reader, err := newStatsReader(genesisTime, s.Env.Consensus)
+	if err != nil {
+		return nil, err
+	}
+	r.cs = util.NewVMConsensusReader(reader)
+
+	// bind the names first, so the accounts can refer to each other
+	for key, a := range s.Accounts {
+		if strings.HasPrefix(key, types.AddressPrefix) {
+			continue
+		}
+		if a.Address == "" {
+			return nil, fmt.Errorf("account %s: address is required for a name", key)
+		}
+		addr, err := types.HexToAddress(a.Address)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %v", key, err)
+		}
+		r.names[key] = addr
+	}
+	for key, a := range s.Accounts {
+		if err := r.initAccount(key, a); err != nil {
+			return nil, fmt.Errorf("account %s: %v", key, err)
+		}
+	}
+	return r, nil
+}
+
+func (r *runner) initAccount(key string, a *Account) error {
+	addr, err := r.address(key)
+	if err != nil {
+		return err
+	}
+	acc := r.w.account(addr)
+	for token, amount := range a.Balance {
+		tokenId, err := parseTokenId(token)
+		if err != nil {
+			return err
+		}
+		if acc.balances[tokenId], err = parseAmount(amount); err != nil {
+			return err
+		}
+	}
+	if a.Stake != "" {
+		if acc.stake, err = parseAmount(a.Stake); err != nil {
+			return err
+		}
+	}
+	for k, v := range a.Storage {
+		key, err := parseHex(k)
+		if err != nil {
+			return err
+		}
+		value, err := parseHex(v)
+		if err != nil {
+			return err
+		}
+		acc.storage.Put(key, value)
+	}
+	if acc.code, err = parseHex(a.Code); err != nil {
+		return err
+	}
+	if a.Contract != nil || len(acc.code) > 0 {
+		if !types.IsContractAddr(addr) {
+			return errors.New("not a contract address")
+		}
+		c := a.Contract
+		if c == nil {
+			c = &Contract{}
+		}
+		meta, err := newContractMeta(c.Gid, c.ResponseLatency, c.RandomDegree, c.QuotaMultiplier)
+		if err != nil {
+			return err
+		}
+		acc.meta = meta
+		acc.metaHeight = 1
+	}
+	contract, err := parseAbi(a.Abi)
+	if err != nil {
+		return err
+	}
+	if contract != nil {
+		r.abis[addr] = contract
+	}
+	return nil
+}
+
+func (r *runner) runStep(step *Step) error {
+	var actions int
+	for _, set := range []bool{step.Send != nil, step.Create != nil, step.Receive != nil, step.Settle, step.Snapshot != nil} {
+		if set {
+			actions++
+		}
+	}
+	if actions != 1 {
+		return errors.New("exactly one of Send, Create, Receive, Settle and Snapshot is required")
+	}
+	expect := step.Expect
+	if expect == nil {
+		expect = &Expect{}
+	}
+
+	var result *runResult
+	var err error
+	switch {
+	case step.Send != nil:
+		result, err = r.send(step.Send)
+	case step.Create != nil:
+		result, err = r.create(step.Create)
+	case step.Receive != nil:
+		result, err = r.receive(step.Receive)
+	case step.Settle:
+		err = r.settle()
+	case step.Snapshot != nil:
+		err = r.snapshot(step.Snapshot)
+	}
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	if result != nil {
+		problems = r.checkResult(expect, result)
+	} else if expect.Error != "" || expect.Retry || expect.BlockType != "" || expect.Quota != nil ||
+		expect.QuotaUsed != nil || expect.SendBlocks != nil || expect.Logs != nil {
+		return errors.New("only Accounts can be expected for Settle and Snapshot")
+	}
+	problems = append(problems, r.checkAccounts(expect.Accounts)...)
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ====== actions ======
+
+type runResult struct {
+	vmBlock *interfaces.VmAccountBlock
+	isRetry bool
+	err     error
+}
+
+func (r *runner) send(send *Send) (*runResult, error) {
+	from, err := r.address(send.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := r.address(send.To)
+	if err != nil {
+		return nil, err
+	}
+	block := r.newBlock(from, ledger.BlockTypeSendCall)
+	block.ToAddress = to
+	if block.TokenId, err = parseTokenId(send.TokenId); err != nil {
+		return nil, err
+	}
+	if block.Amount, err = parseAmount(send.Amount); err != nil {
+		return nil, err
+	}
+	if block.Fee, err = parseAmount(send.Fee); err != nil {
+		return nil, err
+	}
+	if block.Difficulty, err = parseDifficulty(send.Difficulty); err != nil {
+		return nil, err
+	}
+	if send.Method != "" {
+		contract, ok := r.abis[to]
+		if !ok {
+			return nil, fmt.Errorf("abi of %s is unknown", send.To)
+		}
+		method, ok := contract.Methods[send.Method]
+		if !ok {
+			return nil, fmt.Errorf("method %s is not found", send.Method)
+		}
+		args, err := r.packArgs(send.Params, method.Inputs)
+		if err != nil {
+			return nil, err
+		}
+		if block.Data, err = contract.PackMethod(send.Method, args...); err != nil {
+			return nil, err
+		}
+	} else if block.Data, err = parseHex(send.Data); err != nil {
+		return nil, err
+	}
+	return r.run(block, nil, nil), nil
+}
+
+func (r *runner) create(create *Create) (*runResult, error) {
+	from, err := r.address(create.From)
+	if err != nil {
+		return nil, err
+	}
+	code, err := parseHex(create.Code)
+	if err != nil {
+		return nil, err
+	}
+	contract, err := parseAbi(create.Abi)
+	if err != nil {
+		return nil, err
+	}
+	if len(create.Params) > 0 {
+		if contract == nil {
+			return nil, errors.New("abi is required to pack the constructor params")
+		}
+		args, err := r.packArgs(create.Params, contract.Constructor.Inputs)
+		if err != nil {
+			return nil, err
+		}
+		params, err := contract.PackMethod("", args...)
+		if err != nil {
+			return nil, err
+		}
+		code = append(code, params...)
+	}
+	meta, err := newContractMeta(create.Gid, create.ResponseLatency, create.RandomDegree, create.QuotaMultiplier)
+	if err != nil {
+		return nil, err
+	}
+
+	block := r.newBlock(from, ledger.BlockTypeSendCreate)
+	block.Data = util.GetCreateContractData(code, util.SolidityPPContractType, meta.SendConfirmedTimes, meta.SeedConfirmedTimes, meta.QuotaRatio, meta.Gid)
+	if block.TokenId, err = parseTokenId(create.TokenId); err != nil {
+		return nil, err
+	}
+	if block.Amount, err = parseAmount(create.Amount); err != nil {
+		return nil, err
+	}
+	if block.Difficulty, err = parseDifficulty(create.Difficulty); err != nil {
+		return nil, err
+	}
+	result := r.run(block, nil, nil)
+	if result.vmBlock != nil {
+		addr := result.vmBlock.AccountBlock.ToAddress
+		if create.Bind != "" {
+			r.names[create.Bind] = addr
+		}
+		if contract != nil {
+			r.abis[addr] = contract
+		}
+	}
+	return result, nil
+}
+
+func (r *runner) receive(receive *Receive) (*runResult, error) {
+	addr, err := r.address(receive.Address)
+	if err != nil {
+		return nil, err
+	}
+	var sendBlock *ledger.AccountBlock
+	for _, onroad := range r.w.account(addr).onroad {
+		if receive.From == "" {
+			sendBlock = onroad
+			break
+		}
+		from, err := r.address(receive.From)
+		if err != nil {
+			return nil, err
+		}
+		if onroad.AccountAddress == from {
+			sendBlock = onroad
+			break
+		}
+	}
+	if sendBlock == nil {
+		return nil, fmt.Errorf("no onroad block of %s", receive.Address)
+	}
+	status, err := r.globalStatus(addr, sendBlock)
+	if err != nil {
+		return nil, err
+	}
+	block := r.newBlock(addr, ledger.BlockTypeReceive)
+	block.FromBlockHash = sendBlock.Hash
+	if block.Difficulty, err = parseDifficulty(receive.Difficulty); err != nil {
+		return nil, err
+	}
+	return r.run(block, sendBlock, status), nil
+}
+
+// settle receives the onroad blocks of contracts, including the ones sent by the
+// receive blocks, in the order they are sent, until no onroad block can be received.
+func (r *runner) settle() error {
+	for i := 0; i < settleLimit; i++ {
+		var sendBlock *ledger.AccountBlock
+		var status util.GlobalStatus
+		for _, info := range r.w.onroadBlocks() {
+			if !types.IsContractAddr(info.block.ToAddress) {
+				continue
+			}
+			var err error
+			if status, err = r.globalStatus(info.block.ToAddress, info.block); err == nil {
+				sendBlock = info.block
+				break
+			}
+		}
+		if sendBlock == nil {
+			return nil
+		}
+		block := r.newBlock(sendBlock.ToAddress, ledger.BlockTypeReceive)
+		block.FromBlockHash = sendBlock.Hash
+		if result := r.run(block, sendBlock, status); result.vmBlock == nil {
+			return fmt.Errorf("receive %s by %s failed, retry: %v, err: %v", sendBlock.Hash, sendBlock.ToAddress, result.isRetry, result.err)
+		}
+	}
+	return fmt.Errorf("more than %d blocks are received", settleLimit)
+}
+
+func (r *runner) snapshot(snapshot *Snapshot) error {
+	height := snapshot.Height
+	if height == 0 {
+		count := snapshot.Count
+		if count == 0 {
+			count = 1
+		}
+		height = r.w.height + count
+	}
+	if height <= r.w.height {
+		return fmt.Errorf("snapshot height %d is not higher than the latest %d", height, r.w.height)
+	}
+	r.w.advance(height)
+	return nil
+}
+
+func (r *runner) newBlock(addr types.Address, blockType byte) *ledger.AccountBlock {
+	block := &ledger.AccountBlock{
+		BlockType:      blockType,
+		AccountAddress: addr,
+		Height:         1,
+		Amount:         big.NewInt(0),
+		Fee:            big.NewInt(0),
+		TokenId:        ledger.ViteTokenId,
+	}
+	if prev := r.w.account(addr).latestBlock(); prev != nil {
+		block.Height = prev.Height + 1
+		block.PrevHash = prev.Hash
+	}
+	return block
+}
+
+// run runs the block by the vm and inserts the result into the ledger, the same as the generator does
+func (r *runner) run(block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, status util.GlobalStatus) *runResult {
+	sb := r.w.latestSnapshotBlock()
+	db, err := vm_db.NewVmDb(r.w, &block.AccountAddress, &sb.Hash, &block.PrevHash)
+	if err != nil {
+		return &runResult{err: err}
+	}
+	vmBlock, isRetry, err := vm.NewVM(r.cs).RunV2(db, block, sendBlock, status)
+	if vmBlock != nil {
+		vb := vmBlock.AccountBlock
+		if vb.IsReceiveBlock() && types.IsContractAddr(vb.AccountAddress) {
+			for idx, v := range vb.SendBlockList {
+				v.Hash = v.ComputeSendHash(vb, uint8(idx))
+			}
+		}
+		vb.Hash = vb.ComputeHash()
+		r.w.insert(vmBlock)
+	}
+	return &runResult{vmBlock: vmBlock, isRetry: isRetry, err: err}
+}
+
+// globalStatus returns the status of the snapshot block which confirms the send block
+// as many times as the contract requires, the same as the onroad module waits for.
+func (r *runner) globalStatus(addr types.Address, sendBlock *ledger.AccountBlock) (util.GlobalStatus, error) {
+	meta, _ := r.w.GetContractMeta(addr)
+	if meta == nil {
+		return nil, nil
+	}
+	times := meta.SendConfirmedTimes
+	if upgrade.IsSeedUpgrade(r.w.height) && meta.SeedConfirmedTimes > times {
+		times = meta.SeedConfirmedTimes
+	}
+	if times == 0 {
+		return nil, nil
+	}
+	if confirmed := r.w.confirmedTimes(sendBlock.Hash); confirmed < uint64(times) {
+		return nil, fmt.Errorf("send block %s is confirmed %d times, %d required, advance the snapshot first", sendBlock.Hash, confirmed, times)
+	}
+	limit := r.w.blocks[sendBlock.Hash].confirmHeight + uint64(times) - 1
+	return &globalStatus{seed: r.s.Env.Seed, sb: r.w.snapshotBlock(limit)}, nil
+}
+
+// ====== checks ======
+
+func (r *runner) checkResult(expect *Expect, result *runResult) []string {
+	var problems []string
+	if expect.Error != "" {
+		if result.err == nil || result.err.Error() != expect.Error {
+			problems = append(problems, fmt.Sprintf("error: expected %q, got %v", expect.Error, result.err))
+		}
+	} else if result.err != nil {
+		problems = append(problems, fmt.Sprintf("unexpected error: %v", result.err))
+	}
+	if expect.Retry != result.isRetry {
+		problems = append(problems, fmt.Sprintf("retry: expected %v, got %v", expect.Retry, result.isRetry))
+	}
+	if result.vmBlock == nil {
+		if expect.BlockType != "" || expect.Quota != nil || expect.QuotaUsed != nil || expect.SendBlocks != nil || expect.Logs != nil {
+			problems = append(problems, "no block is produced")
+		}
+		return problems
+	}
+
+	block := result.vmBlock.AccountBlock
+	if expect.BlockType != "" && expect.BlockType != blockTypeNames[block.BlockType] {
+		problems = append(problems, fmt.Sprintf("block type: expected %s, got %s", expect.BlockType, blockTypeNames[block.BlockType]))
+	}
+	if expect.Quota != nil && *expect.Quota != block.Quota {
+		problems = append(problems, fmt.Sprintf("quota: expected %d, got %d", *expect.Quota, block.Quota))
+	}
+	if expect.QuotaUsed != nil && *expect.QuotaUsed != block.QuotaUsed {
+		problems = append(problems, fmt.Sprintf("quota used: expected %d, got %d", *expect.QuotaUsed, block.QuotaUsed))
+	}
+	if expect.SendBlocks != nil {
+		problems = append(problems, r.checkSendBlocks(expect.SendBlocks, block.SendBlockList)...)
+	}
+	if expect.Logs != nil {
+		problems = append(problems, r.checkLogs(expect.Logs, block.AccountAddress, result.vmBlock.VmDb.GetLogList())...)
+	}
+	return problems
+}
+
+func (r *runner) checkSendBlocks(expected []*ExpectSend, got []*ledger.AccountBlock) []string {
+	if len(expected) != len(got) {
+		return []string{fmt.Sprintf("send blocks: expected %d, got %d", len(expected), len(got))}
+	}
+	var problems []string
+	for i, e := range expected {
+		b := got[i]
+		prefix := fmt.Sprintf("send block %d", i)
+		if e.BlockType != "" && e.BlockType != blockTypeNames[b.BlockType] {
+			problems = append(problems, fmt.Sprintf("%s type: expected %s, got %s", prefix, e.BlockType, blockTypeNames[b.BlockType]))
+		}
+		if e.To != "" {
+			if to, err := r.address(e.To); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", prefix, err))
+			} else if to != b.ToAddress {
+				problems = append(problems, fmt.Sprintf("%s to: expected %s, got %s", prefix, to, b.ToAddress))
+			}
+		}
+		if e.TokenId != "" {
+			if tokenId, err := parseTokenId(e.TokenId); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", prefix, err))
+			} else if tokenId != b.TokenId {
+				problems = append(problems, fmt.Sprintf("%s token: expected %s, got %s", prefix, tokenId, b.TokenId))
+			}
+		}
+		if e.Amount != "" && !amountEquals(e.Amount, b.Amount) {
+			problems = append(problems, fmt.Sprintf("%s amount: expected %s, got %s", prefix, e.Amount, b.Amount))
+		}
+		if e.Data != "" && !hexEquals(e.Data, b.Data) {
+			problems = append(problems, fmt.Sprintf("%s data: expected %s, got %s", prefix, e.Data, hex.EncodeToString(b.Data)))
+		}
+	}
+	return problems
+}
+
+func (r *runner) checkLogs(expected []*ExpectLog, addr types.Address, got ledger.VmLogList) []string {
+	if len(expected) != len(got) {
+		return []string{fmt.Sprintf("logs: expected %d, got %d", len(expected), len(got))}
+	}
+	var problems []string
+	for i, e := range expected {
+		log := got[i]
+		prefix := fmt.Sprintf("log %d", i)
+		if e.Event != "" {
+			var id types.Hash
+			if contract, ok := r.abis[addr]; ok {
+				if event, ok := contract.Events[e.Event]; ok {
+					id = event.Id()
+				}
+			}
+			if id.IsZero() {
+				problems = append(problems, fmt.Sprintf("%s: event %s is not found in the abi", prefix, e.Event))
+			} else if len(log.Topics) == 0 || log.Topics[0] != id {
+				problems = append(problems, fmt.Sprintf("%s: event %s expected", prefix, e.Event))
+			}
+		}
+		if e.Topics != nil {
+			if len(e.Topics) != len(log.Topics) {
+				problems = append(problems, fmt.Sprintf("%s topics: expected %d, got %d", prefix, len(e.Topics), len(log.Topics)))
+			} else {
+				for j, topic := range e.Topics {
+					if !hexEquals(topic, log.Topics[j].Bytes()) {
+						problems = append(problems, fmt.Sprintf("%s topic %d: expected %s, got %s", prefix, j, topic, log.Topics[j]))
+					}
+				}
+			}
+		}
+		if e.Data != "" && !hexEquals(e.Data, log.Data) {
+			problems = append(problems, fmt.Sprintf("%s data: expected %s, got %s", prefix, e.Data, hex.EncodeToString(log.Data)))
+		}
+	}
+	return problems
+}
+
+func (r *runner) checkAccounts(expected map[string]*ExpectAccount) []string {
+	var problems []string
+	keys := make([]string, 0, len(expected))
+	for key := range expected {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		e := expected[key]
+		addr, err := r.address(key)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		acc := r.w.account(addr)
+		for token, amount := range e.Balance {
+			tokenId, err := parseTokenId(token)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			balance, _ := r.w.GetBalance(addr, tokenId)
+			if !amountEquals(amount, balance) {
+				problems = append(problems, fmt.Sprintf("%s balance of %s: expected %s, got %s", key, token, amount, balance))
+			}
+		}
+		for k, v := range e.Storage {
+			storageKey, err := parseHex(k)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			if got := acc.value(storageKey); !hexEquals(v, got) {
+				problems = append(problems, fmt.Sprintf("%s storage %s: expected %s, got %s", key, k, v, hex.EncodeToString(got)))
+			}
+		}
+		if e.Code != nil && !hexEquals(*e.Code, acc.code) {
+			problems = append(problems, fmt.Sprintf("%s code: expected %s, got %s", key, *e.Code, hex.EncodeToString(acc.code)))
+		}
+		if e.Onroad != nil && *e.Onroad != len(acc.onroad) {
+			problems = append(problems, fmt.Sprintf("%s onroad: expected %d, got %d", key, *e.Onroad, len(acc.onroad)))
+		}
+	}
+	return problems
+}
+
+// ====== helpers ======
+
+// address parses an address or a name bound to an address
+func (r *runner) address(s string) (types.Address, error) {
+	if strings.HasPrefix(s, types.AddressPrefix) {
+		return types.HexToAddress(s)
+	}
+	if addr, ok := r.names[s]; ok {
+		return addr, nil
+	}
+	return types.Address{}, fmt.Errorf("unknown address %q", s)
+}
+
+func newContractMeta(gid string, responseLatency, randomDegree, quotaMultiplier uint8) (*ledger.ContractMeta, error) {
+	meta := &ledger.ContractMeta{
+		Gid:                types.DELEGATE_GID,
+		SendConfirmedTimes: responseLatency,
+		SeedConfirmedTimes: randomDegree,
+		QuotaRatio:         quotaMultiplier,
+	}
+	if gid != "" {
+		var err error
+		if meta.Gid, err = types.HexToGid(gid); err != nil {
+			return nil, err
+		}
+	}
+	if meta.QuotaRatio == 0 {
+		meta.QuotaRatio = defaultQuotaMultiplier
+	}
+	return meta, nil
+}
+
+func parseTokenId(s string) (types.TokenTypeId, error) {
+	if s == "" || s == "VITE" {
+		return ledger.ViteTokenId, nil
+	}
+	return types.HexToTokenTypeId(s)
+}
+
+func parseAmount(s string) (*big.Int, error) {
+	if s == "" {
+		return big.NewInt(0), nil
+	}
+	amount, ok := new(big.Int).SetString(s, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	return amount, nil
+}
+
+func parseDifficulty(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return parseAmount(s)
+}
+
+func parseHex(s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid hex %q", s)
+	}
+	return b, nil
+}
+
+func amountEquals(expected string, got *big.Int) bool {
+	amount, ok := new(big.Int).SetString(expected, 10)
+	return ok && got != nil && amount.Cmp(got) == 0
+}
+
+func hexEquals(expected string, got []byte) bool {
+	b, err := parseHex(expected)
+	return err == nil && bytes.Equal(b, got)
+}
+
+// globalStatus is the status of contracts with response latency or random degree
+type globalStatus struct {
+	seed   uint64
+	sb     *ledger.SnapshotBlock
+	source helper.Source64
+}
+
+func (g *globalStatus) Seed() (uint64, error) {
+	return g.seed, nil
+}
+
+func (g *globalStatus) Random() (uint64, error) {
+	if g.source == nil {
+		g.source = helper.NewSource64(int64(g.seed))
+	}
+	return g.source.Uint64(), nil
+}
+
+func (g *globalStatus) SnapshotBlock() *ledger.SnapshotBlock {
+	return g.sb
+}
+
+// statsReader provides the sbp statistics of the scenario to the governance contract
+type statsReader struct {
+	ti    core.TimeIndex
+	stats map[uint64]map[string]*core.SbpStats
+}
+
+func newStatsReader(genesisTime int64, c *Consensus) (*statsReader, error) {
+	interval := defaultConsensusInterval
+	if c != nil && c.Interval > 0 {
+		interval = c.Interval
+	}
+	reader := &statsReader{
+		ti:    core.NewTimeIndex(time.Unix(genesisTime, 0), time.Duration(interval)*time.Second),
+		stats: make(map[uint64]map[string]*core.SbpStats),
+	}
+	if c == nil {
+		return reader, nil
+	}
+	for index, m := range c.Stats {
+		reader.stats[index] = make(map[string]*core.SbpStats, len(m))
+		for name, s := range m {
+			voteCount, err := parseAmount(s.VoteCount)
+			if err != nil {
+				return nil, fmt.Errorf("consensus stats of %s in cycle %d: %v", name, index, err)
+			}
+			reader.stats[index][name] = &core.SbpStats{
+				Index:            index,
+				BlockNum:         s.BlockNum,
+				ExceptedBlockNum: s.ExpectedBlockNum,
+				VoteCnt:          &core.BigInt{Int: voteCount},
+				Name:             name,
+			}
+		}
+	}
+	return reader, nil
+}
+
+func (s *statsReader) DayStats(startIndex uint64, endIndex uint64) ([]*core.DayStats, error) {
+	list := make([]*core.DayStats, 0)
+	for i := startIndex; i <= endIndex; i++ {
+		m, ok := s.stats[i]
+		if !ok {
+			continue
+		}
+		blockTotal := uint64(0)
+		voteSum := big.NewInt(0)
+		for _, stats := range m {
+			blockTotal += stats.BlockNum
+			voteSum.Add(voteSum, stats.VoteCnt.Int)
+		}
+		list = append(list, &core.DayStats{Index: i, Stats: m, VoteSum: &core.BigInt{Int: voteSum}, BlockTotal: blockTotal})
+	}
+	return list, nil
+}
+
+func (s *statsReader) GetDayTimeIndex() core.TimeIndex {
+	return s.ti
+}
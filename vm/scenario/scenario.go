@@ -0,0 +1,217 @@
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vitelabs/go-vite/v2/common/config"
+)
+
+// Scenario is a sequence of blocks run by the vm against an in-memory ledger
+type Scenario struct {
+	Name        string
+	Description string
+	Env         Env
+	Accounts    map[string]*Account
+	Steps       []*Step
+
+	file string
+}
+
+// Env is the environment of the scenario
+type Env struct {
+	Upgrade          *config.Upgrade // latest by default
+	GenesisTime      int64           // unix timestamp of the genesis snapshot block
+	SnapshotHeight   uint64          // height of the latest snapshot block at the start, 1 by default
+	SnapshotInterval int64           // seconds between snapshot blocks, 1 by default
+	Seed             uint64          // random seed of the contracts with random degree
+	TestParam        bool            // use the test params of built-in contracts and quota
+	Consensus        *Consensus
+}
+
+// Consensus is the sbp statistics read by the governance contract to calculate rewards and weight the param votes
+type Consensus struct {
+	Interval int64                           // seconds of a cycle, 86400 by default
+	Stats    map[uint64]map[string]*SbpStats // cycle index => sbp name => stats
+}
+
+type SbpStats struct {
+	BlockNum         uint64
+	ExpectedBlockNum uint64
+	VoteCount        string
+}
+
+// Account is an account existed before the first step, the key of the account in the
+// scenario is the address or a name, the address is required for a name
+type Account struct {
+	Address  string
+	Balance  map[string]string // token id => amount
+	Stake    string            // stake beneficial amount granted out of the quota contract
+	Storage  map[string]string // hex key => hex value
+	Code     string            // hex code of the contract
+	Contract *Contract
+	Abi      json.RawMessage // abi of the contract, used to pack the call data and to match the events
+}
+
+// Contract is the meta of a contract
+type Contract struct {
+	Gid             string // delegate consensus group by default
+	ResponseLatency uint8
+	RandomDegree    uint8
+	QuotaMultiplier uint8 // 10 by default
+}
+
+// Step is a single action of the scenario, exactly one of Send, Create, Receive,
+// Settle and Snapshot must be set
+type Step struct {
+	Name     string
+	Send     *Send
+	Create   *Create
+	Receive  *Receive
+	Settle   bool
+	Snapshot *Snapshot
+	Expect   *Expect
+}
+
+// Send creates a send call block
+type Send struct {
+	From       string
+	To         string
+	TokenId    string // VITE by default
+	Amount     string
+	Fee        string
+	Data       string // hex call data
+	Method     string // the method packed with Params by the abi of To, instead of Data
+	Params     []string
+	Difficulty string // PoW difficulty
+}
+
+// Create creates a send create block, the contract address is bound to the name Bind
+type Create struct {
+	From            string
+	Bind            string
+	Code            string // hex code with the constructor
+	Abi             json.RawMessage
+	Params          []string // the constructor params packed by Abi
+	Gid             string
+	ResponseLatency uint8
+	RandomDegree    uint8
+	QuotaMultiplier uint8
+	TokenId         string
+	Amount          string
+	Difficulty      string
+}
+
+// Receive creates a receive block of the earliest onroad block of Address
+type Receive struct {
+	Address    string
+	From       string // only receive the onroad block sent by From
+	Difficulty string
+}
+
+// Snapshot advances the latest snapshot block and confirms all the account blocks
+type Snapshot struct {
+	Height uint64 // advance to the height
+	Count  uint64 // advance by count, 1 by default
+}
+
+// Expect is checked after the step
+type Expect struct {
+	// the block produced by a Send, Create or Receive step
+	Error      string
+	Retry      bool
+	BlockType  string // receive, receiveError
+	Quota      *uint64
+	QuotaUsed  *uint64
+	SendBlocks []*ExpectSend // send blocks triggered by the receive block
+	Logs       []*ExpectLog
+
+	// the ledger after any step
+	Accounts map[string]*ExpectAccount
+}
+
+type ExpectSend struct {
+	BlockType string // call, create, reward, refund
+	To        string
+	TokenId   string
+	Amount    string
+	Data      string
+}
+
+type ExpectLog struct {
+	Event  string // name of the event in the abi of the account
+	Topics []string
+	Data   string
+}
+
+type ExpectAccount struct {
+	Balance map[string]string // unlisted tokens are not checked
+	Storage map[string]string // unlisted keys are not checked, empty value for deleted
+	Code    *string
+	Onroad  *int
+}
+
+// Load reads a scenario file
+func Load(file string) (*Scenario, error) {
+	buf, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scenario{}
+	if err := json.Unmarshal(buf, s); err != nil {
+		return nil, fmt.Errorf("decode scenario %s failed, %v", file, err)
+	}
+	s.file = file
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+	}
+	return s, nil
+}
+
+// LoadAll reads the scenario files or all the json files in the directories, recursively
+func LoadAll(paths ...string) ([]*Scenario, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		err = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() && filepath.Ext(file) == ".json" {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+
+	list := make([]*Scenario, 0, len(files))
+	for _, file := range files {
+		s, err := Load(file)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// File returns the file the scenario is loaded from
+func (s *Scenario) File() string {
+	return s.file
+}
@@ -0,0 +1,17 @@
+package scenario
+
+import (
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	list, err := LoadAll("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range list {
+		if err := Run(s); err != nil {
+			t.Errorf("%s: %v", s.Name, err)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package scenario
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/comparer"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/memdb"
+	"github.com/vitelabs/go-vite/v2/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/v2/common/types"
+	"github.com/vitelabs/go-vite/v2/interfaces"
+	ledger "github.com/vitelabs/go-vite/v2/interfaces/core"
+	"github.com/vitelabs/go-vite/v2/vm/contracts/abi"
+)
+
+// quotaListLen is the count of snapshot blocks the quota of an account is calculated with
+const quotaListLen = 75
+
+// world is an in-memory ledger which implements vm_db.Chain, so the vm runs
+// against the same vm_db as in the node.
+type world struct {
+	genesisTime time.Time
+	interval    int64
+
+	height    uint64
+	snapshots map[types.Hash]uint64
+
+	accounts map[types.Address]*account
+	blocks   map[types.Hash]*blockInfo
+	logs     map[types.Hash]ledger.VmLogList
+
+	unconfirmed []*blockInfo
+	seq         uint64
+}
+
+type account struct {
+	balances map[types.TokenTypeId]*big.Int
+	storage  *memdb.DB
+	code     []byte
+
+	meta       *ledger.ContractMeta
+	metaHeight uint64 // the snapshot height the contract is created in, 0 if unconfirmed
+
+	stake  *big.Int // stake beneficial amount granted out of the quota contract
+	blocks []*ledger.AccountBlock
+	onroad []*ledger.AccountBlock
+}
+
+type blockInfo struct {
+	seq           uint64 // the order the block is inserted
+	block         *ledger.AccountBlock
+	confirmHeight uint64
+	callDepth     uint16
+}
+
+func newWorld(genesisTime int64, interval int64, height uint64) *world {
+	return &world{
+		genesisTime: time.Unix(genesisTime, 0),
+		interval:    interval,
+		height:      height,
+		snapshots:   make(map[types.Hash]uint64),
+		accounts:    make(map[types.Address]*account),
+		blocks:      make(map[types.Hash]*blockInfo),
+		logs:        make(map[types.Hash]ledger.VmLogList),
+	}
+}
+
+func (w *world) account(addr types.Address) *account {
+	acc, ok := w.accounts[addr]
+	if !ok {
+		acc = &account{
+			balances: make(map[types.TokenTypeId]*big.Int),
+			storage:  memdb.New2(comparer.DefaultComparer, 0),
+		}
+		w.accounts[addr] = acc
+	}
+	return acc
+}
+
+func (w *world) snapshotBlock(height uint64) *ledger.SnapshotBlock {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, height)
+	hash := types.DataHash(buf)
+	w.snapshots[hash] = height
+	timestamp := w.genesisTime.Add(time.Duration(int64(height-1)*w.interval) * time.Second)
+	return &ledger.SnapshotBlock{
+		Hash:      hash,
+		Height:    height,
+		Timestamp: &timestamp,
+	}
+}
+
+func (w *world) latestSnapshotBlock() *ledger.SnapshotBlock {
+	return w.snapshotBlock(w.height)
+}
+
+// advance confirms all the unconfirmed blocks in the next snapshot block and moves the
+// latest snapshot block to the height.
+func (w *world) advance(height uint64) {
+	if height <= w.height {
+		return
+	}
+	confirmHeight := w.height + 1
+	for _, info := range w.unconfirmed {
+		info.confirmHeight = confirmHeight
+	}
+	w.unconfirmed = nil
+	for _, acc := range w.accounts {
+		if acc.meta != nil && acc.metaHeight == 0 {
+			acc.metaHeight = confirmHeight
+		}
+	}
+	w.height = height
+}
+
+func (w *world) confirmedTimes(hash types.Hash) uint64 {
+	info, ok := w.blocks[hash]
+	if !ok || info.confirmHeight == 0 || info.confirmHeight > w.height {
+		return 0
+	}
+	return w.height + 1 - info.confirmHeight
+}
+
+// insert writes the block and the state changes made by the vm into the ledger
+func (w *world) insert(vmBlock *interfaces.VmAccountBlock) {
+	block := vmBlock.AccountBlock
+	db := vmBlock.VmDb
+	acc := w.account(block.AccountAddress)
+
+	for tokenId, balance := range db.GetUnsavedBalanceMap() {
+		acc.balances[tokenId] = new(big.Int).Set(balance)
+	}
+	for _, kv := range db.GetUnsavedStorage() {
+		if len(kv[1]) == 0 {
+			acc.storage.Delete(kv[0])
+		} else {
+			acc.storage.Put(kv[0], kv[1])
+		}
+	}
+	for addr, meta := range db.GetUnsavedContractMeta() {
+		target := w.account(addr)
+		target.meta = meta
+		target.metaHeight = 0
+	}
+	if code := db.GetUnsavedContractCode(); len(code) > 0 {
+		acc.code = code
+	}
+	if block.LogHash != nil {
+		w.logs[*block.LogHash] = db.GetLogList()
+	}
+
+	info := w.newBlockInfo(block, 0)
+	w.blocks[block.Hash] = info
+	w.unconfirmed = append(w.unconfirmed, info)
+	acc.blocks = append(acc.blocks, block)
+
+	if block.IsSendBlock() {
+		w.account(block.ToAddress).onroad = append(w.account(block.ToAddress).onroad, block)
+		return
+	}
+	acc.removeOnroad(block.FromBlockHash)
+	var callDepth uint16
+	if from, ok := w.blocks[block.FromBlockHash]; ok {
+		callDepth = from.callDepth + 1
+	}
+	for _, sendBlock := range block.SendBlockList {
+		sendInfo := w.newBlockInfo(sendBlock, callDepth)
+		w.blocks[sendBlock.Hash] = sendInfo
+		w.unconfirmed = append(w.unconfirmed, sendInfo)
+		w.account(sendBlock.ToAddress).onroad = append(w.account(sendBlock.ToAddress).onroad, sendBlock)
+	}
+}
+
+func (w *world) newBlockInfo(block *ledger.AccountBlock, callDepth uint16) *blockInfo {
+	w.seq++
+	return &blockInfo{seq: w.seq, block: block, callDepth: callDepth}
+}
+
+// onroadBlocks returns all the onroad blocks in the order they are sent
+func (w *world) onroadBlocks() []*blockInfo {
+	var list []*blockInfo
+	for _, acc := range w.accounts {
+		for _, block := range acc.onroad {
+			list = append(list, w.blocks[block.Hash])
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].seq < list[j].seq
+	})
+	return list
+}
+
+func (acc *account) removeOnroad(hash types.Hash) {
+	for i, block := range acc.onroad {
+		if block.Hash == hash {
+			acc.onroad = append(acc.onroad[:i], acc.onroad[i+1:]...)
+			return
+		}
+	}
+}
+
+func (acc *account) latestBlock() *ledger.AccountBlock {
+	if len(acc.blocks) == 0 {
+		return nil
+	}
+	return acc.blocks[len(acc.blocks)-1]
+}
+
+func (acc *account) value(key []byte) []byte {
+	value, err := acc.storage.Get(key)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// ====== vm_db.Chain ======
+
+func (w *world) GetQuotaUsedList(address types.Address) []types.QuotaInfo {
+	list := make([]types.QuotaInfo, quotaListLen)
+	for _, block := range w.account(address).blocks {
+		info := w.blocks[block.Hash]
+		index := quotaListLen - 1
+		if info.confirmHeight > 0 {
+			if info.confirmHeight+quotaListLen-1 <= w.height {
+				continue
+			}
+			index = quotaListLen - 2 - int(w.height-info.confirmHeight)
+		}
+		list[index].BlockCount++
+		list[index].QuotaTotal += block.Quota
+		list[index].QuotaUsedTotal += block.QuotaUsed
+	}
+	return list
+}
+
+func (w *world) GetGlobalQuota() types.QuotaInfo {
+	return types.QuotaInfo{}
+}
+
+func (w *world) GetBalance(addr types.Address, tokenId types.TokenTypeId) (*big.Int, error) {
+	if balance, ok := w.account(addr).balances[tokenId]; ok {
+		return new(big.Int).Set(balance), nil
+	}
+	return big.NewInt(0), nil
+}
+
+func (w *world) GetContractCode(contractAddr types.Address) ([]byte, error) {
+	return w.account(contractAddr).code, nil
+}
+
+func (w *world) GetContractMeta(contractAddress types.Address) (*ledger.ContractMeta, error) {
+	if meta := ledger.GetBuiltinContractMeta(contractAddress, w.latestSnapshotBlock().Height); meta != nil {
+		return meta, nil
+	}
+	return w.account(contractAddress).meta, nil
+}
+
+func (w *world) GetConfirmSnapshotHeaderByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error) {
+	info, ok := w.blocks[abHash]
+	if !ok || info.confirmHeight == 0 {
+		return nil, nil
+	}
+	return w.snapshotBlock(info.confirmHeight), nil
+}
+
+func (w *world) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
+	return w.confirmedTimes(blockHash), nil
+}
+
+func (w *world) GetContractMetaInSnapshot(contractAddress types.Address, snapshotHeight uint64) (*ledger.ContractMeta, error) {
+	if meta := ledger.GetBuiltinContractMeta(contractAddress, snapshotHeight); meta != nil {
+		return meta, nil
+	}
+	acc := w.account(contractAddress)
+	if acc.meta == nil || acc.metaHeight == 0 || acc.metaHeight > snapshotHeight {
+		return nil, nil
+	}
+	return acc.meta, nil
+}
+
+func (w *world) GetSnapshotHeaderByHash(hash types.Hash) (*ledger.SnapshotBlock, error) {
+	height, ok := w.snapshots[hash]
+	if !ok {
+		return nil, nil
+	}
+	return w.snapshotBlock(height), nil
+}
+
+func (w *world) GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	if height == 0 || height > w.height {
+		return nil, nil
+	}
+	return w.snapshotBlock(height), nil
+}
+
+func (w *world) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	if info, ok := w.blocks[blockHash]; ok {
+		return info.block, nil
+	}
+	return nil, nil
+}
+
+func (w *world) GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock, error) {
+	return w.account(addr).latestBlock(), nil
+}
+
+func (w *world) GetVmLogList(logHash *types.Hash) (ledger.VmLogList, error) {
+	if logHash == nil {
+		return nil, nil
+	}
+	return w.logs[*logHash], nil
+}
+
+func (w *world) GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock {
+	var blocks []*ledger.AccountBlock
+	for _, info := range w.unconfirmed {
+		if info.block.AccountAddress == addr && !isContractSend(info.block) {
+			blocks = append(blocks, info.block)
+		}
+	}
+	return blocks
+}
+
+func (w *world) GetGenesisSnapshotBlock() *ledger.SnapshotBlock {
+	return w.snapshotBlock(1)
+}
+
+func (w *world) GetStakeBeneficialAmount(addr types.Address) (*big.Int, error) {
+	amount, err := abi.GetStakeBeneficialAmount(storageReader{w, types.AddressQuota}, addr)
+	if err != nil {
+		return nil, err
+	}
+	if stake := w.account(addr).stake; stake != nil {
+		amount.Add(amount, stake)
+	}
+	return amount, nil
+}
+
+func (w *world) GetGovernanceParam(id uint8, sbHeight uint64) (*big.Int, error) {
+	return abi.GetGovernanceParam(storageReader{w, types.AddressGovernance}, id, sbHeight)
+}
+
+func (w *world) GetAccountPolicy(addr types.Address, sbHeight uint64) (*types.AccountPolicy, error) {
+	return abi.GetAccountPolicy(storageReader{w, types.AddressAccountPolicy}, addr, sbHeight)
+}
+
+func (w *world) GetStorageIterator(address types.Address, prefix []byte) (interfaces.StorageIterator, error) {
+	return w.account(address).storage.NewIterator(util.BytesPrefix(prefix)), nil
+}
+
+func (w *world) GetValue(addr types.Address, key []byte) ([]byte, error) {
+	return w.account(addr).value(key), nil
+}
+
+func (w *world) GetCallDepth(sendBlockHash types.Hash) (uint16, error) {
+	if info, ok := w.blocks[sendBlockHash]; ok {
+		return info.callDepth, nil
+	}
+	return 0, nil
+}
+
+func (w *world) GetSnapshotBlockByContractMeta(addr types.Address, fromHash types.Hash) (*ledger.SnapshotBlock, error) {
+	return nil, errors.New("not supported")
+}
+
+func (w *world) GetSeedConfirmedSnapshotBlock(addr types.Address, fromHash types.Hash) (*ledger.SnapshotBlock, error) {
+	return nil, errors.New("not supported")
+}
+
+func (w *world) GetSeed(limitSb *ledger.SnapshotBlock, fromHash types.Hash) (uint64, error) {
+	return 0, errors.New("not supported")
+}
+
+// isContractSend reports whether the block is a send block triggered by a contract,
+// which is a part of the receive block and doesn't belong to the account chain.
+func isContractSend(block *ledger.AccountBlock) bool {
+	return block.IsSendBlock() && types.IsContractAddr(block.AccountAddress)
+}
+
+// storageReader reads the storage of an account without a vm context
+type storageReader struct {
+	w    *world
+	addr types.Address
+}
+
+func (r storageReader) GetValue(key []byte) ([]byte, error) {
+	return r.w.account(r.addr).value(key), nil
+}
+
+func (r storageReader) NewStorageIterator(prefix []byte) (interfaces.StorageIterator, error) {
+	return r.w.GetStorageIterator(r.addr, prefix)
+}
+
+func (r storageReader) Address() *types.Address {
+	return &r.addr
+}
@@ -24,6 +24,8 @@ var (
 	ErrIDCollision      = errors.New("id collision")
 	ErrRewardNotDue     = errors.New("reward not due")
 
+	ErrAccountPolicyViolated = errors.New("send block violates the account policy")
+
 	ErrExecutionReverted = errors.New("execution reverted")
 	ErrDepth             = errors.New("max call depth exceeded")
 
@@ -0,0 +1,80 @@
+package util
+
+import (
+	"math/big"
+
+	"github.com/vitelabs/go-vite/v2/common/upgrade"
+)
+
+// Governance params are changed by the proposals approved by SBPs in the governance contract,
+// the default value is used before the version x fork or before the first approved change.
+const (
+	// GovernanceParamQuotaStakeRatio defines the percentage of the stake amount counted for quota
+	GovernanceParamQuotaStakeRatio uint8 = 1
+	// GovernanceParamSBPStakeAmount defines the stake amount to register a SBP
+	GovernanceParamSBPStakeAmount uint8 = 2
+)
+
+// GovernanceParamDef defines the default value and the valid range of a governance param
+type GovernanceParamDef struct {
+	Name    string
+	Default *big.Int
+	Min     *big.Int
+	Max     *big.Int
+}
+
+var governanceParamDefs = map[uint8]*GovernanceParamDef{
+	GovernanceParamQuotaStakeRatio: {
+		Name:    "quotaStakeRatio",
+		Default: big.NewInt(100),
+		Min:     big.NewInt(10),
+		Max:     big.NewInt(1000),
+	},
+	GovernanceParamSBPStakeAmount: {
+		Name:    "sbpStakeAmount",
+		Default: new(big.Int).Mul(big.NewInt(1e6), AttovPerVite),
+		Min:     new(big.Int).Mul(big.NewInt(1e5), AttovPerVite),
+		Max:     new(big.Int).Mul(big.NewInt(1e8), AttovPerVite),
+	},
+}
+
+// GetGovernanceParamDef returns the definition of a governance param, nil if the param id is unknown
+func GetGovernanceParamDef(id uint8) *GovernanceParamDef {
+	return governanceParamDefs[id]
+}
+
+// GovernanceParamIdList returns all governance param ids in order
+func GovernanceParamIdList() []uint8 {
+	return []uint8{GovernanceParamQuotaStakeRatio, GovernanceParamSBPStakeAmount}
+}
+
+// CheckGovernanceParam checks whether a value is valid for a governance param
+func CheckGovernanceParam(id uint8, value *big.Int) bool {
+	def := GetGovernanceParamDef(id)
+	return def != nil && value != nil && value.Cmp(def.Min) >= 0 && value.Cmp(def.Max) <= 0
+}
+
+// GovernanceParamReader reads the value of a governance param approved in the governance contract
+type GovernanceParamReader interface {
+	// GetGovernanceParam returns the value taking effect at the snapshot height, nil if the param is not changed
+	GetGovernanceParam(id uint8, sbHeight uint64) (*big.Int, error)
+}
+
+// GetGovernanceParam returns the value of a governance param at the snapshot height
+func GetGovernanceParam(db GovernanceParamReader, id uint8, sbHeight uint64) (*big.Int, error) {
+	def := GetGovernanceParamDef(id)
+	if def == nil {
+		return nil, ErrInvalidMethodParam
+	}
+	if !upgrade.IsVersionXUpgrade(sbHeight) {
+		return def.Default, nil
+	}
+	value, err := db.GetGovernanceParam(id, sbHeight)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return def.Default, nil
+	}
+	return value, nil
+}
@@ -243,6 +243,14 @@ type QuotaTable struct {
 	DexFundTransferQuota                      uint64
 	DexFundAgentDepositQuota                  uint64
 	DexFundAssignedWithdrawQuota              uint64
+	ProposeParamChangeQuota                   uint64
+	VoteForParamChangeQuota                   uint64
+	CreateVestingQuota                        uint64
+	ClaimVestingQuota                         uint64
+	RevokeVestingQuota                        uint64
+	SetAccountPolicyQuota                     uint64
+	InitiateRecoveryQuota                     uint64
+	CancelRecoveryQuota                       uint64
 }
 
 // QuotaTableByHeight returns different quota table by hard fork version
@@ -545,5 +553,13 @@ func newVersionXQuotaTable() QuotaTable {
 	gt := newVersion11QuotaTable()
 	gt.DexFundAgentDepositQuota = 10500
 	gt.DexFundAssignedWithdrawQuota = 10500
+	gt.ProposeParamChangeQuota = 84000
+	gt.VoteForParamChangeQuota = 84000
+	gt.CreateVestingQuota = 105000
+	gt.ClaimVestingQuota = 105000
+	gt.RevokeVestingQuota = 105000
+	gt.SetAccountPolicyQuota = 105000
+	gt.InitiateRecoveryQuota = 63000
+	gt.CancelRecoveryQuota = 63000
 	return gt
 }
@@ -214,7 +214,14 @@ func (vm *VM) RunV2(db interfaces.VmDb, block *ledger.AccountBlock, sendBlock *l
 				return nil, noRetry, err
 			}
 			vmAccountBlock, err = vm.sendCreate(db, blockCopy, true, quotaTotal, quotaAddition)
-			return vmAccountBlock, noRetry, err
+			if err != nil {
+				return nil, noRetry, err
+			}
+			// Check the policy after the fee and the contract address are set.
+			if err := contracts.CheckAccountPolicy(db, vmAccountBlock.AccountBlock, sb.Height); err != nil {
+				return nil, noRetry, err
+			}
+			return vmAccountBlock, noRetry, nil
 		}
 		if blockCopy.BlockType == ledger.BlockTypeSendCall {
 			quotaTotal, quotaAddition, err := quota.GetQuotaForBlock(
@@ -227,7 +234,14 @@ func (vm *VM) RunV2(db interfaces.VmDb, block *ledger.AccountBlock, sendBlock *l
 				return nil, noRetry, err
 			}
 			vmAccountBlock, err = vm.sendCall(db, blockCopy, true, quotaTotal, quotaAddition)
-			return vmAccountBlock, noRetry, err
+			if err != nil {
+				return nil, noRetry, err
+			}
+			// Check the policy after the fee and the contract address are set.
+			if err := contracts.CheckAccountPolicy(db, vmAccountBlock.AccountBlock, sb.Height); err != nil {
+				return nil, noRetry, err
+			}
+			return vmAccountBlock, noRetry, nil
 		}
 	} else {
 		// New interpreter instance according to latest snapshot block height.
@@ -763,7 +777,7 @@ func (vm *VM) receiveReward(db interfaces.VmDb, block *ledger.AccountBlock, send
 	quotaLeft := uint64(0)
 	quotaAddition := uint64(0)
 	var err error
-	if !types.IsBuiltinContractAddrInUse(block.AccountAddress) {
+	if !ledger.IsBuiltinContractAddrInUse(block.AccountAddress, vm.latestSnapshotHeight) {
 		quotaTotal, quotaAddition, err = quota.GetQuotaForBlock(
 			db,
 			block.AccountAddress,
@@ -14,3 +14,11 @@ func (vdb *vmDb) GetStakeBeneficialAmount(addr *types.Address) (*big.Int, error)
 
 	return vdb.chain.GetStakeBeneficialAmount(*addr)
 }
+
+func (vdb *vmDb) GetGovernanceParam(id uint8, sbHeight uint64) (*big.Int, error) {
+	return vdb.chain.GetGovernanceParam(id, sbHeight)
+}
+
+func (vdb *vmDb) GetAccountPolicy(addr *types.Address, sbHeight uint64) (*types.AccountPolicy, error) {
+	return vdb.chain.GetAccountPolicy(*addr, sbHeight)
+}
@@ -41,6 +41,10 @@ type Chain interface {
 
 	GetStakeBeneficialAmount(addr types.Address) (*big.Int, error)
 
+	GetGovernanceParam(id uint8, sbHeight uint64) (*big.Int, error)
+
+	GetAccountPolicy(addr types.Address, sbHeight uint64) (*types.AccountPolicy, error)
+
 	GetStorageIterator(address types.Address, prefix []byte) (interfaces.StorageIterator, error)
 
 	GetValue(addr types.Address, key []byte) ([]byte, error)